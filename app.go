@@ -15,6 +15,8 @@ type app struct {
 	updateMu             sync.Mutex
 	isUpdating           bool
 	storageStartupNotice string
+	backupMu             sync.Mutex
+	backupStop           chan struct{}
 }
 
 // NewApp 创建应用实例
@@ -31,10 +33,16 @@ func (a *app) startup(ctx context.Context) {
 		log.Printf("初始化存储或数据库失败: %v", err)
 		return
 	}
+
+	a.runAutomaticBackup(database.BackupReasonStartup)
+	a.startBackupScheduler()
 }
 
 // shutdown 应用关闭时调用
 func (a *app) shutdown(ctx context.Context) {
+	a.stopBackupScheduler()
+	a.runAutomaticBackup(database.BackupReasonShutdown)
+
 	if a.database != nil {
 		a.database.Close()
 		log.Println("数据库连接已关闭")
@@ -52,12 +60,15 @@ func (a *app) CheckDatabaseStatus() (bool, string) {
 		return false, "数据库未初始化"
 	}
 
-	ok, err := a.database.CheckDatabase()
+	result, err := a.database.CheckDatabase()
 	if err != nil {
 		return false, fmt.Sprintf("数据库检查失败: %v", err)
 	}
+	if result.OrphanTotal > 0 {
+		return result.OK, fmt.Sprintf("数据库状态正常，发现孤立数据 %d 条", result.OrphanTotal)
+	}
 
-	return ok, "数据库状态正常"
+	return result.OK, "数据库状态正常"
 }
 
 // GetDatabaseInfo 获取数据库信息（供前端调用）
@@ -77,11 +88,16 @@ func (a *app) GetDatabaseInfo() map[string]interface{} {
 		info["path"] = path
 	}
 
-	ok, err := a.database.CheckDatabase()
+	if version, err := a.database.SchemaVersion(); err == nil {
+		info["schema_version"] = version
+	}
+
+	result, err := a.database.CheckDatabase()
 	if err != nil {
 		info["status"] = fmt.Sprintf("错误: %v", err)
-	} else if ok {
+	} else if result.OK {
 		info["status"] = "正常"
+		info["orphan_counts"] = result.OrphanCounts
 	} else {
 		info["status"] = "异常"
 	}
@@ -89,6 +105,14 @@ func (a *app) GetDatabaseInfo() map[string]interface{} {
 	return info
 }
 
+// PurgeOrphanRecords 清理孤立数据（供前端调用），返回每张表删除的条数
+func (a *app) PurgeOrphanRecords() (map[string]int, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.PurgeOrphans()
+}
+
 // CheckReleaseUpdate checks whether a newer GitHub release is available.
 func (a *app) CheckReleaseUpdate() (UpdateCheckResult, error) {
 	return checkReleaseUpdate()
@@ -120,10 +144,17 @@ func (a *app) GetCharacterInfo(characterID int) (map[string]interface{}, error)
 		"id":         info.ID,
 		"name":       info.Name,
 		"shili":      info.Shili,
+		"shili_id":   info.ShiliID,
 		"property":   info.Property,
 		"level":      info.Level,
+		"birth_date": info.BirthDate,
 		"attributes": info.Attributes,
 		"skills":     info.Skills,
+		"weapons":    info.Weapons,
+		"items":      info.Items,
+		"pets":       info.Pets,
+		"beibao":     info.Beibao,
+		"faction":    info.Faction,
 	}
 
 	return result, nil
@@ -145,12 +176,20 @@ func (a *app) UpdateCharacterBasicInfo(characterID int, name, shili string, prop
 	return a.database.UpdateCharacterBasicInfo(characterID, name, shili, property, level)
 }
 
-// DeleteCharacter 删除人物
-func (a *app) DeleteCharacter(characterID int) error {
+// SetCharacterShili 设置人物所属势力，shiliID 为 0 时清除
+func (a *app) SetCharacterShili(characterID, shiliID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetCharacterShili(characterID, shiliID)
+}
+
+// DeleteCharacter 删除人物，disposal 指定名下背包保留为无主、转交给其他人物还是一并删除
+func (a *app) DeleteCharacter(characterID int, disposal database.CharacterBeibaoDisposal) error {
 	if a.database == nil {
 		return fmt.Errorf("数据库未初始化")
 	}
-	return a.database.DeleteCharacter(characterID)
+	return a.database.DeleteCharacter(characterID, disposal)
 }
 
 // AddCharacterAttribute 添加人物属性
@@ -251,6 +290,14 @@ func (a *app) UpdateWeaponBasicInfo(weaponID int, name, holder string, level int
 	return a.database.UpdateWeaponBasicInfo(weaponID, name, holder, level)
 }
 
+// SetWeaponHolder 设置武器的持有人物，characterID 为 0 时清除
+func (a *app) SetWeaponHolder(weaponID, characterID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetWeaponHolder(weaponID, characterID)
+}
+
 // DeleteWeapon 删除武器
 func (a *app) DeleteWeapon(weaponID int) error {
 	if a.database == nil {
@@ -357,6 +404,14 @@ func (a *app) UpdatePetBasicInfo(petID int, owner string, level int) error {
 	return a.database.UpdatePetBasicInfo(petID, owner, level)
 }
 
+// SetPetOwner 设置宠物的主人，characterID 为 0 时清除
+func (a *app) SetPetOwner(petID, characterID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetPetOwner(petID, characterID)
+}
+
 // DeletePet 删除宠物
 func (a *app) DeletePet(petID int) error {
 	if a.database == nil {
@@ -440,6 +495,9 @@ func (a *app) GetDaojuInfo(daojuID int) (map[string]interface{}, error) {
 		"name":      info.Name,
 		"level":     info.Level,
 		"holder":    info.Holder,
+		"price":     info.Price,
+		"max_stack": info.MaxStack,
+		"weight":    info.Weight,
 		"functions": info.Functions,
 	}
 
@@ -455,6 +513,14 @@ func (a *app) CreateDaoju(name, holder string, level int) (int, error) {
 	return int(id), err
 }
 
+// SetDaojuStacking 设置道具的堆叠上限（0 表示不限）和单件重量
+func (a *app) SetDaojuStacking(daojuID, maxStack, weight int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetDaojuStacking(daojuID, maxStack, weight)
+}
+
 // UpdateDaojuBasicInfo 更新道具基本信息
 func (a *app) UpdateDaojuBasicInfo(daojuID int, holder string, level int) error {
 	if a.database == nil {
@@ -463,6 +529,14 @@ func (a *app) UpdateDaojuBasicInfo(daojuID int, holder string, level int) error
 	return a.database.UpdateDaojuBasicInfo(daojuID, level, holder)
 }
 
+// SetDaojuHolder 设置道具的持有人物，characterID 为 0 时清除
+func (a *app) SetDaojuHolder(daojuID, characterID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetDaojuHolder(daojuID, characterID)
+}
+
 // DeleteDaoju 删除道具
 func (a *app) DeleteDaoju(daojuID int) error {
 	if a.database == nil {
@@ -541,11 +615,17 @@ func (a *app) GetShiqingInfo(shiqingID int) (map[string]interface{}, error) {
 
 	// 转换为 map 以便 JSON 序列化
 	result := map[string]interface{}{
-		"id":       info.ID,
-		"name":     info.Name,
-		"location": info.Location,
-		"time":     info.Time,
-		"details":  info.Details,
+		"id":           info.ID,
+		"name":         info.Name,
+		"location":     info.Location,
+		"time":         info.Time,
+		"world_date":   info.WorldDate,
+		"status":       info.Status,
+		"started_at":   info.StartedAt,
+		"ended_at":     info.EndedAt,
+		"details":      info.Details,
+		"participants": info.Participants,
+		"rewards":      info.Rewards,
 	}
 
 	return result, nil
@@ -592,6 +672,9 @@ func (a *app) GetShiqingDetails(shiqingID int) ([]map[string]interface{}, error)
 		result[i] = map[string]interface{}{
 			"id":          detail.ID,
 			"description": detail.Description,
+			"position":    detail.Position,
+			"done":        detail.Done,
+			"done_at":     detail.DoneAt,
 		}
 	}
 
@@ -622,6 +705,63 @@ func (a *app) UpdateShiqingDetail(detailID int, description string) error {
 	return a.database.UpdateShiqingDetail(detailID, description)
 }
 
+// SetShiqingStatus 修改任务状态（pending、active、completed、failed、abandoned），完成时发放奖励
+func (a *app) SetShiqingStatus(shiqingID int, status string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetShiqingStatus(shiqingID, status)
+}
+
+// SetShiqingDetailDone 勾选或取消勾选任务目标
+func (a *app) SetShiqingDetailDone(detailID int, done bool) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetShiqingDetailDone(detailID, done)
+}
+
+// ReorderShiqingDetails 调整任务目标的顺序
+func (a *app) ReorderShiqingDetails(shiqingID int, detailIDs []int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.ReorderShiqingDetails(shiqingID, detailIDs)
+}
+
+// AddShiqingParticipant 添加任务参与者
+func (a *app) AddShiqingParticipant(shiqingID, characterID int, role string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.AddShiqingParticipant(shiqingID, characterID, role)
+}
+
+// RemoveShiqingParticipant 移除任务参与者
+func (a *app) RemoveShiqingParticipant(shiqingID, characterID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.RemoveShiqingParticipant(shiqingID, characterID)
+}
+
+// AddShiqingReward 添加任务奖励（item 或 currency）
+func (a *app) AddShiqingReward(shiqingID int, kind, name string, quantity int) (int, error) {
+	if a.database == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+	id, err := a.database.AddShiqingReward(shiqingID, kind, name, quantity)
+	return int(id), err
+}
+
+// DeleteShiqingReward 删除任务奖励
+func (a *app) DeleteShiqingReward(rewardID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.DeleteShiqingReward(rewardID)
+}
+
 // ============ 怪物相关接口 ============
 
 // GetAllGuaiwu 获取所有怪物列表
@@ -634,22 +774,7 @@ func (a *app) GetAllGuaiwu() ([]map[string]interface{}, error) {
 		return nil, err
 	}
 
-	// 转换为 map 以便 JSON 序列化
-	result := make([]map[string]interface{}, len(guaiwuList))
-	for i, g := range guaiwuList {
-		result[i] = map[string]interface{}{
-			"id":      g.ID,
-			"name":    g.Name,
-			"type":    g.Type,
-			"level":   g.Level,
-			"health":  g.Health,
-			"attack":  g.Attack,
-			"defense": g.Defense,
-			"rewards": g.Rewards,
-		}
-	}
-
-	return result, nil
+	return guaiwuListItems(guaiwuList), nil
 }
 
 // GetGuaiwuInfo 获取怪物详细信息
@@ -824,21 +949,7 @@ func (a *app) GetAllShili() ([]map[string]interface{}, error) {
 		return nil, err
 	}
 
-	// 转换为 map 以便 JSON 序列化
-	result := make([]map[string]interface{}, len(shiliList))
-	for i, s := range shiliList {
-		result[i] = map[string]interface{}{
-			"id":           s.ID,
-			"name":         s.Name,
-			"level":        s.Level,
-			"founder":      s.Founder,
-			"wealth":       s.Wealth,
-			"member_count": s.MemberCount,
-			"max_members":  s.MaxMembers,
-		}
-	}
-
-	return result, nil
+	return shiliListItems(shiliList), nil
 }
 
 // GetShiliInfo 获取势力详细信息
@@ -872,6 +983,8 @@ func (a *app) GetShiliInfo(shiliID int) (map[string]interface{}, error) {
 		"wealth":       info.Wealth,
 		"member_count": info.MemberCount,
 		"max_members":  info.MaxMembers,
+		"founded_date": info.FoundedDate,
+		"members":      info.Members,
 		"positions":    positions,
 		"attributes":   attributes,
 	}
@@ -888,12 +1001,12 @@ func (a *app) CreateShili(name, founder string, level, wealth, maxMembers int) (
 	return int(id), err
 }
 
-// UpdateShiliBasicInfo 更新势力基本信息
-func (a *app) UpdateShiliBasicInfo(shiliID int, level int, founder string, wealth, memberCount, maxMembers int) error {
+// UpdateShiliBasicInfo 更新势力基本信息（成员数由成员名单统计）
+func (a *app) UpdateShiliBasicInfo(shiliID int, level int, founder string, wealth, maxMembers int) error {
 	if a.database == nil {
 		return fmt.Errorf("数据库未初始化")
 	}
-	return a.database.UpdateShiliBasicInfo(shiliID, level, founder, wealth, memberCount, maxMembers)
+	return a.database.UpdateShiliBasicInfo(shiliID, level, founder, wealth, maxMembers)
 }
 
 // DeleteShili 删除势力
@@ -904,6 +1017,39 @@ func (a *app) DeleteShili(shiliID int) error {
 	return a.database.DeleteShili(shiliID)
 }
 
+// GetShiliMembers 获取势力成员名单
+func (a *app) GetShiliMembers(shiliID int) ([]database.ShiliMember, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetShiliMembers(shiliID)
+}
+
+// AddShiliMember 添加势力成员
+func (a *app) AddShiliMember(shiliID, characterID int, role string) (int, error) {
+	if a.database == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+	id, err := a.database.AddShiliMember(shiliID, characterID, role)
+	return int(id), err
+}
+
+// UpdateShiliMemberRole 更新势力成员身份
+func (a *app) UpdateShiliMemberRole(memberID int, role string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.UpdateShiliMemberRole(memberID, role)
+}
+
+// RemoveShiliMember 将成员移出势力
+func (a *app) RemoveShiliMember(memberID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.RemoveShiliMember(memberID)
+}
+
 // GetShiliPositions 获取势力职务列表
 func (a *app) GetShiliPositions(shiliID int) ([]map[string]interface{}, error) {
 	if a.database == nil {
@@ -1060,12 +1206,18 @@ func (a *app) RenameMarkdownFile(oldName, newName string) error {
 
 // ============ 背包相关接口 ============
 
-// GetAllBeibao 获取所有背包列表
-func (a *app) GetAllBeibao() ([]map[string]interface{}, error) {
+// GetAllBeibao 获取背包列表；ownerType 不为空时只返回该类型主人的背包，
+// ownerID 不为 0 时进一步限定为该主人，ownerType 与 ownerID 都为空时返回全部背包
+func (a *app) GetAllBeibao(ownerType string, ownerID int) ([]map[string]interface{}, error) {
 	if a.database == nil {
 		return nil, fmt.Errorf("数据库未初始化")
 	}
-	return a.database.GetAllBeibao()
+	q := database.ListQuery{Type: ownerType}
+	if ownerID != 0 {
+		q.OwnerID = &ownerID
+	}
+	beibaoList, _, err := a.database.ListBeibao(q)
+	return beibaoList, err
 }
 
 // GetBeibaoInfo 获取背包详细信息
@@ -1081,9 +1233,17 @@ func (a *app) GetBeibaoInfo(beibaoID int) (map[string]interface{}, error) {
 
 	// 转换为 map 以便 JSON 序列化
 	result := map[string]interface{}{
-		"id":    info.ID,
-		"name":  info.Name,
-		"items": info.Items,
+		"id":         info.ID,
+		"name":       info.Name,
+		"balance":    info.Balance,
+		"owner_type": info.OwnerType,
+		"owner_id":   info.OwnerID,
+		"owner_name": info.OwnerName,
+		"capacity":   info.Capacity,
+		"max_weight": info.MaxWeight,
+		"used_slots": info.UsedSlots,
+		"weight":     info.Weight,
+		"items":      info.Items,
 	}
 
 	return result, nil
@@ -1106,6 +1266,54 @@ func (a *app) UpdateBeibao(beibaoID int, name string) error {
 	return a.database.UpdateBeibao(beibaoID, name)
 }
 
+// SetBeibaoBalance 设置背包的货币余额
+func (a *app) SetBeibaoBalance(beibaoID, balance int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetBeibaoBalance(beibaoID, balance)
+}
+
+// TransferBeibaoItem 把物品的部分或全部数量转移到另一个背包
+func (a *app) TransferBeibaoItem(fromItemID, toBeibaoID, qty int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.TransferBeibaoItem(fromItemID, toBeibaoID, qty)
+}
+
+// TradeBetweenBeibao 两个背包之间交换物品和货币
+func (a *app) TradeBetweenBeibao(offerA, offerB database.BeibaoTradeOffer) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.TradeBetweenBeibao(offerA, offerB)
+}
+
+// GetBeibaoMovements 获取背包最近 100 条物品流水
+func (a *app) GetBeibaoMovements(beibaoID int) ([]database.BeibaoMovement, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetBeibaoMovements(beibaoID, 100)
+}
+
+// SetBeibaoCapacity 设置背包的格子容量和重量上限，为空表示不限
+func (a *app) SetBeibaoCapacity(beibaoID int, capacity, maxWeight *int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetBeibaoCapacity(beibaoID, capacity, maxWeight)
+}
+
+// SetBeibaoOwner 设置背包的主人（character、pet 或 faction），ownerType 为空时清除主人
+func (a *app) SetBeibaoOwner(beibaoID int, ownerType string, ownerID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetBeibaoOwner(beibaoID, ownerType, ownerID)
+}
+
 // DeleteBeibao 删除背包
 func (a *app) DeleteBeibao(beibaoID int) error {
 	if a.database == nil {
@@ -1114,12 +1322,12 @@ func (a *app) DeleteBeibao(beibaoID int) error {
 	return a.database.DeleteBeibao(beibaoID)
 }
 
-// AddBeibaoItem 添加背包物品
-func (a *app) AddBeibaoItem(beibaoID int, name string, quantity int, description string) error {
+// AddBeibaoItem 添加背包物品，daojuID 为 0 时按名称关联道具
+func (a *app) AddBeibaoItem(beibaoID int, name string, quantity int, description string, daojuID int) error {
 	if a.database == nil {
 		return fmt.Errorf("数据库未初始化")
 	}
-	return a.database.AddBeibaoItem(beibaoID, name, quantity, description)
+	return a.database.AddBeibaoItem(beibaoID, name, quantity, description, daojuID)
 }
 
 // DeleteBeibaoItem 删除背包物品
@@ -1130,12 +1338,12 @@ func (a *app) DeleteBeibaoItem(itemID int) error {
 	return a.database.DeleteBeibaoItem(itemID)
 }
 
-// UpdateBeibaoItem 更新背包物品
-func (a *app) UpdateBeibaoItem(itemID int, name string, quantity int, description string) error {
+// UpdateBeibaoItem 更新背包物品，daojuID 为 0 时按名称关联道具
+func (a *app) UpdateBeibaoItem(itemID int, name string, quantity int, description string, daojuID int) error {
 	if a.database == nil {
 		return fmt.Errorf("数据库未初始化")
 	}
-	return a.database.UpdateBeibaoItem(itemID, name, quantity, description)
+	return a.database.UpdateBeibaoItem(itemID, name, quantity, description, daojuID)
 }
 
 // ============ 商城相关接口 ============
@@ -1150,19 +1358,7 @@ func (a *app) GetAllShopping() ([]map[string]interface{}, error) {
 		return nil, err
 	}
 
-	// 转换为 map 以便 JSON 序列化
-	result := make([]map[string]interface{}, len(items))
-	for i, item := range items {
-		result[i] = map[string]interface{}{
-			"id":          item.ID,
-			"name":        item.Name,
-			"value":       item.Value,
-			"description": item.Description,
-			"condition":   item.Condition,
-		}
-	}
-
-	return result, nil
+	return shoppingListItems(items), nil
 }
 
 // GetShoppingInfo 获取指定商城商品的详细信息
@@ -1183,6 +1379,7 @@ func (a *app) GetShoppingInfo(id int) (map[string]interface{}, error) {
 		"value":       item.Value,
 		"description": item.Description,
 		"condition":   item.Condition,
+		"stock":       item.Stock,
 	}
 
 	return result, nil
@@ -1213,31 +1410,155 @@ func (a *app) UpdateShopping(id int, name string, value int, description, condit
 	return a.database.UpdateShopping(id, name, value, description, condition)
 }
 
+// SetShoppingStock 设置商品库存，stock 为空表示不限库存
+func (a *app) SetShoppingStock(id int, stock *int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetShoppingStock(id, stock)
+}
+
+// PurchaseShoppingItem 用背包余额购买商品，商品放入该背包
+func (a *app) PurchaseShoppingItem(itemID, buyerID, qty int) (database.ShoppingPurchase, error) {
+	if a.database == nil {
+		return database.ShoppingPurchase{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.PurchaseShoppingItem(itemID, buyerID, qty)
+}
+
+// GetShoppingPurchases 获取最近 100 条购买记录，beibaoID 为 0 时返回所有背包的记录
+func (a *app) GetShoppingPurchases(beibaoID int) ([]database.ShoppingPurchase, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetShoppingPurchases(beibaoID, 100)
+}
+
+// EvaluateShoppingCondition 针对人物检查商品的购买条件，逐条说明是否满足
+func (a *app) EvaluateShoppingCondition(itemID, characterID int) (database.ShoppingConditionResult, error) {
+	if a.database == nil {
+		return database.ShoppingConditionResult{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.EvaluateShoppingCondition(itemID, characterID)
+}
+
+// ============ 账本相关接口 ============
+
+// TransferCurrency 在人物、势力、背包、商城与世界之间转账
+func (a *app) TransferCurrency(from, to database.LedgerAccount, amount int, memo string) (database.LedgerEntry, error) {
+	if a.database == nil {
+		return database.LedgerEntry{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.TransferCurrency(from, to, amount, memo)
+}
+
+// ReverseLedgerEntry 冲正一笔转账
+func (a *app) ReverseLedgerEntry(entryID int, memo string) (database.LedgerEntry, error) {
+	if a.database == nil {
+		return database.LedgerEntry{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.ReverseLedgerEntry(entryID, memo)
+}
+
+// GetLedgerHistory 获取账户最近 100 条转账记录
+func (a *app) GetLedgerHistory(account database.LedgerAccount) ([]database.LedgerEntry, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetLedgerHistory(account, 100)
+}
+
+// GetLedgerBalance 获取账户按账本计算的余额
+func (a *app) GetLedgerBalance(account database.LedgerAccount) (database.LedgerBalance, error) {
+	if a.database == nil {
+		return database.LedgerBalance{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetLedgerBalance(account)
+}
+
+// ReconcileLedger 对账，fix 为 true 时补记差额
+func (a *app) ReconcileLedger(fix bool) ([]database.LedgerBalance, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.ReconcileLedger(fix)
+}
+
+// ============ 历法与时间线相关接口 ============
+
+// GetWorldCalendar 获取世界历法
+func (a *app) GetWorldCalendar() (database.WorldCalendar, error) {
+	if a.database == nil {
+		return database.WorldCalendar{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetWorldCalendar()
+}
+
+// SetWorldCalendar 设置世界历法，已保存的日期随之换算
+func (a *app) SetWorldCalendar(calendar database.WorldCalendar) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetWorldCalendar(calendar)
+}
+
+// ParseWorldDate 按当前历法解析日期文本
+func (a *app) ParseWorldDate(text string) (database.WorldDate, error) {
+	if a.database == nil {
+		return database.WorldDate{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.ParseWorldDate(text)
+}
+
+// SetCharacterBirth 设置人物的出生日期，为空时清除
+func (a *app) SetCharacterBirth(characterID int, date string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetCharacterBirth(characterID, date)
+}
+
+// SetShiliFounding 设置势力的创立日期，为空时清除
+func (a *app) SetShiliFounding(shiliID int, date string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetShiliFounding(shiliID, date)
+}
+
+// SetShiqingWorldDate 设置任务发生的世界日期，为空时清除
+func (a *app) SetShiqingWorldDate(shiqingID int, date string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetShiqingWorldDate(shiqingID, date)
+}
+
+// GetTimeline 获取 from 到 to 之间按世界日期排列的事件，日期为空表示不限
+func (a *app) GetTimeline(from, to string, filter database.TimelineFilter) ([]database.TimelineEvent, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetTimeline(from, to, filter)
+}
+
 // ============ 抽奖相关接口 ============
 
-// GetAllPrizes 获取所有奖品列表
-func (a *app) GetAllPrizes() ([]map[string]interface{}, error) {
+// GetAllPrizes 获取奖池中的所有奖品，poolID 为 0 时使用默认奖池
+func (a *app) GetAllPrizes(poolID int) ([]map[string]interface{}, error) {
 	if a.database == nil {
 		return nil, fmt.Errorf("数据库未初始化")
 	}
-	prizes, err := a.database.GetAllPrizes()
+	pool, err := a.database.GetPrizePool(poolID)
 	if err != nil {
 		return nil, err
 	}
-
-	// 转换为 map 以便 JSON 序列化
-	result := make([]map[string]interface{}, len(prizes))
-	for i, prize := range prizes {
-		result[i] = map[string]interface{}{
-			"id":          prize.ID,
-			"name":        prize.Name,
-			"rate":        prize.Rate,
-			"description": prize.Description,
-			"variety":     prize.Variety,
-		}
+	prizes, _, err := a.database.ListPrizes(database.ListQuery{PoolID: &pool.ID})
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return prizeListItems(prizes), nil
 }
 
 // GetPrizeInfo 获取奖品详细信息
@@ -1254,21 +1575,25 @@ func (a *app) GetPrizeInfo(prizeID int) (map[string]interface{}, error) {
 	// 转换为 map 以便 JSON 序列化
 	result := map[string]interface{}{
 		"id":          info.ID,
+		"pool_id":     info.PoolID,
 		"name":        info.Name,
 		"rate":        info.Rate,
 		"description": info.Description,
 		"variety":     info.Variety,
+		"featured":    info.Featured,
+		"consolation": info.Consolation,
+		"quantity":    info.Quantity,
 	}
 
 	return result, nil
 }
 
-// CreatePrize 创建新奖品
-func (a *app) CreatePrize(name string, rate float64, description, prizeVariety string) (int, error) {
+// CreatePrize 在奖池中创建新奖品，poolID 为 0 时放入默认奖池
+func (a *app) CreatePrize(poolID int, name string, rate float64, description, prizeVariety string) (int, error) {
 	if a.database == nil {
 		return 0, fmt.Errorf("数据库未初始化")
 	}
-	id, err := a.database.CreatePrize(name, rate, description, prizeVariety)
+	id, err := a.database.CreatePrize(poolID, name, rate, description, prizeVariety)
 	return int(id), err
 }
 
@@ -1288,104 +1613,244 @@ func (a *app) UpdatePrize(prizeID int, name string, rate float64, description, p
 	return a.database.UpdatePrize(prizeID, name, rate, description, prizeVariety)
 }
 
-// DrawOnce 单抽
-func (a *app) DrawOnce() (map[string]interface{}, error) {
+// DrawOnce 在奖池中单抽，poolID 为 0 时使用默认奖池；beibaoID 不为 0 时奖品放入该背包
+func (a *app) DrawOnce(poolID, beibaoID int) (map[string]interface{}, error) {
 	if a.database == nil {
 		return nil, fmt.Errorf("数据库未初始化")
 	}
 
-	result, err := a.database.DrawPrize()
+	result, err := a.database.DrawPrize(poolID, beibaoID)
 	if err != nil {
 		return nil, err
 	}
+	return a.drawResultItem(result)
+}
+
+// DrawTen 在奖池中十连抽，poolID 为 0 时使用默认奖池；beibaoID 不为 0 时奖品放入该背包
+func (a *app) DrawTen(poolID, beibaoID int) ([]map[string]interface{}, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
 
-	// 获取奖品的详细信息（包括rate）
-	prizeInfo, err := a.database.GetPrizeInfo(result.PrizeID)
+	results, err := a.database.DrawTenPrizes(poolID, beibaoID)
 	if err != nil {
 		return nil, err
 	}
+	return a.drawResultItems(results)
+}
+
+// GetDrawHistory 获取抽奖历史，poolID 为 0 时返回所有奖池的记录
+func (a *app) GetDrawHistory(poolID int) ([]map[string]interface{}, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	history, err := a.database.GetDrawHistory(poolID, 100)
+	if err != nil {
+		return nil, err
+	}
+	return a.drawResultItems(history)
+}
+
+func (a *app) drawResultItems(results []database.DrawResult) ([]map[string]interface{}, error) {
+	items := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		item, err := a.drawResultItem(result)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// drawResultItem 将抽奖结果转换为 map 以便 JSON 序列化，并补充奖品的爆率等详细信息；
+// “未中奖”结果没有对应的奖品
+func (a *app) drawResultItem(result database.DrawResult) (map[string]interface{}, error) {
+	prizeInfo := database.PrizeInfo{Name: result.PrizeName, Variety: database.NothingPrizeName}
+	if result.PrizeID != 0 {
+		var err error
+		if prizeInfo, err = a.database.GetPrizeInfo(result.PrizeID); err != nil {
+			return nil, err
+		}
+	}
 
-	// 转换为 map 以便 JSON 序列化
 	return map[string]interface{}{
-		"id":          prizeInfo.ID,
-		"name":        prizeInfo.Name,
+		"id":          result.PrizeID,
+		"name":        result.PrizeName,
 		"rate":        prizeInfo.Rate,
 		"description": prizeInfo.Description,
 		"variety":     prizeInfo.Variety,
+		"featured":    prizeInfo.Featured,
+		"pool_id":     result.PoolID,
+		"cost":        result.Cost,
+		"history_id":  result.ID,
+		"seed":        result.Seed,
+		"sequence":    result.Sequence,
+		"roll":        result.Roll,
+		"beibao_id":   result.BeibaoID,
+		"claimed_at":  result.ClaimedAt,
 		"drawn_at":    result.DrawnAt,
 	}, nil
 }
 
-// DrawTen 十连抽
-func (a *app) DrawTen() ([]map[string]interface{}, error) {
+// ClearDrawHistory 清空抽奖历史，poolID 为 0 时清空所有奖池的记录
+func (a *app) ClearDrawHistory(poolID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.ClearDrawHistory(poolID)
+}
+
+// GetPrizePools 获取所有奖池
+func (a *app) GetPrizePools() ([]database.PrizePool, error) {
 	if a.database == nil {
 		return nil, fmt.Errorf("数据库未初始化")
 	}
+	return a.database.GetPrizePools()
+}
 
-	results, err := a.database.DrawTenPrizes()
-	if err != nil {
-		return nil, err
+// CreatePrizePool 创建奖池
+func (a *app) CreatePrizePool(name, description, startAt, endAt string, drawCost int) (int, error) {
+	if a.database == nil {
+		return 0, fmt.Errorf("数据库未初始化")
 	}
+	id, err := a.database.CreatePrizePool(name, description, startAt, endAt, drawCost)
+	return int(id), err
+}
 
-	// 转换为 map 以便 JSON 序列化，并获取每个奖品的详细信息
-	result := make([]map[string]interface{}, len(results))
-	for i, item := range results {
-		// 获取奖品的详细信息（包括rate）
-		prizeInfo, err := a.database.GetPrizeInfo(item.PrizeID)
-		if err != nil {
-			return nil, err
-		}
+// UpdatePrizePool 更新奖池信息
+func (a *app) UpdatePrizePool(poolID int, name, description, startAt, endAt string, drawCost int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.UpdatePrizePool(poolID, name, description, startAt, endAt, drawCost)
+}
 
-		result[i] = map[string]interface{}{
-			"id":          prizeInfo.ID,
-			"name":        prizeInfo.Name,
-			"rate":        prizeInfo.Rate,
-			"description": prizeInfo.Description,
-			"variety":     prizeInfo.Variety,
-			"drawn_at":    item.DrawnAt,
-		}
+// DeletePrizePool 删除奖池及其奖品和抽奖历史
+func (a *app) DeletePrizePool(poolID int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
 	}
+	return a.database.DeletePrizePool(poolID)
+}
 
-	return result, nil
+// SetPrizePoolSeed 设置奖池的随机种子，seed 为 0 时重新生成
+func (a *app) SetPrizePoolSeed(poolID int, seed int64) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetPrizePoolSeed(poolID, seed)
 }
 
-// GetDrawHistory 获取抽奖历史
-func (a *app) GetDrawHistory() ([]map[string]interface{}, error) {
+// ReplayDraws 从某条抽奖历史开始重放 count 次抽奖并与记录对照
+func (a *app) ReplayDraws(historyID, count int) ([]database.DrawReplay, error) {
 	if a.database == nil {
 		return nil, fmt.Errorf("数据库未初始化")
 	}
+	return a.database.ReplayDraws(historyID, count)
+}
 
-	history, err := a.database.GetDrawHistory(100)
-	if err != nil {
-		return nil, err
+// SetPrizePoolRateMode 设置奖池的概率模式（normalized 按比例 / absolute 绝对）
+func (a *app) SetPrizePoolRateMode(poolID int, mode string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
 	}
+	return a.database.SetPrizePoolRateMode(poolID, mode)
+}
 
-	// 转换为 map 以便 JSON 序列化，并获取每个奖品的详细信息
-	result := make([]map[string]interface{}, len(history))
-	for i, item := range history {
-		// 获取奖品的详细信息（包括rate和variety）
-		prizeInfo, err := a.database.GetPrizeInfo(item.PrizeID)
-		if err != nil {
-			return nil, err
-		}
+// SetPrizeQuantity 设置奖品每次抽中放入背包的数量
+func (a *app) SetPrizeQuantity(prizeID, quantity int) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetPrizeQuantity(prizeID, quantity)
+}
 
-		result[i] = map[string]interface{}{
-			"id":          item.PrizeID,
-			"name":        item.PrizeName,
-			"rate":        prizeInfo.Rate,
-			"variety":     prizeInfo.Variety,
-			"description": prizeInfo.Description,
-			"drawn_at":    item.DrawnAt,
-		}
+// ClaimDrawHistory 将尚未领取的抽奖记录放入背包，返回实际领取的条数
+func (a *app) ClaimDrawHistory(historyIDs []int, beibaoID int) (int, error) {
+	if a.database == nil {
+		return 0, fmt.Errorf("数据库未初始化")
 	}
+	return a.database.ClaimDrawHistory(historyIDs, beibaoID)
+}
 
-	return result, nil
+// SetPrizeConsolation 设置奖品是否为所在奖池的安慰奖
+func (a *app) SetPrizeConsolation(prizeID int, consolation bool) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetPrizeConsolation(prizeID, consolation)
+}
+
+// CheckPrizePoolRates 检查奖池的爆率配置
+func (a *app) CheckPrizePoolRates(poolID int) (database.PrizeRateCheck, error) {
+	if a.database == nil {
+		return database.PrizeRateCheck{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.CheckPrizePoolRates(poolID)
+}
+
+// SimulateDraws 在奖池中模拟 trials 轮、每轮 n 次抽奖并统计，不写入抽奖历史
+func (a *app) SimulateDraws(poolID, n, trials int) (database.DrawStatistics, error) {
+	if a.database == nil {
+		return database.DrawStatistics{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SimulateDraws(poolID, n, trials)
+}
+
+// GetDrawStatistics 按奖池的抽奖历史统计实际爆率和抽中所需抽数
+func (a *app) GetDrawStatistics(poolID int) (database.DrawStatistics, error) {
+	if a.database == nil {
+		return database.DrawStatistics{}, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetDrawStatistics(poolID)
+}
+
+// SetPrizeFeatured 设置奖品是否为 UP 奖品
+func (a *app) SetPrizeFeatured(prizeID int, featured bool) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetPrizeFeatured(prizeID, featured)
+}
+
+// GetPityRules 获取所有保底规则
+func (a *app) GetPityRules() ([]database.PityRule, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetPityRules()
+}
+
+// SetPityRule 新增或更新某个奖品品种的保底规则
+func (a *app) SetPityRule(rule database.PityRule) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.SetPityRule(rule)
+}
+
+// DeletePityRule 删除某个奖品品种的保底规则
+func (a *app) DeletePityRule(variety string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	return a.database.DeletePityRule(variety)
+}
+
+// GetPityState 获取奖池的当前保底状态，poolID 为 0 时使用默认奖池
+func (a *app) GetPityState(poolID int) ([]database.PityState, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GetPityState(poolID)
 }
 
-// ClearDrawHistory 清空抽奖历史
-func (a *app) ClearDrawHistory() error {
+// ResetPityState 重置奖池的保底计数，poolID 为 0 时使用默认奖池
+func (a *app) ResetPityState(poolID int) error {
 	if a.database == nil {
 		return fmt.Errorf("数据库未初始化")
 	}
-	return a.database.ClearDrawHistory()
+	return a.database.ResetPityState(poolID)
 }
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"nooltools/apps/database"
+	"nooltools/apps/storage"
+	"strings"
+	"time"
+)
+
+// createBackup 创建备份并按保留策略清理旧的自动备份，调用方需持有 backupMu
+func (a *app) createBackup(reason string) (*database.BackupInfo, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	info, err := a.database.CreateBackup(reason)
+	if err != nil {
+		return nil, err
+	}
+
+	retention, err := storage.LoadBackupConfig()
+	if err != nil {
+		log.Printf("读取备份配置失败，跳过清理旧备份: %v", err)
+		return info, nil
+	}
+	dataDir, err := a.database.GetDataDir()
+	if err != nil {
+		return info, nil
+	}
+	if removed, err := database.PruneBackups(dataDir, retention); err != nil {
+		log.Printf("清理旧备份失败: %v", err)
+	} else if len(removed) > 0 {
+		log.Printf("已清理旧备份 %d 份", len(removed))
+	}
+	return info, nil
+}
+
+// runAutomaticBackup 执行自动备份，失败只记录日志
+func (a *app) runAutomaticBackup(reason string) {
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	if a.database == nil {
+		return
+	}
+	info, err := a.createBackup(reason)
+	if err != nil {
+		log.Printf("自动备份失败(%s): %v", reason, err)
+		return
+	}
+	log.Printf("自动备份完成(%s): %s", reason, info.ID)
+}
+
+// startBackupScheduler 按配置的间隔启动定时备份，已有的定时任务会先停止
+func (a *app) startBackupScheduler() {
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	a.stopBackupSchedulerLocked()
+
+	cfg, err := storage.LoadBackupConfig()
+	if err != nil {
+		log.Printf("读取备份配置失败，定时备份未启动: %v", err)
+		return
+	}
+	if cfg.IntervalMinutes <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	a.backupStop = stop
+	interval := time.Duration(cfg.IntervalMinutes) * time.Minute
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				a.runAutomaticBackup(database.BackupReasonInterval)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopBackupScheduler 停止定时备份
+func (a *app) stopBackupScheduler() {
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	a.stopBackupSchedulerLocked()
+}
+
+func (a *app) stopBackupSchedulerLocked() {
+	if a.backupStop != nil {
+		close(a.backupStop)
+		a.backupStop = nil
+	}
+}
+
+// CreateBackup 手动创建备份，手动备份不会被保留策略清理
+func (a *app) CreateBackup() (*database.BackupInfo, error) {
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	return a.createBackup(database.BackupReasonManual)
+}
+
+// ListBackups 获取当前数据目录下的所有备份
+func (a *app) ListBackups() ([]database.BackupInfo, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	dataDir, err := a.database.GetDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return database.ListBackups(dataDir)
+}
+
+// RestoreBackup 从指定备份恢复数据库与 markdown 目录。
+// 恢复前会先校验备份并为当前数据创建一份备份，然后关闭数据库、替换文件并重新打开；
+// 重新打开失败时还原恢复前的备份。
+func (a *app) RestoreBackup(id string) error {
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("备份ID不能为空")
+	}
+
+	dataDir, err := a.database.GetDataDir()
+	if err != nil {
+		return err
+	}
+	backups, err := database.ListBackups(dataDir)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, backup := range backups {
+		if backup.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("备份不存在: %s", id)
+	}
+
+	// 关闭当前数据库之前先确认备份可以打开，无效的备份直接拒绝
+	if err := database.VerifyBackup(dataDir, id); err != nil {
+		return err
+	}
+
+	// 这里不执行保留策略清理，以免要恢复的备份被清理掉
+	preRestore, err := a.database.CreateBackup(database.BackupReasonPreRestore)
+	if err != nil {
+		return fmt.Errorf("恢复前备份当前数据失败: %w", err)
+	}
+
+	if err := a.database.Close(); err != nil {
+		return fmt.Errorf("关闭当前数据库失败: %w", err)
+	}
+	a.database = nil
+
+	restoreErr := database.RestoreBackup(dataDir, id)
+	reopenErr := a.reopenDatabaseAtDir(dataDir)
+	if reopenErr == nil {
+		return restoreErr
+	}
+
+	// 恢复后的数据库无法打开时，放回恢复前的快照，保证应用仍有可用的数据库
+	if err := database.RestoreBackup(dataDir, preRestore.ID); err != nil {
+		return fmt.Errorf("恢复后重新打开数据库失败: %v；还原恢复前的数据也失败: %v", reopenErr, err)
+	}
+	if err := a.reopenDatabaseAtDir(dataDir); err != nil {
+		return fmt.Errorf("恢复后重新打开数据库失败: %v；还原恢复前的数据后仍无法打开: %v", reopenErr, err)
+	}
+	if restoreErr != nil {
+		return fmt.Errorf("恢复备份失败: %v；已还原恢复前的数据", restoreErr)
+	}
+	return fmt.Errorf("恢复后重新打开数据库失败: %v；已还原恢复前的数据", reopenErr)
+}
+
+// GetBackupSettings 获取自动备份配置
+func (a *app) GetBackupSettings() (storage.BackupConfig, error) {
+	return storage.LoadBackupConfig()
+}
+
+// UpdateBackupSettings 保存自动备份配置并按新的间隔重启定时备份
+func (a *app) UpdateBackupSettings(cfg storage.BackupConfig) error {
+	if err := storage.SetBackupConfig(cfg); err != nil {
+		return err
+	}
+	a.startBackupScheduler()
+	return nil
+}
+
+func (a *app) reopenDatabaseAtDir(dataDir string) error {
+	db, err := database.NewDatabaseAtDir(dataDir)
+	if err != nil {
+		a.database = nil
+		return err
+	}
+	a.database = db
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"nooltools/apps/database"
+	"nooltools/apps/storage"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreBackup_ReopensDatabaseAndKeepsPreRestoreBackup(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+
+	if err := storage.SetBackupConfig(storage.BackupConfig{KeepLast: 1}); err != nil {
+		t.Fatalf("SetBackupConfig() failed: %v", err)
+	}
+
+	appInstance := NewApp()
+	db, err := database.NewDatabase()
+	if err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+	appInstance.database = db
+	t.Cleanup(func() {
+		if appInstance.database != nil {
+			_ = appInstance.database.Close()
+		}
+	})
+
+	appInstance.runAutomaticBackup(database.BackupReasonStartup)
+	backups, err := appInstance.ListBackups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("ListBackups() = %+v, %v", backups, err)
+	}
+	target := backups[0].ID
+
+	if _, err := appInstance.database.CreateShopping("恢复后应消失", 1, "", ""); err != nil {
+		t.Fatalf("CreateShopping() failed: %v", err)
+	}
+
+	if err := appInstance.RestoreBackup(target); err != nil {
+		t.Fatalf("RestoreBackup() failed: %v", err)
+	}
+	if appInstance.database == nil {
+		t.Fatalf("expected database to be reopened after restore")
+	}
+	items, err := appInstance.database.GetAllShopping()
+	if err != nil || len(items) != 0 {
+		t.Fatalf("expected restored database to be empty, got %v, %v", items, err)
+	}
+
+	backups, err = appInstance.ListBackups()
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("expected target and pre-restore backups, got %+v, %v", backups, err)
+	}
+
+	if err := appInstance.RestoreBackup("missing"); err == nil {
+		t.Fatalf("expected error for unknown backup id")
+	}
+	if appInstance.database == nil {
+		t.Fatalf("database must stay open after a rejected restore")
+	}
+}
+
+func TestRestoreBackup_RejectsNewerBackupWithoutClosingDatabase(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+
+	appInstance := NewApp()
+	db, err := database.NewDatabase()
+	if err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+	appInstance.database = db
+	t.Cleanup(func() {
+		if appInstance.database != nil {
+			_ = appInstance.database.Close()
+		}
+	})
+
+	backup, err := appInstance.CreateBackup()
+	if err != nil {
+		t.Fatalf("CreateBackup() failed: %v", err)
+	}
+	dataDir, _ := appInstance.database.GetDataDir()
+	backupDB, err := sql.Open("sqlite3", filepath.Join(database.BackupsDir(dataDir), backup.ID, storage.DatabaseFileName))
+	if err != nil {
+		t.Fatalf("failed to open backup database: %v", err)
+	}
+	if _, err := backupDB.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, '未来的迁移')`, database.LatestSchemaVersion()+1); err != nil {
+		t.Fatalf("failed to bump backup version: %v", err)
+	}
+	backupDB.Close()
+
+	if _, err := appInstance.database.CreateShopping("应当保留", 1, "", ""); err != nil {
+		t.Fatalf("CreateShopping() failed: %v", err)
+	}
+	if err := appInstance.RestoreBackup(backup.ID); !errors.Is(err, database.ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+	if appInstance.database == nil {
+		t.Fatalf("database must stay open after a rejected restore")
+	}
+	items, err := appInstance.database.GetAllShopping()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected current data to be kept, got %v, %v", items, err)
+	}
+	backups, err := appInstance.ListBackups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected no pre-restore backup for a rejected restore, got %+v, %v", backups, err)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"nooltools/apps/database"
+	"strings"
+)
+
+// GetCSVEntityTypes 获取支持 CSV 导入导出的实体类型
+func (a *app) GetCSVEntityTypes() []string {
+	return database.GetCSVEntityTypes()
+}
+
+// ExportEntityCSV 将指定类型的实体导出为 CSV 文件
+func (a *app) ExportEntityCSV(entityType, path string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if strings.TrimSpace(path) == "" {
+		return fmt.Errorf("导出路径不能为空")
+	}
+	return a.database.ExportCSVToFile(entityType, path)
+}
+
+// ImportEntityCSV 从 CSV 文件导入指定类型的实体，返回逐行的错误报告
+func (a *app) ImportEntityCSV(entityType, path string) (*database.CSVImportResult, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("导入路径不能为空")
+	}
+	return a.database.ImportCSVFromFile(entityType, path)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"nooltools/apps/database"
+)
+
+// ListPage 分页列表结果
+type ListPage struct {
+	Items      []map[string]interface{} `json:"items"`
+	Total      int                      `json:"total"`       // 符合筛选条件的记录总数
+	NextCursor string                   `json:"next_cursor"` // 下一页游标，已无更多记录时为空
+}
+
+func newListPage(items []map[string]interface{}, result database.ListResult) ListPage {
+	if items == nil {
+		items = []map[string]interface{}{}
+	}
+	return ListPage{Items: items, Total: result.Total, NextCursor: result.NextCursor}
+}
+
+// ListCharacters 按筛选、排序和分页条件获取人物列表
+func (a *app) ListCharacters(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	items, result, err := a.database.ListCharacters(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(items, result), nil
+}
+
+// ListWeapons 按筛选、排序和分页条件获取武器列表
+func (a *app) ListWeapons(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	items, result, err := a.database.ListWeapons(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(items, result), nil
+}
+
+// ListPets 按筛选、排序和分页条件获取宠物列表
+func (a *app) ListPets(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	items, result, err := a.database.ListPets(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(items, result), nil
+}
+
+// ListDaoju 按筛选、排序和分页条件获取道具列表
+func (a *app) ListDaoju(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	items, result, err := a.database.ListDaoju(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(items, result), nil
+}
+
+// ListShiqing 按筛选、排序和分页条件获取任务列表
+func (a *app) ListShiqing(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	items, result, err := a.database.ListShiqing(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(items, result), nil
+}
+
+// ListGuaiwu 按筛选、排序和分页条件获取怪物列表
+func (a *app) ListGuaiwu(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	guaiwuList, result, err := a.database.ListGuaiwu(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(guaiwuListItems(guaiwuList), result), nil
+}
+
+// ListShili 按筛选、排序和分页条件获取势力列表
+func (a *app) ListShili(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	shiliList, result, err := a.database.ListShili(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(shiliListItems(shiliList), result), nil
+}
+
+// ListBeibao 按筛选、排序和分页条件获取背包列表
+func (a *app) ListBeibao(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	items, result, err := a.database.ListBeibao(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(items, result), nil
+}
+
+// ListShopping 按筛选、排序和分页条件获取商城商品
+func (a *app) ListShopping(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	items, result, err := a.database.ListShopping(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(shoppingListItems(items), result), nil
+}
+
+// ListPrizes 按筛选、排序和分页条件获取奖品列表
+func (a *app) ListPrizes(query database.ListQuery) (ListPage, error) {
+	if a.database == nil {
+		return ListPage{}, fmt.Errorf("数据库未初始化")
+	}
+	prizes, result, err := a.database.ListPrizes(query)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return newListPage(prizeListItems(prizes), result), nil
+}
+
+// guaiwuListItems 转换为 map 以便 JSON 序列化
+func guaiwuListItems(guaiwuList []database.GuaiwuInfo) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(guaiwuList))
+	for i, g := range guaiwuList {
+		result[i] = map[string]interface{}{
+			"id":      g.ID,
+			"name":    g.Name,
+			"type":    g.Type,
+			"level":   g.Level,
+			"health":  g.Health,
+			"attack":  g.Attack,
+			"defense": g.Defense,
+			"rewards": g.Rewards,
+		}
+	}
+	return result
+}
+
+// shiliListItems 转换为 map 以便 JSON 序列化
+func shiliListItems(shiliList []database.ShiliInfo) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(shiliList))
+	for i, s := range shiliList {
+		result[i] = map[string]interface{}{
+			"id":           s.ID,
+			"name":         s.Name,
+			"level":        s.Level,
+			"founder":      s.Founder,
+			"wealth":       s.Wealth,
+			"member_count": s.MemberCount,
+			"max_members":  s.MaxMembers,
+		}
+	}
+	return result
+}
+
+// shoppingListItems 转换为 map 以便 JSON 序列化
+func shoppingListItems(items []database.ShoppingInfo) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		result[i] = map[string]interface{}{
+			"id":          item.ID,
+			"name":        item.Name,
+			"value":       item.Value,
+			"description": item.Description,
+			"condition":   item.Condition,
+			"stock":       item.Stock,
+		}
+	}
+	return result
+}
+
+// prizeListItems 转换为 map 以便 JSON 序列化
+func prizeListItems(prizes []database.PrizeInfo) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(prizes))
+	for i, prize := range prizes {
+		result[i] = map[string]interface{}{
+			"id":          prize.ID,
+			"pool_id":     prize.PoolID,
+			"name":        prize.Name,
+			"rate":        prize.Rate,
+			"description": prize.Description,
+			"variety":     prize.Variety,
+			"featured":    prize.Featured,
+			"consolation": prize.Consolation,
+			"quantity":    prize.Quantity,
+		}
+	}
+	return result
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"nooltools/apps/database"
+	"nooltools/apps/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WorldProject 世界（项目）信息，每个世界拥有独立的数据目录
+type WorldProject struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	DataDir      string    `json:"data_dir"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastOpenedAt time.Time `json:"last_opened_at"`
+	IsCurrent    bool      `json:"is_current"`
+	IsMissing    bool      `json:"is_missing"` // 数据目录已不存在
+}
+
+// ListWorlds 获取所有已登记的世界，最近打开的在前
+func (a *app) ListWorlds() ([]WorldProject, error) {
+	projects, err := storage.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	currentDataDir, err := storage.ResolveDataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	worlds := make([]WorldProject, 0, len(projects))
+	for _, project := range projects {
+		worlds = append(worlds, toWorldProject(project, currentDataDir))
+	}
+	return worlds, nil
+}
+
+// GetCurrentWorld 获取当前打开的世界；只读取世界登记信息，登记由启动流程完成。
+// 当前目录尚未登记（例如启动时登记失败）时返回没有ID的世界信息
+func (a *app) GetCurrentWorld() (WorldProject, error) {
+	currentDataDir, err := storage.ResolveDataDir()
+	if err != nil {
+		return WorldProject{}, err
+	}
+	project, found, err := storage.FindProjectByDataDir(currentDataDir)
+	if err != nil {
+		return WorldProject{}, err
+	}
+	if !found {
+		project = storage.Project{Name: defaultProjectName(currentDataDir), DataDir: currentDataDir}
+	}
+	return toWorldProject(project, currentDataDir), nil
+}
+
+// CreateWorld 创建一个新的空世界；dataDir 为空时在默认数据目录下自动分配位置。
+// 创建后不会自动切换，需要再调用 SwitchWorld。
+func (a *app) CreateWorld(name, dataDir string) (WorldProject, error) {
+	if strings.TrimSpace(name) == "" {
+		return WorldProject{}, fmt.Errorf("世界名称不能为空")
+	}
+
+	if strings.TrimSpace(dataDir) == "" {
+		generated, err := storage.NewProjectDataDir()
+		if err != nil {
+			return WorldProject{}, err
+		}
+		dataDir = generated
+	}
+	normalizedDir, err := storage.NormalizePath(dataDir)
+	if err != nil {
+		return WorldProject{}, err
+	}
+	if _, err := os.Stat(filepath.Join(normalizedDir, storage.DatabaseFileName)); err == nil {
+		return WorldProject{}, fmt.Errorf("目录中已存在数据库，请使用打开世界: %s", normalizedDir)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return WorldProject{}, fmt.Errorf("failed to inspect database file: %w", err)
+	}
+
+	project, err := storage.RegisterProject(name, normalizedDir)
+	if err != nil {
+		return WorldProject{}, err
+	}
+	if err := initializeWorldDir(normalizedDir); err != nil {
+		if removeErr := storage.RemoveProject(project.ID); removeErr != nil {
+			log.Printf("撤销世界登记失败: %v", removeErr)
+		}
+		return WorldProject{}, err
+	}
+
+	currentDataDir, _ := storage.ResolveDataDir()
+	return toWorldProject(project, currentDataDir), nil
+}
+
+// OpenWorld 登记一个已有的数据目录为世界并切换过去
+func (a *app) OpenWorld(name, dataDir string) (WorldProject, error) {
+	normalizedDir, err := storage.NormalizePath(dataDir)
+	if err != nil {
+		return WorldProject{}, err
+	}
+	if _, err := os.Stat(filepath.Join(normalizedDir, storage.DatabaseFileName)); err != nil {
+		return WorldProject{}, fmt.Errorf("目录中没有找到数据库文件: %s", normalizedDir)
+	}
+
+	project, found, err := storage.FindProjectByDataDir(normalizedDir)
+	if err != nil {
+		return WorldProject{}, err
+	}
+	if !found {
+		if strings.TrimSpace(name) == "" {
+			name = filepath.Base(normalizedDir)
+		}
+		project, err = storage.RegisterProject(name, normalizedDir)
+		if err != nil {
+			return WorldProject{}, err
+		}
+	}
+	return a.SwitchWorld(project.ID)
+}
+
+// SwitchWorld 切换到指定世界，无需重启即可替换当前数据库
+func (a *app) SwitchWorld(id string) (WorldProject, error) {
+	project, err := storage.FindProject(id)
+	if errors.Is(err, storage.ErrProjectNotFound) {
+		return WorldProject{}, fmt.Errorf("世界不存在")
+	}
+	if err != nil {
+		return WorldProject{}, err
+	}
+	if _, err := os.Stat(project.DataDir); errors.Is(err, os.ErrNotExist) {
+		return WorldProject{}, fmt.Errorf("世界「%s」的存储目录不存在: %s", project.Name, project.DataDir)
+	}
+
+	previousDataDir, err := storage.ResolveDataDir()
+	if err != nil {
+		return WorldProject{}, err
+	}
+
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	// bootstrapDataDir 只有在新数据库可用时才会替换并关闭当前数据库
+	if err := a.bootstrapDataDir(project.DataDir); err != nil {
+		return WorldProject{}, fmt.Errorf("打开世界「%s」失败: %w", project.Name, err)
+	}
+
+	activated, err := storage.ActivateProject(project.ID)
+	if err != nil {
+		if reopenErr := a.bootstrapDataDir(previousDataDir); reopenErr != nil {
+			return WorldProject{}, fmt.Errorf("failed to switch storage config: %v; additionally failed to reopen previous world: %v", err, reopenErr)
+		}
+		return WorldProject{}, err
+	}
+
+	a.storageStartupNotice = ""
+	return toWorldProject(activated, activated.DataDir), nil
+}
+
+// RenameWorld 重命名世界
+func (a *app) RenameWorld(id, name string) error {
+	if err := storage.RenameProject(id, name); errors.Is(err, storage.ErrProjectNotFound) {
+		return fmt.Errorf("世界不存在")
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RemoveWorld 从列表中移除世界，数据目录中的文件保持不变；不能移除当前打开的世界
+func (a *app) RemoveWorld(id string) error {
+	project, err := storage.FindProject(id)
+	if errors.Is(err, storage.ErrProjectNotFound) {
+		return fmt.Errorf("世界不存在")
+	}
+	if err != nil {
+		return err
+	}
+
+	currentDataDir, err := storage.ResolveDataDir()
+	if err != nil {
+		return err
+	}
+	if storage.PathsEqual(project.DataDir, currentDataDir) {
+		return fmt.Errorf("不能移除当前打开的世界，请先切换到其他世界")
+	}
+	return storage.RemoveProject(id)
+}
+
+// initializeWorldDir 创建世界的目录结构并初始化数据库
+func initializeWorldDir(dataDir string) error {
+	if err := ensureStorageLayout(dataDir); err != nil {
+		return err
+	}
+	db, err := database.NewDatabaseAtDir(dataDir)
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+func toWorldProject(project storage.Project, currentDataDir string) WorldProject {
+	_, statErr := os.Stat(project.DataDir)
+	return WorldProject{
+		ID:           project.ID,
+		Name:         project.Name,
+		DataDir:      project.DataDir,
+		CreatedAt:    project.CreatedAt,
+		LastOpenedAt: project.LastOpenedAt,
+		IsCurrent:    storage.PathsEqual(project.DataDir, currentDataDir),
+		IsMissing:    errors.Is(statErr, os.ErrNotExist),
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"nooltools/apps/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSwitchWorld_HotSwapsDatabase(t *testing.T) {
+	setupTempHome(t)
+
+	appInstance := NewApp()
+	if err := appInstance.initializeStorageAndDatabase(); err != nil {
+		t.Fatalf("initializeStorageAndDatabase() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if appInstance.database != nil {
+			_ = appInstance.database.Close()
+		}
+	})
+	if _, err := appInstance.database.CreateShopping("默认世界的商品", 1, "", ""); err != nil {
+		t.Fatalf("CreateShopping() failed: %v", err)
+	}
+
+	defaultWorld, err := appInstance.GetCurrentWorld()
+	if err != nil || defaultWorld.Name != "默认世界" {
+		t.Fatalf("GetCurrentWorld() = %+v, %v", defaultWorld, err)
+	}
+
+	created, err := appInstance.CreateWorld("第二部小说", "")
+	if err != nil {
+		t.Fatalf("CreateWorld() failed: %v", err)
+	}
+	if created.IsCurrent {
+		t.Fatalf("CreateWorld() must not switch worlds")
+	}
+
+	if _, err := appInstance.SwitchWorld(created.ID); err != nil {
+		t.Fatalf("SwitchWorld() failed: %v", err)
+	}
+	items, err := appInstance.database.GetAllShopping()
+	if err != nil || len(items) != 0 {
+		t.Fatalf("expected empty new world, got %v, %v", items, err)
+	}
+	if err := appInstance.RemoveWorld(created.ID); err == nil {
+		t.Fatalf("expected error when removing the current world")
+	}
+
+	if _, err := appInstance.SwitchWorld(defaultWorld.ID); err != nil {
+		t.Fatalf("SwitchWorld() back failed: %v", err)
+	}
+	items, err = appInstance.database.GetAllShopping()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected default world data after switching back, got %v, %v", items, err)
+	}
+
+	if err := appInstance.RenameWorld(created.ID, "续集"); err != nil {
+		t.Fatalf("RenameWorld() failed: %v", err)
+	}
+	if err := appInstance.RemoveWorld(created.ID); err != nil {
+		t.Fatalf("RemoveWorld() failed: %v", err)
+	}
+	if _, err := os.Stat(created.DataDir); err != nil {
+		t.Fatalf("RemoveWorld() must keep the data dir: %v", err)
+	}
+}
+
+func TestInitializeStorageAndDatabase_MissingWorldFallsBackWithNotice(t *testing.T) {
+	tempHome := setupTempHome(t)
+
+	missingDir := filepath.Join(tempHome, "unplugged-drive", "novel")
+	project, err := storage.RegisterProject("遗失的世界", missingDir)
+	if err != nil {
+		t.Fatalf("RegisterProject() failed: %v", err)
+	}
+	if _, err := storage.ActivateProject(project.ID); err != nil {
+		t.Fatalf("ActivateProject() failed: %v", err)
+	}
+
+	appInstance := NewApp()
+	if err := appInstance.initializeStorageAndDatabase(); err != nil {
+		t.Fatalf("initializeStorageAndDatabase() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if appInstance.database != nil {
+			_ = appInstance.database.Close()
+		}
+	})
+
+	if !strings.Contains(appInstance.storageStartupNotice, "遗失的世界") {
+		t.Fatalf("expected notice to name the missing world, got %q", appInstance.storageStartupNotice)
+	}
+	if _, err := os.Stat(missingDir); !os.IsNotExist(err) {
+		t.Fatalf("missing world directory must not be recreated, stat err: %v", err)
+	}
+
+	worlds, err := appInstance.ListWorlds()
+	if err != nil {
+		t.Fatalf("ListWorlds() failed: %v", err)
+	}
+	for _, world := range worlds {
+		if world.ID == project.ID && (!world.IsMissing || world.IsCurrent) {
+			t.Fatalf("expected missing world to be flagged and inactive, got %+v", world)
+		}
+	}
+	if _, err := appInstance.SwitchWorld(project.ID); err == nil {
+		t.Fatalf("expected error when switching to a missing world")
+	}
+}
+
+func TestGetCurrentWorld_DoesNotRegisterProject(t *testing.T) {
+	setupTempHome(t)
+
+	appInstance := NewApp()
+	world, err := appInstance.GetCurrentWorld()
+	if err != nil || world.ID != "" || world.Name != "默认世界" || !world.IsCurrent {
+		t.Fatalf("GetCurrentWorld() = %+v, %v", world, err)
+	}
+	projects, err := storage.ListProjects()
+	if err != nil || len(projects) != 0 {
+		t.Fatalf("expected GetCurrentWorld() to leave the registry untouched, got %+v, %v", projects, err)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"nooltools/apps/database"
+)
+
+// GetSearchKinds 获取全局搜索可过滤的实体类型
+func (a *app) GetSearchKinds() []string {
+	return database.GetSearchKinds()
+}
+
+// GlobalSearch 在所有实体和 markdown 笔记中全文搜索
+func (a *app) GlobalSearch(query string, filters database.SearchFilters) ([]database.SearchResult, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return a.database.GlobalSearch(query, filters)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"log"
 	"nooltools/apps/database"
 	"nooltools/apps/storage"
 	"os"
@@ -118,6 +119,10 @@ func (a *app) MigrateStorageDirectory(parentDir string) (StorageMigrationResult,
 		return StorageMigrationResult{}, err
 	}
 
+	if err := storage.MoveProjectDataDir(fromDir, toDir); err != nil {
+		log.Printf("更新世界目录登记失败: %v", err)
+	}
+
 	newDB, err := database.NewDatabase()
 	if err != nil {
 		rollbackErr := storage.SaveConfig(configBefore)
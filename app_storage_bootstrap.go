@@ -29,10 +29,24 @@ func (a *app) initializeStorageAndDatabase() error {
 	}
 	hasCustomDir := strings.TrimSpace(cfg.CustomDataDir) != ""
 
-	if err := a.bootstrapDataDir(currentDataDir); err == nil {
+	project, isProject, err := storage.FindProjectByDataDir(currentDataDir)
+	if err != nil {
+		return fmt.Errorf("failed to read world registry: %w", err)
+	}
+
+	if err := a.bootstrapProjectDir(currentDataDir, isProject); err == nil {
+		a.recordOpenedProject(currentDataDir)
 		return nil
-	} else if !hasCustomDir {
+	} else if !hasCustomDir || errors.Is(err, database.ErrSchemaTooNew) {
+		// 数据库版本高于程序时不回退，避免清除用户的自定义目录配置
 		return err
+	} else if isProject {
+		a.storageStartupNotice = fmt.Sprintf(
+			"检测到世界「%s」的存储目录不可用，已自动回退到默认目录。失败原因：%v",
+			project.Name,
+			err,
+		)
+		log.Println(a.storageStartupNotice)
 	} else {
 		a.storageStartupNotice = fmt.Sprintf(
 			"检测到自定义存储目录不可用，已自动回退到默认目录。失败原因：%v",
@@ -49,10 +63,42 @@ func (a *app) initializeStorageAndDatabase() error {
 		return fmt.Errorf("failed to initialize fallback default data directory: %w", err)
 	}
 
+	a.recordOpenedProject(defaultDataDir)
 	log.Printf("存储目录已回退至默认目录: %s", defaultDataDir)
 	return nil
 }
 
+// bootstrapProjectDir 初始化数据目录；已登记为世界的目录如果已不存在则视为不可用，
+// 避免在移除的磁盘或被删除的文件夹位置上悄悄创建一个空世界
+func (a *app) bootstrapProjectDir(dataDir string, isProject bool) error {
+	if isProject {
+		if _, err := os.Stat(dataDir); errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("world directory is missing: %s", dataDir)
+		}
+	}
+	return a.bootstrapDataDir(dataDir)
+}
+
+// recordOpenedProject 将当前数据目录登记为世界并更新最近打开时间，失败只记录日志
+func (a *app) recordOpenedProject(dataDir string) {
+	project, err := storage.EnsureProject(defaultProjectName(dataDir), dataDir)
+	if err != nil {
+		log.Printf("登记当前世界失败: %v", err)
+		return
+	}
+	if _, err := storage.ActivateProject(project.ID); err != nil {
+		log.Printf("更新世界打开时间失败: %v", err)
+	}
+}
+
+// defaultProjectName 为尚未登记的数据目录生成世界名称
+func defaultProjectName(dataDir string) string {
+	if defaultDir, err := storage.DefaultDataDir(); err == nil && storage.PathsEqual(dataDir, defaultDir) {
+		return "默认世界"
+	}
+	return filepath.Base(dataDir)
+}
+
 func (a *app) bootstrapDataDir(dataDir string) error {
 	normalizedDir, err := storage.NormalizePath(dataDir)
 	if err != nil {
@@ -75,12 +121,12 @@ func (a *app) bootstrapDataDir(dataDir string) error {
 		return err
 	}
 
-	ok, err := db.CheckDatabase()
+	result, err := db.CheckDatabase()
 	if err != nil {
 		_ = db.Close()
 		return fmt.Errorf("database check failed after init: %w", err)
 	}
-	if !ok {
+	if !result.OK {
 		_ = db.Close()
 		return errors.New("database check returned abnormal state after init")
 	}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"nooltools/apps/database"
 	"nooltools/apps/storage"
 	"os"
 	"path/filepath"
@@ -116,3 +118,35 @@ func TestInitializeStorageAndDatabase_DefaultDirNoNotice(t *testing.T) {
 		t.Fatalf("expected no startup notice for default dir init, got %q", appInstance.storageStartupNotice)
 	}
 }
+
+func TestInitializeStorageAndDatabase_NewerSchemaDoesNotFallBack(t *testing.T) {
+	tempHome := setupTempHome(t)
+	customDir := filepath.Join(tempHome, "custom-data")
+
+	db, err := database.NewDatabaseAtDir(customDir)
+	if err != nil {
+		t.Fatalf("failed to create custom database: %v", err)
+	}
+	if _, err := db.GetDB().Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, 'future')`, database.LatestSchemaVersion()+1); err != nil {
+		t.Fatalf("failed to record future schema version: %v", err)
+	}
+	_ = db.Close()
+
+	if err := storage.SetCustomDataDir(customDir); err != nil {
+		t.Fatalf("failed to set custom data dir: %v", err)
+	}
+
+	appInstance := NewApp()
+	err = appInstance.initializeStorageAndDatabase()
+	if !errors.Is(err, database.ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+
+	cfg, err := storage.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+	if !storage.PathsEqual(cfg.CustomDataDir, customDir) {
+		t.Fatalf("expected custom data dir to be kept, got %q", cfg.CustomDataDir)
+	}
+}
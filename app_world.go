@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"nooltools/apps/database"
+	"strings"
+)
+
+// ExportWorld 将整个世界的数据导出为 JSON 文件
+func (a *app) ExportWorld(path string) error {
+	if a.database == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if strings.TrimSpace(path) == "" {
+		return fmt.Errorf("导出路径不能为空")
+	}
+	return a.database.ExportWorldToFile(path)
+}
+
+// ImportWorld 从 JSON 文件导入世界数据，mode 可选 merge、replace、skip
+func (a *app) ImportWorld(path, mode string) (*database.WorldImportResult, error) {
+	if a.database == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("导入路径不能为空")
+	}
+	return a.database.ImportWorldFromFile(path, mode)
+}
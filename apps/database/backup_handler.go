@@ -0,0 +1,403 @@
+// 数据库与 markdown 目录的滚动备份、保留策略与恢复
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"nooltools/apps/storage"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 备份触发原因
+const (
+	BackupReasonStartup    = "startup"
+	BackupReasonShutdown   = "shutdown"
+	BackupReasonInterval   = "interval"
+	BackupReasonManual     = "manual"
+	BackupReasonPreRestore = "pre_restore"
+)
+
+// backupManifestName 每个备份目录中记录备份信息的文件
+const backupManifestName = "backup.json"
+
+// backupIDLayout 备份ID的时间格式，按字典序即为时间顺序
+const backupIDLayout = "20060102_150405"
+
+// BackupInfo 备份信息
+type BackupInfo struct {
+	ID            string    `json:"id"`
+	Reason        string    `json:"reason"`
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Size          int64     `json:"size"` // 备份目录占用的字节数
+}
+
+// BackupsDir 获取数据目录下的备份目录
+func BackupsDir(dataDir string) string {
+	return filepath.Join(dataDir, storage.BackupsDirName)
+}
+
+// CreateBackup 创建一份备份：通过 VACUUM INTO 生成数据库的一致性快照，并复制 markdown 目录
+func (d *Database) CreateBackup(reason string) (*BackupInfo, error) {
+	dataDir, err := d.GetDataDir()
+	if err != nil {
+		return nil, fmt.Errorf("获取数据目录失败: %v", err)
+	}
+
+	schemaVersion, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	id, backupDir, err := newBackupDir(dataDir, now)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanup := func(err error) (*BackupInfo, error) {
+		os.RemoveAll(backupDir)
+		return nil, err
+	}
+
+	dbPath := filepath.Join(backupDir, storage.DatabaseFileName)
+	if _, err := d.db.Exec(`VACUUM INTO ?`, dbPath); err != nil {
+		return cleanup(fmt.Errorf("备份数据库失败: %v", err))
+	}
+
+	markdownDir := filepath.Join(dataDir, storage.MarkdownDirName)
+	if _, err := os.Stat(markdownDir); err == nil {
+		if err := copyDirectory(markdownDir, filepath.Join(backupDir, storage.MarkdownDirName)); err != nil {
+			return cleanup(fmt.Errorf("备份markdown目录失败: %v", err))
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return cleanup(fmt.Errorf("检查markdown目录失败: %v", err))
+	}
+
+	info := BackupInfo{
+		ID:            id,
+		Reason:        reason,
+		SchemaVersion: schemaVersion,
+		CreatedAt:     now,
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return cleanup(fmt.Errorf("编码备份信息失败: %v", err))
+	}
+	if err := os.WriteFile(filepath.Join(backupDir, backupManifestName), data, 0644); err != nil {
+		return cleanup(fmt.Errorf("写入备份信息失败: %v", err))
+	}
+
+	info.Size, _ = directorySize(backupDir)
+	return &info, nil
+}
+
+// newBackupDir 按创建时间生成备份ID并创建对应目录，同一秒内的备份追加序号
+func newBackupDir(dataDir string, now time.Time) (string, string, error) {
+	backupsDir := BackupsDir(dataDir)
+	if err := os.MkdirAll(backupsDir, 0755); err != nil {
+		return "", "", fmt.Errorf("创建备份目录失败: %v", err)
+	}
+
+	base := now.UTC().Format(backupIDLayout)
+	for idx := 0; idx < 1000; idx++ {
+		id := base
+		if idx > 0 {
+			id += "_" + strconv.Itoa(idx)
+		}
+		dir := filepath.Join(backupsDir, id)
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return id, dir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", "", fmt.Errorf("创建备份目录失败: %v", err)
+		}
+	}
+	return "", "", fmt.Errorf("无法生成备份ID")
+}
+
+// ListBackups 列出数据目录下的所有备份，按创建时间从新到旧排序
+func ListBackups(dataDir string) ([]BackupInfo, error) {
+	entries, err := os.ReadDir(BackupsDir(dataDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return []BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取备份目录失败: %v", err)
+	}
+
+	backups := []BackupInfo{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := readBackupInfo(dataDir, entry.Name())
+		if err != nil {
+			// 没有备份信息的目录（例如创建过程中中断）不视为有效备份
+			continue
+		}
+		backups = append(backups, *info)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ID > backups[j].ID
+	})
+	return backups, nil
+}
+
+// readBackupInfo 读取单个备份的信息
+func readBackupInfo(dataDir, id string) (*BackupInfo, error) {
+	if id == "" || filepath.Base(id) != id || id == "." || id == ".." {
+		return nil, fmt.Errorf("备份ID无效: %s", id)
+	}
+
+	backupDir := filepath.Join(BackupsDir(dataDir), id)
+	data, err := os.ReadFile(filepath.Join(backupDir, backupManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("备份不存在: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取备份信息失败: %v", err)
+	}
+
+	var info BackupInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("解析备份信息失败: %v", err)
+	}
+	info.ID = id
+	info.Size, _ = directorySize(backupDir)
+	return &info, nil
+}
+
+// PruneBackups 按保留策略删除多余的自动备份，返回被删除的备份ID。
+// 保留最近 KeepLast 份，以及最近 KeepDaily 天、KeepWeekly 周中每天/每周的最后一份；
+// 手动创建的备份不参与清理。
+func PruneBackups(dataDir string, retention storage.BackupConfig) ([]string, error) {
+	backups, err := ListBackups(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := selectBackupsToKeep(backups, retention)
+	removed := []string{}
+	for _, backup := range backups {
+		if keep[backup.ID] || backup.Reason == BackupReasonManual {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(BackupsDir(dataDir), backup.ID)); err != nil {
+			return removed, fmt.Errorf("删除备份 %s 失败: %v", backup.ID, err)
+		}
+		removed = append(removed, backup.ID)
+	}
+	return removed, nil
+}
+
+// selectBackupsToKeep 计算保留策略下需要保留的备份；backups 需按从新到旧排序
+func selectBackupsToKeep(backups []BackupInfo, retention storage.BackupConfig) map[string]bool {
+	keep := make(map[string]bool)
+	for i, backup := range backups {
+		if i < retention.KeepLast {
+			keep[backup.ID] = true
+		}
+	}
+
+	keepNewestPerPeriod := func(limit int, period func(time.Time) string) {
+		seen := make(map[string]bool)
+		for _, backup := range backups {
+			if len(seen) >= limit {
+				return
+			}
+			key := period(backup.CreatedAt.Local())
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			keep[backup.ID] = true
+		}
+	}
+	keepNewestPerPeriod(retention.KeepDaily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepNewestPerPeriod(retention.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+
+	return keep
+}
+
+// VerifyBackup 以只读方式打开备份中的数据库，检查文件完整性以及结构版本不高于当前程序支持的版本；
+// 恢复前调用，避免用无法打开的备份覆盖当前数据
+func VerifyBackup(dataDir, id string) error {
+	if _, err := readBackupInfo(dataDir, id); err != nil {
+		return err
+	}
+	dbPath := filepath.Join(BackupsDir(dataDir), id, storage.DatabaseFileName)
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("备份数据库文件不存在: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(dbPath)+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("打开备份数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("检查备份数据库失败: %v", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("备份数据库已损坏: %s", result)
+	}
+
+	// 早于迁移记录表的备份按版本 0 处理，打开时会执行全部迁移
+	var hasMigrations int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&hasMigrations); err != nil {
+		return fmt.Errorf("检查备份数据库失败: %v", err)
+	}
+	if hasMigrations == 0 {
+		return nil
+	}
+	version, err := currentSchemaVersion(db.QueryRow(schemaVersionQuery))
+	if err != nil {
+		return fmt.Errorf("读取备份数据库版本失败: %v", err)
+	}
+	if latest := LatestSchemaVersion(); version > latest {
+		return fmt.Errorf("%w（备份数据库版本 %d，程序支持的最高版本 %d）", ErrSchemaTooNew, version, latest)
+	}
+	return nil
+}
+
+// RestoreBackup 用指定备份覆盖数据目录中的数据库和 markdown 目录。
+// 调用前必须关闭该目录上打开的 Database，恢复完成后再重新打开。
+func RestoreBackup(dataDir, id string) error {
+	if _, err := readBackupInfo(dataDir, id); err != nil {
+		return err
+	}
+	backupDir := filepath.Join(BackupsDir(dataDir), id)
+
+	// 先复制到临时文件再替换，避免复制中断导致数据库文件损坏
+	dbPath := filepath.Join(dataDir, storage.DatabaseFileName)
+	tempDBPath := dbPath + ".restore"
+	if err := copyFile(filepath.Join(backupDir, storage.DatabaseFileName), tempDBPath, 0644); err != nil {
+		os.Remove(tempDBPath)
+		return fmt.Errorf("复制备份数据库失败: %v", err)
+	}
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			os.Remove(tempDBPath)
+			return fmt.Errorf("清理数据库日志文件失败: %v", err)
+		}
+	}
+
+	// markdown 目录先整体移到一旁，恢复失败时可以放回
+	markdownDir := filepath.Join(dataDir, storage.MarkdownDirName)
+	oldMarkdownDir := markdownDir + ".restore_old"
+	if err := os.RemoveAll(oldMarkdownDir); err != nil {
+		os.Remove(tempDBPath)
+		return fmt.Errorf("清理临时目录失败: %v", err)
+	}
+	if err := os.Rename(markdownDir, oldMarkdownDir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		os.Remove(tempDBPath)
+		return fmt.Errorf("移动markdown目录失败: %v", err)
+	}
+
+	rollback := func(err error) error {
+		os.RemoveAll(markdownDir)
+		os.Rename(oldMarkdownDir, markdownDir)
+		os.Remove(tempDBPath)
+		return err
+	}
+
+	backupMarkdownDir := filepath.Join(backupDir, storage.MarkdownDirName)
+	if _, err := os.Stat(backupMarkdownDir); err == nil {
+		if err := copyDirectory(backupMarkdownDir, markdownDir); err != nil {
+			return rollback(fmt.Errorf("恢复markdown目录失败: %v", err))
+		}
+	} else if err := os.MkdirAll(markdownDir, 0755); err != nil {
+		return rollback(fmt.Errorf("创建markdown目录失败: %v", err))
+	}
+
+	if err := os.Rename(tempDBPath, dbPath); err != nil {
+		return rollback(fmt.Errorf("替换数据库文件失败: %v", err))
+	}
+
+	os.RemoveAll(oldMarkdownDir)
+	return nil
+}
+
+// copyDirectory 递归复制目录
+func copyDirectory(sourceDir, targetDir string) error {
+	return filepath.WalkDir(sourceDir, func(sourcePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		relPath, err := filepath.Rel(sourceDir, sourcePath)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(targetDir, relPath)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(targetPath, info.Mode().Perm())
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(sourcePath, targetPath, info.Mode().Perm())
+	})
+}
+
+// copyFile 复制单个文件
+func copyFile(sourcePath, targetPath string, perm os.FileMode) error {
+	sourceFile, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	targetFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(targetFile, sourceFile); err != nil {
+		targetFile.Close()
+		return err
+	}
+	return targetFile.Close()
+}
+
+// directorySize 统计目录中所有文件的大小
+func directorySize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"nooltools/apps/storage"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackup_CreateListAndRestore(t *testing.T) {
+	dataDir := t.TempDir()
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() failed: %v", err)
+	}
+	if _, err := db.CreateBeibao("备份前的背包"); err != nil {
+		t.Fatalf("CreateBeibao() failed: %v", err)
+	}
+	markdownDir := filepath.Join(dataDir, storage.MarkdownDirName)
+	if err := os.MkdirAll(markdownDir, 0755); err != nil {
+		t.Fatalf("failed to create markdown dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(markdownDir, "notes.md"), []byte("# 旧笔记"), 0644); err != nil {
+		t.Fatalf("failed to write markdown file: %v", err)
+	}
+
+	info, err := db.CreateBackup(BackupReasonManual)
+	if err != nil {
+		t.Fatalf("CreateBackup() failed: %v", err)
+	}
+	if info.SchemaVersion != LatestSchemaVersion() || info.Size == 0 {
+		t.Fatalf("unexpected backup info: %+v", info)
+	}
+
+	if _, err := db.CreateBeibao("备份后的背包"); err != nil {
+		t.Fatalf("CreateBeibao() failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(markdownDir, "notes.md"), []byte("# 新笔记"), 0644); err != nil {
+		t.Fatalf("failed to write markdown file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(markdownDir, "extra.md"), []byte("# 多余"), 0644); err != nil {
+		t.Fatalf("failed to write markdown file: %v", err)
+	}
+
+	backups, err := ListBackups(dataDir)
+	if err != nil || len(backups) != 1 || backups[0].ID != info.ID {
+		t.Fatalf("ListBackups() = %+v, %v", backups, err)
+	}
+
+	db.Close()
+	if err := RestoreBackup(dataDir, info.ID); err != nil {
+		t.Fatalf("RestoreBackup() failed: %v", err)
+	}
+	if err := RestoreBackup(dataDir, "../escape"); err == nil {
+		t.Fatalf("expected error for invalid backup id")
+	}
+
+	db, err = NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer db.Close()
+
+	list, err := db.GetAllBeibao()
+	if err != nil || len(list) != 1 || list[0]["name"] != "备份前的背包" {
+		t.Fatalf("expected restored backpacks, got %v, %v", list, err)
+	}
+	data, err := os.ReadFile(filepath.Join(markdownDir, "notes.md"))
+	if err != nil || string(data) != "# 旧笔记" {
+		t.Fatalf("expected restored markdown, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(markdownDir, "extra.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected markdown created after backup to be removed, stat err: %v", err)
+	}
+}
+
+func TestVerifyBackup_RejectsNewerAndCorruptBackups(t *testing.T) {
+	dataDir := t.TempDir()
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() failed: %v", err)
+	}
+	defer db.Close()
+
+	info, err := db.CreateBackup(BackupReasonManual)
+	if err != nil {
+		t.Fatalf("CreateBackup() failed: %v", err)
+	}
+	if err := VerifyBackup(dataDir, info.ID); err != nil {
+		t.Fatalf("VerifyBackup() failed: %v", err)
+	}
+	if err := VerifyBackup(dataDir, "../escape"); err == nil {
+		t.Fatalf("expected error for invalid backup id")
+	}
+
+	// 备份来自更新版本的程序
+	backupDBPath := filepath.Join(BackupsDir(dataDir), info.ID, storage.DatabaseFileName)
+	backupDB, err := sql.Open("sqlite3", backupDBPath)
+	if err != nil {
+		t.Fatalf("failed to open backup database: %v", err)
+	}
+	if _, err := backupDB.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, '未来的迁移')`, LatestSchemaVersion()+1); err != nil {
+		t.Fatalf("failed to bump backup version: %v", err)
+	}
+	backupDB.Close()
+	if err := VerifyBackup(dataDir, info.ID); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+
+	// 备份数据库文件已损坏
+	if err := os.WriteFile(backupDBPath, []byte("not a database"), 0644); err != nil {
+		t.Fatalf("failed to corrupt backup: %v", err)
+	}
+	if err := VerifyBackup(dataDir, info.ID); err == nil {
+		t.Fatalf("expected error for corrupt backup")
+	}
+}
+
+func TestSelectBackupsToKeep_RetentionRules(t *testing.T) {
+	base := time.Date(2026, 3, 20, 12, 0, 0, 0, time.Local)
+	var backups []BackupInfo
+	// 每 6 小时一份，共 20 天，从新到旧排列
+	for i := 0; i < 80; i++ {
+		createdAt := base.Add(-time.Duration(i) * 6 * time.Hour)
+		backups = append(backups, BackupInfo{ID: createdAt.Format(backupIDLayout), CreatedAt: createdAt})
+	}
+
+	keep := selectBackupsToKeep(backups, storage.BackupConfig{KeepLast: 2, KeepDaily: 3, KeepWeekly: 2})
+
+	for _, id := range []string{backups[0].ID, backups[1].ID} {
+		if !keep[id] {
+			t.Fatalf("expected latest backup %s to be kept", id)
+		}
+	}
+	days := make(map[string]bool)
+	for _, backup := range backups {
+		if keep[backup.ID] {
+			days[backup.CreatedAt.Format("2006-01-02")] = true
+		}
+	}
+	if len(keep) < 3 || len(keep) > 5 {
+		t.Fatalf("unexpected number of kept backups: %d", len(keep))
+	}
+	if !days["2026-03-18"] {
+		t.Fatalf("expected one backup from each of the last 3 days, kept days: %v", days)
+	}
+	if !days["2026-03-15"] {
+		t.Fatalf("expected newest backup of the previous week to be kept, kept days: %v", days)
+	}
+}
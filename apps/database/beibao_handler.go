@@ -8,50 +8,78 @@ import (
 
 // BeibaoInfo 背包信息结构
 type BeibaoInfo struct {
-	ID    int          `json:"id"`
-	Name  string       `json:"name"`
-	Items []BeibaoItem `json:"items"`
+	ID        int          `json:"id"`
+	Name      string       `json:"name"`
+	Balance   int          `json:"balance"`    // 货币余额，用于商城购买
+	OwnerType string       `json:"owner_type"` // character、pet、faction，无主时为空
+	OwnerID   int          `json:"owner_id"`
+	OwnerName string       `json:"owner_name"`
+	Capacity  *int         `json:"capacity"`   // 格子容量，为空表示不限
+	MaxWeight *int         `json:"max_weight"` // 重量上限，为空表示不限
+	UsedSlots int          `json:"used_slots"`
+	Weight    int          `json:"weight"`
+	Items     []BeibaoItem `json:"items"`
 }
 
-// BeibaoItem 背包物品结构
+// BeibaoItem 背包物品结构；关联道具时等级、价格、重量、堆叠上限和功能取自道具
 type BeibaoItem struct {
-	ID          int    `json:"id"`
-	BeibaoID    int    `json:"beibao_id"`
-	Name        string `json:"name"`
-	Quantity    int    `json:"quantity"`
-	Description string `json:"description"`
+	ID          int             `json:"id"`
+	BeibaoID    int             `json:"beibao_id"`
+	Name        string          `json:"name"`
+	Quantity    int             `json:"quantity"`
+	Description string          `json:"description"`
+	DaojuID     *int            `json:"daoju_id"`
+	Level       int             `json:"level"`
+	Price       int             `json:"price"`
+	Weight      int             `json:"weight"`    // 单件重量
+	MaxStack    int             `json:"max_stack"` // 0 表示不限
+	Functions   []DaojuFunction `json:"functions"`
+}
+
+// beibaoListSpec 背包列表的筛选与排序
+var beibaoListSpec = listSpec{
+	label:         "背包",
+	table:         "beibao",
+	ownerIDColumn: beibaoOwnerIDExpr,
+	typeColumn:    beibaoOwnerTypeExpr,
+	sortColumns:   map[string]string{"id": "id", "name": "name"},
+	defaultSort:   "id",
 }
 
 // GetAllBeibao 获取所有背包列表
 func (d *Database) GetAllBeibao() ([]map[string]interface{}, error) {
-	query := `
-	SELECT id, name
-	FROM beibao
-	ORDER BY id ASC`
-
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("查询背包列表失败: %v", err)
-	}
-	defer rows.Close()
+	beibaoList, _, err := d.ListBeibao(ListQuery{})
+	return beibaoList, err
+}
 
+// ListBeibao 按筛选、排序和分页条件获取背包列表
+func (d *Database) ListBeibao(q ListQuery) ([]map[string]interface{}, ListResult, error) {
 	var beibaoList []map[string]interface{}
-	for rows.Next() {
-		var id int
-		var name string
+	columns := `id, name, balance, ` + beibaoOwnerTypeExpr + `, ` + beibaoOwnerIDExpr + `, ` + beibaoOwnerNameExpr
+	result, err := d.queryList(beibaoListSpec, q, columns, func(rows *sql.Rows) (int64, error) {
+		var id, balance, ownerID int
+		var name, ownerType, ownerName string
 
-		if err := rows.Scan(&id, &name); err != nil {
-			return nil, fmt.Errorf("扫描背包数据失败: %v", err)
+		if err := rows.Scan(&id, &name, &balance, &ownerType, &ownerID, &ownerName); err != nil {
+			return 0, fmt.Errorf("扫描背包数据失败: %v", err)
 		}
 
 		beibao := map[string]interface{}{
-			"id":   id,
-			"name": name,
+			"id":         id,
+			"name":       name,
+			"balance":    balance,
+			"owner_type": ownerType,
+			"owner_id":   ownerID,
+			"owner_name": ownerName,
 		}
 		beibaoList = append(beibaoList, beibao)
+		return int64(id), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return beibaoList, nil
+	return beibaoList, result, nil
 }
 
 // GetBeibaoInfo 获取背包详细信息（包含物品）
@@ -59,11 +87,12 @@ func (d *Database) GetBeibaoInfo(beibaoID int) (*BeibaoInfo, error) {
 	// 查询背包基本信息
 	var info BeibaoInfo
 	query := `
-	SELECT id, name
+	SELECT id, name, balance, capacity, max_weight, ` + beibaoOwnerTypeExpr + `, ` + beibaoOwnerIDExpr + `, ` + beibaoOwnerNameExpr + `
 	FROM beibao
 	WHERE id = ?`
 
-	err := d.db.QueryRow(query, beibaoID).Scan(&info.ID, &info.Name)
+	err := d.db.QueryRow(query, beibaoID).Scan(&info.ID, &info.Name, &info.Balance, &info.Capacity, &info.MaxWeight,
+		&info.OwnerType, &info.OwnerID, &info.OwnerName)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("背包不存在")
@@ -77,6 +106,10 @@ func (d *Database) GetBeibaoInfo(beibaoID int) (*BeibaoInfo, error) {
 		return nil, fmt.Errorf("查询背包物品失败: %v", err)
 	}
 	info.Items = items
+	for _, item := range items {
+		info.UsedSlots++
+		info.Weight += item.Weight * item.Quantity
+	}
 
 	return &info, nil
 }
@@ -100,28 +133,30 @@ func (d *Database) CreateBeibao(name string) (int64, error) {
 	return id, nil
 }
 
-// DeleteBeibao 删除背包
+// DeleteBeibao 删除背包（背包与物品在同一事务中删除）
 func (d *Database) DeleteBeibao(beibaoID int) error {
-	// 先删除背包中的所有物品
-	_, err := d.db.Exec("DELETE FROM beibao_items WHERE beibao_id = ?", beibaoID)
-	if err != nil {
-		return fmt.Errorf("删除背包物品失败: %v", err)
-	}
+	return d.WithTx(func(tx *sql.Tx) error {
+		// 先删除背包中的所有物品
+		_, err := tx.Exec("DELETE FROM beibao_items WHERE beibao_id = ?", beibaoID)
+		if err != nil {
+			return fmt.Errorf("删除背包物品失败: %v", err)
+		}
 
-	// 删除背包
-	query := `DELETE FROM beibao WHERE id = ?`
+		// 删除背包
+		query := `DELETE FROM beibao WHERE id = ?`
 
-	result, err := d.db.Exec(query, beibaoID)
-	if err != nil {
-		return fmt.Errorf("删除背包失败: %v", err)
-	}
+		result, err := tx.Exec(query, beibaoID)
+		if err != nil {
+			return fmt.Errorf("删除背包失败: %v", err)
+		}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("背包不存在")
-	}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return fmt.Errorf("背包不存在")
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // UpdateBeibao 更新背包名称
@@ -147,10 +182,12 @@ func (d *Database) UpdateBeibao(beibaoID int, name string) error {
 // GetBeibaoItems 获取背包物品列表
 func (d *Database) GetBeibaoItems(beibaoID int) ([]BeibaoItem, error) {
 	query := `
-	SELECT id, beibao_id, name, quantity, description
-	FROM beibao_items
-	WHERE beibao_id = ?
-	ORDER BY id ASC`
+	SELECT bi.id, bi.beibao_id, bi.name, bi.quantity, COALESCE(bi.description, ''), bi.daoju_id,
+		COALESCE(dj.level, 0), COALESCE(dj.price, 0), COALESCE(dj.weight, 0), COALESCE(dj.max_stack, 0)
+	FROM beibao_items bi
+	LEFT JOIN daoju dj ON dj.id = bi.daoju_id
+	WHERE bi.beibao_id = ?
+	ORDER BY bi.id ASC`
 
 	rows, err := d.db.Query(query, beibaoID)
 	if err != nil {
@@ -161,27 +198,45 @@ func (d *Database) GetBeibaoItems(beibaoID int) ([]BeibaoItem, error) {
 	var items []BeibaoItem
 	for rows.Next() {
 		var item BeibaoItem
-		if err := rows.Scan(&item.ID, &item.BeibaoID, &item.Name, &item.Quantity, &item.Description); err != nil {
+		if err := rows.Scan(&item.ID, &item.BeibaoID, &item.Name, &item.Quantity, &item.Description, &item.DaojuID,
+			&item.Level, &item.Price, &item.Weight, &item.MaxStack); err != nil {
 			return nil, fmt.Errorf("扫描物品数据失败: %v", err)
 		}
 		items = append(items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询物品列表失败: %v", err)
+	}
+	rows.Close()
+
+	// 关联道具的物品继承道具功能
+	functions := make(map[int][]DaojuFunction)
+	for i, item := range items {
+		items[i].Functions = []DaojuFunction{}
+		if item.DaojuID == nil {
+			continue
+		}
+		if _, ok := functions[*item.DaojuID]; !ok {
+			list, err := d.GetDaojuFunctions(*item.DaojuID)
+			if err != nil {
+				return nil, err
+			}
+			functions[*item.DaojuID] = list
+		}
+		if list := functions[*item.DaojuID]; list != nil {
+			items[i].Functions = list
+		}
+	}
 
 	return items, nil
 }
 
-// AddBeibaoItem 添加背包物品
-func (d *Database) AddBeibaoItem(beibaoID int, name string, quantity int, description string) error {
-	query := `
-	INSERT INTO beibao_items (beibao_id, name, quantity, description)
-	VALUES (?, ?, ?, ?)`
-
-	_, err := d.db.Exec(query, beibaoID, name, quantity, description)
-	if err != nil {
-		return fmt.Errorf("添加物品失败: %v", err)
-	}
-
-	return nil
+// AddBeibaoItem 添加背包物品：与已有的同类物品堆叠，超出堆叠上限时占用新格子；超出背包容量或重量上限时拒绝。
+// daojuID 为 0 时按名称关联唯一的同名道具
+func (d *Database) AddBeibaoItem(beibaoID int, name string, quantity int, description string, daojuID int) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		return depositBeibaoItem(tx, beibaoID, name, quantity, description, daojuID)
+	})
 }
 
 // DeleteBeibaoItem 删除背包物品
@@ -201,22 +256,77 @@ func (d *Database) DeleteBeibaoItem(itemID int) error {
 	return nil
 }
 
-// UpdateBeibaoItem 更新背包物品
-func (d *Database) UpdateBeibaoItem(itemID int, name string, quantity int, description string) error {
-	query := `
-	UPDATE beibao_items
-	SET name = ?, quantity = ?, description = ?, updated_at = CURRENT_TIMESTAMP
-	WHERE id = ?`
+// UpdateBeibaoItem 更新背包物品；关联 daojuID 指定的道具，为 0 时按新名称重新关联唯一的同名道具。
+// 数量必须大于 0 且不能超过堆叠上限，更新后不能超过背包重量上限；更新仍占用原来的格子，不会超出背包容量
+func (d *Database) UpdateBeibaoItem(itemID int, name string, quantity int, description string, daojuID int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("物品数量必须大于 0")
+	}
+	return d.WithTx(func(tx *sql.Tx) error {
+		var beibaoID int
+		err := tx.QueryRow(`SELECT beibao_id FROM beibao_items WHERE id = ?`, itemID).Scan(&beibaoID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("物品不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询物品失败: %v", err)
+		}
+
+		rule, err := getItemStackRule(tx, daojuID, name)
+		if err != nil {
+			return err
+		}
+		if rule.maxStack > 0 && quantity > rule.maxStack {
+			return fmt.Errorf("「%s」每格最多堆叠 %d 个", name, rule.maxStack)
+		}
+
+		query := `
+		UPDATE beibao_items
+		SET name = ?, quantity = ?, description = ?, daoju_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`
+
+		if _, err := tx.Exec(query, name, quantity, description, rule.daojuID, itemID); err != nil {
+			return fmt.Errorf("更新物品失败: %v", err)
+		}
 
-	result, err := d.db.Exec(query, name, quantity, description, itemID)
+		usage, err := getBeibaoUsage(tx, beibaoID)
+		if err != nil {
+			return err
+		}
+		return usage.checkWeight(0)
+	})
+}
+
+// checkBeibaoExists 检查背包是否存在
+func checkBeibaoExists(tx dbExecutor, beibaoID int) error {
+	var id int
+	err := tx.QueryRow(`SELECT id FROM beibao WHERE id = ?`, beibaoID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("背包不存在")
+	}
 	if err != nil {
-		return fmt.Errorf("更新物品失败: %v", err)
+		return fmt.Errorf("查询背包失败: %v", err)
 	}
+	return nil
+}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("物品不存在")
+// SetBeibaoBalance 设置背包的货币余额
+func (d *Database) SetBeibaoBalance(beibaoID, balance int) error {
+	if balance < 0 {
+		return fmt.Errorf("余额不能为负数")
 	}
-
-	return nil
+	return d.WithTx(func(tx *sql.Tx) error {
+		var oldBalance int
+		err := tx.QueryRow(`SELECT balance FROM beibao WHERE id = ?`, beibaoID).Scan(&oldBalance)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("背包不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询背包余额失败: %v", err)
+		}
+		if _, err := tx.Exec(`UPDATE beibao SET balance = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, balance, beibaoID); err != nil {
+			return fmt.Errorf("更新背包余额失败: %v", err)
+		}
+		return recordBalanceAdjustment(tx, LedgerAccount{Type: LedgerAccountBeibao, ID: beibaoID}, oldBalance, balance, "余额调整")
+	})
 }
@@ -0,0 +1,145 @@
+// 背包归属：背包可以属于一个人物、宠物或势力（作为仓库），三者互斥，也可以无主；
+// 宠物或势力被删除时背包变为无主，删除人物时由调用方选择保留、转交或一并删除其背包
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// 背包主人类型
+const (
+	BeibaoOwnerCharacter = "character"
+	BeibaoOwnerPet       = "pet"
+	BeibaoOwnerFaction   = "faction"
+)
+
+// 删除人物时对其背包的处理方式
+const (
+	CharacterBeibaoOrphan   = "orphan"   // 保留为无主背包
+	CharacterBeibaoTransfer = "transfer" // 转交给其他人物
+	CharacterBeibaoDelete   = "delete"   // 连同物品一并删除
+)
+
+// beibaoOwnerColumns 主人类型对应的关联列和表
+var beibaoOwnerColumns = map[string]struct{ column, table, label string }{
+	BeibaoOwnerCharacter: {"owner_renwu_id", "renwu", "人物"},
+	BeibaoOwnerPet:       {"owner_chongwu_id", "chongwu", "宠物"},
+	BeibaoOwnerFaction:   {"owner_shili_id", "shili", "势力"},
+}
+
+// 由关联列推导主人类型、ID和名称的表达式，用于背包列表的查询与筛选
+const (
+	beibaoOwnerTypeExpr = `CASE WHEN beibao.owner_renwu_id IS NOT NULL THEN 'character'
+		WHEN beibao.owner_chongwu_id IS NOT NULL THEN 'pet'
+		WHEN beibao.owner_shili_id IS NOT NULL THEN 'faction' ELSE '' END`
+	beibaoOwnerIDExpr   = `COALESCE(beibao.owner_renwu_id, beibao.owner_chongwu_id, beibao.owner_shili_id, 0)`
+	beibaoOwnerNameExpr = `COALESCE((SELECT name FROM renwu WHERE id = beibao.owner_renwu_id),
+		(SELECT name FROM chongwu WHERE id = beibao.owner_chongwu_id),
+		(SELECT name FROM shili WHERE id = beibao.owner_shili_id), '')`
+)
+
+// CharacterBeibaoDisposal 删除人物时对其背包的处理
+type CharacterBeibaoDisposal struct {
+	Action     string `json:"action"`      // orphan（默认）、transfer 或 delete
+	TransferTo int    `json:"transfer_to"` // transfer 时接收背包的人物ID
+}
+
+// SetBeibaoOwner 设置背包的主人，ownerType 为空时清除主人
+func (d *Database) SetBeibaoOwner(beibaoID int, ownerType string, ownerID int) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		if err := checkBeibaoExists(tx, beibaoID); err != nil {
+			return err
+		}
+		values := map[string]interface{}{}
+		if ownerType != "" {
+			owner, ok := beibaoOwnerColumns[ownerType]
+			if !ok {
+				return fmt.Errorf("无效的背包主人类型: %s", ownerType)
+			}
+			var id int
+			err := tx.QueryRow(`SELECT id FROM `+owner.table+` WHERE id = ?`, ownerID).Scan(&id)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%s不存在", owner.label)
+			}
+			if err != nil {
+				return fmt.Errorf("查询%s失败: %v", owner.label, err)
+			}
+			values[owner.column] = ownerID
+		}
+
+		_, err := tx.Exec(`
+		UPDATE beibao SET owner_renwu_id = ?, owner_chongwu_id = ?, owner_shili_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, values["owner_renwu_id"], values["owner_chongwu_id"], values["owner_shili_id"], beibaoID)
+		if err != nil {
+			return fmt.Errorf("设置背包主人失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// getOwnedBeibao 获取主人名下的所有背包（包含物品）
+func (d *Database) getOwnedBeibao(ownerType string, ownerID int) ([]BeibaoInfo, error) {
+	rows, err := d.db.Query(`SELECT id FROM beibao WHERE `+beibaoOwnerColumns[ownerType].column+` = ? ORDER BY id ASC`, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询背包失败: %v", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("扫描背包数据失败: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询背包失败: %v", err)
+	}
+
+	result := []BeibaoInfo{}
+	for _, id := range ids {
+		info, err := d.GetBeibaoInfo(id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *info)
+	}
+	return result, nil
+}
+
+// disposeCharacterBeibao 删除人物前按 disposal 处理其名下的背包；保留时由外键在删除人物后置空主人
+func disposeCharacterBeibao(tx dbExecutor, characterID int, disposal CharacterBeibaoDisposal) error {
+	switch disposal.Action {
+	case "", CharacterBeibaoOrphan:
+		return nil
+	case CharacterBeibaoTransfer:
+		if disposal.TransferTo == characterID {
+			return fmt.Errorf("不能把背包转交给被删除的人物")
+		}
+		var id int
+		err := tx.QueryRow(`SELECT id FROM renwu WHERE id = ?`, disposal.TransferTo).Scan(&id)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("接收背包的人物不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询人物失败: %v", err)
+		}
+		if _, err := tx.Exec(`UPDATE beibao SET owner_renwu_id = ?, updated_at = CURRENT_TIMESTAMP WHERE owner_renwu_id = ?`,
+			disposal.TransferTo, characterID); err != nil {
+			return fmt.Errorf("转交背包失败: %v", err)
+		}
+		return nil
+	case CharacterBeibaoDelete:
+		if _, err := tx.Exec(`DELETE FROM beibao_items WHERE beibao_id IN (SELECT id FROM beibao WHERE owner_renwu_id = ?)`, characterID); err != nil {
+			return fmt.Errorf("删除背包物品失败: %v", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM beibao WHERE owner_renwu_id = ?`, characterID); err != nil {
+			return fmt.Errorf("删除背包失败: %v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("无效的背包处理方式: %s", disposal.Action)
+	}
+}
@@ -0,0 +1,113 @@
+package database
+
+import "testing"
+
+func TestSetBeibaoOwner(t *testing.T) {
+	db := openTestDatabase(t)
+	characterID, _ := db.CreateCharacter("林动", "", 100, 10)
+	shiliID, _ := db.CreateShili("道宗", "", 1, 1000, 0)
+	packID, _ := db.CreateBeibao("林动的背包")
+	storeID, _ := db.CreateBeibao("道宗仓库")
+	db.CreateBeibao("无主背包")
+
+	if err := db.SetBeibaoOwner(int(packID), BeibaoOwnerCharacter, characterID); err != nil {
+		t.Fatalf("SetBeibaoOwner() failed: %v", err)
+	}
+	if err := db.SetBeibaoOwner(int(storeID), BeibaoOwnerFaction, int(shiliID)); err != nil {
+		t.Fatalf("SetBeibaoOwner() for faction failed: %v", err)
+	}
+	if err := db.SetBeibaoOwner(int(storeID), BeibaoOwnerPet, 999); err == nil {
+		t.Fatalf("expected error for missing pet")
+	}
+	if err := db.SetBeibaoOwner(int(storeID), "npc", 1); err == nil {
+		t.Fatalf("expected error for unknown owner type")
+	}
+
+	info, err := db.GetBeibaoInfo(int(storeID))
+	if err != nil || info.OwnerType != BeibaoOwnerFaction || info.OwnerID != int(shiliID) || info.OwnerName != "道宗" {
+		t.Fatalf("GetBeibaoInfo() = %+v, %v", info, err)
+	}
+
+	list, _, err := db.ListBeibao(ListQuery{Type: BeibaoOwnerCharacter, OwnerID: &characterID})
+	if err != nil || len(list) != 1 || list[0]["name"] != "林动的背包" || list[0]["owner_name"] != "林动" {
+		t.Fatalf("ListBeibao() by owner = %+v, %v", list, err)
+	}
+	list, _, _ = db.ListBeibao(ListQuery{Type: ""})
+	if len(list) != 3 {
+		t.Fatalf("expected all backpacks without a filter, got %+v", list)
+	}
+
+	// 换成势力主人时清除原来的人物主人
+	if err := db.SetBeibaoOwner(int(packID), BeibaoOwnerFaction, int(shiliID)); err != nil {
+		t.Fatalf("SetBeibaoOwner() failed: %v", err)
+	}
+	if info, _ = db.GetBeibaoInfo(int(packID)); info.OwnerType != BeibaoOwnerFaction {
+		t.Fatalf("expected faction owner, got %+v", info)
+	}
+	if err := db.SetBeibaoOwner(int(packID), "", 0); err != nil {
+		t.Fatalf("clearing owner failed: %v", err)
+	}
+	if info, _ = db.GetBeibaoInfo(int(packID)); info.OwnerType != "" || info.OwnerID != 0 {
+		t.Fatalf("expected no owner, got %+v", info)
+	}
+}
+
+func TestDeleteCharacterBeibaoDisposal(t *testing.T) {
+	db := openTestDatabase(t)
+	heirID, _ := db.CreateCharacter("林炎", "", 0, 1)
+	newOwner := func(name string) (int, int) {
+		characterID, _ := db.CreateCharacter(name, "", 0, 1)
+		beibaoID, _ := db.CreateBeibao(name + "的背包")
+		db.AddBeibaoItem(int(beibaoID), "回血丹", 3, "", 0)
+		db.SetBeibaoOwner(int(beibaoID), BeibaoOwnerCharacter, characterID)
+		return characterID, int(beibaoID)
+	}
+
+	characterID, beibaoID := newOwner("林动")
+	info, err := db.GetCharacterInfo(characterID)
+	if err != nil || len(info.Beibao) != 1 || info.Beibao[0].ID != beibaoID || len(info.Beibao[0].Items) != 1 {
+		t.Fatalf("GetCharacterInfo() backpacks = %+v, %v", info, err)
+	}
+	if owned, _ := characterOwnedCount(db.db, characterID, "回血丹"); owned != 3 {
+		t.Fatalf("expected backpack items to count as owned, got %d", owned)
+	}
+
+	// 无效的处理方式或接收人时不删除人物
+	if err := db.DeleteCharacter(characterID, CharacterBeibaoDisposal{Action: "burn"}); err == nil {
+		t.Fatalf("expected error for unknown disposal")
+	}
+	if err := db.DeleteCharacter(characterID, CharacterBeibaoDisposal{Action: CharacterBeibaoTransfer, TransferTo: 999}); err == nil {
+		t.Fatalf("expected error for missing heir")
+	}
+	if _, err := db.GetCharacterInfo(characterID); err != nil {
+		t.Fatalf("failed delete must keep the character: %v", err)
+	}
+
+	if err := db.DeleteCharacter(characterID, CharacterBeibaoDisposal{Action: CharacterBeibaoTransfer, TransferTo: heirID}); err != nil {
+		t.Fatalf("DeleteCharacter() with transfer failed: %v", err)
+	}
+	if got, _ := db.GetBeibaoInfo(beibaoID); got.OwnerID != heirID {
+		t.Fatalf("expected backpack to pass to heir, got %+v", got)
+	}
+
+	characterID, beibaoID = newOwner("小貂")
+	if err := db.DeleteCharacter(characterID, CharacterBeibaoDisposal{}); err != nil {
+		t.Fatalf("DeleteCharacter() with orphan failed: %v", err)
+	}
+	if got, err := db.GetBeibaoInfo(beibaoID); err != nil || got.OwnerType != "" || len(got.Items) != 1 {
+		t.Fatalf("expected orphaned backpack with items, got %+v, %v", got, err)
+	}
+
+	characterID, beibaoID = newOwner("绫清竹")
+	if err := db.DeleteCharacter(characterID, CharacterBeibaoDisposal{Action: CharacterBeibaoDelete}); err != nil {
+		t.Fatalf("DeleteCharacter() with delete failed: %v", err)
+	}
+	if _, err := db.GetBeibaoInfo(beibaoID); err == nil {
+		t.Fatalf("expected backpack to be deleted")
+	}
+	var items int
+	db.db.QueryRow(`SELECT COUNT(*) FROM beibao_items WHERE beibao_id = ?`, beibaoID).Scan(&items)
+	if items != 0 {
+		t.Fatalf("expected backpack items to be deleted, got %d", items)
+	}
+}
@@ -0,0 +1,225 @@
+// 背包容量与物品堆叠：背包可限制格子数（每条物品记录占一格）和总重量；
+// 物品关联指定的道具，未指定时按名称关联唯一的同名道具，继承道具的等级、功能、价格、重量和堆叠上限。
+// 放入物品时先填满已有的同类堆叠，剩余部分按堆叠上限拆成新格子，超出容量或重量上限时整笔拒绝
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// beibaoUsage 背包的容量设置与当前占用
+type beibaoUsage struct {
+	capacity  sql.NullInt64
+	maxWeight sql.NullInt64
+	usedSlots int
+	weight    int
+}
+
+// itemStackRule 物品的堆叠规则，来自关联的道具；未关联道具的物品不限堆叠、重量为 0
+type itemStackRule struct {
+	daojuID  sql.NullInt64
+	maxStack int // 0 表示不限
+	weight   int
+}
+
+// SetBeibaoCapacity 设置背包的格子容量和重量上限，nil 表示不限；新上限不能低于当前占用
+func (d *Database) SetBeibaoCapacity(beibaoID int, capacity, maxWeight *int) error {
+	if (capacity != nil && *capacity < 0) || (maxWeight != nil && *maxWeight < 0) {
+		return fmt.Errorf("容量和重量上限不能为负数")
+	}
+	return d.WithTx(func(tx *sql.Tx) error {
+		usage, err := getBeibaoUsage(tx, beibaoID)
+		if err != nil {
+			return err
+		}
+		if capacity != nil && usage.usedSlots > *capacity {
+			return fmt.Errorf("容量不能少于已占用的格子数（%d 格）", usage.usedSlots)
+		}
+		if maxWeight != nil && usage.weight > *maxWeight {
+			return fmt.Errorf("重量上限不能低于当前重量（%d）", usage.weight)
+		}
+		if _, err := tx.Exec(`UPDATE beibao SET capacity = ?, max_weight = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			capacity, maxWeight, beibaoID); err != nil {
+			return fmt.Errorf("更新背包容量失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// SetDaojuStacking 设置道具的堆叠上限（0 表示不限）和单件重量；已放入背包的物品不会重新拆分
+func (d *Database) SetDaojuStacking(daojuID, maxStack, weight int) error {
+	if maxStack < 0 || weight < 0 {
+		return fmt.Errorf("堆叠上限和重量不能为负数")
+	}
+	result, err := d.db.Exec(`UPDATE daoju SET max_stack = ?, weight = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, maxStack, weight, daojuID)
+	if err != nil {
+		return fmt.Errorf("更新道具堆叠规则失败: %v", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("道具不存在")
+	}
+	return nil
+}
+
+// getBeibaoUsage 查询背包的容量设置、已占用格子数和当前重量
+func getBeibaoUsage(tx dbExecutor, beibaoID int) (beibaoUsage, error) {
+	var usage beibaoUsage
+	err := tx.QueryRow(`
+	SELECT b.capacity, b.max_weight,
+		(SELECT COUNT(*) FROM beibao_items WHERE beibao_id = b.id),
+		COALESCE((SELECT SUM(bi.quantity * dj.weight) FROM beibao_items bi JOIN daoju dj ON dj.id = bi.daoju_id WHERE bi.beibao_id = b.id), 0)
+	FROM beibao b WHERE b.id = ?`, beibaoID).Scan(&usage.capacity, &usage.maxWeight, &usage.usedSlots, &usage.weight)
+	if err == sql.ErrNoRows {
+		return beibaoUsage{}, fmt.Errorf("背包不存在")
+	}
+	if err != nil {
+		return beibaoUsage{}, fmt.Errorf("查询背包容量失败: %v", err)
+	}
+	return usage, nil
+}
+
+// checkWeight 检查重量增加 added 后是否超过上限
+func (u beibaoUsage) checkWeight(added int64) error {
+	if !u.maxWeight.Valid {
+		return nil
+	}
+	total := int64(math.MaxInt64)
+	if added <= math.MaxInt64-int64(u.weight) {
+		total = int64(u.weight) + added
+	}
+	if total > u.maxWeight.Int64 {
+		return fmt.Errorf("背包超重：放入后重量为 %d，上限 %d", total, u.maxWeight.Int64)
+	}
+	return nil
+}
+
+// stackWeight 计算 quantity 件单件重量为 weight 的物品总重，超出 int64 范围时取最大值
+func stackWeight(weight, quantity int) int64 {
+	if weight <= 0 || quantity <= 0 {
+		return 0
+	}
+	if int64(quantity) > math.MaxInt64/int64(weight) {
+		return math.MaxInt64
+	}
+	return int64(weight) * int64(quantity)
+}
+
+// getItemStackRule 查找物品关联的道具作为堆叠规则；daojuID 为 0 时按名称关联唯一的同名道具，
+// 没有或有多个同名道具时不关联
+func getItemStackRule(tx dbExecutor, daojuID int, name string) (itemStackRule, error) {
+	id := int64(daojuID)
+	if daojuID == 0 {
+		resolved, err := resolveReferenceID(tx, "daoju", name)
+		if err != nil {
+			return itemStackRule{}, fmt.Errorf("查询道具失败: %v", err)
+		}
+		if resolved == nil {
+			return itemStackRule{}, nil
+		}
+		id = resolved.(int64)
+	}
+
+	var rule itemStackRule
+	err := tx.QueryRow(`SELECT id, max_stack, weight FROM daoju WHERE id = ?`, id).Scan(&rule.daojuID, &rule.maxStack, &rule.weight)
+	if err == sql.ErrNoRows {
+		return itemStackRule{}, fmt.Errorf("道具不存在")
+	}
+	if err != nil {
+		return itemStackRule{}, fmt.Errorf("查询道具失败: %v", err)
+	}
+	return rule, nil
+}
+
+// depositBeibaoItem 将物品放入背包：先填满已有的同类堆叠（原描述为空时补上描述），
+// 剩余部分按堆叠上限新增格子；daojuID 为 0 时按名称关联道具。超出背包容量或重量上限时返回错误且不做修改
+func depositBeibaoItem(tx dbExecutor, beibaoID int, name string, quantity int, description string, daojuID int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("物品数量必须大于 0")
+	}
+	usage, err := getBeibaoUsage(tx, beibaoID)
+	if err != nil {
+		return err
+	}
+	rule, err := getItemStackRule(tx, daojuID, name)
+	if err != nil {
+		return err
+	}
+	if err := usage.checkWeight(stackWeight(rule.weight, quantity)); err != nil {
+		return err
+	}
+
+	// 同类堆叠：关联同一道具，或都未关联道具且名称相同
+	query := `SELECT id, quantity FROM beibao_items WHERE beibao_id = ? AND daoju_id = ? ORDER BY id ASC`
+	args := []interface{}{beibaoID, rule.daojuID}
+	if !rule.daojuID.Valid {
+		query = `SELECT id, quantity FROM beibao_items WHERE beibao_id = ? AND daoju_id IS NULL AND name = ? ORDER BY id ASC`
+		args = []interface{}{beibaoID, name}
+	}
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("查询背包物品失败: %v", err)
+	}
+	type stackFill struct{ id, add int }
+	var fills []stackFill
+	remaining := quantity
+	for rows.Next() && remaining > 0 {
+		var id, current int
+		if err := rows.Scan(&id, &current); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描背包物品失败: %v", err)
+		}
+		add := remaining
+		if rule.maxStack > 0 && current+add > rule.maxStack {
+			add = rule.maxStack - current
+		}
+		if add > 0 {
+			fills = append(fills, stackFill{id, add})
+			remaining -= add
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("查询背包物品失败: %v", err)
+	}
+
+	// 剩余部分需要的新格子数，先检查容量再写入
+	newStacks := 0
+	if remaining > 0 {
+		newStacks = 1
+		if rule.maxStack > 0 {
+			newStacks = remaining / rule.maxStack
+			if remaining%rule.maxStack != 0 {
+				newStacks++
+			}
+		}
+	}
+	if usage.capacity.Valid && int64(newStacks) > usage.capacity.Int64-int64(usage.usedSlots) {
+		return fmt.Errorf("背包空间不足：需要 %d 个新格子，剩余 %d 个", newStacks, usage.capacity.Int64-int64(usage.usedSlots))
+	}
+
+	for _, fill := range fills {
+		if _, err := tx.Exec(`
+		UPDATE beibao_items
+		SET quantity = quantity + ?,
+			description = CASE WHEN COALESCE(description, '') = '' THEN ? ELSE description END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, fill.add, description, fill.id); err != nil {
+			return fmt.Errorf("添加物品失败: %v", err)
+		}
+	}
+	for remaining > 0 {
+		size := remaining
+		if rule.maxStack > 0 && size > rule.maxStack {
+			size = rule.maxStack
+		}
+		if _, err := tx.Exec(`INSERT INTO beibao_items (beibao_id, name, quantity, description, daoju_id) VALUES (?, ?, ?, ?, ?)`,
+			beibaoID, name, size, description, rule.daojuID); err != nil {
+			return fmt.Errorf("添加物品失败: %v", err)
+		}
+		remaining -= size
+	}
+	return nil
+}
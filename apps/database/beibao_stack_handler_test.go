@@ -0,0 +1,212 @@
+package database
+
+import (
+	"database/sql"
+	"math"
+	"nooltools/apps/storage"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddBeibaoItemStacksAndLimits(t *testing.T) {
+	db := openTestDatabase(t)
+	daojuID, _ := db.CreateDaoju("回血丹", 2, "")
+	db.AddDaojuFunction(int(daojuID), "回血", "恢复 100 点气血")
+	if err := db.SetDaojuStacking(int(daojuID), 10, 2); err != nil {
+		t.Fatalf("SetDaojuStacking() failed: %v", err)
+	}
+	beibaoID, _ := db.CreateBeibao("行囊")
+	capacity, maxWeight := 3, 50
+	if err := db.SetBeibaoCapacity(int(beibaoID), &capacity, &maxWeight); err != nil {
+		t.Fatalf("SetBeibaoCapacity() failed: %v", err)
+	}
+
+	// 7 + 8 个回血丹：第一格堆满 10 个，剩余 5 个占新格子
+	for _, qty := range []int{7, 8} {
+		if err := db.AddBeibaoItem(int(beibaoID), "回血丹", qty, "", 0); err != nil {
+			t.Fatalf("AddBeibaoItem(%d) failed: %v", qty, err)
+		}
+	}
+	if err := db.AddBeibaoItem(int(beibaoID), "地图", 1, "青阳镇地图", 0); err != nil {
+		t.Fatalf("AddBeibaoItem() failed: %v", err)
+	}
+	info, err := db.GetBeibaoInfo(int(beibaoID))
+	if err != nil {
+		t.Fatalf("GetBeibaoInfo() failed: %v", err)
+	}
+	if len(info.Items) != 3 || info.Items[0].Quantity != 10 || info.Items[1].Quantity != 5 || info.UsedSlots != 3 || info.Weight != 30 {
+		t.Fatalf("unexpected backpack: %+v", info)
+	}
+	pill := info.Items[0]
+	if pill.DaojuID == nil || *pill.DaojuID != int(daojuID) || pill.Level != 2 || pill.MaxStack != 10 || len(pill.Functions) != 1 {
+		t.Fatalf("expected item to inherit daoju definition, got %+v", pill)
+	}
+
+	// 地图不限堆叠，继续放入不占新格子
+	if err := db.AddBeibaoItem(int(beibaoID), "地图", 2, "", 0); err != nil {
+		t.Fatalf("AddBeibaoItem() into existing stack failed: %v", err)
+	}
+	// 回血丹还能填满第二格，再多就需要新格子
+	if err := db.AddBeibaoItem(int(beibaoID), "回血丹", 6, "", 0); err == nil {
+		t.Fatalf("expected error when out of slots")
+	}
+	maxWeight = 1000
+	db.SetBeibaoCapacity(int(beibaoID), &capacity, &maxWeight)
+	if err := db.AddBeibaoItem(int(beibaoID), "回血丹", 5, "", 0); err != nil {
+		t.Fatalf("AddBeibaoItem() filling a stack failed: %v", err)
+	}
+	capacity = 10
+	maxWeight = 39
+	if err := db.SetBeibaoCapacity(int(beibaoID), &capacity, &maxWeight); err == nil {
+		t.Fatalf("expected error when lowering weight limit below current weight")
+	}
+	maxWeight = 50
+	db.SetBeibaoCapacity(int(beibaoID), &capacity, &maxWeight)
+	if err := db.AddBeibaoItem(int(beibaoID), "回血丹", 6, "", 0); err == nil {
+		t.Fatalf("expected error when overweight")
+	}
+	if err := db.UpdateBeibaoItem(pill.ID, "回血丹", 11, "", 0); err == nil {
+		t.Fatalf("expected error when exceeding max stack")
+	}
+	for _, qty := range []int{0, -3} {
+		if err := db.UpdateBeibaoItem(pill.ID, "回血丹", qty, "", 0); err == nil {
+			t.Fatalf("expected error when updating quantity to %d", qty)
+		}
+	}
+	mapItem := info.Items[2]
+	if err := db.UpdateBeibaoItem(mapItem.ID, "回血丹", 6, "", 0); err == nil {
+		t.Fatalf("expected error when an update would exceed the weight limit")
+	}
+
+	info, _ = db.GetBeibaoInfo(int(beibaoID))
+	if len(info.Items) != 3 || info.Items[1].Quantity != 10 || info.Items[2].Quantity != 3 || info.Weight != 40 {
+		t.Fatalf("rejected adds must not change the backpack: %+v", info)
+	}
+}
+
+// openLegacyDaojuDatabase 模拟道具名称还没有唯一约束的旧版本数据库：有两个同名道具「玄铁」，
+// 背包中已有未关联道具的「玄铁」和「回血丹」
+func openLegacyDaojuDatabase(t *testing.T) *Database {
+	t.Helper()
+	dataDir := t.TempDir()
+	raw, err := sql.Open("sqlite3", filepath.Join(dataDir, storage.DatabaseFileName))
+	if err != nil {
+		t.Fatalf("failed to open raw database: %v", err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE daoju (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			level INTEGER DEFAULT 1,
+			function TEXT,
+			durability INTEGER DEFAULT 100,
+			holder TEXT DEFAULT '',
+			price INTEGER DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE beibao (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL DEFAULT '默认背包',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE beibao_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			beibao_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			quantity INTEGER DEFAULT 1,
+			description TEXT DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (beibao_id) REFERENCES beibao(id) ON DELETE CASCADE
+		)`,
+		`INSERT INTO daoju (id, name, level) VALUES (1, '玄铁', 1), (2, '玄铁', 3), (3, '回血丹', 1)`,
+		`INSERT INTO beibao (id, name) VALUES (1, '行囊')`,
+		`INSERT INTO beibao_items (beibao_id, name, quantity) VALUES (1, '玄铁', 1), (1, '回血丹', 1)`,
+	} {
+		if _, err := raw.Exec(stmt); err != nil {
+			t.Fatalf("failed to prepare legacy database: %v", err)
+		}
+	}
+	raw.Close()
+
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() on legacy database failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrate_LinksBeibaoItemsOnlyToUniqueDaojuNames(t *testing.T) {
+	db := openLegacyDaojuDatabase(t)
+	info, err := db.GetBeibaoInfo(1)
+	if err != nil {
+		t.Fatalf("GetBeibaoInfo() failed: %v", err)
+	}
+	if len(info.Items) != 2 || info.Items[0].DaojuID != nil || info.Items[1].DaojuID == nil || *info.Items[1].DaojuID != 3 {
+		t.Fatalf("expected only the unique name to be linked, got %+v", info.Items)
+	}
+}
+
+func TestAddBeibaoItemLinksOnlyUniqueOrExplicitDaoju(t *testing.T) {
+	db := openLegacyDaojuDatabase(t)
+	db.SetDaojuStacking(2, 5, 1)
+
+	// 有两个同名道具时按名称不关联，与已有的未关联物品堆叠；指定道具ID时关联指定的道具
+	if err := db.AddBeibaoItem(1, "玄铁", 1, "", 0); err != nil {
+		t.Fatalf("AddBeibaoItem() failed: %v", err)
+	}
+	if err := db.AddBeibaoItem(1, "玄铁", 7, "", 2); err != nil {
+		t.Fatalf("AddBeibaoItem() with daoju failed: %v", err)
+	}
+	if err := db.AddBeibaoItem(1, "玄铁", 1, "", 9999); err == nil {
+		t.Fatalf("expected error for missing daoju")
+	}
+	info, _ := db.GetBeibaoInfo(1)
+	if len(info.Items) != 4 || info.Items[0].DaojuID != nil || info.Items[0].Quantity != 2 ||
+		info.Items[2].DaojuID == nil || *info.Items[2].DaojuID != 2 || info.Items[2].Quantity != 5 || info.Items[3].Quantity != 2 {
+		t.Fatalf("unexpected backpack: %+v", info.Items)
+	}
+
+	if err := db.UpdateBeibaoItem(info.Items[0].ID, "玄铁", 2, "", 1); err != nil {
+		t.Fatalf("UpdateBeibaoItem() failed: %v", err)
+	}
+	if err := db.UpdateBeibaoItem(info.Items[1].ID, "回血丹", 1, "", 0); err != nil {
+		t.Fatalf("UpdateBeibaoItem() failed: %v", err)
+	}
+	info, _ = db.GetBeibaoInfo(1)
+	if info.Items[0].DaojuID == nil || *info.Items[0].DaojuID != 1 || info.Items[1].DaojuID == nil || *info.Items[1].DaojuID != 3 {
+		t.Fatalf("expected items to link the given or unique daoju, got %+v", info.Items)
+	}
+}
+
+func TestAddBeibaoItemRejectsHugeQuantities(t *testing.T) {
+	db := openTestDatabase(t)
+	daojuID, _ := db.CreateDaoju("玄铁", 1, "")
+	db.SetDaojuStacking(int(daojuID), 1, 1<<40)
+	beibaoID, _ := db.CreateBeibao("行囊")
+	maxWeight := 100
+	db.SetBeibaoCapacity(int(beibaoID), nil, &maxWeight)
+
+	// 重量相乘溢出时不能绕过重量上限
+	if err := db.AddBeibaoItem(int(beibaoID), "玄铁", 1<<40, "", 0); err == nil {
+		t.Fatalf("expected error when the total weight overflows")
+	}
+
+	// 需要的新格子数直接计算，超出容量时不逐格构建
+	db.SetDaojuStacking(int(daojuID), 1, 0)
+	capacity := 3
+	db.SetBeibaoCapacity(int(beibaoID), &capacity, nil)
+	if err := db.AddBeibaoItem(int(beibaoID), "玄铁", math.MaxInt, "", 0); err == nil {
+		t.Fatalf("expected error when out of slots")
+	}
+	if err := db.AddBeibaoItem(int(beibaoID), "玄铁", 3, "", 0); err != nil {
+		t.Fatalf("AddBeibaoItem() failed: %v", err)
+	}
+	info, _ := db.GetBeibaoInfo(int(beibaoID))
+	if info.UsedSlots != 3 {
+		t.Fatalf("expected 3 single-item stacks, got %+v", info)
+	}
+}
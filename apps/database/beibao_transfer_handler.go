@@ -0,0 +1,213 @@
+// 背包之间的物品转移与交易：取出、放入（按堆叠规则和容量检查）与流水记录在同一事务中完成，
+// 任何一步失败都不做修改；每次操作的流水共用一个批次号，双方背包各记一条
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// 物品流水类型
+const (
+	BeibaoMovementTransfer = "transfer" // 转移
+	BeibaoMovementTrade    = "trade"    // 交易
+	BeibaoMovementReward   = "reward"   // 任务奖励，没有对方背包
+)
+
+// BeibaoMovement 背包物品流水
+type BeibaoMovement struct {
+	ID                  int    `json:"id"`
+	Batch               int    `json:"batch"` // 同一次转移或交易的流水批次号相同
+	Kind                string `json:"kind"`
+	BeibaoID            int    `json:"beibao_id"`
+	CounterpartBeibaoID int    `json:"counterpart_beibao_id"` // 对方背包，已删除或没有对方时为 0
+	CounterpartName     string `json:"counterpart_name"`
+	ItemName            string `json:"item_name"`
+	Quantity            int    `json:"quantity"` // 放入为正，取出为负
+	CreatedAt           string `json:"created_at"`
+}
+
+// BeibaoTradeOffer 交易中一方背包拿出的物品和货币
+type BeibaoTradeOffer struct {
+	BeibaoID int               `json:"beibao_id"`
+	Items    []BeibaoTradeItem `json:"items"`
+	Currency int               `json:"currency"` // 从背包余额中支付给对方的货币
+}
+
+// BeibaoTradeItem 交易中拿出的一件物品及数量
+type BeibaoTradeItem struct {
+	ItemID   int `json:"item_id"`
+	Quantity int `json:"quantity"`
+}
+
+// withdrawnItem 从背包中取出的物品
+type withdrawnItem struct {
+	beibaoID    int
+	name        string
+	quantity    int
+	description string
+	daojuID     sql.NullInt64
+}
+
+// TransferBeibaoItem 把物品的 qty 个转移到另一个背包，放入时按目标背包的堆叠规则和容量检查
+func (d *Database) TransferBeibaoItem(fromItemID, toBeibaoID, qty int) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		if err := checkBeibaoExists(tx, toBeibaoID); err != nil {
+			return err
+		}
+		item, err := withdrawBeibaoItem(tx, fromItemID, 0, qty)
+		if err != nil {
+			return err
+		}
+		if item.beibaoID == toBeibaoID {
+			return fmt.Errorf("不能转移到同一个背包")
+		}
+		batch, err := nextBeibaoMovementBatch(tx)
+		if err != nil {
+			return err
+		}
+		return moveWithdrawnItem(tx, batch, BeibaoMovementTransfer, item, toBeibaoID)
+	})
+}
+
+// TradeBetweenBeibao 两个背包交换物品和货币：先取出双方拿出的全部物品，再放入对方背包，
+// 货币通过账本在双方背包余额之间转账
+func (d *Database) TradeBetweenBeibao(offerA, offerB BeibaoTradeOffer) error {
+	if offerA.BeibaoID == offerB.BeibaoID {
+		return fmt.Errorf("交易双方不能是同一个背包")
+	}
+	if len(offerA.Items) == 0 && len(offerB.Items) == 0 && offerA.Currency == 0 && offerB.Currency == 0 {
+		return fmt.Errorf("交易双方都没有拿出物品或货币")
+	}
+	if offerA.Currency < 0 || offerB.Currency < 0 {
+		return fmt.Errorf("交易货币不能为负数")
+	}
+
+	return d.WithTx(func(tx *sql.Tx) error {
+		batch, err := nextBeibaoMovementBatch(tx)
+		if err != nil {
+			return err
+		}
+
+		offers := []BeibaoTradeOffer{offerA, offerB}
+		withdrawn := make([][]withdrawnItem, len(offers))
+		for i, offer := range offers {
+			if err := checkBeibaoExists(tx, offer.BeibaoID); err != nil {
+				return err
+			}
+			for _, tradeItem := range offer.Items {
+				item, err := withdrawBeibaoItem(tx, tradeItem.ItemID, offer.BeibaoID, tradeItem.Quantity)
+				if err != nil {
+					return err
+				}
+				withdrawn[i] = append(withdrawn[i], item)
+			}
+		}
+
+		for i, offer := range offers {
+			counterpart := offers[1-i].BeibaoID
+			for _, item := range withdrawn[i] {
+				if err := moveWithdrawnItem(tx, batch, BeibaoMovementTrade, item, counterpart); err != nil {
+					return err
+				}
+			}
+			if offer.Currency > 0 {
+				_, err := postLedgerEntry(tx, LedgerAccount{Type: LedgerAccountBeibao, ID: offer.BeibaoID},
+					LedgerAccount{Type: LedgerAccountBeibao, ID: counterpart}, offer.Currency, fmt.Sprintf("背包交易 #%d", batch), 0)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// GetBeibaoMovements 获取背包的物品流水，按时间倒序
+func (d *Database) GetBeibaoMovements(beibaoID, limit int) ([]BeibaoMovement, error) {
+	rows, err := d.db.Query(`
+	SELECT m.id, m.batch, m.kind, m.beibao_id, COALESCE(m.counterpart_beibao_id, 0), COALESCE(b.name, ''),
+		m.item_name, m.quantity, m.created_at
+	FROM beibao_movements m
+	LEFT JOIN beibao b ON b.id = m.counterpart_beibao_id
+	WHERE m.beibao_id = ?
+	ORDER BY m.id DESC LIMIT ?`, beibaoID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询物品流水失败: %v", err)
+	}
+	defer rows.Close()
+
+	movements := []BeibaoMovement{}
+	for rows.Next() {
+		var m BeibaoMovement
+		if err := rows.Scan(&m.ID, &m.Batch, &m.Kind, &m.BeibaoID, &m.CounterpartBeibaoID, &m.CounterpartName,
+			&m.ItemName, &m.Quantity, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描物品流水失败: %v", err)
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
+
+// withdrawBeibaoItem 从物品记录中取出 qty 个，取完时删除该记录；beibaoID 不为 0 时要求物品属于该背包
+func withdrawBeibaoItem(tx dbExecutor, itemID, beibaoID, qty int) (withdrawnItem, error) {
+	if qty <= 0 {
+		return withdrawnItem{}, fmt.Errorf("物品数量必须大于 0")
+	}
+	var item withdrawnItem
+	var current int
+	err := tx.QueryRow(`SELECT beibao_id, name, quantity, COALESCE(description, ''), daoju_id FROM beibao_items WHERE id = ?`, itemID).
+		Scan(&item.beibaoID, &item.name, &current, &item.description, &item.daojuID)
+	if err == sql.ErrNoRows {
+		return withdrawnItem{}, fmt.Errorf("物品不存在")
+	}
+	if err != nil {
+		return withdrawnItem{}, fmt.Errorf("查询物品失败: %v", err)
+	}
+	if beibaoID != 0 && item.beibaoID != beibaoID {
+		return withdrawnItem{}, fmt.Errorf("物品「%s」不在该背包中", item.name)
+	}
+	if qty > current {
+		return withdrawnItem{}, fmt.Errorf("物品「%s」数量不足：需要 %d，现有 %d", item.name, qty, current)
+	}
+
+	if qty == current {
+		_, err = tx.Exec(`DELETE FROM beibao_items WHERE id = ?`, itemID)
+	} else {
+		_, err = tx.Exec(`UPDATE beibao_items SET quantity = quantity - ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, qty, itemID)
+	}
+	if err != nil {
+		return withdrawnItem{}, fmt.Errorf("取出物品失败: %v", err)
+	}
+	item.quantity = qty
+	return item, nil
+}
+
+// moveWithdrawnItem 把取出的物品放入目标背包，并为双方各记一条流水
+func moveWithdrawnItem(tx dbExecutor, batch int, kind string, item withdrawnItem, toBeibaoID int) error {
+	if err := depositBeibaoItem(tx, toBeibaoID, item.name, item.quantity, item.description, int(item.daojuID.Int64)); err != nil {
+		return err
+	}
+	now := time.Now().Format("2006-01-02 15:04:05")
+	for _, m := range []struct{ beibaoID, counterpart, quantity int }{
+		{item.beibaoID, toBeibaoID, -item.quantity},
+		{toBeibaoID, item.beibaoID, item.quantity},
+	} {
+		if _, err := tx.Exec(`
+		INSERT INTO beibao_movements (batch, kind, beibao_id, counterpart_beibao_id, item_name, quantity, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, batch, kind, m.beibaoID, m.counterpart, item.name, m.quantity, now); err != nil {
+			return fmt.Errorf("保存物品流水失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// nextBeibaoMovementBatch 分配新的流水批次号
+func nextBeibaoMovementBatch(tx dbExecutor) (int, error) {
+	var batch int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(batch), 0) + 1 FROM beibao_movements`).Scan(&batch); err != nil {
+		return 0, fmt.Errorf("分配流水批次失败: %v", err)
+	}
+	return batch, nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import "testing"
+
+func TestTransferBeibaoItem(t *testing.T) {
+	db := openTestDatabase(t)
+	fromID, _ := db.CreateBeibao("林动的背包")
+	toID, _ := db.CreateBeibao("宗门仓库")
+	db.AddBeibaoItem(int(fromID), "回血丹", 5, "恢复气血", 0)
+	db.AddBeibaoItem(int(toID), "回血丹", 2, "", 0)
+	from, _ := db.GetBeibaoInfo(int(fromID))
+	itemID := from.Items[0].ID
+
+	if err := db.TransferBeibaoItem(itemID, int(toID), 3); err != nil {
+		t.Fatalf("TransferBeibaoItem() failed: %v", err)
+	}
+	if err := db.TransferBeibaoItem(itemID, int(toID), 3); err == nil {
+		t.Fatalf("expected error when moving more than available")
+	}
+	if err := db.TransferBeibaoItem(itemID, int(fromID), 1); err == nil {
+		t.Fatalf("expected error when moving into the same backpack")
+	}
+
+	// 目标背包已满时整笔拒绝
+	capacity := 1
+	db.SetBeibaoCapacity(int(toID), &capacity, nil)
+	db.AddBeibaoItem(int(fromID), "地图", 1, "", 0)
+	from, _ = db.GetBeibaoInfo(int(fromID))
+	if err := db.TransferBeibaoItem(from.Items[1].ID, int(toID), 1); err == nil {
+		t.Fatalf("expected error when target backpack is full")
+	}
+
+	if err := db.TransferBeibaoItem(itemID, int(toID), 2); err != nil {
+		t.Fatalf("TransferBeibaoItem() of the rest failed: %v", err)
+	}
+	from, _ = db.GetBeibaoInfo(int(fromID))
+	to, _ := db.GetBeibaoInfo(int(toID))
+	if len(from.Items) != 1 || from.Items[0].Name != "地图" {
+		t.Fatalf("expected only the map to stay, got %+v", from.Items)
+	}
+	if len(to.Items) != 1 || to.Items[0].Quantity != 7 || to.Items[0].Description != "恢复气血" {
+		t.Fatalf("expected transferred pills to stack, got %+v", to.Items)
+	}
+
+	movements, err := db.GetBeibaoMovements(int(fromID), 10)
+	if err != nil || len(movements) != 2 {
+		t.Fatalf("GetBeibaoMovements() = %+v, %v", movements, err)
+	}
+	if movements[0].Quantity != -2 || movements[0].CounterpartName != "宗门仓库" || movements[0].Kind != BeibaoMovementTransfer {
+		t.Fatalf("unexpected movement: %+v", movements[0])
+	}
+}
+
+func TestTradeBetweenBeibao(t *testing.T) {
+	db := openTestDatabase(t)
+	aID, _ := db.CreateBeibao("甲")
+	bID, _ := db.CreateBeibao("乙")
+	db.AddBeibaoItem(int(aID), "玄铁", 10, "", 0)
+	db.AddBeibaoItem(int(bID), "灵石", 4, "", 0)
+	db.SetBeibaoBalance(int(bID), 100)
+	a, _ := db.GetBeibaoInfo(int(aID))
+	b, _ := db.GetBeibaoInfo(int(bID))
+
+	// 乙出价超过余额，整笔交易不生效
+	offerA := BeibaoTradeOffer{BeibaoID: int(aID), Items: []BeibaoTradeItem{{ItemID: a.Items[0].ID, Quantity: 6}}}
+	offerB := BeibaoTradeOffer{BeibaoID: int(bID), Items: []BeibaoTradeItem{{ItemID: b.Items[0].ID, Quantity: 4}}, Currency: 200}
+	if err := db.TradeBetweenBeibao(offerA, offerB); err == nil {
+		t.Fatalf("expected error for insufficient currency")
+	}
+	if a, _ = db.GetBeibaoInfo(int(aID)); a.Items[0].Quantity != 10 {
+		t.Fatalf("failed trade must not change items, got %+v", a.Items)
+	}
+	if err := db.TradeBetweenBeibao(offerA, BeibaoTradeOffer{BeibaoID: int(bID), Items: []BeibaoTradeItem{{ItemID: a.Items[0].ID, Quantity: 1}}}); err == nil {
+		t.Fatalf("expected error when offering an item from the other backpack")
+	}
+
+	offerB.Currency = 30
+	if err := db.TradeBetweenBeibao(offerA, offerB); err != nil {
+		t.Fatalf("TradeBetweenBeibao() failed: %v", err)
+	}
+	a, _ = db.GetBeibaoInfo(int(aID))
+	b, _ = db.GetBeibaoInfo(int(bID))
+	if len(a.Items) != 2 || a.Items[0].Quantity != 4 || a.Items[1].Name != "灵石" || a.Balance != 30 {
+		t.Fatalf("unexpected backpack A: %+v", a)
+	}
+	if len(b.Items) != 1 || b.Items[0].Name != "玄铁" || b.Items[0].Quantity != 6 || b.Balance != 70 {
+		t.Fatalf("unexpected backpack B: %+v", b)
+	}
+
+	movements, _ := db.GetBeibaoMovements(int(bID), 10)
+	if len(movements) != 2 || movements[0].Batch != movements[1].Batch || movements[0].Kind != BeibaoMovementTrade {
+		t.Fatalf("expected two trade movements in one batch, got %+v", movements)
+	}
+}
@@ -0,0 +1,383 @@
+// 世界历法：由按时间顺序排列的纪元和一年中的月份组成，纪元可以限定年数（最后一个纪元可以不限）。
+// 世界内的日期以“日序”保存，即从第一个纪元元年第一个月第一日起经过的天数，便于排序和按范围查询；
+// 日期文本形如“玄天300年三月5日”，解析时也接受数字月份和“-”“/”分隔，如“玄天 300-3-5”
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WorldCalendar 世界历法
+type WorldCalendar struct {
+	Eras   []CalendarEra   `json:"eras"`   // 按时间顺序排列
+	Months []CalendarMonth `json:"months"` // 一年中的月份，按顺序排列
+}
+
+// CalendarEra 纪元
+type CalendarEra struct {
+	Name  string `json:"name"`
+	Years int    `json:"years"` // 纪元的年数，0 表示不限，只有最后一个纪元可以不限
+}
+
+// CalendarMonth 月份
+type CalendarMonth struct {
+	Name string `json:"name"`
+	Days int    `json:"days"`
+}
+
+// WorldDate 解析后的世界日期
+type WorldDate struct {
+	Era       string `json:"era"`
+	Year      int    `json:"year"`
+	Month     int    `json:"month"` // 从 1 开始
+	MonthName string `json:"month_name"`
+	Day       int    `json:"day"`
+	Ordinal   int    `json:"ordinal"` // 日序
+	Text      string `json:"text"`    // 规范的日期文本
+}
+
+// worldDateColumns 保存世界日期（日序）的列
+var worldDateColumns = []struct{ table, column, label string }{
+	{"renwu", "birth_day", "人物"},
+	{"shili", "founded_day", "势力"},
+	{"shiqing", "world_day", "任务"},
+}
+
+// defaultWorldCalendar 尚未配置历法时使用的默认历法：一个不限年数的纪元，十二个月，每月 30 日
+func defaultWorldCalendar() WorldCalendar {
+	calendar := WorldCalendar{Eras: []CalendarEra{{Name: "纪元"}}}
+	for _, name := range []string{"一", "二", "三", "四", "五", "六", "七", "八", "九", "十", "十一", "十二"} {
+		calendar.Months = append(calendar.Months, CalendarMonth{Name: name + "月", Days: 30})
+	}
+	return calendar
+}
+
+// GetWorldCalendar 获取世界历法，尚未配置时返回默认历法
+func (d *Database) GetWorldCalendar() (WorldCalendar, error) {
+	return loadWorldCalendar(d.db)
+}
+
+// SetWorldCalendar 设置世界历法；已保存的日期按第几个纪元、年、第几个月、日换算到新历法，
+// 因此纪元和月份改名不影响已有日期；任何一个日期在新历法中无效时整体不做修改
+func (d *Database) SetWorldCalendar(calendar WorldCalendar) error {
+	if err := calendar.validate(); err != nil {
+		return err
+	}
+	return d.WithTx(func(tx *sql.Tx) error {
+		old, err := loadWorldCalendar(tx)
+		if err != nil {
+			return err
+		}
+		if err := remapWorldDates(tx, old, calendar); err != nil {
+			return err
+		}
+		return saveWorldCalendar(tx, calendar)
+	})
+}
+
+// ParseWorldDate 按当前历法解析日期文本
+func (d *Database) ParseWorldDate(text string) (WorldDate, error) {
+	calendar, err := loadWorldCalendar(d.db)
+	if err != nil {
+		return WorldDate{}, err
+	}
+	return calendar.Parse(text)
+}
+
+// FormatWorldDate 按当前历法把日序格式化为日期文本
+func (d *Database) FormatWorldDate(ordinal int) (string, error) {
+	calendar, err := loadWorldCalendar(d.db)
+	if err != nil {
+		return "", err
+	}
+	date, err := calendar.FromOrdinal(ordinal)
+	if err != nil {
+		return "", err
+	}
+	return date.Text, nil
+}
+
+// Parse 解析日期文本：纪元名（只有一个纪元时可省略）、年、月（名称或数字）、日
+func (c WorldCalendar) Parse(text string) (WorldDate, error) {
+	rest := strings.TrimSpace(text)
+	if rest == "" {
+		return WorldDate{}, fmt.Errorf("日期不能为空")
+	}
+
+	var date WorldDate
+	eraIndex := -1
+	for i, era := range c.Eras {
+		if strings.HasPrefix(rest, era.Name) && (eraIndex < 0 || len(era.Name) > len(c.Eras[eraIndex].Name)) {
+			eraIndex = i
+		}
+	}
+	if eraIndex >= 0 {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, c.Eras[eraIndex].Name))
+	} else if len(c.Eras) == 1 {
+		eraIndex = 0
+	} else {
+		return WorldDate{}, fmt.Errorf("无法识别日期「%s」的纪元", text)
+	}
+	date.Era = c.Eras[eraIndex].Name
+
+	var ok bool
+	if date.Year, rest, ok = readDateNumber(rest, "年", "-", "/"); !ok {
+		return WorldDate{}, fmt.Errorf("无法识别日期「%s」的年份", text)
+	}
+
+	if date.Month, rest, ok = readDateNumber(rest, "月", "-", "/"); !ok {
+		monthIndex := -1
+		for i, month := range c.Months {
+			if strings.HasPrefix(rest, month.Name) && (monthIndex < 0 || len(month.Name) > len(c.Months[monthIndex].Name)) {
+				monthIndex = i
+			}
+		}
+		if monthIndex < 0 {
+			return WorldDate{}, fmt.Errorf("无法识别日期「%s」的月份", text)
+		}
+		date.Month = monthIndex + 1
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, c.Months[monthIndex].Name))
+	}
+
+	if date.Day, rest, ok = readDateNumber(rest, "日", "号"); !ok || rest != "" {
+		return WorldDate{}, fmt.Errorf("无法识别日期「%s」的日", text)
+	}
+
+	ordinal, err := c.ordinal(eraIndex, date.Year, date.Month, date.Day)
+	if err != nil {
+		return WorldDate{}, fmt.Errorf("日期「%s」无效: %v", text, err)
+	}
+	return c.FromOrdinal(ordinal)
+}
+
+// FromOrdinal 把日序换算为日期
+func (c WorldCalendar) FromOrdinal(ordinal int) (WorldDate, error) {
+	if ordinal < 0 {
+		return WorldDate{}, fmt.Errorf("日序不能为负数")
+	}
+	daysPerYear := c.daysPerYear()
+	rest := ordinal
+	for _, era := range c.Eras {
+		if era.Years > 0 && rest >= era.Years*daysPerYear {
+			rest -= era.Years * daysPerYear
+			continue
+		}
+		date := WorldDate{Era: era.Name, Year: rest/daysPerYear + 1, Ordinal: ordinal}
+		rest %= daysPerYear
+		for i, month := range c.Months {
+			if rest < month.Days {
+				date.Month, date.MonthName, date.Day = i+1, month.Name, rest+1
+				break
+			}
+			rest -= month.Days
+		}
+		date.Text = fmt.Sprintf("%s%d年%s%d日", date.Era, date.Year, date.MonthName, date.Day)
+		return date, nil
+	}
+	return WorldDate{}, fmt.Errorf("日序 %d 超出历法范围", ordinal)
+}
+
+// eraIndex 返回纪元的位置，不存在时为 -1
+func (c WorldCalendar) eraIndex(name string) int {
+	for i, era := range c.Eras {
+		if era.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// ordinal 计算日期的日序
+func (c WorldCalendar) ordinal(eraIndex, year, month, day int) (int, error) {
+	if eraIndex < 0 || eraIndex >= len(c.Eras) {
+		return 0, fmt.Errorf("历法只有 %d 个纪元", len(c.Eras))
+	}
+	era := c.Eras[eraIndex]
+	if year < 1 || (era.Years > 0 && year > era.Years) {
+		return 0, fmt.Errorf("%s只有 %d 年", era.Name, era.Years)
+	}
+	if month < 1 || month > len(c.Months) {
+		return 0, fmt.Errorf("一年只有 %d 个月", len(c.Months))
+	}
+	if day < 1 || day > c.Months[month-1].Days {
+		return 0, fmt.Errorf("%s只有 %d 日", c.Months[month-1].Name, c.Months[month-1].Days)
+	}
+
+	daysPerYear := c.daysPerYear()
+	ordinal := (year - 1) * daysPerYear
+	for _, previous := range c.Eras[:eraIndex] {
+		ordinal += previous.Years * daysPerYear
+	}
+	for _, previous := range c.Months[:month-1] {
+		ordinal += previous.Days
+	}
+	return ordinal + day - 1, nil
+}
+
+// daysPerYear 一年的天数
+func (c WorldCalendar) daysPerYear() int {
+	days := 0
+	for _, month := range c.Months {
+		days += month.Days
+	}
+	return days
+}
+
+// validate 检查历法配置
+func (c WorldCalendar) validate() error {
+	if len(c.Eras) == 0 || len(c.Months) == 0 {
+		return fmt.Errorf("历法至少需要一个纪元和一个月份")
+	}
+	names := make(map[string]bool)
+	for i, era := range c.Eras {
+		if strings.TrimSpace(era.Name) == "" || strings.IndexFunc(era.Name, unicode.IsDigit) >= 0 {
+			return fmt.Errorf("纪元名称不能为空或包含数字")
+		}
+		if names[era.Name] {
+			return fmt.Errorf("纪元名称重复: %s", era.Name)
+		}
+		names[era.Name] = true
+		if era.Years < 0 || (era.Years == 0 && i < len(c.Eras)-1) {
+			return fmt.Errorf("纪元「%s」的年数无效：只有最后一个纪元可以不限年数", era.Name)
+		}
+	}
+	names = make(map[string]bool)
+	for _, month := range c.Months {
+		if strings.TrimSpace(month.Name) == "" || strings.IndexFunc(month.Name, unicode.IsDigit) >= 0 {
+			return fmt.Errorf("月份名称不能为空或包含数字")
+		}
+		if names[month.Name] {
+			return fmt.Errorf("月份名称重复: %s", month.Name)
+		}
+		names[month.Name] = true
+		if month.Days <= 0 {
+			return fmt.Errorf("月份「%s」的天数必须大于 0", month.Name)
+		}
+	}
+	return nil
+}
+
+// readDateNumber 读取开头的数字并跳过其后的一个单位或分隔符，没有数字时 ok 为 false
+func readDateNumber(text string, suffixes ...string) (int, string, bool) {
+	end := strings.IndexFunc(text, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(text)
+	}
+	if end == 0 {
+		return 0, text, false
+	}
+	n, err := strconv.Atoi(text[:end])
+	if err != nil {
+		return 0, text, false
+	}
+	rest := strings.TrimSpace(text[end:])
+	for _, suffix := range suffixes {
+		if strings.HasPrefix(rest, suffix) {
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, suffix))
+			break
+		}
+	}
+	return n, rest, true
+}
+
+// loadWorldCalendar 读取历法配置，未配置时返回默认历法
+func loadWorldCalendar(q dbExecutor) (WorldCalendar, error) {
+	var calendar WorldCalendar
+	rows, err := q.Query(`SELECT name, years FROM calendar_eras ORDER BY position ASC`)
+	if err != nil {
+		return calendar, fmt.Errorf("查询纪元失败: %v", err)
+	}
+	for rows.Next() {
+		var era CalendarEra
+		if err := rows.Scan(&era.Name, &era.Years); err != nil {
+			rows.Close()
+			return calendar, fmt.Errorf("扫描纪元失败: %v", err)
+		}
+		calendar.Eras = append(calendar.Eras, era)
+	}
+	rows.Close()
+
+	rows, err = q.Query(`SELECT name, days FROM calendar_months ORDER BY position ASC`)
+	if err != nil {
+		return calendar, fmt.Errorf("查询月份失败: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var month CalendarMonth
+		if err := rows.Scan(&month.Name, &month.Days); err != nil {
+			return calendar, fmt.Errorf("扫描月份失败: %v", err)
+		}
+		calendar.Months = append(calendar.Months, month)
+	}
+	if err := rows.Err(); err != nil {
+		return calendar, fmt.Errorf("查询月份失败: %v", err)
+	}
+
+	if len(calendar.Eras) == 0 || len(calendar.Months) == 0 {
+		return defaultWorldCalendar(), nil
+	}
+	return calendar, nil
+}
+
+// saveWorldCalendar 保存历法配置，不换算已保存的日期
+func saveWorldCalendar(tx dbExecutor, calendar WorldCalendar) error {
+	for _, statement := range []string{`DELETE FROM calendar_eras`, `DELETE FROM calendar_months`} {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("清空历法失败: %v", err)
+		}
+	}
+	for i, era := range calendar.Eras {
+		if _, err := tx.Exec(`INSERT INTO calendar_eras (position, name, years) VALUES (?, ?, ?)`, i+1, era.Name, era.Years); err != nil {
+			return fmt.Errorf("保存纪元失败: %v", err)
+		}
+	}
+	for i, month := range calendar.Months {
+		if _, err := tx.Exec(`INSERT INTO calendar_months (position, name, days) VALUES (?, ?, ?)`, i+1, month.Name, month.Days); err != nil {
+			return fmt.Errorf("保存月份失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// remapWorldDates 把已保存的日序从旧历法换算到新历法
+func remapWorldDates(tx dbExecutor, old, calendar WorldCalendar) error {
+	for _, c := range worldDateColumns {
+		rows, err := tx.Query(`SELECT id, name, ` + c.column + ` FROM ` + c.table + ` WHERE ` + c.column + ` IS NOT NULL`)
+		if err != nil {
+			return fmt.Errorf("查询%s日期失败: %v", c.label, err)
+		}
+		updates := map[int]int{}
+		for rows.Next() {
+			var id, ordinal int
+			var name string
+			if err := rows.Scan(&id, &name, &ordinal); err != nil {
+				rows.Close()
+				return fmt.Errorf("扫描%s日期失败: %v", c.label, err)
+			}
+			date, err := old.FromOrdinal(ordinal)
+			if err == nil {
+				ordinal, err = calendar.ordinal(old.eraIndex(date.Era), date.Year, date.Month, date.Day)
+			}
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("%s「%s」的日期在新历法中无效: %v", c.label, name, err)
+			}
+			updates[id] = ordinal
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("查询%s日期失败: %v", c.label, err)
+		}
+
+		for id, ordinal := range updates {
+			if _, err := tx.Exec(`UPDATE `+c.table+` SET `+c.column+` = ? WHERE id = ?`, ordinal, id); err != nil {
+				return fmt.Errorf("更新%s日期失败: %v", c.label, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import "testing"
+
+func testWorldCalendar() WorldCalendar {
+	return WorldCalendar{
+		Eras:   []CalendarEra{{Name: "太古", Years: 100}, {Name: "玄天"}},
+		Months: []CalendarMonth{{Name: "春月", Days: 30}, {Name: "夏月", Days: 31}, {Name: "秋月", Days: 30}, {Name: "冬月", Days: 29}},
+	}
+}
+
+func TestWorldCalendarParseAndFormat(t *testing.T) {
+	calendar := testWorldCalendar()
+	tests := []struct {
+		text    string
+		ordinal int
+		want    string
+	}{
+		{"太古1年春月1日", 0, "太古1年春月1日"},
+		{"太古 1-2-1", 30, "太古1年夏月1日"},
+		{"玄天1年春月1日", 100 * 120, "玄天1年春月1日"},
+		{"玄天300年秋月5号", 100*120 + 299*120 + 61 + 4, "玄天300年秋月5日"},
+		{"玄天 300/3/5", 100*120 + 299*120 + 61 + 4, "玄天300年秋月5日"},
+	}
+	for _, tt := range tests {
+		date, err := calendar.Parse(tt.text)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.text, err)
+		}
+		if date.Ordinal != tt.ordinal || date.Text != tt.want {
+			t.Fatalf("Parse(%q) = %d %q, want %d %q", tt.text, date.Ordinal, date.Text, tt.ordinal, tt.want)
+		}
+		back, err := calendar.FromOrdinal(date.Ordinal)
+		if err != nil || back.Text != tt.want {
+			t.Fatalf("FromOrdinal(%d) = %q, %v", date.Ordinal, back.Text, err)
+		}
+	}
+
+	for _, text := range []string{"", "300年春月1日", "太古101年春月1日", "玄天1年冬月30日", "玄天1年闰月1日", "玄天1年春月1日午时"} {
+		if _, err := calendar.Parse(text); err == nil {
+			t.Fatalf("expected Parse(%q) to fail", text)
+		}
+	}
+
+	// 只有一个纪元时可以省略纪元名
+	if date, err := defaultWorldCalendar().Parse("3年二月5日"); err != nil || date.Text != "纪元3年二月5日" {
+		t.Fatalf("Parse() without era = %+v, %v", date, err)
+	}
+}
+
+func TestSetWorldCalendarRemapsDates(t *testing.T) {
+	db := openTestDatabase(t)
+	characterID, _ := db.CreateCharacter("林动", "", 0, 1)
+	if err := db.SetCharacterBirth(characterID, "5年三月10日"); err != nil {
+		t.Fatalf("SetCharacterBirth() with default calendar failed: %v", err)
+	}
+
+	invalid := testWorldCalendar()
+	invalid.Eras[0].Years = 0
+	if err := db.SetWorldCalendar(invalid); err == nil {
+		t.Fatalf("expected error when a non-final era is unlimited")
+	}
+
+	// 改名不影响已有日期：第一个纪元第 5 年第三个月第 10 日
+	if err := db.SetWorldCalendar(testWorldCalendar()); err != nil {
+		t.Fatalf("SetWorldCalendar() failed: %v", err)
+	}
+	info, _ := db.GetCharacterInfo(characterID)
+	if info.BirthDate != "太古5年秋月10日" {
+		t.Fatalf("expected remapped birth date, got %q", info.BirthDate)
+	}
+
+	// 第三个月被删除时整体拒绝
+	shorter := testWorldCalendar()
+	shorter.Months = shorter.Months[:2]
+	if err := db.SetWorldCalendar(shorter); err == nil {
+		t.Fatalf("expected error when a stored date becomes invalid")
+	}
+	if calendar, _ := db.GetWorldCalendar(); len(calendar.Months) != 4 {
+		t.Fatalf("rejected calendar must not be saved, got %+v", calendar)
+	}
+}
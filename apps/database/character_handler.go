@@ -26,33 +26,46 @@ type CharacterInfo struct {
 	ID         int                  `json:"id"`
 	Name       string               `json:"name"`
 	Shili      string               `json:"shili"`
+	ShiliID    *int                 `json:"shili_id"` // 关联的势力ID，势力名称未关联到势力记录时为空
 	Property   int                  `json:"property"`
 	Level      int                  `json:"level"`
+	BirthDate  string               `json:"birth_date"` // 世界历法中的出生日期，未设置时为空
 	Attributes []CharacterAttribute `json:"attributes"`
 	Skills     []CharacterSkill     `json:"skills"`
+	Weapons    []CharacterBelonging `json:"weapons"`
+	Items      []CharacterBelonging `json:"items"`
+	Pets       []CharacterBelonging `json:"pets"`
+	Beibao     []BeibaoInfo         `json:"beibao"` // 人物名下的背包（包含物品）
+	Faction    *CharacterFaction    `json:"faction"`
+}
+
+// characterListSpec 人物列表的筛选与排序；持有者筛选对应所属势力
+var characterListSpec = listSpec{
+	label:         "人物",
+	table:         "renwu",
+	levelColumn:   "level",
+	ownerColumn:   "shili",
+	ownerIDColumn: "shili_id",
+	sortColumns:   map[string]string{"id": "id", "name": "name", "level": "level", "property": "property"},
+	defaultSort:   "id",
 }
 
 // GetAllCharacters 获取所有人物列表
 func (d *Database) GetAllCharacters() ([]map[string]interface{}, error) {
-	query := `
-	SELECT id, name, shili, property, level
-	FROM renwu
-	ORDER BY id ASC`
-
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("查询人物列表失败: %v", err)
-	}
-	defer rows.Close()
+	characters, _, err := d.ListCharacters(ListQuery{})
+	return characters, err
+}
 
+// ListCharacters 按筛选、排序和分页条件获取人物列表
+func (d *Database) ListCharacters(q ListQuery) ([]map[string]interface{}, ListResult, error) {
 	var characters []map[string]interface{}
-	for rows.Next() {
+	result, err := d.queryList(characterListSpec, q, `id, name, shili, property, level`, func(rows *sql.Rows) (int64, error) {
 		var id int
 		var name, shili string
 		var property, level int
 
 		if err := rows.Scan(&id, &name, &shili, &property, &level); err != nil {
-			return nil, fmt.Errorf("扫描人物数据失败: %v", err)
+			return 0, fmt.Errorf("扫描人物数据失败: %v", err)
 		}
 
 		character := map[string]interface{}{
@@ -63,27 +76,39 @@ func (d *Database) GetAllCharacters() ([]map[string]interface{}, error) {
 			"level":    level,
 		}
 		characters = append(characters, character)
+		return int64(id), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return characters, nil
+	return characters, result, nil
 }
 
-// GetCharacterInfo 获取人物详细信息（包含属性和技能）
+// GetCharacterInfo 获取人物详细信息（包含属性、技能以及拥有的武器、道具、宠物和所属势力）
 func (d *Database) GetCharacterInfo(characterID int) (*CharacterInfo, error) {
 	// 查询人物基本信息
 	var info CharacterInfo
+	var shiliID, birthDay sql.NullInt64
 	query := `
-	SELECT id, name, shili, property, level
+	SELECT id, name, shili, shili_id, property, level, birth_day
 	FROM renwu
 	WHERE id = ?`
 
-	err := d.db.QueryRow(query, characterID).Scan(&info.ID, &info.Name, &info.Shili, &info.Property, &info.Level)
+	err := d.db.QueryRow(query, characterID).Scan(&info.ID, &info.Name, &info.Shili, &shiliID, &info.Property, &info.Level, &birthDay)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("人物不存在")
 		}
 		return nil, fmt.Errorf("查询人物信息失败: %v", err)
 	}
+	if shiliID.Valid {
+		id := int(shiliID.Int64)
+		info.ShiliID = &id
+	}
+	if info.BirthDate, err = formatWorldDay(d.db, birthDay); err != nil {
+		return nil, err
+	}
 
 	// 查询人物属性
 	attributes, err := d.GetCharacterAttributes(characterID)
@@ -99,6 +124,25 @@ func (d *Database) GetCharacterInfo(characterID int) (*CharacterInfo, error) {
 	}
 	info.Skills = skills
 
+	// 查询人物拥有的武器、道具和宠物
+	if info.Weapons, err = d.getCharacterBelongings(weaponHolderReference, characterID); err != nil {
+		return nil, fmt.Errorf("查询人物武器失败: %v", err)
+	}
+	if info.Items, err = d.getCharacterBelongings(daojuHolderReference, characterID); err != nil {
+		return nil, fmt.Errorf("查询人物道具失败: %v", err)
+	}
+	if info.Pets, err = d.getCharacterBelongings(petOwnerReference, characterID); err != nil {
+		return nil, fmt.Errorf("查询人物宠物失败: %v", err)
+	}
+	if info.Beibao, err = d.getOwnedBeibao(BeibaoOwnerCharacter, characterID); err != nil {
+		return nil, fmt.Errorf("查询人物背包失败: %v", err)
+	}
+
+	// 查询所属势力及职务
+	if info.Faction, err = d.getCharacterFaction(characterID); err != nil {
+		return nil, fmt.Errorf("查询人物所属势力失败: %v", err)
+	}
+
 	return &info, nil
 }
 
@@ -156,11 +200,16 @@ func (d *Database) GetCharacterSkills(characterID int) ([]CharacterSkill, error)
 
 // AddCharacterAttribute 添加人物属性
 func (d *Database) AddCharacterAttribute(characterID int, name, description string, value int) error {
+	return addCharacterAttribute(d.db, characterID, name, description, value)
+}
+
+// addCharacterAttribute 使用指定的执行器添加人物属性，供事务内复用
+func addCharacterAttribute(tx dbExecutor, characterID int, name, description string, value int) error {
 	query := `
 	INSERT INTO renwu_attributes (renwu_id, name, description, value)
 	VALUES (?, ?, ?, ?)`
 
-	_, err := d.db.Exec(query, characterID, name, description, value)
+	_, err := tx.Exec(query, characterID, name, description, value)
 	if err != nil {
 		return fmt.Errorf("添加属性失败: %v", err)
 	}
@@ -256,77 +305,135 @@ func (d *Database) UpdateCharacterSkill(skillID int, name, description string) e
 	return nil
 }
 
-// CreateCharacter 创建新人物
+// CreateCharacter 创建新人物（人物与默认属性在同一事务中写入）
 func (d *Database) CreateCharacter(name, shili string, property, level int) (int, error) {
-	query := `
-	INSERT INTO renwu (name, shili, property, level)
-	VALUES (?, ?, ?, ?)`
+	var id int64
+	err := d.WithTx(func(tx *sql.Tx) error {
+		shiliID, err := resolveReferenceID(tx, "shili", shili)
+		if err != nil {
+			return fmt.Errorf("查询势力失败: %v", err)
+		}
 
-	result, err := d.db.Exec(query, name, shili, property, level)
-	if err != nil {
-		return 0, fmt.Errorf("创建人物失败: %v", err)
-	}
+		query := `
+		INSERT INTO renwu (name, shili, shili_id, property, level)
+		VALUES (?, ?, ?, ?, ?)`
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("获取人物ID失败: %v", err)
-	}
-
-	// 为新人物添加默认属性
-	defaultAttributes := []struct {
-		name        string
-		description string
-		value       int
-	}{
-		{"血量", "当前生命值", 100},
-		{"蓝量", "当前魔法值", 100},
-		{"攻击", "攻击力", 100},
-		{"防御", "防御力", 100},
-	}
+		result, err := tx.Exec(query, name, shili, shiliID, property, level)
+		if err != nil {
+			return fmt.Errorf("创建人物失败: %v", err)
+		}
 
-	for _, attr := range defaultAttributes {
-		err = d.AddCharacterAttribute(int(id), attr.name, attr.description, attr.value)
+		id, err = result.LastInsertId()
 		if err != nil {
-			return 0, fmt.Errorf("添加默认属性失败: %v", err)
+			return fmt.Errorf("获取人物ID失败: %v", err)
+		}
+
+		if err := setCharacterMembership(tx, int(id), shiliID); err != nil {
+			return err
+		}
+		if err := recordBalanceAdjustment(tx, LedgerAccount{Type: LedgerAccountCharacter, ID: int(id)}, 0, property, "期初余额"); err != nil {
+			return err
+		}
+
+		// 为新人物添加默认属性
+		defaultAttributes := []struct {
+			name        string
+			description string
+			value       int
+		}{
+			{"血量", "当前生命值", 100},
+			{"蓝量", "当前魔法值", 100},
+			{"攻击", "攻击力", 100},
+			{"防御", "防御力", 100},
+		}
+
+		for _, attr := range defaultAttributes {
+			if err := addCharacterAttribute(tx, int(id), attr.name, attr.description, attr.value); err != nil {
+				return fmt.Errorf("添加默认属性失败: %v", err)
+			}
 		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return int(id), nil
 }
 
-// UpdateCharacterBasicInfo 更新人物基本信息
+// UpdateCharacterBasicInfo 更新人物基本信息。
+// 势力名称未变化时保留原有的势力关联，否则按新名称重新关联并同步势力成员关系；
+// 改名后同步更新关联到该人物的武器、道具持有者和宠物主人名称；财产变化记入账本
 func (d *Database) UpdateCharacterBasicInfo(characterID int, name, shili string, property, level int) error {
-	query := `
-	UPDATE renwu
-	SET name = ?, shili = ?, property = ?, level = ?, updated_at = CURRENT_TIMESTAMP
-	WHERE id = ?`
+	return d.WithTx(func(tx *sql.Tx) error {
+		var oldProperty int
+		err := tx.QueryRow(`SELECT COALESCE(property, 0) FROM renwu WHERE id = ?`, characterID).Scan(&oldProperty)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("人物不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询人物信息失败: %v", err)
+		}
 
-	result, err := d.db.Exec(query, name, shili, property, level, characterID)
-	if err != nil {
-		return fmt.Errorf("更新人物信息失败: %v", err)
-	}
+		shiliID, err := resolveReferenceID(tx, "shili", shili)
+		if err != nil {
+			return fmt.Errorf("查询势力失败: %v", err)
+		}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("人物不存在")
-	}
+		query := `
+		UPDATE renwu
+		SET name = ?, shili_id = CASE WHEN shili = ? AND shili_id IS NOT NULL THEN shili_id ELSE ? END, shili = ?,
+			property = ?, level = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`
 
-	return nil
+		result, err := tx.Exec(query, name, shili, shiliID, shili, property, level, characterID)
+		if err != nil {
+			return fmt.Errorf("更新人物信息失败: %v", err)
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return fmt.Errorf("人物不存在")
+		}
+		if err := recordBalanceAdjustment(tx, LedgerAccount{Type: LedgerAccountCharacter, ID: characterID}, oldProperty, property, "余额调整"); err != nil {
+			return err
+		}
+
+		// 所属势力变化时同步成员关系
+		var linkedShili sql.NullInt64
+		if err := tx.QueryRow(`SELECT shili_id FROM renwu WHERE id = ?`, characterID).Scan(&linkedShili); err != nil {
+			return fmt.Errorf("查询人物所属势力失败: %v", err)
+		}
+		var membership interface{}
+		if linkedShili.Valid {
+			membership = linkedShili.Int64
+		}
+		if err := setCharacterMembership(tx, characterID, membership); err != nil {
+			return err
+		}
+
+		return syncCharacterReferenceNames(tx, characterID, name)
+	})
 }
 
-// DeleteCharacter 删除人物
-func (d *Database) DeleteCharacter(characterID int) error {
-	query := `DELETE FROM renwu WHERE id = ?`
+// DeleteCharacter 删除人物，名下的背包按 disposal 保留为无主背包、转交给其他人物或一并删除
+func (d *Database) DeleteCharacter(characterID int, disposal CharacterBeibaoDisposal) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		if err := disposeCharacterBeibao(tx, characterID, disposal); err != nil {
+			return err
+		}
 
-	result, err := d.db.Exec(query, characterID)
-	if err != nil {
-		return fmt.Errorf("删除人物失败: %v", err)
-	}
+		result, err := tx.Exec(`DELETE FROM renwu WHERE id = ?`, characterID)
+		if err != nil {
+			return fmt.Errorf("删除人物失败: %v", err)
+		}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("人物不存在")
-	}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return fmt.Errorf("人物不存在")
+		}
 
-	return nil
+		return nil
+	})
 }
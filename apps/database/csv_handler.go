@@ -0,0 +1,639 @@
+// 实体表的 CSV 导入/导出，便于在电子表格中批量维护怪物、道具、武器、商品和奖品
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// csvAttributePrefix 自定义属性列的表头前缀，例如 "attr:攻击"
+const csvAttributePrefix = "attr:"
+
+// csvListSeparator 技能/功能列中多个名称之间的分隔符
+const csvListSeparator = "|"
+
+// utf8BOM 写入 CSV 时附加的 BOM，使 Excel 能正确识别中文
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// csvColumnKind CSV 列的数据类型
+type csvColumnKind int
+
+const (
+	csvText csvColumnKind = iota
+	csvInteger
+	csvReal
+)
+
+// csvColumn 实体表的基础列
+type csvColumn struct {
+	name     string
+	kind     csvColumnKind
+	required bool
+}
+
+// csvEntity 支持 CSV 导入导出的实体定义
+type csvEntity struct {
+	table          string
+	columns        []csvColumn
+	attributeTable string                                              // 自定义属性子表，为空表示不支持
+	listTable      string                                              // 技能/功能子表，为空表示不支持
+	listColumn     string                                              // 技能/功能在 CSV 中的列名
+	parentColumn   string                                              // 子表中指向实体的列
+	reference      *entityReference                                    // 需要随文本列重新解析的关联列，为空表示没有
+	defaults       func(tx dbExecutor) (map[string]interface{}, error) // 新增记录时未提供的列的默认值
+}
+
+// csvEntities 支持 CSV 导入导出的实体
+var csvEntities = map[string]csvEntity{
+	"guaiwu": {
+		table: "guaiwu",
+		columns: []csvColumn{
+			{"name", csvText, true},
+			{"type", csvText, true},
+			{"level", csvInteger, false},
+			{"health", csvInteger, false},
+			{"attack", csvInteger, false},
+			{"defense", csvInteger, false},
+			{"rewards", csvText, false},
+		},
+		attributeTable: "guaiwu_attributes",
+		listTable:      "guaiwu_skills",
+		listColumn:     "skills",
+		parentColumn:   "guaiwu_id",
+	},
+	"daoju": {
+		table: "daoju",
+		columns: []csvColumn{
+			{"name", csvText, true},
+			{"level", csvInteger, false},
+			{"function", csvText, false},
+			{"durability", csvInteger, false},
+			{"holder", csvText, false},
+			{"price", csvInteger, false},
+			{"max_stack", csvInteger, false},
+			{"weight", csvInteger, false},
+		},
+		listTable:    "daoju_functions",
+		listColumn:   "functions",
+		parentColumn: "daoju_id",
+		reference:    &daojuHolderReference,
+	},
+	"wuqi": {
+		table: "wuqi",
+		columns: []csvColumn{
+			{"name", csvText, true},
+			{"holder", csvText, false},
+			{"level", csvInteger, false},
+		},
+		attributeTable: "wuqi_attributes",
+		listTable:      "wuqi_skills",
+		listColumn:     "skills",
+		parentColumn:   "wuqi_id",
+		reference:      &weaponHolderReference,
+	},
+	"shopping": {
+		table: "shopping",
+		columns: []csvColumn{
+			{"name", csvText, true},
+			{"value", csvInteger, true},
+			{"description", csvText, false},
+			{"condition", csvText, false},
+			{"stock", csvInteger, false},
+		},
+	},
+	"prizes": {
+		table: "prizes",
+		columns: []csvColumn{
+			{"pool_id", csvInteger, false},
+			{"name", csvText, true},
+			{"rate", csvReal, true},
+			{"description", csvText, false},
+			{"variety", csvText, false},
+			{"featured", csvInteger, false},
+			{"consolation", csvInteger, false},
+			{"quantity", csvInteger, false},
+		},
+		defaults: func(tx dbExecutor) (map[string]interface{}, error) {
+			poolID, err := defaultPrizePoolID(tx)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"pool_id": poolID}, nil
+		},
+	},
+}
+
+// CSVRowError CSV 导入时单行的错误
+type CSVRowError struct {
+	Row     int    `json:"row"` // 行号，从 1 开始，包含表头
+	Column  string `json:"column"`
+	Message string `json:"message"`
+}
+
+// CSVImportResult CSV 导入结果
+type CSVImportResult struct {
+	Inserted int           `json:"inserted"`
+	Updated  int           `json:"updated"`
+	Errors   []CSVRowError `json:"errors"`
+}
+
+// GetCSVEntityTypes 获取支持 CSV 导入导出的实体类型
+func GetCSVEntityTypes() []string {
+	types := make([]string, 0, len(csvEntities))
+	for name := range csvEntities {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// lookupCSVEntity 获取实体定义
+func lookupCSVEntity(entityType string) (csvEntity, error) {
+	entity, ok := csvEntities[entityType]
+	if !ok {
+		return csvEntity{}, fmt.Errorf("不支持的实体类型: %s", entityType)
+	}
+	return entity, nil
+}
+
+// ExportCSVToFile 将指定类型的实体导出为 CSV 文件
+func (d *Database) ExportCSVToFile(entityType, path string) error {
+	var buf bytes.Buffer
+	if err := d.ExportCSV(entityType, &buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写入CSV文件失败: %v", err)
+	}
+	return nil
+}
+
+// ImportCSVFromFile 从 CSV 文件导入指定类型的实体
+func (d *Database) ImportCSVFromFile(entityType, path string) (*CSVImportResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开CSV文件失败: %v", err)
+	}
+	defer file.Close()
+
+	return d.ImportCSV(entityType, file)
+}
+
+// ExportCSV 将指定类型的实体写为 CSV；自定义属性展开为 "attr:名称" 列，技能/功能以 "|" 分隔
+func (d *Database) ExportCSV(entityType string, w io.Writer) error {
+	entity, err := lookupCSVEntity(entityType)
+	if err != nil {
+		return err
+	}
+
+	columnNames := []string{"id"}
+	for _, column := range entity.columns {
+		columnNames = append(columnNames, column.name)
+	}
+	rows, err := selectTableRows(d.db, `SELECT `+strings.Join(columnNames, ", ")+` FROM `+entity.table+` ORDER BY id ASC`)
+	if err != nil {
+		return fmt.Errorf("查询%s数据失败: %v", entity.table, err)
+	}
+
+	// 自定义属性：父ID -> 属性名 -> 数值，属性列按首次出现的顺序排列
+	attributes := make(map[int64]map[string]int64)
+	var attributeNames []string
+	if entity.attributeTable != "" {
+		attrRows, err := selectTableRows(d.db, `SELECT `+entity.parentColumn+`, name, value FROM `+entity.attributeTable+` ORDER BY id ASC`)
+		if err != nil {
+			return fmt.Errorf("查询%s数据失败: %v", entity.attributeTable, err)
+		}
+		seen := make(map[string]bool)
+		for _, row := range attrRows {
+			parentID, _ := toInt64(row[entity.parentColumn])
+			name := fmt.Sprint(row["name"])
+			value, _ := toInt64(row["value"])
+			if attributes[parentID] == nil {
+				attributes[parentID] = make(map[string]int64)
+			}
+			attributes[parentID][name] = value
+			if !seen[name] {
+				seen[name] = true
+				attributeNames = append(attributeNames, name)
+			}
+		}
+	}
+
+	lists := make(map[int64][]string)
+	if entity.listTable != "" {
+		listRows, err := selectTableRows(d.db, `SELECT `+entity.parentColumn+`, name FROM `+entity.listTable+` ORDER BY id ASC`)
+		if err != nil {
+			return fmt.Errorf("查询%s数据失败: %v", entity.listTable, err)
+		}
+		for _, row := range listRows {
+			parentID, _ := toInt64(row[entity.parentColumn])
+			lists[parentID] = append(lists[parentID], fmt.Sprint(row["name"]))
+		}
+	}
+
+	header := append([]string{}, columnNames...)
+	if entity.listTable != "" {
+		header = append(header, entity.listColumn)
+	}
+	for _, name := range attributeNames {
+		header = append(header, csvAttributePrefix+name)
+	}
+
+	if _, err := w.Write(utf8BOM); err != nil {
+		return fmt.Errorf("写入CSV失败: %v", err)
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("写入CSV失败: %v", err)
+	}
+
+	for _, row := range rows {
+		id, _ := toInt64(row["id"])
+		record := make([]string, 0, len(header))
+		for _, name := range columnNames {
+			record = append(record, formatCSVValue(row[name]))
+		}
+		if entity.listTable != "" {
+			record = append(record, strings.Join(lists[id], csvListSeparator))
+		}
+		for _, name := range attributeNames {
+			if value, ok := attributes[id][name]; ok {
+				record = append(record, strconv.FormatInt(value, 10))
+			} else {
+				record = append(record, "")
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("写入CSV失败: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("写入CSV失败: %v", err)
+	}
+	return nil
+}
+
+// ImportCSV 从 CSV 导入指定类型的实体。
+// id 列对应已有记录时更新该记录，否则新增；每行在独立的保存点中写入，
+// 校验或写入失败的行会记录到错误报告中并跳过，不影响其他行。
+func (d *Database) ImportCSV(entityType string, r io.Reader) (*CSVImportResult, error) {
+	entity, err := lookupCSVEntity(entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV失败: %v", err)
+	}
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("CSV文件为空")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV表头失败: %v", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	if err := validateCSVHeader(entity, header); err != nil {
+		return nil, err
+	}
+
+	result := &CSVImportResult{}
+	err = d.WithTx(func(tx *sql.Tx) error {
+		for rowNumber := 2; ; rowNumber++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, CSVRowError{Row: rowNumber, Message: fmt.Sprintf("解析失败: %v", err)})
+				continue
+			}
+			if isBlankCSVRecord(record) {
+				continue
+			}
+
+			row, rowErrors := parseCSVRow(entity, header, record, rowNumber)
+			if len(rowErrors) > 0 {
+				result.Errors = append(result.Errors, rowErrors...)
+				continue
+			}
+
+			updated, err := importCSVRow(tx, entity, row)
+			if err != nil {
+				result.Errors = append(result.Errors, CSVRowError{Row: rowNumber, Message: err.Error()})
+				continue
+			}
+			if updated {
+				result.Updated++
+			} else {
+				result.Inserted++
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// csvRow 已通过校验的一行数据
+type csvRow struct {
+	id         int64
+	fields     map[string]interface{}
+	list       []string
+	hasList    bool
+	attributes map[string]int64
+}
+
+// validateCSVHeader 校验表头：必填列必须存在，未知列视为错误以避免拼写错误被静默忽略
+func validateCSVHeader(entity csvEntity, header []string) error {
+	known := map[string]bool{"id": true}
+	for _, column := range entity.columns {
+		known[column.name] = true
+	}
+	if entity.listTable != "" {
+		known[entity.listColumn] = true
+	}
+
+	present := make(map[string]bool)
+	for _, name := range header {
+		if strings.HasPrefix(name, csvAttributePrefix) {
+			if entity.attributeTable == "" {
+				return fmt.Errorf("%s 不支持自定义属性列: %s", entity.table, name)
+			}
+			continue
+		}
+		if !known[name] {
+			return fmt.Errorf("未知的列: %s", name)
+		}
+		present[name] = true
+	}
+
+	for _, column := range entity.columns {
+		if column.required && !present[column.name] {
+			return fmt.Errorf("缺少必填列: %s", column.name)
+		}
+	}
+	return nil
+}
+
+// parseCSVRow 按列类型校验并转换一行数据，返回该行的全部错误
+func parseCSVRow(entity csvEntity, header, record []string, rowNumber int) (csvRow, []CSVRowError) {
+	row := csvRow{
+		fields:     make(map[string]interface{}),
+		attributes: make(map[string]int64),
+	}
+	var rowErrors []CSVRowError
+	addError := func(column, message string) {
+		rowErrors = append(rowErrors, CSVRowError{Row: rowNumber, Column: column, Message: message})
+	}
+
+	values := make(map[string]string, len(header))
+	for i, name := range header {
+		if i < len(record) {
+			values[name] = strings.TrimSpace(record[i])
+		} else {
+			values[name] = ""
+		}
+	}
+
+	if raw := values["id"]; raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			addError("id", fmt.Sprintf("不是有效的ID: %q", raw))
+		}
+		row.id = id
+	}
+
+	for _, column := range entity.columns {
+		raw, present := values[column.name]
+		if !present {
+			continue
+		}
+		if raw == "" {
+			if column.required {
+				addError(column.name, "不能为空")
+			}
+			continue
+		}
+
+		switch column.kind {
+		case csvInteger:
+			value, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				addError(column.name, fmt.Sprintf("应为整数: %q", raw))
+				continue
+			}
+			row.fields[column.name] = value
+		case csvReal:
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				addError(column.name, fmt.Sprintf("应为数字: %q", raw))
+				continue
+			}
+			if value < 0 {
+				addError(column.name, "不能为负数")
+				continue
+			}
+			row.fields[column.name] = value
+		default:
+			row.fields[column.name] = raw
+		}
+	}
+
+	if entity.listTable != "" {
+		if raw, present := values[entity.listColumn]; present {
+			row.hasList = true
+			for _, name := range strings.Split(raw, csvListSeparator) {
+				if name = strings.TrimSpace(name); name != "" {
+					row.list = append(row.list, name)
+				}
+			}
+		}
+	}
+
+	for _, name := range header {
+		if !strings.HasPrefix(name, csvAttributePrefix) {
+			continue
+		}
+		raw := values[name]
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			addError(name, fmt.Sprintf("属性值应为整数: %q", raw))
+			continue
+		}
+		row.attributes[strings.TrimPrefix(name, csvAttributePrefix)] = value
+	}
+
+	return row, rowErrors
+}
+
+// importCSVRow 在保存点中写入一行数据，返回是否为更新已有记录
+func importCSVRow(tx *sql.Tx, entity csvEntity, row csvRow) (bool, error) {
+	if _, err := tx.Exec(`SAVEPOINT csv_row`); err != nil {
+		return false, fmt.Errorf("创建保存点失败: %v", err)
+	}
+
+	updated, err := writeCSVRow(tx, entity, row)
+	if err != nil {
+		tx.Exec(`ROLLBACK TO csv_row`)
+		tx.Exec(`RELEASE csv_row`)
+		return false, err
+	}
+
+	if _, err := tx.Exec(`RELEASE csv_row`); err != nil {
+		return false, fmt.Errorf("释放保存点失败: %v", err)
+	}
+	return updated, nil
+}
+
+// writeCSVRow 写入实体及其属性、技能/功能
+func writeCSVRow(tx *sql.Tx, entity csvEntity, row csvRow) (bool, error) {
+	var columns []string
+	for _, column := range entity.columns {
+		if _, ok := row.fields[column.name]; ok {
+			columns = append(columns, column.name)
+		}
+	}
+
+	id := row.id
+	updated := false
+	if id != 0 {
+		var existing int64
+		err := tx.QueryRow(`SELECT id FROM `+entity.table+` WHERE id = ?`, id).Scan(&existing)
+		if err != nil && err != sql.ErrNoRows {
+			return false, fmt.Errorf("查询记录失败: %v", err)
+		}
+		updated = err == nil
+	}
+
+	if updated {
+		if err := updateRow(tx, entity.table, columns, id, row.fields); err != nil {
+			return false, fmt.Errorf("更新记录失败: %v", err)
+		}
+		if _, err := tx.Exec(`UPDATE `+entity.table+` SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, id); err != nil {
+			return false, fmt.Errorf("更新记录失败: %v", err)
+		}
+	} else {
+		if entity.defaults != nil {
+			defaults, err := entity.defaults(tx)
+			if err != nil {
+				return false, err
+			}
+			for column, value := range defaults {
+				if _, ok := row.fields[column]; !ok {
+					row.fields[column] = value
+					columns = append(columns, column)
+				}
+			}
+		}
+		newID, err := insertRow(tx, entity.table, columns, row.fields, nil)
+		if err != nil {
+			return false, fmt.Errorf("新增记录失败: %v", err)
+		}
+		id = newID
+	}
+
+	if entity.reference != nil {
+		if err := relinkEntityReference(tx, *entity.reference, id); err != nil {
+			return false, err
+		}
+	}
+
+	for name, value := range row.attributes {
+		result, err := tx.Exec(`UPDATE `+entity.attributeTable+` SET value = ?, updated_at = CURRENT_TIMESTAMP WHERE `+entity.parentColumn+` = ? AND name = ?`, value, id, name)
+		if err != nil {
+			return false, fmt.Errorf("更新属性 %s 失败: %v", name, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO `+entity.attributeTable+` (`+entity.parentColumn+`, name, value) VALUES (?, ?, ?)`, id, name, value); err != nil {
+			return false, fmt.Errorf("添加属性 %s 失败: %v", name, err)
+		}
+	}
+
+	if row.hasList {
+		if err := syncCSVList(tx, entity, id, row.list); err != nil {
+			return false, err
+		}
+	}
+
+	return updated, nil
+}
+
+// syncCSVList 使技能/功能与 CSV 中列出的名称一致：保留已有条目（及其描述），删除未列出的，新增缺少的
+func syncCSVList(tx *sql.Tx, entity csvEntity, parentID int64, names []string) error {
+	rows, err := selectTableRows(tx, `SELECT id, name FROM `+entity.listTable+` WHERE `+entity.parentColumn+` = ?`, parentID)
+	if err != nil {
+		return fmt.Errorf("查询%s失败: %v", entity.listColumn, err)
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	existing := make(map[string]bool)
+	for _, row := range rows {
+		name := fmt.Sprint(row["name"])
+		if wanted[name] && !existing[name] {
+			existing[name] = true
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM `+entity.listTable+` WHERE id = ?`, row["id"]); err != nil {
+			return fmt.Errorf("删除%s %s 失败: %v", entity.listColumn, name, err)
+		}
+	}
+
+	for _, name := range names {
+		if existing[name] {
+			continue
+		}
+		existing[name] = true
+		if _, err := tx.Exec(`INSERT INTO `+entity.listTable+` (`+entity.parentColumn+`, name) VALUES (?, ?)`, parentID, name); err != nil {
+			return fmt.Errorf("添加%s %s 失败: %v", entity.listColumn, name, err)
+		}
+	}
+	return nil
+}
+
+// formatCSVValue 将查询结果格式化为 CSV 单元格文本
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// isBlankCSVRecord 判断是否为空行
+func isBlankCSVRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
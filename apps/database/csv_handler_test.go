@@ -0,0 +1,103 @@
+package database
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCSVExportImport_GuaiwuRoundTrip(t *testing.T) {
+	source := openTestDatabase(t)
+	guaiwuID, err := source.CreateGuaiwu("妖狼", "野兽", 3, 300, 40, 20, "狼牙")
+	if err != nil {
+		t.Fatalf("CreateGuaiwu() failed: %v", err)
+	}
+	if err := source.AddGuaiwuSkill(guaiwuID, "撕咬", ""); err != nil {
+		t.Fatalf("AddGuaiwuSkill() failed: %v", err)
+	}
+	if err := source.AddGuaiwuSkill(guaiwuID, "嚎叫", ""); err != nil {
+		t.Fatalf("AddGuaiwuSkill() failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := source.ExportCSV("guaiwu", &buf); err != nil {
+		t.Fatalf("ExportCSV() failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "attr:攻击") || !strings.Contains(buf.String(), "撕咬|嚎叫") {
+		t.Fatalf("unexpected CSV output:\n%s", buf.String())
+	}
+
+	target := openTestDatabase(t)
+	result, err := target.ImportCSV("guaiwu", &buf)
+	if err != nil {
+		t.Fatalf("ImportCSV() failed: %v", err)
+	}
+	if result.Inserted != 1 || len(result.Errors) != 0 {
+		t.Fatalf("unexpected import result: %+v", result)
+	}
+
+	list, err := target.GetAllGuaiwu()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("GetAllGuaiwu() = %v, %v", list, err)
+	}
+	if list[0].Name != "妖狼" || list[0].Health != 300 || list[0].Rewards != "狼牙" {
+		t.Fatalf("unexpected guaiwu: %+v", list[0])
+	}
+	attributes, _ := target.GetGuaiwuAttributes(list[0].ID)
+	skills, _ := target.GetGuaiwuSkills(list[0].ID)
+	if len(attributes) != 2 || len(skills) != 2 {
+		t.Fatalf("children not imported: attributes=%+v skills=%+v", attributes, skills)
+	}
+}
+
+func TestCSVImport_ReportsRowErrorsAndKeepsValidRows(t *testing.T) {
+	db := openTestDatabase(t)
+	guaiwuID, err := db.CreateGuaiwu("石魔", "元素", 1, 100, 10, 10, "")
+	if err != nil {
+		t.Fatalf("CreateGuaiwu() failed: %v", err)
+	}
+
+	input := strings.Join([]string{
+		"id,name,type,level,skills,attr:攻击",
+		"1,石魔,元素,5,重击,250",
+		",火灵,,2,,",
+		",水灵,元素,abc,,",
+		",风灵,元素,4,疾风|回旋,x",
+		",雷灵,元素,6,,",
+	}, "\n")
+
+	result, err := db.ImportCSV("guaiwu", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ImportCSV() failed: %v", err)
+	}
+	if result.Updated != 1 || result.Inserted != 1 {
+		t.Fatalf("unexpected counts: %+v", result)
+	}
+	if len(result.Errors) != 3 {
+		t.Fatalf("expected 3 row errors, got %+v", result.Errors)
+	}
+	if result.Errors[0].Row != 3 || result.Errors[0].Column != "type" {
+		t.Fatalf("unexpected first error: %+v", result.Errors[0])
+	}
+
+	info, err := db.GetGuaiwuInfo(guaiwuID)
+	if err != nil {
+		t.Fatalf("GetGuaiwuInfo() failed: %v", err)
+	}
+	if info.Level != 5 {
+		t.Fatalf("expected level to be updated, got %d", info.Level)
+	}
+	attributes, _ := db.GetGuaiwuAttributes(guaiwuID)
+	for _, attribute := range attributes {
+		if attribute.Name == "攻击" && attribute.Value != 250 {
+			t.Fatalf("expected attribute to be updated, got %+v", attribute)
+		}
+	}
+
+	if _, err := db.ImportCSV("guaiwu", strings.NewReader("name,typo\n妖狼,野兽")); err == nil {
+		t.Fatalf("expected error for unknown column")
+	}
+	if _, err := db.ImportCSV("renwu", strings.NewReader("name\n林动")); err == nil {
+		t.Fatalf("expected error for unsupported entity type")
+	}
+}
@@ -19,30 +19,39 @@ type DaojuInfo struct {
 	Name      string          `json:"name"`
 	Level     int             `json:"level"`
 	Holder    string          `json:"holder"`
+	Price     int             `json:"price"`
+	MaxStack  int             `json:"max_stack"` // 放入背包时每格的堆叠上限，0 表示不限
+	Weight    int             `json:"weight"`    // 单件重量
 	Functions []DaojuFunction `json:"functions"`
 }
 
+// daojuListSpec 道具列表的筛选与排序
+var daojuListSpec = listSpec{
+	label:         "道具",
+	table:         "daoju",
+	levelColumn:   "level",
+	ownerColumn:   "holder",
+	ownerIDColumn: "holder_renwu_id",
+	sortColumns:   map[string]string{"id": "id", "name": "name", "level": "level", "holder": "holder"},
+	defaultSort:   "id",
+}
+
 // GetAllDaoju 获取所有道具列表
 func (d *Database) GetAllDaoju() ([]map[string]interface{}, error) {
-	query := `
-	SELECT id, name, level, holder
-	FROM daoju
-	ORDER BY id ASC`
-
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("查询道具列表失败: %v", err)
-	}
-	defer rows.Close()
+	daojuList, _, err := d.ListDaoju(ListQuery{})
+	return daojuList, err
+}
 
+// ListDaoju 按筛选、排序和分页条件获取道具列表
+func (d *Database) ListDaoju(q ListQuery) ([]map[string]interface{}, ListResult, error) {
 	var daojuList []map[string]interface{}
-	for rows.Next() {
+	result, err := d.queryList(daojuListSpec, q, `id, name, level, holder`, func(rows *sql.Rows) (int64, error) {
 		var id int
 		var name, holder string
 		var level int
 
 		if err := rows.Scan(&id, &name, &level, &holder); err != nil {
-			return nil, fmt.Errorf("扫描道具数据失败: %v", err)
+			return 0, fmt.Errorf("扫描道具数据失败: %v", err)
 		}
 
 		daoju := map[string]interface{}{
@@ -52,9 +61,13 @@ func (d *Database) GetAllDaoju() ([]map[string]interface{}, error) {
 			"holder": holder,
 		}
 		daojuList = append(daojuList, daoju)
+		return int64(id), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return daojuList, nil
+	return daojuList, result, nil
 }
 
 // GetDaojuInfo 获取道具详细信息（包含功能）
@@ -62,11 +75,11 @@ func (d *Database) GetDaojuInfo(daojuID int) (*DaojuInfo, error) {
 	// 查询道具基本信息
 	var info DaojuInfo
 	query := `
-	SELECT id, name, level, holder
+	SELECT id, name, level, holder, COALESCE(price, 0), max_stack, weight
 	FROM daoju
 	WHERE id = ?`
 
-	err := d.db.QueryRow(query, daojuID).Scan(&info.ID, &info.Name, &info.Level, &info.Holder)
+	err := d.db.QueryRow(query, daojuID).Scan(&info.ID, &info.Name, &info.Level, &info.Holder, &info.Price, &info.MaxStack, &info.Weight)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("道具不存在")
@@ -86,11 +99,16 @@ func (d *Database) GetDaojuInfo(daojuID int) (*DaojuInfo, error) {
 
 // CreateDaoju 创建道具
 func (d *Database) CreateDaoju(name string, level int, holder string) (int64, error) {
+	holderID, err := resolveReferenceID(d.db, "renwu", holder)
+	if err != nil {
+		return 0, fmt.Errorf("查询持有人物失败: %v", err)
+	}
+
 	query := `
-	INSERT INTO daoju (name, level, holder)
-	VALUES (?, ?, ?)`
+	INSERT INTO daoju (name, level, holder, holder_renwu_id)
+	VALUES (?, ?, ?, ?)`
 
-	result, err := d.db.Exec(query, name, level, holder)
+	result, err := d.db.Exec(query, name, level, holder, holderID)
 	if err != nil {
 		return 0, fmt.Errorf("创建道具失败: %v", err)
 	}
@@ -120,14 +138,20 @@ func (d *Database) DeleteDaoju(daojuID int) error {
 	return nil
 }
 
-// UpdateDaojuBasicInfo 更新道具基本信息（等级、所有人）
+// UpdateDaojuBasicInfo 更新道具基本信息（等级、所有人）；所有人名称变化时按新名称重新关联人物
 func (d *Database) UpdateDaojuBasicInfo(daojuID int, level int, holder string) error {
+	holderID, err := resolveReferenceID(d.db, "renwu", holder)
+	if err != nil {
+		return fmt.Errorf("查询持有人物失败: %v", err)
+	}
+
 	query := `
 	UPDATE daoju
-	SET level = ?, holder = ?, updated_at = CURRENT_TIMESTAMP
+	SET level = ?, holder_renwu_id = CASE WHEN holder = ? AND holder_renwu_id IS NOT NULL THEN holder_renwu_id ELSE ? END,
+		holder = ?, updated_at = CURRENT_TIMESTAMP
 	WHERE id = ?`
 
-	result, err := d.db.Exec(query, level, holder, daojuID)
+	result, err := d.db.Exec(query, level, holder, holderID, holder, daojuID)
 	if err != nil {
 		return fmt.Errorf("更新道具基本信息失败: %v", err)
 	}
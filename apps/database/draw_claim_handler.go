@@ -0,0 +1,85 @@
+// 领取抽奖结果：把抽中的奖品按奖品的数量和描述放入背包，同名物品叠加
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SetPrizeQuantity 设置奖品每次抽中放入背包的数量
+func (db *Database) SetPrizeQuantity(prizeID, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("数量必须大于 0")
+	}
+	result, err := db.db.Exec(`UPDATE prizes SET quantity = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, quantity, prizeID)
+	if err != nil {
+		return fmt.Errorf("更新奖品失败: %v", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("奖品不存在")
+	}
+	return nil
+}
+
+// ClaimDrawHistory 将尚未领取的抽奖记录放入背包，返回实际领取的条数；
+// 已领取的记录和“未中奖”记录会被跳过，任一记录不存在则全部不领取
+func (db *Database) ClaimDrawHistory(historyIDs []int, beibaoID int) (int, error) {
+	claimed := 0
+	err := db.WithTx(func(tx *sql.Tx) error {
+		if err := checkBeibaoExists(tx, beibaoID); err != nil {
+			return err
+		}
+
+		for _, historyID := range historyIDs {
+			var result DrawResult
+			var claimedAt sql.NullString
+			err := tx.QueryRow(`SELECT id, pool_id, COALESCE(prize_id, 0), prize_name, claimed_at FROM draw_history WHERE id = ?`, historyID).
+				Scan(&result.ID, &result.PoolID, &result.PrizeID, &result.PrizeName, &claimedAt)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("抽奖记录 %d 不存在", historyID)
+			}
+			if err != nil {
+				return fmt.Errorf("查询抽奖记录失败: %v", err)
+			}
+			if claimedAt.Valid || result.PrizeID == 0 {
+				continue
+			}
+
+			if _, err := claimDraw(tx, result, beibaoID); err != nil {
+				return err
+			}
+			claimed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return claimed, nil
+}
+
+// claimDraw 将一条抽奖记录对应的奖品放入背包并标记为已领取
+func claimDraw(tx dbExecutor, result DrawResult, beibaoID int) (DrawResult, error) {
+	var quantity int
+	var description string
+	err := tx.QueryRow(`SELECT quantity, COALESCE(description, '') FROM prizes WHERE id = ?`, result.PrizeID).Scan(&quantity, &description)
+	if err == sql.ErrNoRows {
+		return DrawResult{}, fmt.Errorf("奖品不存在")
+	}
+	if err != nil {
+		return DrawResult{}, fmt.Errorf("查询奖品失败: %v", err)
+	}
+
+	if err := depositBeibaoItem(tx, beibaoID, result.PrizeName, quantity, description, 0); err != nil {
+		return DrawResult{}, err
+	}
+
+	result.BeibaoID = beibaoID
+	result.ClaimedAt = time.Now().Format("2006-01-02 15:04:05")
+	if _, err := tx.Exec(`UPDATE draw_history SET claimed_beibao_id = ?, claimed_at = ? WHERE id = ?`, beibaoID, result.ClaimedAt, result.ID); err != nil {
+		return DrawResult{}, fmt.Errorf("更新抽奖记录失败: %v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package database
+
+import "testing"
+
+func TestDrawPrize_DepositsIntoBeibao(t *testing.T) {
+	db := openTestDatabase(t)
+	prizeID, err := db.CreatePrize(0, "灵石", 100, "上品灵石", "普通")
+	if err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if err := db.SetPrizeQuantity(int(prizeID), 5); err != nil {
+		t.Fatalf("SetPrizeQuantity() failed: %v", err)
+	}
+	beibaoID, _ := db.CreateBeibao("储物戒")
+
+	results, err := db.DrawTenPrizes(0, int(beibaoID))
+	if err != nil {
+		t.Fatalf("DrawTenPrizes() failed: %v", err)
+	}
+	if results[0].BeibaoID != int(beibaoID) || results[0].ClaimedAt == "" {
+		t.Fatalf("expected draw to be claimed into the backpack: %+v", results[0])
+	}
+	items, _ := db.GetBeibaoItems(int(beibaoID))
+	if len(items) != 1 || items[0].Quantity != 50 || items[0].Description != "上品灵石" {
+		t.Fatalf("expected one stacked item of 50, got %+v", items)
+	}
+
+	// 背包不存在时整次抽奖回滚
+	if _, err := db.DrawPrize(0, 9999); err == nil {
+		t.Fatalf("expected error for missing backpack")
+	}
+	if history, _ := db.GetDrawHistory(0, 100); len(history) != 10 {
+		t.Fatalf("expected failed draw to be rolled back, got %d records", len(history))
+	}
+}
+
+func TestClaimDrawHistory(t *testing.T) {
+	db := openTestDatabase(t)
+	if _, err := db.CreatePrize(0, "铜币", 100, "", "普通"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	beibaoID, _ := db.CreateBeibao("钱袋")
+
+	results, err := db.DrawTenPrizes(0, 0)
+	if err != nil {
+		t.Fatalf("DrawTenPrizes() failed: %v", err)
+	}
+	var ids []int
+	for _, result := range results[:3] {
+		ids = append(ids, result.ID)
+	}
+
+	if _, err := db.ClaimDrawHistory(append(ids, 9999), int(beibaoID)); err == nil {
+		t.Fatalf("expected error for missing history record")
+	}
+	claimed, err := db.ClaimDrawHistory(ids, int(beibaoID))
+	if err != nil || claimed != 3 {
+		t.Fatalf("ClaimDrawHistory() = %d, %v", claimed, err)
+	}
+	// 已领取的记录不会重复领取
+	claimed, err = db.ClaimDrawHistory(ids, int(beibaoID))
+	if err != nil || claimed != 0 {
+		t.Fatalf("expected already claimed records to be skipped, got %d, %v", claimed, err)
+	}
+
+	items, _ := db.GetBeibaoItems(int(beibaoID))
+	if len(items) != 1 || items[0].Quantity != 3 {
+		t.Fatalf("expected 3 stacked coins, got %+v", items)
+	}
+	history, _ := db.GetDrawHistory(0, 100)
+	unclaimed := 0
+	for _, record := range history {
+		if record.ClaimedAt == "" {
+			unclaimed++
+		}
+	}
+	if unclaimed != 7 {
+		t.Fatalf("expected 7 unclaimed records, got %d", unclaimed)
+	}
+}
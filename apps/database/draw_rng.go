@@ -0,0 +1,159 @@
+// 抽奖随机数：每个奖池保存一个随机种子和已使用的序号，第 n 次抽奖的随机数只由 (种子, n) 决定，
+// 抽奖历史记录种子、序号、随机值和抽奖前的保底计数，因此可以重放或核对任意一段历史
+package database
+
+import (
+	crand "crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+)
+
+// maxDrawSeed 种子上限；限制在 2^53 以内，使种子在前端 JavaScript 中也能精确表示
+const maxDrawSeed = 1<<53 - 1
+
+// DrawReplay 一次重放的结果，与抽奖历史中同一序号的记录对照
+type DrawReplay struct {
+	Sequence          int64   `json:"sequence"`
+	Roll              float64 `json:"roll"`
+	PrizeID           int     `json:"prize_id"` // 按当前奖品和保底规则重放得到的奖品
+	PrizeName         string  `json:"prize_name"`
+	HistoryID         int     `json:"history_id"` // 对应的历史记录，不存在时为 0
+	RecordedPrizeID   int     `json:"recorded_prize_id"`
+	RecordedPrizeName string  `json:"recorded_prize_name"`
+	RecordedRoll      float64 `json:"recorded_roll"`
+	Matches           bool    `json:"matches"` // 重放结果与历史记录一致
+}
+
+// SetPrizePoolSeed 设置奖池的随机种子并将序号归零；seed 为 0 时重新生成随机种子
+func (db *Database) SetPrizePoolSeed(poolID int, seed int64) error {
+	if seed < 0 || seed > maxDrawSeed {
+		return fmt.Errorf("随机种子必须在 0 到 %d 之间（0 表示重新生成）", int64(maxDrawSeed))
+	}
+	if seed == 0 {
+		seed = newDrawSeed()
+	}
+
+	result, err := db.db.Exec(`
+	UPDATE prize_pools SET rng_seed = ?, rng_sequence = 0, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?`, seed, poolID)
+	if err != nil {
+		return fmt.Errorf("设置随机种子失败: %v", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("奖池不存在")
+	}
+	return nil
+}
+
+// ReplayDraws 从某条抽奖历史开始，用记录的种子、序号和保底计数重新抽取 count 次，
+// 并与同一种子下相同序号的历史记录对照；重放使用奖池当前的奖品和保底规则，不写入数据库
+func (db *Database) ReplayDraws(historyID, count int) ([]DrawReplay, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("重放次数必须大于 0")
+	}
+
+	var poolID int
+	var seed, sequence sql.NullInt64
+	var pityState sql.NullString
+	err := db.db.QueryRow(`SELECT pool_id, seed, sequence, pity_state FROM draw_history WHERE id = ?`, historyID).
+		Scan(&poolID, &seed, &sequence, &pityState)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("抽奖记录不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询抽奖记录失败: %v", err)
+	}
+	if !seed.Valid || !sequence.Valid {
+		return nil, fmt.Errorf("该抽奖记录没有随机种子，无法重放")
+	}
+
+	pool, err := getPrizePool(db.db, poolID)
+	if err != nil {
+		return nil, err
+	}
+	prizes, err := getPoolPrizes(db.db, pool.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(prizes) == 0 {
+		return nil, fmt.Errorf("奖池中没有奖品")
+	}
+	prizes = drawCandidates(pool, prizes)
+	rules, err := getPityRules(db.db)
+	if err != nil {
+		return nil, err
+	}
+	counters, err := decodePityCounters(pityState.String)
+	if err != nil {
+		return nil, err
+	}
+
+	replays := make([]DrawReplay, 0, count)
+	for i := 0; i < count; i++ {
+		replay := DrawReplay{Sequence: sequence.Int64 + int64(i)}
+		prize, roll, err := drawWithPity(prizes, rules, counters, newDrawRand(seed.Int64, replay.Sequence))
+		if err != nil {
+			return nil, err
+		}
+		replay.Roll = roll
+		replay.PrizeID = prize.ID
+		replay.PrizeName = prize.Name
+
+		// 同一种子可能被重新设置过，取本条记录之后最早的一条
+		err = db.db.QueryRow(`
+		SELECT id, COALESCE(prize_id, 0), prize_name, roll
+		FROM draw_history
+		WHERE pool_id = ? AND seed = ? AND sequence = ? AND id >= ?
+		ORDER BY id ASC LIMIT 1`, poolID, seed.Int64, replay.Sequence, historyID).
+			Scan(&replay.HistoryID, &replay.RecordedPrizeID, &replay.RecordedPrizeName, &replay.RecordedRoll)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("查询抽奖记录失败: %v", err)
+		}
+		replay.Matches = replay.HistoryID != 0 && replay.RecordedPrizeID == replay.PrizeID && replay.RecordedRoll == replay.Roll
+		replays = append(replays, replay)
+	}
+	return replays, nil
+}
+
+// nextDrawSequence 取出奖池的种子和下一个序号，并把序号加一；奖池没有种子时先生成
+func nextDrawSequence(tx dbExecutor, poolID int) (int64, int64, error) {
+	var seed sql.NullInt64
+	var sequence int64
+	if err := tx.QueryRow(`SELECT rng_seed, rng_sequence FROM prize_pools WHERE id = ?`, poolID).Scan(&seed, &sequence); err != nil {
+		return 0, 0, fmt.Errorf("查询随机种子失败: %v", err)
+	}
+	if !seed.Valid {
+		seed = sql.NullInt64{Int64: newDrawSeed(), Valid: true}
+		sequence = 0
+	}
+
+	if _, err := tx.Exec(`UPDATE prize_pools SET rng_seed = ?, rng_sequence = ? WHERE id = ?`, seed.Int64, sequence+1, poolID); err != nil {
+		return 0, 0, fmt.Errorf("保存随机种子失败: %v", err)
+	}
+	return seed.Int64, sequence, nil
+}
+
+// newDrawRand 返回第 sequence 次抽奖使用的随机数生成器，结果只由种子和序号决定
+func newDrawRand(seed, sequence int64) *rand.Rand {
+	return rand.New(rand.NewSource(int64(splitMix64(uint64(seed) ^ splitMix64(uint64(sequence))))))
+}
+
+// newDrawSeed 生成一个新的随机种子
+func newDrawSeed() int64 {
+	var buf [8]byte
+	if _, err := crand.Read(buf[:]); err != nil {
+		return rand.Int63n(maxDrawSeed) + 1
+	}
+	return int64(binary.LittleEndian.Uint64(buf[:])%maxDrawSeed) + 1
+}
+
+// splitMix64 将相邻的输入打散为互不相关的输出
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
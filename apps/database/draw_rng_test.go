@@ -0,0 +1,86 @@
+package database
+
+import "testing"
+
+func TestDrawSeed_ReproducesAndReplaysDraws(t *testing.T) {
+	drawWithSeed := func(db *Database) []DrawResult {
+		for _, prize := range []struct {
+			name    string
+			rate    float64
+			variety string
+		}{{"铜币", 80, "普通"}, {"灵石", 19, "稀有"}, {"神兵", 1, "金"}} {
+			if _, err := db.CreatePrize(0, prize.name, prize.rate, "", prize.variety); err != nil {
+				t.Fatalf("CreatePrize() failed: %v", err)
+			}
+		}
+		if err := db.SetPityRule(PityRule{Variety: "金", HardPity: 5}); err != nil {
+			t.Fatalf("SetPityRule() failed: %v", err)
+		}
+		pool, err := db.GetPrizePool(0)
+		if err != nil {
+			t.Fatalf("GetPrizePool() failed: %v", err)
+		}
+		if err := db.SetPrizePoolSeed(pool.ID, 20240601); err != nil {
+			t.Fatalf("SetPrizePoolSeed() failed: %v", err)
+		}
+		results, err := db.DrawTenPrizes(0, 0)
+		if err != nil {
+			t.Fatalf("DrawTenPrizes() failed: %v", err)
+		}
+		return results
+	}
+
+	first := drawWithSeed(openTestDatabase(t))
+	second := drawWithSeed(openTestDatabase(t))
+	for i := range first {
+		if first[i].PrizeName != second[i].PrizeName || first[i].Roll != second[i].Roll {
+			t.Fatalf("draw %d differs with the same seed: %+v vs %+v", i, first[i], second[i])
+		}
+		if first[i].Seed != 20240601 || first[i].Sequence != int64(i) || first[i].Roll < 0 || first[i].Roll >= 1 {
+			t.Fatalf("unexpected seed record: %+v", first[i])
+		}
+	}
+
+	db := openTestDatabase(t)
+	results := drawWithSeed(db)
+	replays, err := db.ReplayDraws(results[0].ID, 10)
+	if err != nil {
+		t.Fatalf("ReplayDraws() failed: %v", err)
+	}
+	for i, replay := range replays {
+		if !replay.Matches || replay.HistoryID != results[i].ID {
+			t.Fatalf("replay %d does not match history: %+v", i, replay)
+		}
+	}
+
+	// 爆率改动后重放能发现差异
+	prizes, _ := db.GetAllPrizes()
+	for _, prize := range prizes {
+		if prize.Name == "铜币" {
+			if err := db.UpdatePrize(prize.ID, prize.Name, 0, "", prize.Variety); err != nil {
+				t.Fatalf("UpdatePrize() failed: %v", err)
+			}
+		}
+	}
+	replays, _ = db.ReplayDraws(results[0].ID, 10)
+	mismatched := 0
+	for _, replay := range replays {
+		if !replay.Matches {
+			mismatched++
+		}
+	}
+	if mismatched == 0 {
+		t.Fatalf("expected replay to differ after changing rates")
+	}
+
+	if err := db.SetPrizePoolSeed(results[0].PoolID, -1); err == nil {
+		t.Fatalf("expected error for negative seed")
+	}
+	if err := db.SetPrizePoolSeed(results[0].PoolID, 0); err != nil {
+		t.Fatalf("SetPrizePoolSeed(0) failed: %v", err)
+	}
+	pool, _ := db.GetPrizePool(results[0].PoolID)
+	if pool.Seed <= 0 || pool.Sequence != 0 {
+		t.Fatalf("expected a fresh random seed, got %+v", pool)
+	}
+}
@@ -0,0 +1,293 @@
+// 抽奖统计：蒙特卡洛模拟与抽奖历史统计，两者输出相同的指标，便于对照
+package database
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// 模拟规模上限，避免一次调用占用过长时间
+const (
+	maxSimulateDrawsPerTrial = 10000
+	maxSimulateTotalDraws    = 10000000
+)
+
+// DrawStatistics 抽奖统计结果
+type DrawStatistics struct {
+	PoolID        int                  `json:"pool_id"`
+	Trials        int                  `json:"trials"`          // 模拟轮数，历史统计为 1
+	DrawsPerTrial int                  `json:"draws_per_trial"` // 每轮抽数
+	TotalDraws    int                  `json:"total_draws"`
+	Prizes        []DrawItemStatistics `json:"prizes"`
+	Varieties     []DrawItemStatistics `json:"varieties"`
+}
+
+// DrawItemStatistics 单个奖品或品种的统计
+type DrawItemStatistics struct {
+	PrizeID        int             `json:"prize_id"` // 品种统计和“未中奖”时为 0
+	Name           string          `json:"name"`     // 奖品名称，品种统计时为品种名
+	Variety        string          `json:"variety"`
+	ConfiguredRate float64         `json:"configured_rate"` // 按配置爆率归一化后的概率（百分比，不含保底）
+	ObservedRate   float64         `json:"observed_rate"`   // 实际抽中次数占总抽数的比例（百分比，含保底）
+	Hits           int             `json:"hits"`
+	TrialHitRate   float64         `json:"trial_hit_rate"` // 至少抽中一次的轮数占比（百分比）
+	ExpectedPulls  float64         `json:"expected_pulls"` // 平均多少抽抽中一次（总抽数 / 抽中次数），从未抽中时为 0
+	P50            int             `json:"p50"`            // 抽中所需抽数的分位数，从未抽中时为 0；每轮抽数较少时长间隔会被截断
+	P90            int             `json:"p90"`
+	P99            int             `json:"p99"`
+	Distribution   []DrawPullCount `json:"distribution"` // 抽中所需抽数的分布，按抽数升序
+}
+
+// DrawPullCount 抽中所需抽数为 Pulls 的次数
+type DrawPullCount struct {
+	Pulls int `json:"pulls"`
+	Count int `json:"count"`
+}
+
+// SimulateDraws 在奖池中模拟 trials 轮、每轮 n 次抽奖（每轮保底计数从零开始），不写入抽奖历史和保底计数；
+// poolID 为 0 时使用默认奖池
+func (db *Database) SimulateDraws(poolID, n, trials int) (DrawStatistics, error) {
+	if n <= 0 || trials <= 0 {
+		return DrawStatistics{}, fmt.Errorf("抽数和模拟轮数必须大于 0")
+	}
+	if n > maxSimulateDrawsPerTrial || n*trials > maxSimulateTotalDraws {
+		return DrawStatistics{}, fmt.Errorf("模拟规模过大：每轮最多 %d 抽，总抽数最多 %d", maxSimulateDrawsPerTrial, maxSimulateTotalDraws)
+	}
+
+	pool, err := getPrizePool(db.db, poolID)
+	if err != nil {
+		return DrawStatistics{}, err
+	}
+	prizes, err := getPoolPrizes(db.db, pool.ID)
+	if err != nil {
+		return DrawStatistics{}, err
+	}
+	if len(prizes) == 0 {
+		return DrawStatistics{}, fmt.Errorf("奖池中没有奖品")
+	}
+	prizes = drawCandidates(pool, prizes)
+	rules, err := getPityRules(db.db)
+	if err != nil {
+		return DrawStatistics{}, err
+	}
+
+	collector := newDrawStatsCollector(prizes)
+	rng := rand.New(rand.NewSource(newDrawSeed()))
+	for trial := 0; trial < trials; trial++ {
+		collector.startTrial()
+		counters := make(map[string]pityCounter)
+		for i := 0; i < n; i++ {
+			prize, _, err := drawWithPity(prizes, rules, counters, rng)
+			if err != nil {
+				return DrawStatistics{}, err
+			}
+			collector.add(prize.ID, prize.Name, prize.Variety)
+		}
+	}
+	return collector.result(pool.ID, trials, n), nil
+}
+
+// GetDrawStatistics 按奖池的抽奖历史（按抽奖顺序）计算与 SimulateDraws 相同的指标；poolID 为 0 时使用默认奖池
+func (db *Database) GetDrawStatistics(poolID int) (DrawStatistics, error) {
+	pool, err := getPrizePool(db.db, poolID)
+	if err != nil {
+		return DrawStatistics{}, err
+	}
+	prizes, err := getPoolPrizes(db.db, pool.ID)
+	if err != nil {
+		return DrawStatistics{}, err
+	}
+
+	rows, err := db.db.Query(`
+	SELECT COALESCE(h.prize_id, 0), h.prize_name, COALESCE(p.variety, '')
+	FROM draw_history h
+	LEFT JOIN prizes p ON p.id = h.prize_id
+	WHERE h.pool_id = ?
+	ORDER BY h.id ASC`, pool.ID)
+	if err != nil {
+		return DrawStatistics{}, fmt.Errorf("查询抽奖历史失败: %v", err)
+	}
+	defer rows.Close()
+
+	collector := newDrawStatsCollector(drawCandidates(pool, prizes))
+	collector.startTrial()
+	for rows.Next() {
+		var prizeID int
+		var name, variety string
+		if err := rows.Scan(&prizeID, &name, &variety); err != nil {
+			return DrawStatistics{}, fmt.Errorf("扫描抽奖历史失败: %v", err)
+		}
+		if prizeID == 0 {
+			variety = NothingPrizeName
+		}
+		collector.add(prizeID, name, variety)
+	}
+	if err := rows.Err(); err != nil {
+		return DrawStatistics{}, fmt.Errorf("查询抽奖历史失败: %v", err)
+	}
+	return collector.result(pool.ID, 1, collector.totalDraws), nil
+}
+
+// drawStatsCollector 逐抽累计统计；抽中所需抽数为距上次抽中（或本轮开始）的抽数
+type drawStatsCollector struct {
+	totalDraws int
+	trialDraws int
+	prizes     map[int]*drawStatsItem
+	varieties  map[string]*drawStatsItem
+}
+
+type drawStatsItem struct {
+	stats      DrawItemStatistics
+	lastHit    int
+	hitInTrial bool
+	trialsHit  int
+	pulls      map[int]int
+}
+
+func newDrawStatsCollector(prizes []PrizeInfo) *drawStatsCollector {
+	c := &drawStatsCollector{
+		prizes:    make(map[int]*drawStatsItem),
+		varieties: make(map[string]*drawStatsItem),
+	}
+	total := 0.0
+	for _, prize := range prizes {
+		total += prize.Rate
+	}
+	for _, prize := range prizes {
+		rate := 0.0
+		if total > 0 {
+			rate = prize.Rate / total * 100
+		} else {
+			rate = 100 / float64(len(prizes))
+		}
+		c.prizeItem(prize.ID, prize.Name, prize.Variety).stats.ConfiguredRate += rate
+		c.varietyItem(prize.Variety).stats.ConfiguredRate += rate
+	}
+	return c
+}
+
+func (c *drawStatsCollector) prizeItem(id int, name, variety string) *drawStatsItem {
+	item, ok := c.prizes[id]
+	if !ok {
+		item = &drawStatsItem{stats: DrawItemStatistics{PrizeID: id, Name: name, Variety: variety}, pulls: make(map[int]int)}
+		c.prizes[id] = item
+	}
+	return item
+}
+
+func (c *drawStatsCollector) varietyItem(variety string) *drawStatsItem {
+	item, ok := c.varieties[variety]
+	if !ok {
+		item = &drawStatsItem{stats: DrawItemStatistics{Name: variety, Variety: variety}, pulls: make(map[int]int)}
+		c.varieties[variety] = item
+	}
+	return item
+}
+
+// startTrial 开始新的一轮，结算上一轮的“至少抽中一次”
+func (c *drawStatsCollector) startTrial() {
+	c.finishTrial()
+	c.trialDraws = 0
+}
+
+func (c *drawStatsCollector) finishTrial() {
+	for _, item := range c.prizes {
+		item.finishTrial()
+	}
+	for _, item := range c.varieties {
+		item.finishTrial()
+	}
+}
+
+func (item *drawStatsItem) finishTrial() {
+	if item.hitInTrial {
+		item.trialsHit++
+	}
+	item.hitInTrial = false
+	item.lastHit = 0
+}
+
+func (c *drawStatsCollector) add(prizeID int, name, variety string) {
+	c.totalDraws++
+	c.trialDraws++
+	for _, item := range []*drawStatsItem{c.prizeItem(prizeID, name, variety), c.varietyItem(variety)} {
+		item.stats.Hits++
+		item.pulls[c.trialDraws-item.lastHit]++
+		item.lastHit = c.trialDraws
+		item.hitInTrial = true
+	}
+}
+
+func (c *drawStatsCollector) result(poolID, trials, drawsPerTrial int) DrawStatistics {
+	c.finishTrial()
+	stats := DrawStatistics{
+		PoolID:        poolID,
+		Trials:        trials,
+		DrawsPerTrial: drawsPerTrial,
+		TotalDraws:    c.totalDraws,
+		Prizes:        make([]DrawItemStatistics, 0, len(c.prizes)),
+		Varieties:     make([]DrawItemStatistics, 0, len(c.varieties)),
+	}
+	for _, item := range c.prizes {
+		stats.Prizes = append(stats.Prizes, item.finish(c.totalDraws, trials))
+	}
+	for _, item := range c.varieties {
+		stats.Varieties = append(stats.Varieties, item.finish(c.totalDraws, trials))
+	}
+	for _, list := range [][]DrawItemStatistics{stats.Prizes, stats.Varieties} {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].ConfiguredRate != list[j].ConfiguredRate {
+				return list[i].ConfiguredRate > list[j].ConfiguredRate
+			}
+			if list[i].PrizeID != list[j].PrizeID {
+				return list[i].PrizeID < list[j].PrizeID
+			}
+			return list[i].Name < list[j].Name
+		})
+	}
+	return stats
+}
+
+// finish 根据抽中所需抽数的分布计算平均值和分位数
+func (item *drawStatsItem) finish(totalDraws, trials int) DrawItemStatistics {
+	stats := item.stats
+	if totalDraws > 0 {
+		stats.ObservedRate = float64(stats.Hits) / float64(totalDraws) * 100
+	}
+	if trials > 0 {
+		stats.TrialHitRate = float64(item.trialsHit) / float64(trials) * 100
+	}
+
+	stats.Distribution = make([]DrawPullCount, 0, len(item.pulls))
+	for pulls, count := range item.pulls {
+		stats.Distribution = append(stats.Distribution, DrawPullCount{Pulls: pulls, Count: count})
+	}
+	sort.Slice(stats.Distribution, func(i, j int) bool { return stats.Distribution[i].Pulls < stats.Distribution[j].Pulls })
+	if stats.Hits == 0 {
+		return stats
+	}
+
+	stats.ExpectedPulls = float64(totalDraws) / float64(stats.Hits)
+	stats.P50 = pullPercentile(stats.Distribution, stats.Hits, 0.5)
+	stats.P90 = pullPercentile(stats.Distribution, stats.Hits, 0.9)
+	stats.P99 = pullPercentile(stats.Distribution, stats.Hits, 0.99)
+	return stats
+}
+
+// pullPercentile 返回分布中第 p 分位的抽数（最近秩法）
+func pullPercentile(distribution []DrawPullCount, total int, p float64) int {
+	rank := int(math.Ceil(p * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	seen := 0
+	for _, bucket := range distribution {
+		seen += bucket.Count
+		if seen >= rank {
+			return bucket.Pulls
+		}
+	}
+	return distribution[len(distribution)-1].Pulls
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimulateDraws_MatchesConfiguredRatesAndPity(t *testing.T) {
+	db := openTestDatabase(t)
+	if _, err := db.CreatePrize(0, "铜币", 90, "", "普通"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if _, err := db.CreatePrize(0, "神兵", 10, "", "金"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+
+	stats, err := db.SimulateDraws(0, 50, 2000)
+	if err != nil {
+		t.Fatalf("SimulateDraws() failed: %v", err)
+	}
+	if stats.TotalDraws != 100000 || len(stats.Prizes) != 2 || len(stats.Varieties) != 2 {
+		t.Fatalf("unexpected statistics shape: %+v", stats)
+	}
+	gold := stats.Prizes[1]
+	if gold.Name != "神兵" || gold.ConfiguredRate != 10 {
+		t.Fatalf("unexpected prize order: %+v", stats.Prizes)
+	}
+	if math.Abs(gold.ObservedRate-10) > 1 || math.Abs(gold.ExpectedPulls-10) > 1.5 {
+		t.Fatalf("observed %.2f%% / %.2f pulls, want about 10%% / 10 pulls", gold.ObservedRate, gold.ExpectedPulls)
+	}
+	if gold.P50 > gold.P90 || gold.P90 > gold.P99 || gold.TrialHitRate < 99 {
+		t.Fatalf("unexpected percentiles: %+v", gold)
+	}
+	if history, _ := db.GetDrawHistory(0, 10); len(history) != 0 {
+		t.Fatalf("simulation must not write draw history")
+	}
+
+	// 硬保底限制最多抽数
+	if err := db.SetPityRule(PityRule{Variety: "金", HardPity: 5}); err != nil {
+		t.Fatalf("SetPityRule() failed: %v", err)
+	}
+	stats, err = db.SimulateDraws(0, 20, 200)
+	if err != nil {
+		t.Fatalf("SimulateDraws() failed: %v", err)
+	}
+	for _, variety := range stats.Varieties {
+		if variety.Name == "金" && (variety.P99 > 5 || variety.Distribution[len(variety.Distribution)-1].Pulls > 5) {
+			t.Fatalf("hard pity should cap pulls at 5: %+v", variety)
+		}
+	}
+
+	if _, err := db.SimulateDraws(0, 0, 10); err == nil {
+		t.Fatalf("expected error for zero draws")
+	}
+}
+
+func TestGetDrawStatistics_FromHistory(t *testing.T) {
+	db := openTestDatabase(t)
+	commonID, _ := db.CreatePrize(0, "铜币", 1e9, "", "普通")
+	goldID, _ := db.CreatePrize(0, "神兵", 0, "", "金")
+	pool, _ := db.GetPrizePool(0)
+
+	// 按“铜币 铜币 神兵 铜币 神兵”的顺序写入历史
+	for _, id := range []int64{commonID, commonID, goldID, commonID, goldID} {
+		if _, err := db.GetDB().Exec(`INSERT INTO draw_history (pool_id, prize_id, prize_name) SELECT pool_id, id, name FROM prizes WHERE id = ?`, id); err != nil {
+			t.Fatalf("insert history failed: %v", err)
+		}
+	}
+
+	stats, err := db.GetDrawStatistics(pool.ID)
+	if err != nil {
+		t.Fatalf("GetDrawStatistics() failed: %v", err)
+	}
+	if stats.TotalDraws != 5 || stats.Trials != 1 {
+		t.Fatalf("unexpected totals: %+v", stats)
+	}
+	var gold DrawItemStatistics
+	for _, prize := range stats.Prizes {
+		if prize.Name == "神兵" {
+			gold = prize
+		}
+	}
+	if gold.Hits != 2 || gold.ObservedRate != 40 || gold.ExpectedPulls != 2.5 || gold.P50 != 2 || gold.P99 != 3 {
+		t.Fatalf("unexpected gold statistics: %+v", gold)
+	}
+}
@@ -1,70 +1,114 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
-	"math/rand"
 	"time"
 )
 
 // PrizeInfo 奖品信息结构体
 type PrizeInfo struct {
 	ID          int     `json:"id"`
+	PoolID      int     `json:"pool_id"` // 所属奖池
 	Name        string  `json:"name"`
 	Rate        float64 `json:"rate"` // 爆率（百分比，如 1.5 表示 1.5%）
 	Description string  `json:"description"`
-	Variety     string  `json:"variety"` // 品种
+	Variety     string  `json:"variety"`     // 品种
+	Featured    bool    `json:"featured"`    // UP 奖品，用于保底规则中的“下次必出 UP”
+	Consolation bool    `json:"consolation"` // 安慰奖，绝对概率模式下剩余概率抽中该奖品
+	Quantity    int     `json:"quantity"`    // 每次抽中放入背包的数量
 }
 
 // DrawResult 抽奖结果
 type DrawResult struct {
-	PrizeID      int    `json:"prize_id"`
-	PrizeName    string `json:"prize_name"`
-	PrizeVariety string `json:"prize_variety"`
-	Description  string `json:"description"`
-	DrawnAt      string `json:"drawn_at"`
+	ID           int     `json:"id"` // 抽奖历史记录ID
+	PoolID       int     `json:"pool_id"`
+	PrizeID      int     `json:"prize_id"`
+	PrizeName    string  `json:"prize_name"`
+	PrizeVariety string  `json:"prize_variety"`
+	Description  string  `json:"description"`
+	Cost         int     `json:"cost"`       // 本次抽奖消耗，取自奖池的单抽消耗，只做记录不实际扣除
+	Seed         int64   `json:"seed"`       // 奖池随机种子，迁移前的记录为 0
+	Sequence     int64   `json:"sequence"`   // 该种子下的抽奖序号
+	Roll         float64 `json:"roll"`       // 本次抽奖的随机值，范围 [0, 1)
+	BeibaoID     int     `json:"beibao_id"`  // 奖品放入的背包，未领取或背包已删除时为 0
+	ClaimedAt    string  `json:"claimed_at"` // 领取时间，未领取时为空
+	DrawnAt      string  `json:"drawn_at"`
 }
 
-// GetAllPrizes 获取所有奖品
+// prizeListSpec 奖品列表的筛选与排序，默认按爆率从高到低
+var prizeListSpec = listSpec{
+	label:       "奖品",
+	table:       "prizes",
+	typeColumn:  "variety",
+	poolColumn:  "pool_id",
+	sortColumns: map[string]string{"id": "id", "name": "name", "rate": "rate", "variety": "variety"},
+	defaultSort: "rate",
+	defaultDesc: true,
+}
+
+// GetAllPrizes 获取所有奖池中的奖品
 func (db *Database) GetAllPrizes() ([]PrizeInfo, error) {
-	query := `SELECT id, name, rate, description, variety FROM prizes ORDER BY rate DESC`
-	rows, err := db.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	prizes, _, err := db.ListPrizes(ListQuery{})
+	return prizes, err
+}
 
+// ListPrizes 按筛选、排序和分页条件获取奖品
+func (db *Database) ListPrizes(q ListQuery) ([]PrizeInfo, ListResult, error) {
 	var prizes []PrizeInfo
-	for rows.Next() {
+	result, err := db.queryList(prizeListSpec, q, `id, pool_id, name, rate, description, variety, featured, consolation, quantity`, func(rows *sql.Rows) (int64, error) {
 		var prize PrizeInfo
-		err := rows.Scan(&prize.ID, &prize.Name, &prize.Rate, &prize.Description, &prize.Variety)
+		err := rows.Scan(&prize.ID, &prize.PoolID, &prize.Name, &prize.Rate, &prize.Description, &prize.Variety, &prize.Featured, &prize.Consolation, &prize.Quantity)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 		prizes = append(prizes, prize)
+		return int64(prize.ID), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return prizes, nil
+	return prizes, result, nil
 }
 
 // GetPrizeInfo 获取指定奖品的详细信息
 func (db *Database) GetPrizeInfo(id int) (PrizeInfo, error) {
-	query := `SELECT id, name, rate, description, variety FROM prizes WHERE id = ?`
+	query := `SELECT id, pool_id, name, rate, description, variety, featured, consolation, quantity FROM prizes WHERE id = ?`
 	var prize PrizeInfo
-	err := db.db.QueryRow(query, id).Scan(&prize.ID, &prize.Name, &prize.Rate, &prize.Description, &prize.Variety)
+	err := db.db.QueryRow(query, id).Scan(&prize.ID, &prize.PoolID, &prize.Name, &prize.Rate, &prize.Description, &prize.Variety,
+		&prize.Featured, &prize.Consolation, &prize.Quantity)
 	if err != nil {
 		return PrizeInfo{}, err
 	}
 	return prize, nil
 }
 
-// CreatePrize 创建新奖品
-func (db *Database) CreatePrize(name string, rate float64, description, prizeVariety string) (int64, error) {
-	query := `INSERT INTO prizes (name, rate, description, variety) VALUES (?, ?, ?, ?)`
-	result, err := db.db.Exec(query, name, rate, description, prizeVariety)
-	if err != nil {
+// CreatePrize 在奖池中创建新奖品，poolID 为 0 时放入默认奖池；绝对概率模式的奖池爆率合计不能超过 100%
+func (db *Database) CreatePrize(poolID int, name string, rate float64, description, prizeVariety string) (int64, error) {
+	if err := validatePrizeRate(rate); err != nil {
 		return 0, err
 	}
-	return result.LastInsertId()
+
+	var id int64
+	err := db.WithTx(func(tx *sql.Tx) error {
+		pool, err := getPrizePool(tx, poolID)
+		if err != nil {
+			return err
+		}
+		if err := checkAbsoluteRateTotal(tx, pool.ID, 0, rate); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO prizes (pool_id, name, rate, description, variety) VALUES (?, ?, ?, ?, ?)`
+		result, err := tx.Exec(query, pool.ID, name, rate, description, prizeVariety)
+		if err != nil {
+			return err
+		}
+		id, err = result.LastInsertId()
+		return err
+	})
+	return id, err
 }
 
 // DeletePrize 删除奖品
@@ -74,94 +118,183 @@ func (db *Database) DeletePrize(id int) error {
 	return err
 }
 
-// UpdatePrize 更新奖品信息
+// UpdatePrize 更新奖品信息；绝对概率模式的奖池爆率合计不能超过 100%
 func (db *Database) UpdatePrize(id int, name string, rate float64, description, prizeVariety string) error {
-	query := `UPDATE prizes SET name = ?, rate = ?, description = ?, variety = ? WHERE id = ?`
-	_, err := db.db.Exec(query, name, rate, description, prizeVariety, id)
-	return err
+	if err := validatePrizeRate(rate); err != nil {
+		return err
+	}
+
+	return db.WithTx(func(tx *sql.Tx) error {
+		var poolID int
+		err := tx.QueryRow(`SELECT pool_id FROM prizes WHERE id = ?`, id).Scan(&poolID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("奖品不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询奖品失败: %v", err)
+		}
+		if err := checkAbsoluteRateTotal(tx, poolID, id, rate); err != nil {
+			return err
+		}
+
+		query := `UPDATE prizes SET name = ?, rate = ?, description = ?, variety = ? WHERE id = ?`
+		_, err = tx.Exec(query, name, rate, description, prizeVariety, id)
+		return err
+	})
 }
 
-// DrawPrize 单抽
-func (db *Database) DrawPrize() (DrawResult, error) {
-	// 获取所有奖品
-	prizes, err := db.GetAllPrizes()
+// DrawPrize 在奖池中单抽，poolID 为 0 时使用默认奖池；beibaoID 不为 0 时奖品直接放入该背包
+func (db *Database) DrawPrize(poolID, beibaoID int) (DrawResult, error) {
+	results, err := db.drawPrizes(poolID, beibaoID, 1)
 	if err != nil {
 		return DrawResult{}, err
 	}
+	return results[0], nil
+}
 
-	if len(prizes) == 0 {
-		return DrawResult{}, fmt.Errorf("奖池中没有奖品")
+// DrawTenPrizes 在奖池中十连抽，poolID 为 0 时使用默认奖池；beibaoID 不为 0 时奖品直接放入该背包
+func (db *Database) DrawTenPrizes(poolID, beibaoID int) ([]DrawResult, error) {
+	return db.drawPrizes(poolID, beibaoID, 10)
+}
+
+// drawPrizes 连续抽取 count 次；全部结果、保底计数和放入背包的物品在同一事务中写入，任一失败则全部回滚
+func (db *Database) drawPrizes(poolID, beibaoID, count int) ([]DrawResult, error) {
+	var results []DrawResult
+	err := db.WithTx(func(tx *sql.Tx) error {
+		pool, err := getPrizePool(tx, poolID)
+		if err != nil {
+			return err
+		}
+		if beibaoID != 0 {
+			if err := checkBeibaoExists(tx, beibaoID); err != nil {
+				return err
+			}
+		}
+		if err := checkPrizePoolOpen(pool, time.Now()); err != nil {
+			return err
+		}
+
+		prizes, err := getPoolPrizes(tx, pool.ID)
+		if err != nil {
+			return err
+		}
+		if len(prizes) == 0 {
+			return fmt.Errorf("奖池中没有奖品")
+		}
+		prizes = drawCandidates(pool, prizes)
+
+		for i := 0; i < count; i++ {
+			result, err := drawAndRecord(tx, pool, prizes)
+			if err != nil {
+				return err
+			}
+			if beibaoID != 0 && result.PrizeID != 0 {
+				if result, err = claimDraw(tx, result, beibaoID); err != nil {
+					return err
+				}
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 基于爆率进行抽奖
-	prize, err := drawByRate(prizes)
+	return results, nil
+}
+
+// drawAndRecord 按保底规则抽取一次，将结果写入抽奖历史并更新保底计数
+func drawAndRecord(tx dbExecutor, pool PrizePool, prizes []PrizeInfo) (DrawResult, error) {
+	rules, err := getPityRules(tx)
+	if err != nil {
+		return DrawResult{}, err
+	}
+	counters, err := getPityCounters(tx, pool.ID)
+	if err != nil {
+		return DrawResult{}, err
+	}
+	// 保存抽奖前的保底计数，重放时从这里开始
+	pityState, err := encodePityCounters(counters)
+	if err != nil {
+		return DrawResult{}, err
+	}
+	seed, sequence, err := nextDrawSequence(tx, pool.ID)
 	if err != nil {
 		return DrawResult{}, err
 	}
 
+	// 基于爆率和保底规则进行抽奖
+	prize, roll, err := drawWithPity(prizes, rules, counters, newDrawRand(seed, sequence))
+	if err != nil {
+		return DrawResult{}, err
+	}
+	if err := savePityCounters(tx, pool.ID, rules, counters); err != nil {
+		return DrawResult{}, err
+	}
+
 	// 记录抽奖结果
 	result := DrawResult{
+		PoolID:       pool.ID,
 		PrizeID:      prize.ID,
 		PrizeName:    prize.Name,
 		PrizeVariety: prize.Variety,
 		Description:  prize.Description,
+		Cost:         pool.DrawCost,
+		Seed:         seed,
+		Sequence:     sequence,
+		Roll:         roll,
 		DrawnAt:      time.Now().Format("2006-01-02 15:04:05"),
 	}
 
 	// 将抽奖结果保存到数据库
-	insertQuery := `INSERT INTO draw_history (prize_id, prize_name, drawn_at) VALUES (?, ?, ?)`
-	_, err = db.db.Exec(insertQuery, result.PrizeID, result.PrizeName, result.DrawnAt)
+	insertQuery := `
+	INSERT INTO draw_history (pool_id, prize_id, prize_name, cost, seed, sequence, roll, pity_state, drawn_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	// 未中奖时奖品ID为空
+	var prizeID interface{}
+	if result.PrizeID != 0 {
+		prizeID = result.PrizeID
+	}
+	res, err := tx.Exec(insertQuery, result.PoolID, prizeID, result.PrizeName, result.Cost,
+		result.Seed, result.Sequence, result.Roll, pityState, result.DrawnAt)
 	if err != nil {
 		return DrawResult{}, fmt.Errorf("保存抽奖历史失败: %v", err)
 	}
-
-	return result, nil
-}
-
-// DrawTenPrizes 十连抽
-func (db *Database) DrawTenPrizes() ([]DrawResult, error) {
-	var results []DrawResult
-
-	for i := 0; i < 10; i++ {
-		result, err := db.DrawPrize()
-		if err != nil {
-			return nil, err
-		}
-		results = append(results, result)
+	id, err := res.LastInsertId()
+	if err != nil {
+		return DrawResult{}, fmt.Errorf("保存抽奖历史失败: %v", err)
 	}
+	result.ID = int(id)
 
-	return results, nil
+	return result, nil
 }
 
-// drawByRate 根据爆率进行抽奖
-func drawByRate(prizes []PrizeInfo) (PrizeInfo, error) {
+// drawByRate 根据爆率和 [0, 1) 范围内的随机值 roll 选出奖品
+func drawByRate(prizes []PrizeInfo, roll float64) (PrizeInfo, error) {
 	if len(prizes) == 0 {
 		return PrizeInfo{}, fmt.Errorf("奖品列表为空")
 	}
 
-	// 初始化随机数生成器
-	rand.Seed(time.Now().UnixNano())
-
 	// 计算总爆率
 	totalRate := 0.0
 	for _, prize := range prizes {
 		totalRate += prize.Rate
 	}
 
-	// 如果总爆率为0，随机返回一个奖品
+	// 如果总爆率为0，等概率返回一个奖品
 	if totalRate == 0 {
-		randomIndex := rand.Intn(len(prizes))
-		return prizes[randomIndex], nil
+		return prizes[int(roll*float64(len(prizes)))%len(prizes)], nil
 	}
 
-	// 生成 0 到总爆率之间的随机数
-	randomNum := rand.Float64() * totalRate
+	// 将随机值映射到 0 到总爆率之间
+	randomNum := roll * totalRate
 
 	// 按比例计算中奖
 	accumulatedRate := 0.0
 	for _, prize := range prizes {
 		accumulatedRate += prize.Rate
-		if randomNum <= accumulatedRate {
+		if randomNum < accumulatedRate {
 			return prize, nil
 		}
 	}
@@ -170,10 +303,20 @@ func drawByRate(prizes []PrizeInfo) (PrizeInfo, error) {
 	return prizes[len(prizes)-1], nil
 }
 
-// GetDrawHistory 获取抽奖历史记录
-func (db *Database) GetDrawHistory(limit int) ([]DrawResult, error) {
-	query := `SELECT prize_id, prize_name, drawn_at FROM draw_history ORDER BY drawn_at DESC LIMIT ?`
-	rows, err := db.db.Query(query, limit)
+// GetDrawHistory 获取抽奖历史记录，poolID 为 0 时返回所有奖池的记录
+func (db *Database) GetDrawHistory(poolID, limit int) ([]DrawResult, error) {
+	query := `SELECT id, pool_id, COALESCE(prize_id, 0), prize_name, cost, COALESCE(seed, 0), COALESCE(sequence, 0), COALESCE(roll, 0),
+		COALESCE(claimed_beibao_id, 0), COALESCE(claimed_at, ''), drawn_at
+	FROM draw_history`
+	var args []interface{}
+	if poolID != 0 {
+		query += ` WHERE pool_id = ?`
+		args = append(args, poolID)
+	}
+	query += ` ORDER BY drawn_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -182,7 +325,8 @@ func (db *Database) GetDrawHistory(limit int) ([]DrawResult, error) {
 	var history []DrawResult
 	for rows.Next() {
 		var result DrawResult
-		err := rows.Scan(&result.PrizeID, &result.PrizeName, &result.DrawnAt)
+		err := rows.Scan(&result.ID, &result.PoolID, &result.PrizeID, &result.PrizeName, &result.Cost,
+			&result.Seed, &result.Sequence, &result.Roll, &result.BeibaoID, &result.ClaimedAt, &result.DrawnAt)
 		if err != nil {
 			return nil, err
 		}
@@ -192,9 +336,12 @@ func (db *Database) GetDrawHistory(limit int) ([]DrawResult, error) {
 	return history, nil
 }
 
-// ClearDrawHistory 清空抽奖历史
-func (db *Database) ClearDrawHistory() error {
-	query := `DELETE FROM draw_history`
-	_, err := db.db.Exec(query)
+// ClearDrawHistory 清空抽奖历史，poolID 为 0 时清空所有奖池的记录
+func (db *Database) ClearDrawHistory(poolID int) error {
+	if poolID == 0 {
+		_, err := db.db.Exec(`DELETE FROM draw_history`)
+		return err
+	}
+	_, err := db.db.Exec(`DELETE FROM draw_history WHERE pool_id = ?`, poolID)
 	return err
 }
@@ -41,30 +41,48 @@ type GuaiwuSkill struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// guaiwuListSpec 怪物列表的筛选与排序
+var guaiwuListSpec = listSpec{
+	label:       "怪物",
+	table:       "guaiwu",
+	levelColumn: "level",
+	typeColumn:  "type",
+	sortColumns: map[string]string{
+		"id":      "id",
+		"name":    "name",
+		"type":    "type",
+		"level":   "level",
+		"health":  "health",
+		"attack":  "attack",
+		"defense": "defense",
+	},
+	defaultSort: "id",
+}
+
 // GetAllGuaiwu 获取所有怪物
 func (d *Database) GetAllGuaiwu() ([]GuaiwuInfo, error) {
-	query := `
-	SELECT id, name, type, level, health, attack, defense, rewards, created_at, updated_at
-	FROM guaiwu
-	ORDER BY id ASC`
-
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("查询怪物列表失败: %v", err)
-	}
-	defer rows.Close()
+	guaiwuList, _, err := d.ListGuaiwu(ListQuery{})
+	return guaiwuList, err
+}
 
+// ListGuaiwu 按筛选、排序和分页条件获取怪物
+func (d *Database) ListGuaiwu(q ListQuery) ([]GuaiwuInfo, ListResult, error) {
 	var guaiwuList []GuaiwuInfo
-	for rows.Next() {
+	columns := `id, name, type, level, health, attack, defense, rewards, created_at, updated_at`
+	result, err := d.queryList(guaiwuListSpec, q, columns, func(rows *sql.Rows) (int64, error) {
 		var g GuaiwuInfo
 		err := rows.Scan(&g.ID, &g.Name, &g.Type, &g.Level, &g.Health, &g.Attack, &g.Defense, &g.Rewards, &g.CreatedAt, &g.UpdatedAt)
 		if err != nil {
-			return nil, fmt.Errorf("扫描怪物数据失败: %v", err)
+			return 0, fmt.Errorf("扫描怪物数据失败: %v", err)
 		}
 		guaiwuList = append(guaiwuList, g)
+		return int64(g.ID), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return guaiwuList, nil
+	return guaiwuList, result, nil
 }
 
 // GetGuaiwuInfo 获取怪物基本信息
@@ -142,11 +160,16 @@ func (d *Database) GetGuaiwuSkills(guaiwuID int) ([]GuaiwuSkill, error) {
 
 // AddGuaiwuAttribute 添加怪物属性
 func (d *Database) AddGuaiwuAttribute(guaiwuID int, name string, description string, value int) error {
+	return addGuaiwuAttribute(d.db, guaiwuID, name, description, value)
+}
+
+// addGuaiwuAttribute 使用指定的执行器添加怪物属性，供事务内复用
+func addGuaiwuAttribute(tx dbExecutor, guaiwuID int, name string, description string, value int) error {
 	query := `
 	INSERT INTO guaiwu_attributes (guaiwu_id, name, description, value)
 	VALUES (?, ?, ?, ?)`
 
-	_, err := d.db.Exec(query, guaiwuID, name, description, value)
+	_, err := tx.Exec(query, guaiwuID, name, description, value)
 	if err != nil {
 		return fmt.Errorf("添加怪物属性失败: %v", err)
 	}
@@ -242,26 +265,37 @@ func (d *Database) UpdateGuaiwuSkill(skillID int, name string, description strin
 	return nil
 }
 
-// CreateGuaiwu 创建怪物
+// CreateGuaiwu 创建怪物（怪物与默认属性在同一事务中写入）
 func (d *Database) CreateGuaiwu(name string, guaiwuType string, level int, health int, attack int, defense int, rewards string) (int, error) {
-	query := `
-	INSERT INTO guaiwu (name, type, level, health, attack, defense, rewards)
-	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	var guaiwuID int64
+	err := d.WithTx(func(tx *sql.Tx) error {
+		query := `
+		INSERT INTO guaiwu (name, type, level, health, attack, defense, rewards)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := d.db.Exec(query, name, guaiwuType, level, health, attack, defense, rewards)
-	if err != nil {
-		return 0, fmt.Errorf("创建怪物失败: %v", err)
-	}
+		result, err := tx.Exec(query, name, guaiwuType, level, health, attack, defense, rewards)
+		if err != nil {
+			return fmt.Errorf("创建怪物失败: %v", err)
+		}
 
-	guaiwuID, _ := result.LastInsertId()
+		guaiwuID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("获取怪物ID失败: %v", err)
+		}
 
-	// 自动添加默认属性：攻击100，防御100
-	if err := d.AddGuaiwuAttribute(int(guaiwuID), "攻击", "基础攻击力", 100); err != nil {
-		return 0, fmt.Errorf("添加默认攻击属性失败: %v", err)
-	}
+		// 自动添加默认属性：攻击100，防御100
+		if err := addGuaiwuAttribute(tx, int(guaiwuID), "攻击", "基础攻击力", 100); err != nil {
+			return fmt.Errorf("添加默认攻击属性失败: %v", err)
+		}
 
-	if err := d.AddGuaiwuAttribute(int(guaiwuID), "防御", "基础防御力", 100); err != nil {
-		return 0, fmt.Errorf("添加默认防御属性失败: %v", err)
+		if err := addGuaiwuAttribute(tx, int(guaiwuID), "防御", "基础防御力", 100); err != nil {
+			return fmt.Errorf("添加默认防御属性失败: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return int(guaiwuID), nil
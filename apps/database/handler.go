@@ -8,14 +8,23 @@ import (
 	"nooltools/apps/storage"
 	"os"
 	"path/filepath"
+	"sync"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 // Database 数据库处理器
 type Database struct {
-	db      *sql.DB
-	dataDir string
+	db       *sql.DB
+	dataDir  string
+	searchMu sync.Mutex // 串行化搜索索引的检查与重建
+}
+
+// dbExecutor 是 *sql.DB 与 *sql.Tx 的公共方法集合，使同一段 SQL 逻辑可以在事务内外复用
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 // NewDatabase 创建新的数据库实例
@@ -42,8 +51,8 @@ func NewDatabaseAtDir(dataDir string) (*Database, error) {
 	// 数据库文件路径
 	dbPath := filepath.Join(normalizedDir, storage.DatabaseFileName)
 
-	// 打开数据库连接
-	db, err := sql.Open("sqlite3", dbPath)
+	// 打开数据库连接；外键约束是连接级别的设置，通过连接参数对连接池中的每个连接启用
+	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on")
 	if err != nil {
 		return nil, fmt.Errorf("打开数据库失败: %v", err)
 	}
@@ -58,10 +67,10 @@ func NewDatabaseAtDir(dataDir string) (*Database, error) {
 		dataDir: normalizedDir,
 	}
 
-	// 初始化数据库表
-	if err := database.initTables(); err != nil {
+	// 按版本执行数据库结构迁移
+	if err := database.migrate(); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("初始化数据库表失败: %v", err)
+		return nil, fmt.Errorf("初始化数据库表失败: %w", err)
 	}
 
 	return database, nil
@@ -88,140 +97,140 @@ func (d *Database) GetDataDir() (string, error) {
 	return storage.ResolveDataDir()
 }
 
-// initTables 初始化所有数据库表
-func (d *Database) initTables() error {
+// createBaseTables 创建所有基础数据库表（迁移版本 1）
+func createBaseTables(tx dbExecutor) error {
 	// 创建人物表
-	if err := d.createRenwuTable(); err != nil {
+	if err := createRenwuTable(tx); err != nil {
 		return err
 	}
 
 	// 更新人物表结构（处理旧版本数据库）
-	if err := d.updateRenwuTableSchema(); err != nil {
+	if err := updateRenwuTableSchema(tx); err != nil {
 		return err
 	}
 
 	// 创建人物属性表
-	if err := d.createRenwuAttributesTable(); err != nil {
+	if err := createRenwuAttributesTable(tx); err != nil {
 		return err
 	}
 
 	// 创建人物技能表
-	if err := d.createRenwuSkillsTable(); err != nil {
+	if err := createRenwuSkillsTable(tx); err != nil {
 		return err
 	}
 
 	// 创建武器表
-	if err := d.createWuqiTable(); err != nil {
+	if err := createWuqiTable(tx); err != nil {
 		return err
 	}
 
 	// 创建武器属性表
-	if err := d.createWuqiAttributesTable(); err != nil {
+	if err := createWuqiAttributesTable(tx); err != nil {
 		return err
 	}
 
 	// 创建武器技能表
-	if err := d.createWuqiSkillsTable(); err != nil {
+	if err := createWuqiSkillsTable(tx); err != nil {
 		return err
 	}
 
 	// 创建任务表
-	if err := d.createShiqingTable(); err != nil {
+	if err := createShiqingTable(tx); err != nil {
 		return err
 	}
 
 	// 创建任务详情表
-	if err := d.createShiqingDetailsTable(); err != nil {
+	if err := createShiqingDetailsTable(tx); err != nil {
 		return err
 	}
 
 	// 创建帮派表
-	if err := d.createShiliTable(); err != nil {
+	if err := createShiliTable(tx); err != nil {
 		return err
 	}
 
 	// 更新势力表结构（处理旧版本数据库）
-	if err := d.updateShiliTableSchema(); err != nil {
+	if err := updateShiliTableSchema(tx); err != nil {
 		return err
 	}
 
 	// 创建势力职务表
-	if err := d.createShiliPositionsTable(); err != nil {
+	if err := createShiliPositionsTable(tx); err != nil {
 		return err
 	}
 
 	// 创建势力属性表
-	if err := d.createShiliAttributesTable(); err != nil {
+	if err := createShiliAttributesTable(tx); err != nil {
 		return err
 	}
 
 	// 创建怪物表
-	if err := d.createGuaiwuTable(); err != nil {
+	if err := createGuaiwuTable(tx); err != nil {
 		return err
 	}
 
 	// 更新怪物表结构（处理旧版本数据库）
-	if err := d.updateGuaiwuTableSchema(); err != nil {
+	if err := updateGuaiwuTableSchema(tx); err != nil {
 		return err
 	}
 
 	// 创建怪物属性表
-	if err := d.createGuaiwuAttributesTable(); err != nil {
+	if err := createGuaiwuAttributesTable(tx); err != nil {
 		return err
 	}
 
 	// 创建怪物技能表
-	if err := d.createGuaiwuSkillsTable(); err != nil {
+	if err := createGuaiwuSkillsTable(tx); err != nil {
 		return err
 	}
 
 	// 创建道具表
-	if err := d.createDaojuTable(); err != nil {
+	if err := createDaojuTable(tx); err != nil {
 		return err
 	}
 
 	// 创建宠物表
-	if err := d.createChongwuTable(); err != nil {
+	if err := createChongwuTable(tx); err != nil {
 		return err
 	}
 
 	// 创建宠物属性表
-	if err := d.createChongwuAttributesTable(); err != nil {
+	if err := createChongwuAttributesTable(tx); err != nil {
 		return err
 	}
 
 	// 创建宠物技能表
-	if err := d.createChongwuSkillsTable(); err != nil {
+	if err := createChongwuSkillsTable(tx); err != nil {
 		return err
 	}
 
 	// 创建道具功能表
-	if err := d.createDaojuFunctionsTable(); err != nil {
+	if err := createDaojuFunctionsTable(tx); err != nil {
 		return err
 	}
 
 	// 创建背包表
-	if err := d.createBeibaoTable(); err != nil {
+	if err := createBeibaoTable(tx); err != nil {
 		return err
 	}
 
 	// 创建背包物品表
-	if err := d.createBeibaoItemsTable(); err != nil {
+	if err := createBeibaoItemsTable(tx); err != nil {
 		return err
 	}
 
 	// 创建商城表
-	if err := d.createShoppingTable(); err != nil {
+	if err := createShoppingTable(tx); err != nil {
 		return err
 	}
 
 	// 创建奖池表
-	if err := d.createPrizesTable(); err != nil {
+	if err := createPrizesTable(tx); err != nil {
 		return err
 	}
 
 	// 创建抽奖历史表
-	if err := d.createDrawHistoryTable(); err != nil {
+	if err := createDrawHistoryTable(tx); err != nil {
 		return err
 	}
 
@@ -229,7 +238,7 @@ func (d *Database) initTables() error {
 }
 
 // createRenwuTable 创建人物表
-func (d *Database) createRenwuTable() error {
+func createRenwuTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS renwu (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -246,39 +255,33 @@ func (d *Database) createRenwuTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // updateRenwuTableSchema 更新人物表结构（处理旧版本数据库）
-func (d *Database) updateRenwuTableSchema() error {
+func updateRenwuTableSchema(tx dbExecutor) error {
 	// 检查并添加 shili 字段
-	if err := d.addColumnIfNotExists("renwu", "shili", "TEXT DEFAULT 'xxx'"); err != nil {
+	if err := addColumnIfNotExists(tx, "renwu", "shili", "TEXT DEFAULT 'xxx'"); err != nil {
 		return err
 	}
 
 	// 检查并添加 property 字段
-	if err := d.addColumnIfNotExists("renwu", "property", "INTEGER DEFAULT 100"); err != nil {
+	if err := addColumnIfNotExists(tx, "renwu", "property", "INTEGER DEFAULT 100"); err != nil {
 		return err
 	}
 
-	// 更新 level 默认值为 0（如果需要）
-	// 注意：SQLite 不支持直接修改列的默认值，这里只是示例
-
-	// 更新 attack 默认值为 100（如果需要）
-	// 注意：SQLite 不支持直接修改列的默认值，这里只是示例
-
-	// 更新 defense 默认值为 100（如果需要）
-	// 注意：SQLite 不支持直接修改列的默认值，这里只是示例
+	// level、attack、defense 等列的默认值修正由迁移版本 2 通过重建表完成
+	// （SQLite 的 ALTER TABLE 不支持直接修改列的默认值）
 
 	return nil
 }
 
 // addColumnIfNotExists 如果列不存在则添加列
-func (d *Database) addColumnIfNotExists(tableName, columnName, columnDef string) error {
+func addColumnIfNotExists(tx dbExecutor, tableName, columnName, columnDef string) error {
 	// 检查列是否存在
 	query := `PRAGMA table_info(` + tableName + `)`
-	rows, err := d.db.Query(query)
+	rows, err := tx.Query(query)
 	if err != nil {
 		return err
 	}
@@ -306,7 +309,7 @@ func (d *Database) addColumnIfNotExists(tableName, columnName, columnDef string)
 	// 如果列不存在，则添加列
 	if !columnExists {
 		alterQuery := `ALTER TABLE ` + tableName + ` ADD COLUMN ` + columnName + ` ` + columnDef
-		_, err := d.db.Exec(alterQuery)
+		_, err := tx.Exec(alterQuery)
 		if err != nil {
 			return err
 		}
@@ -316,7 +319,7 @@ func (d *Database) addColumnIfNotExists(tableName, columnName, columnDef string)
 }
 
 // createWuqiTable 创建武器表
-func (d *Database) createWuqiTable() error {
+func createWuqiTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS wuqi (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -327,12 +330,12 @@ func (d *Database) createWuqiTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createWuqiAttributesTable 创建武器属性表
-func (d *Database) createWuqiAttributesTable() error {
+func createWuqiAttributesTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS wuqi_attributes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -345,12 +348,12 @@ func (d *Database) createWuqiAttributesTable() error {
 		FOREIGN KEY (wuqi_id) REFERENCES wuqi(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createWuqiSkillsTable 创建武器技能表
-func (d *Database) createWuqiSkillsTable() error {
+func createWuqiSkillsTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS wuqi_skills (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -362,12 +365,12 @@ func (d *Database) createWuqiSkillsTable() error {
 		FOREIGN KEY (wuqi_id) REFERENCES wuqi(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createShiqingTable 创建任务表
-func (d *Database) createShiqingTable() error {
+func createShiqingTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS shiqing (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -382,12 +385,12 @@ func (d *Database) createShiqingTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createShiliTable 创建帮派表
-func (d *Database) createShiliTable() error {
+func createShiliTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS shili (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -401,34 +404,34 @@ func (d *Database) createShiliTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // updateShiliTableSchema 更新势力表结构（处理旧版本数据库）
-func (d *Database) updateShiliTableSchema() error {
+func updateShiliTableSchema(tx dbExecutor) error {
 	// 检查并添加 level 字段
-	if err := d.addColumnIfNotExists("shili", "level", "INTEGER DEFAULT 1"); err != nil {
+	if err := addColumnIfNotExists(tx, "shili", "level", "INTEGER DEFAULT 1"); err != nil {
 		return err
 	}
 
 	// 检查并添加 founder 字段
-	if err := d.addColumnIfNotExists("shili", "founder", "TEXT NOT NULL DEFAULT ''"); err != nil {
+	if err := addColumnIfNotExists(tx, "shili", "founder", "TEXT NOT NULL DEFAULT ''"); err != nil {
 		return err
 	}
 
 	// 检查并添加 wealth 字段
-	if err := d.addColumnIfNotExists("shili", "wealth", "INTEGER DEFAULT 0"); err != nil {
+	if err := addColumnIfNotExists(tx, "shili", "wealth", "INTEGER DEFAULT 0"); err != nil {
 		return err
 	}
 
 	// 检查并添加 member_count 字段
-	if err := d.addColumnIfNotExists("shili", "member_count", "INTEGER DEFAULT 0"); err != nil {
+	if err := addColumnIfNotExists(tx, "shili", "member_count", "INTEGER DEFAULT 0"); err != nil {
 		return err
 	}
 
 	// 检查并添加 max_members 字段
-	if err := d.addColumnIfNotExists("shili", "max_members", "INTEGER DEFAULT 10"); err != nil {
+	if err := addColumnIfNotExists(tx, "shili", "max_members", "INTEGER DEFAULT 10"); err != nil {
 		return err
 	}
 
@@ -436,7 +439,7 @@ func (d *Database) updateShiliTableSchema() error {
 }
 
 // createShiliPositionsTable 创建势力职务表
-func (d *Database) createShiliPositionsTable() error {
+func createShiliPositionsTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS shili_positions (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -449,12 +452,12 @@ func (d *Database) createShiliPositionsTable() error {
 		FOREIGN KEY (shili_id) REFERENCES shili(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createShiliAttributesTable 创建势力属性表
-func (d *Database) createShiliAttributesTable() error {
+func createShiliAttributesTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS shili_attributes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -467,12 +470,12 @@ func (d *Database) createShiliAttributesTable() error {
 		FOREIGN KEY (shili_id) REFERENCES shili(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createGuaiwuTable 创建怪物表
-func (d *Database) createGuaiwuTable() error {
+func createGuaiwuTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS guaiwu (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -487,14 +490,14 @@ func (d *Database) createGuaiwuTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // updateGuaiwuTableSchema 更新怪物表结构（处理旧版本数据库）
-func (d *Database) updateGuaiwuTableSchema() error {
+func updateGuaiwuTableSchema(tx dbExecutor) error {
 	// 检查并添加 name 字段
-	if err := d.addColumnIfNotExists("guaiwu", "name", "TEXT NOT NULL DEFAULT '未命名'"); err != nil {
+	if err := addColumnIfNotExists(tx, "guaiwu", "name", "TEXT NOT NULL DEFAULT '未命名'"); err != nil {
 		return err
 	}
 
@@ -502,7 +505,7 @@ func (d *Database) updateGuaiwuTableSchema() error {
 }
 
 // createDaojuTable 创建道具表
-func (d *Database) createDaojuTable() error {
+func createDaojuTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS daoju (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -516,12 +519,12 @@ func (d *Database) createDaojuTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createChongwuTable 创建宠物表
-func (d *Database) createChongwuTable() error {
+func createChongwuTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS chongwu (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -534,27 +537,41 @@ func (d *Database) createChongwuTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
-// CheckDatabase 检查数据库和表是否存在
-func (d *Database) CheckDatabase() (bool, error) {
+// CheckDatabase 检查数据库和表是否存在，并统计各表的孤立数据
+func (d *Database) CheckDatabase() (DatabaseCheckResult, error) {
 	// 检查数据库连接
 	if d.db == nil {
-		return false, fmt.Errorf("数据库连接为空")
+		return DatabaseCheckResult{}, fmt.Errorf("数据库连接为空")
 	}
 
 	// 检查所有表是否存在
-	tables := []string{"renwu", "renwu_attributes", "renwu_skills", "wuqi", "wuqi_attributes", "wuqi_skills", "shiqing", "shiqing_details", "shili", "shili_positions", "shili_attributes", "guaiwu", "guaiwu_attributes", "guaiwu_skills", "daoju", "chongwu", "chongwu_attributes", "chongwu_skills", "beibao", "beibao_items"}
+	tables := []string{"renwu", "renwu_attributes", "renwu_skills", "wuqi", "wuqi_attributes", "wuqi_skills", "shiqing", "shiqing_details", "shili", "shili_positions", "shili_attributes", "guaiwu", "guaiwu_attributes", "guaiwu_skills", "daoju", "chongwu", "chongwu_attributes", "chongwu_skills", "beibao", "beibao_items", "schema_migrations"}
 
 	for _, table := range tables {
 		if err := d.checkTableExists(table); err != nil {
-			return false, err
+			return DatabaseCheckResult{}, err
 		}
 	}
 
-	return true, nil
+	// 统计孤立数据
+	orphanCounts, err := d.GetOrphanCounts()
+	if err != nil {
+		return DatabaseCheckResult{}, err
+	}
+
+	result := DatabaseCheckResult{
+		OK:           true,
+		OrphanCounts: orphanCounts,
+	}
+	for _, count := range orphanCounts {
+		result.OrphanTotal += count
+	}
+
+	return result, nil
 }
 
 // checkTableExists 检查表是否存在
@@ -587,7 +604,7 @@ func GetDatabasePath() (string, error) {
 }
 
 // createRenwuAttributesTable 创建人物属性表
-func (d *Database) createRenwuAttributesTable() error {
+func createRenwuAttributesTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS renwu_attributes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -600,12 +617,12 @@ func (d *Database) createRenwuAttributesTable() error {
 		FOREIGN KEY (renwu_id) REFERENCES renwu(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createRenwuSkillsTable 创建人物技能表
-func (d *Database) createRenwuSkillsTable() error {
+func createRenwuSkillsTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS renwu_skills (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -617,12 +634,12 @@ func (d *Database) createRenwuSkillsTable() error {
 		FOREIGN KEY (renwu_id) REFERENCES renwu(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createChongwuAttributesTable 创建宠物属性表
-func (d *Database) createChongwuAttributesTable() error {
+func createChongwuAttributesTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS chongwu_attributes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -635,12 +652,12 @@ func (d *Database) createChongwuAttributesTable() error {
 		FOREIGN KEY (chongwu_id) REFERENCES chongwu(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createChongwuSkillsTable 创建宠物技能表
-func (d *Database) createChongwuSkillsTable() error {
+func createChongwuSkillsTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS chongwu_skills (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -652,12 +669,12 @@ func (d *Database) createChongwuSkillsTable() error {
 		FOREIGN KEY (chongwu_id) REFERENCES chongwu(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createDaojuFunctionsTable 创建道具功能表
-func (d *Database) createDaojuFunctionsTable() error {
+func createDaojuFunctionsTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS daoju_functions (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -669,12 +686,12 @@ func (d *Database) createDaojuFunctionsTable() error {
 		FOREIGN KEY (daoju_id) REFERENCES daoju(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createShiqingDetailsTable 创建任务详情表
-func (d *Database) createShiqingDetailsTable() error {
+func createShiqingDetailsTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS shiqing_details (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -685,12 +702,12 @@ func (d *Database) createShiqingDetailsTable() error {
 		FOREIGN KEY (shiqing_id) REFERENCES shiqing(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createGuaiwuAttributesTable 创建怪物属性表
-func (d *Database) createGuaiwuAttributesTable() error {
+func createGuaiwuAttributesTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS guaiwu_attributes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -703,12 +720,12 @@ func (d *Database) createGuaiwuAttributesTable() error {
 		FOREIGN KEY (guaiwu_id) REFERENCES guaiwu(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createGuaiwuSkillsTable 创建怪物技能表
-func (d *Database) createGuaiwuSkillsTable() error {
+func createGuaiwuSkillsTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS guaiwu_skills (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -720,12 +737,12 @@ func (d *Database) createGuaiwuSkillsTable() error {
 		FOREIGN KEY (guaiwu_id) REFERENCES guaiwu(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createBeibaoTable 创建背包表
-func (d *Database) createBeibaoTable() error {
+func createBeibaoTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS beibao (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -734,12 +751,12 @@ func (d *Database) createBeibaoTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createBeibaoItemsTable 创建背包物品表
-func (d *Database) createBeibaoItemsTable() error {
+func createBeibaoItemsTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS beibao_items (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -752,12 +769,12 @@ func (d *Database) createBeibaoItemsTable() error {
 		FOREIGN KEY (beibao_id) REFERENCES beibao(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createShoppingTable 创建商城表
-func (d *Database) createShoppingTable() error {
+func createShoppingTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS shopping (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -769,12 +786,12 @@ func (d *Database) createShoppingTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createPrizesTable 创建奖池表
-func (d *Database) createPrizesTable() error {
+func createPrizesTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS prizes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -786,12 +803,12 @@ func (d *Database) createPrizesTable() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
 // createDrawHistoryTable 创建抽奖历史表
-func (d *Database) createDrawHistoryTable() error {
+func createDrawHistoryTable(tx dbExecutor) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS draw_history (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -801,6 +818,6 @@ func (d *Database) createDrawHistoryTable() error {
 		FOREIGN KEY (prize_id) REFERENCES prizes(id) ON DELETE CASCADE
 	)`
 
-	_, err := d.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
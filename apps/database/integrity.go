@@ -0,0 +1,95 @@
+// 数据完整性检查：查找并清理外键约束启用前遗留的孤立数据
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// DatabaseCheckResult 数据库检查结果
+type DatabaseCheckResult struct {
+	OK           bool           `json:"ok"`
+	OrphanCounts map[string]int `json:"orphan_counts"` // 各表的孤立数据条数
+	OrphanTotal  int            `json:"orphan_total"`
+}
+
+// findOrphans 通过 PRAGMA foreign_key_check 查找所有父记录已不存在的子记录
+// 返回 表名 -> rowid 列表；该检查不依赖外键约束是否开启
+func findOrphans(tx dbExecutor) (map[string][]int64, error) {
+	rows, err := tx.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return nil, fmt.Errorf("检查外键约束失败: %v", err)
+	}
+	defer rows.Close()
+
+	orphans := make(map[string][]int64)
+	for rows.Next() {
+		var table, parent string
+		var rowID *int64
+		var fkID int
+
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return nil, fmt.Errorf("扫描外键检查结果失败: %v", err)
+		}
+		if rowID == nil {
+			continue
+		}
+		orphans[table] = append(orphans[table], *rowID)
+	}
+
+	return orphans, rows.Err()
+}
+
+// countOrphans 将孤立数据列表转换为每张表的条数
+func countOrphans(orphans map[string][]int64) map[string]int {
+	counts := make(map[string]int, len(orphans))
+	for table, rowIDs := range orphans {
+		counts[table] = len(rowIDs)
+	}
+	return counts
+}
+
+// purgeOrphans 删除所有孤立数据，返回每张表删除的条数
+func purgeOrphans(tx dbExecutor) (map[string]int, error) {
+	orphans, err := findOrphans(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for table, rowIDs := range orphans {
+		for _, rowID := range rowIDs {
+			if _, err := tx.Exec(`DELETE FROM `+table+` WHERE rowid = ?`, rowID); err != nil {
+				return nil, fmt.Errorf("清理表 %s 的孤立数据失败: %v", table, err)
+			}
+		}
+	}
+
+	return countOrphans(orphans), nil
+}
+
+// GetOrphanCounts 获取每张表的孤立数据条数
+func (d *Database) GetOrphanCounts() (map[string]int, error) {
+	orphans, err := findOrphans(d.db)
+	if err != nil {
+		return nil, err
+	}
+	return countOrphans(orphans), nil
+}
+
+// PurgeOrphans 清理所有孤立数据，返回每张表删除的条数；
+// 可为空的关联列（如武器持有者）只会被置空，不会删除所在记录
+func (d *Database) PurgeOrphans() (map[string]int, error) {
+	var purged map[string]int
+	err := d.WithTx(func(tx *sql.Tx) error {
+		if err := clearDanglingReferences(tx); err != nil {
+			return err
+		}
+		var err error
+		purged, err = purgeOrphans(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
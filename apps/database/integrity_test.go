@@ -0,0 +1,263 @@
+package database
+
+import (
+	"database/sql"
+	"nooltools/apps/storage"
+	"path/filepath"
+	"testing"
+)
+
+func TestForeignKeys_DeleteCascadesToChildren(t *testing.T) {
+	db := openTestDatabase(t)
+
+	characterID, err := db.CreateCharacter("测试人物", "无", 100, 1)
+	if err != nil {
+		t.Fatalf("CreateCharacter() failed: %v", err)
+	}
+	if err := db.AddCharacterSkill(characterID, "剑法", ""); err != nil {
+		t.Fatalf("AddCharacterSkill() failed: %v", err)
+	}
+
+	if err := db.DeleteCharacter(characterID, CharacterBeibaoDisposal{}); err != nil {
+		t.Fatalf("DeleteCharacter() failed: %v", err)
+	}
+
+	var remaining int
+	if err := db.GetDB().QueryRow(`SELECT COUNT(*) FROM renwu_attributes WHERE renwu_id = ?`, characterID).Scan(&remaining); err != nil {
+		t.Fatalf("failed to count attributes: %v", err)
+	}
+	if remaining != 0 {
+		t.Fatalf("expected attributes to cascade, %d left", remaining)
+	}
+	if err := db.GetDB().QueryRow(`SELECT COUNT(*) FROM renwu_skills WHERE renwu_id = ?`, characterID).Scan(&remaining); err != nil {
+		t.Fatalf("failed to count skills: %v", err)
+	}
+	if remaining != 0 {
+		t.Fatalf("expected skills to cascade, %d left", remaining)
+	}
+}
+
+func TestForeignKeys_RejectsMissingParent(t *testing.T) {
+	db := openTestDatabase(t)
+
+	if err := db.AddBeibaoItem(9999, "回血丹", 1, "", 0); err == nil {
+		t.Fatalf("expected error when adding item to missing backpack")
+	}
+}
+
+func TestCheckDatabase_ReportsAndPurgesOrphans(t *testing.T) {
+	dataDir := t.TempDir()
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() failed: %v", err)
+	}
+	db.Close()
+
+	// 不带外键参数打开，模拟旧版本程序写入的孤立数据
+	raw, err := sql.Open("sqlite3", filepath.Join(dataDir, storage.DatabaseFileName))
+	if err != nil {
+		t.Fatalf("failed to open raw database: %v", err)
+	}
+	for _, query := range []string{
+		`INSERT INTO wuqi_skills (wuqi_id, name) VALUES (404, '孤立技能')`,
+		`INSERT INTO beibao_items (beibao_id, name) VALUES (404, '孤立物品')`,
+		`INSERT INTO beibao_items (beibao_id, name) VALUES (404, '孤立物品2')`,
+	} {
+		if _, err := raw.Exec(query); err != nil {
+			t.Fatalf("failed to insert orphan: %v", err)
+		}
+	}
+	raw.Close()
+
+	db, err = NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer db.Close()
+
+	result, err := db.CheckDatabase()
+	if err != nil {
+		t.Fatalf("CheckDatabase() failed: %v", err)
+	}
+	if result.OrphanTotal != 3 || result.OrphanCounts["beibao_items"] != 2 || result.OrphanCounts["wuqi_skills"] != 1 {
+		t.Fatalf("unexpected orphan report: %+v", result)
+	}
+
+	purged, err := db.PurgeOrphans()
+	if err != nil {
+		t.Fatalf("PurgeOrphans() failed: %v", err)
+	}
+	if purged["beibao_items"] != 2 {
+		t.Fatalf("unexpected purge result: %v", purged)
+	}
+
+	result, err = db.CheckDatabase()
+	if err != nil {
+		t.Fatalf("CheckDatabase() after purge failed: %v", err)
+	}
+	if result.OrphanTotal != 0 {
+		t.Fatalf("expected no orphans after purge, got %+v", result)
+	}
+}
+
+func TestMigrate_ReportsLegacyOrphansWithoutDeleting(t *testing.T) {
+	dataDir := t.TempDir()
+	raw, err := sql.Open("sqlite3", filepath.Join(dataDir, storage.DatabaseFileName))
+	if err != nil {
+		t.Fatalf("failed to open raw database: %v", err)
+	}
+	if err := createBaseTables(raw); err != nil {
+		t.Fatalf("createBaseTables() failed: %v", err)
+	}
+	if _, err := raw.Exec(`INSERT INTO renwu_attributes (renwu_id, name) VALUES (404, '孤立属性')`); err != nil {
+		t.Fatalf("failed to insert orphan: %v", err)
+	}
+	raw.Close()
+
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() failed: %v", err)
+	}
+	defer db.Close()
+
+	// 迁移只报告孤立数据，删除需要显式调用 PurgeOrphans
+	counts, err := db.GetOrphanCounts()
+	if err != nil {
+		t.Fatalf("GetOrphanCounts() failed: %v", err)
+	}
+	if counts["renwu_attributes"] != 1 {
+		t.Fatalf("expected migration to keep legacy orphans for review, got %v", counts)
+	}
+	purged, err := db.PurgeOrphans()
+	if err != nil || purged["renwu_attributes"] != 1 {
+		t.Fatalf("PurgeOrphans() = %v, %v", purged, err)
+	}
+}
+
+func TestPurgeOrphans_ClearsSetNullReferences(t *testing.T) {
+	dataDir := t.TempDir()
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() failed: %v", err)
+	}
+	db.Close()
+
+	// 可为空的关联列指向已不存在的记录时，只应置空该列而不删除所在记录
+	raw, err := sql.Open("sqlite3", filepath.Join(dataDir, storage.DatabaseFileName))
+	if err != nil {
+		t.Fatalf("failed to open raw database: %v", err)
+	}
+	for _, query := range []string{
+		`INSERT INTO beibao (id, name, owner_renwu_id) VALUES (1, '人物背包', 404)`,
+		`INSERT INTO beibao (id, name, owner_chongwu_id) VALUES (2, '宠物背包', 404)`,
+		`INSERT INTO beibao (id, name, owner_shili_id) VALUES (3, '势力背包', 404)`,
+		`INSERT INTO beibao_items (beibao_id, name, daoju_id) VALUES (1, '回血丹', 404)`,
+		`INSERT INTO draw_history (pool_id, prize_name, claimed_beibao_id) VALUES (1, '金币', 404)`,
+		`INSERT INTO shopping_purchases (shopping_id, item_name, beibao_id, quantity, unit_price, total_price, balance_after, purchased_at)
+		VALUES (404, '回血丹', 404, 1, 10, 10, 0, '2024-01-01 00:00:00')`,
+		`INSERT INTO beibao_movements (batch, kind, beibao_id, counterpart_beibao_id, item_name, quantity, created_at)
+		VALUES (1, 'transfer', 1, 404, '回血丹', 1, '2024-01-01 00:00:00')`,
+	} {
+		if _, err := raw.Exec(query); err != nil {
+			t.Fatalf("failed to insert dangling reference: %v", err)
+		}
+	}
+	raw.Close()
+
+	db, err = NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer db.Close()
+
+	purged, err := db.PurgeOrphans()
+	if err != nil {
+		t.Fatalf("PurgeOrphans() failed: %v", err)
+	}
+	for table, count := range purged {
+		if count != 0 {
+			t.Fatalf("expected nothing purged from %s, got %v", table, purged)
+		}
+	}
+
+	for _, check := range []struct{ table, column string }{
+		{"beibao", "COALESCE(owner_renwu_id, owner_chongwu_id, owner_shili_id)"},
+		{"beibao_items", "daoju_id"},
+		{"draw_history", "claimed_beibao_id"},
+		{"shopping_purchases", "COALESCE(shopping_id, beibao_id)"},
+		{"beibao_movements", "counterpart_beibao_id"},
+	} {
+		var rows, linked int
+		if err := db.GetDB().QueryRow(`SELECT COUNT(*), COUNT(`+check.column+`) FROM `+check.table).Scan(&rows, &linked); err != nil {
+			t.Fatalf("failed to query %s: %v", check.table, err)
+		}
+		if rows == 0 || linked != 0 {
+			t.Fatalf("expected %s rows kept with references cleared, got %d rows, %d linked", check.table, rows, linked)
+		}
+	}
+
+	counts, err := db.GetOrphanCounts()
+	if err != nil {
+		t.Fatalf("GetOrphanCounts() failed: %v", err)
+	}
+	if len(counts) != 0 {
+		t.Fatalf("expected no orphans after purge, got %v", counts)
+	}
+}
+
+func TestSetNullReferences_CoverLiveSchema(t *testing.T) {
+	db := openTestDatabase(t)
+	refs, err := setNullReferences(db.GetDB())
+	if err != nil {
+		t.Fatalf("setNullReferences() failed: %v", err)
+	}
+	covered := make(map[string]string)
+	for _, ref := range refs {
+		covered[ref.table+"."+ref.column] = ref.parent
+	}
+
+	// 逐表读取外键定义，所有 ON DELETE SET NULL 的列都必须被覆盖且可为空
+	tables, err := db.GetDB().Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		t.Fatalf("failed to list tables: %v", err)
+	}
+	var names []string
+	for tables.Next() {
+		var name string
+		tables.Scan(&name)
+		names = append(names, name)
+	}
+	tables.Close()
+
+	total := 0
+	for _, table := range names {
+		rows, err := db.GetDB().Query(`SELECT "from", "table", on_delete FROM pragma_foreign_key_list(?)`, table)
+		if err != nil {
+			t.Fatalf("failed to read foreign keys of %s: %v", table, err)
+		}
+		for rows.Next() {
+			var column, parent, onDelete string
+			rows.Scan(&column, &parent, &onDelete)
+			if onDelete != "SET NULL" {
+				continue
+			}
+			total++
+			if covered[table+"."+column] != parent {
+				t.Fatalf("SET NULL column %s.%s -> %s is not covered: %v", table, column, parent, covered)
+			}
+			var notNull int
+			if err := db.GetDB().QueryRow(`SELECT "notnull" FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&notNull); err != nil || notNull != 0 {
+				t.Fatalf("SET NULL column %s.%s must be nullable (notnull=%d, %v)", table, column, notNull, err)
+			}
+		}
+		rows.Close()
+	}
+	if total != len(refs) {
+		t.Fatalf("expected %d SET NULL columns, got %d", total, len(refs))
+	}
+	for _, ref := range entityReferences {
+		if covered[ref.table+"."+ref.column] != ref.parent {
+			t.Fatalf("entity reference %s.%s is not declared ON DELETE SET NULL", ref.table, ref.column)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+// 货币账本：记录人物、势力、背包、商城与“世界”之间的每一笔转账。
+// 人物财产（renwu.property）、势力财富（shili.wealth）和背包余额（beibao.balance）随转账同步更新，
+// 商城和世界没有余额列，余额完全由账本计算；直接修改余额列造成的差额可通过对账发现并补记
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 账户类型
+const (
+	LedgerAccountWorld     = "world"     // 世界：货币的来源与去处，余额可以为负，ID 固定为 0
+	LedgerAccountCharacter = "character" // 人物财产
+	LedgerAccountFaction   = "faction"   // 势力财富
+	LedgerAccountBeibao    = "beibao"    // 背包余额
+	LedgerAccountShop      = "shop"      // 商城，ID 为商品ID，0 表示整个商城
+)
+
+// ledgerStoredBalances 有余额列的账户类型对应的表和列
+var ledgerStoredBalances = map[string]struct{ table, column string }{
+	LedgerAccountCharacter: {"renwu", "property"},
+	LedgerAccountFaction:   {"shili", "wealth"},
+	LedgerAccountBeibao:    {"beibao", "balance"},
+}
+
+// ledgerAccountTypes 对账时的账户类型顺序
+var ledgerAccountTypes = []string{LedgerAccountCharacter, LedgerAccountFaction, LedgerAccountBeibao}
+
+// LedgerAccount 账本中的账户
+type LedgerAccount struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+// LedgerEntry 一笔转账
+type LedgerEntry struct {
+	ID         int    `json:"id"`
+	FromType   string `json:"from_type"`
+	FromID     int    `json:"from_id"`
+	ToType     string `json:"to_type"`
+	ToID       int    `json:"to_id"`
+	Amount     int    `json:"amount"`
+	Memo       string `json:"memo"`
+	ReversalOf int    `json:"reversal_of"` // 冲正的原记录ID，普通转账为 0
+	ReversedBy int    `json:"reversed_by"` // 冲正本记录的记录ID，未冲正时为 0
+	CreatedAt  string `json:"created_at"`
+}
+
+// LedgerBalance 账户余额与对账结果
+type LedgerBalance struct {
+	Type    string `json:"type"`
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Balance int    `json:"balance"` // 按账本计算的余额
+	Stored  *int   `json:"stored"`  // 余额列中的值，世界和商城没有余额列时为空
+	Drift   int    `json:"drift"`   // 余额列与账本余额之差
+}
+
+// TransferCurrency 从 from 账户向 to 账户转账；除世界外账户余额不足时拒绝
+func (db *Database) TransferCurrency(from, to LedgerAccount, amount int, memo string) (LedgerEntry, error) {
+	var entry LedgerEntry
+	err := db.WithTx(func(tx *sql.Tx) error {
+		var err error
+		entry, err = postLedgerEntry(tx, from, to, amount, memo, 0)
+		return err
+	})
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+	return entry, nil
+}
+
+// ReverseLedgerEntry 冲正一笔转账：按原金额反向转账并关联原记录；每笔记录只能冲正一次，冲正记录不能再冲正
+func (db *Database) ReverseLedgerEntry(entryID int, memo string) (LedgerEntry, error) {
+	var entry LedgerEntry
+	err := db.WithTx(func(tx *sql.Tx) error {
+		original, err := getLedgerEntry(tx, entryID)
+		if err != nil {
+			return err
+		}
+		if original.ReversalOf != 0 {
+			return fmt.Errorf("冲正记录不能再冲正")
+		}
+		if original.ReversedBy != 0 {
+			return fmt.Errorf("该记录已被冲正（记录 %d）", original.ReversedBy)
+		}
+		if memo == "" {
+			memo = fmt.Sprintf("冲正记录 %d", original.ID)
+		}
+		entry, err = postLedgerEntry(tx,
+			LedgerAccount{Type: original.ToType, ID: original.ToID},
+			LedgerAccount{Type: original.FromType, ID: original.FromID},
+			original.Amount, memo, original.ID)
+		return err
+	})
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+	return entry, nil
+}
+
+// GetLedgerHistory 获取账户的转账记录，按时间倒序
+func (db *Database) GetLedgerHistory(account LedgerAccount, limit int) ([]LedgerEntry, error) {
+	rows, err := db.db.Query(ledgerEntrySelect+`
+	WHERE (e.from_type = ?1 AND e.from_id = ?2) OR (e.to_type = ?1 AND e.to_id = ?2)
+	ORDER BY e.id DESC LIMIT ?3`, account.Type, account.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询转账记录失败: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []LedgerEntry{}
+	for rows.Next() {
+		entry, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询转账记录失败: %v", err)
+	}
+	return entries, nil
+}
+
+// GetLedgerBalance 获取账户按账本计算的余额，以及与余额列的差额
+func (db *Database) GetLedgerBalance(account LedgerAccount) (LedgerBalance, error) {
+	name, err := ledgerAccountName(db.db, account)
+	if err != nil {
+		return LedgerBalance{}, err
+	}
+	balance, err := ledgerBalance(db.db, account)
+	if err != nil {
+		return LedgerBalance{}, err
+	}
+
+	result := LedgerBalance{Type: account.Type, ID: account.ID, Name: name, Balance: balance}
+	if stored, ok := ledgerStoredBalances[account.Type]; ok {
+		var value int
+		if err := db.db.QueryRow(`SELECT COALESCE(`+stored.column+`, 0) FROM `+stored.table+` WHERE id = ?`, account.ID).Scan(&value); err != nil {
+			return LedgerBalance{}, fmt.Errorf("查询余额失败: %v", err)
+		}
+		result.Stored = &value
+		result.Drift = value - balance
+	}
+	return result, nil
+}
+
+// ReconcileLedger 对比人物、势力和背包的余额列与账本余额，返回存在差额的账户；
+// fix 为 true 时以余额列为准，为每个差额补记一笔与世界之间的“对账调整”
+func (db *Database) ReconcileLedger(fix bool) ([]LedgerBalance, error) {
+	drifts := []LedgerBalance{}
+	err := db.WithTx(func(tx *sql.Tx) error {
+		for _, accountType := range ledgerAccountTypes {
+			stored := ledgerStoredBalances[accountType]
+			rows, err := tx.Query(`
+			SELECT t.id, COALESCE(t.name, ''), COALESCE(t.`+stored.column+`, 0),
+				COALESCE((SELECT SUM(amount) FROM ledger_entries WHERE to_type = ?1 AND to_id = t.id), 0)
+				- COALESCE((SELECT SUM(amount) FROM ledger_entries WHERE from_type = ?1 AND from_id = t.id), 0)
+			FROM `+stored.table+` t
+			ORDER BY t.id`, accountType)
+			if err != nil {
+				return fmt.Errorf("对账失败: %v", err)
+			}
+			var found []LedgerBalance
+			for rows.Next() {
+				b := LedgerBalance{Type: accountType}
+				var value int
+				if err := rows.Scan(&b.ID, &b.Name, &value, &b.Balance); err != nil {
+					rows.Close()
+					return fmt.Errorf("对账失败: %v", err)
+				}
+				if value != b.Balance {
+					b.Stored = &value
+					b.Drift = value - b.Balance
+					found = append(found, b)
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("对账失败: %v", err)
+			}
+			drifts = append(drifts, found...)
+		}
+
+		if !fix {
+			return nil
+		}
+		for _, b := range drifts {
+			if err := recordBalanceAdjustment(tx, LedgerAccount{Type: b.Type, ID: b.ID}, b.Balance, *b.Stored, "对账调整"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+// postLedgerEntry 在事务中记一笔转账并同步更新双方的余额列；reversalOf 为冲正的原记录ID
+func postLedgerEntry(tx dbExecutor, from, to LedgerAccount, amount int, memo string, reversalOf int) (LedgerEntry, error) {
+	if amount <= 0 {
+		return LedgerEntry{}, fmt.Errorf("转账金额必须大于 0")
+	}
+	if from == to {
+		return LedgerEntry{}, fmt.Errorf("转出和转入账户不能相同")
+	}
+	for _, account := range []LedgerAccount{from, to} {
+		if _, err := ledgerAccountName(tx, account); err != nil {
+			return LedgerEntry{}, err
+		}
+	}
+
+	if from.Type != LedgerAccountWorld {
+		balance, err := ledgerAccountBalance(tx, from)
+		if err != nil {
+			return LedgerEntry{}, err
+		}
+		if balance < amount {
+			return LedgerEntry{}, fmt.Errorf("余额不足：需要 %d，当前 %d", amount, balance)
+		}
+	}
+	if err := adjustStoredBalance(tx, from, -amount); err != nil {
+		return LedgerEntry{}, err
+	}
+	if err := adjustStoredBalance(tx, to, amount); err != nil {
+		return LedgerEntry{}, err
+	}
+	return insertLedgerEntry(tx, from, to, amount, memo, reversalOf)
+}
+
+// recordBalanceAdjustment 余额列被直接设置（从 before 改为 after）时，补记一笔与世界之间的调整，
+// 使账本与余额列保持一致
+func recordBalanceAdjustment(tx dbExecutor, account LedgerAccount, before, after int, memo string) error {
+	if before == after {
+		return nil
+	}
+	world := LedgerAccount{Type: LedgerAccountWorld}
+	from, to, amount := world, account, after-before
+	if amount < 0 {
+		from, to, amount = account, world, -amount
+	}
+	_, err := insertLedgerEntry(tx, from, to, amount, memo, 0)
+	return err
+}
+
+// insertLedgerEntry 只写入转账记录，不修改余额列
+func insertLedgerEntry(tx dbExecutor, from, to LedgerAccount, amount int, memo string, reversalOf int) (LedgerEntry, error) {
+	entry := LedgerEntry{
+		FromType:   from.Type,
+		FromID:     from.ID,
+		ToType:     to.Type,
+		ToID:       to.ID,
+		Amount:     amount,
+		Memo:       memo,
+		ReversalOf: reversalOf,
+		CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+	}
+	var reversal interface{}
+	if reversalOf != 0 {
+		reversal = reversalOf
+	}
+	result, err := tx.Exec(`
+	INSERT INTO ledger_entries (from_type, from_id, to_type, to_id, amount, memo, reversal_of, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.FromType, entry.FromID, entry.ToType, entry.ToID, entry.Amount, entry.Memo, reversal, entry.CreatedAt)
+	if err != nil {
+		return LedgerEntry{}, fmt.Errorf("保存转账记录失败: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return LedgerEntry{}, fmt.Errorf("保存转账记录失败: %v", err)
+	}
+	entry.ID = int(id)
+	return entry, nil
+}
+
+// ledgerAccountBalance 返回账户的可用余额：有余额列的账户取余额列，商城按账本计算
+func ledgerAccountBalance(q dbExecutor, account LedgerAccount) (int, error) {
+	stored, ok := ledgerStoredBalances[account.Type]
+	if !ok {
+		return ledgerBalance(q, account)
+	}
+	var balance int
+	if err := q.QueryRow(`SELECT COALESCE(`+stored.column+`, 0) FROM `+stored.table+` WHERE id = ?`, account.ID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("查询余额失败: %v", err)
+	}
+	return balance, nil
+}
+
+// adjustStoredBalance 按 delta 更新账户的余额列，没有余额列的账户不做处理
+func adjustStoredBalance(q dbExecutor, account LedgerAccount, delta int) error {
+	stored, ok := ledgerStoredBalances[account.Type]
+	if !ok {
+		return nil
+	}
+	var balance int
+	if err := q.QueryRow(`SELECT COALESCE(`+stored.column+`, 0) FROM `+stored.table+` WHERE id = ?`, account.ID).Scan(&balance); err != nil {
+		return fmt.Errorf("查询余额失败: %v", err)
+	}
+	if int64(balance)+int64(delta) > math.MaxInt32 {
+		return fmt.Errorf("余额过大")
+	}
+	_, err := q.Exec(`UPDATE `+stored.table+` SET `+stored.column+` = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, balance+delta, account.ID)
+	if err != nil {
+		return fmt.Errorf("更新余额失败: %v", err)
+	}
+	return nil
+}
+
+// ledgerBalance 按账本计算账户余额（转入合计减转出合计）
+func ledgerBalance(q dbExecutor, account LedgerAccount) (int, error) {
+	var balance int
+	err := q.QueryRow(`
+	SELECT COALESCE((SELECT SUM(amount) FROM ledger_entries WHERE to_type = ?1 AND to_id = ?2), 0)
+		- COALESCE((SELECT SUM(amount) FROM ledger_entries WHERE from_type = ?1 AND from_id = ?2), 0)`,
+		account.Type, account.ID).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("计算账本余额失败: %v", err)
+	}
+	return balance, nil
+}
+
+// ledgerAccountName 校验账户存在并返回名称
+func ledgerAccountName(q dbExecutor, account LedgerAccount) (string, error) {
+	var table, label string
+	switch account.Type {
+	case LedgerAccountWorld:
+		if account.ID != 0 {
+			return "", fmt.Errorf("世界账户的ID必须为 0")
+		}
+		return "世界", nil
+	case LedgerAccountShop:
+		if account.ID == 0 {
+			return "商城", nil
+		}
+		table, label = "shopping", "商品"
+	case LedgerAccountCharacter:
+		table, label = "renwu", "人物"
+	case LedgerAccountFaction:
+		table, label = "shili", "势力"
+	case LedgerAccountBeibao:
+		table, label = "beibao", "背包"
+	default:
+		return "", fmt.Errorf("无效的账户类型: %s", account.Type)
+	}
+
+	var name string
+	err := q.QueryRow(`SELECT COALESCE(name, '') FROM `+table+` WHERE id = ?`, account.ID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%s不存在", label)
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询%s失败: %v", label, err)
+	}
+	return name, nil
+}
+
+const ledgerEntrySelect = `
+	SELECT e.id, e.from_type, e.from_id, e.to_type, e.to_id, e.amount, COALESCE(e.memo, ''),
+		COALESCE(e.reversal_of, 0), COALESCE((SELECT r.id FROM ledger_entries r WHERE r.reversal_of = e.id), 0), e.created_at
+	FROM ledger_entries e`
+
+// getLedgerEntry 获取单笔转账记录
+func getLedgerEntry(q dbExecutor, entryID int) (LedgerEntry, error) {
+	entry, err := scanLedgerEntry(q.QueryRow(ledgerEntrySelect+` WHERE e.id = ?`, entryID))
+	if err == sql.ErrNoRows {
+		return LedgerEntry{}, fmt.Errorf("转账记录不存在")
+	}
+	return entry, err
+}
+
+func scanLedgerEntry(row interface{ Scan(...interface{}) error }) (LedgerEntry, error) {
+	var e LedgerEntry
+	err := row.Scan(&e.ID, &e.FromType, &e.FromID, &e.ToType, &e.ToID, &e.Amount, &e.Memo, &e.ReversalOf, &e.ReversedBy, &e.CreatedAt)
+	if err == sql.ErrNoRows {
+		return LedgerEntry{}, err
+	}
+	if err != nil {
+		return LedgerEntry{}, fmt.Errorf("扫描转账记录失败: %v", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import "testing"
+
+func TestLedgerTransferAndReverse(t *testing.T) {
+	db := openTestDatabase(t)
+	characterID, _ := db.CreateCharacter("林动", "", 500, 10)
+	shiliID, _ := db.CreateShili("道宗", "应欢欢", 3, 0, 50)
+	character := LedgerAccount{Type: LedgerAccountCharacter, ID: characterID}
+	faction := LedgerAccount{Type: LedgerAccountFaction, ID: int(shiliID)}
+
+	entry, err := db.TransferCurrency(character, faction, 200, "上缴宗门")
+	if err != nil {
+		t.Fatalf("TransferCurrency() failed: %v", err)
+	}
+	if _, err := db.TransferCurrency(character, faction, 301, ""); err == nil {
+		t.Fatalf("expected error for insufficient balance")
+	}
+	if _, err := db.TransferCurrency(LedgerAccount{Type: LedgerAccountCharacter, ID: 9999}, faction, 1, ""); err == nil {
+		t.Fatalf("expected error for missing account")
+	}
+
+	balance, err := db.GetLedgerBalance(character)
+	if err != nil || balance.Balance != 300 || balance.Drift != 0 {
+		t.Fatalf("GetLedgerBalance() = %+v, %v", balance, err)
+	}
+	shili, _ := db.GetShiliInfo(int(shiliID))
+	if shili.Wealth != 200 {
+		t.Fatalf("expected faction wealth 200, got %d", shili.Wealth)
+	}
+
+	reversal, err := db.ReverseLedgerEntry(entry.ID, "")
+	if err != nil {
+		t.Fatalf("ReverseLedgerEntry() failed: %v", err)
+	}
+	if reversal.ReversalOf != entry.ID || reversal.FromType != LedgerAccountFaction || reversal.Amount != 200 {
+		t.Fatalf("unexpected reversal: %+v", reversal)
+	}
+	if _, err := db.ReverseLedgerEntry(entry.ID, ""); err == nil {
+		t.Fatalf("expected error when reversing twice")
+	}
+	if _, err := db.ReverseLedgerEntry(reversal.ID, ""); err == nil {
+		t.Fatalf("expected error when reversing a reversal")
+	}
+
+	history, err := db.GetLedgerHistory(character, 10)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("GetLedgerHistory() = %+v, %v", history, err)
+	}
+	if history[1].ReversedBy != reversal.ID || history[2].Memo != "期初余额" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+}
+
+func TestReconcileLedger(t *testing.T) {
+	db := openTestDatabase(t)
+	characterID, _ := db.CreateCharacter("萧炎", "", 100, 1)
+	beibaoID, _ := db.CreateBeibao("纳戒")
+	itemID, _ := db.CreateShopping("聚气散", 30, "", "")
+	if err := db.SetBeibaoBalance(int(beibaoID), 100); err != nil {
+		t.Fatalf("SetBeibaoBalance() failed: %v", err)
+	}
+	if _, err := db.PurchaseShoppingItem(int(itemID), int(beibaoID), 2); err != nil {
+		t.Fatalf("PurchaseShoppingItem() failed: %v", err)
+	}
+	if err := db.UpdateCharacterBasicInfo(characterID, "萧炎", "", 80, 1); err != nil {
+		t.Fatalf("UpdateCharacterBasicInfo() failed: %v", err)
+	}
+
+	drifts, err := db.ReconcileLedger(false)
+	if err != nil || len(drifts) != 0 {
+		t.Fatalf("expected no drift, got %+v, %v", drifts, err)
+	}
+	shop, _ := db.GetLedgerBalance(LedgerAccount{Type: LedgerAccountShop, ID: int(itemID)})
+	if shop.Balance != 60 || shop.Stored != nil {
+		t.Fatalf("unexpected shop balance: %+v", shop)
+	}
+
+	// 绕过接口直接修改余额列
+	if _, err := db.db.Exec(`UPDATE renwu SET property = 50 WHERE id = ?`, characterID); err != nil {
+		t.Fatalf("update property failed: %v", err)
+	}
+	drifts, err = db.ReconcileLedger(true)
+	if err != nil || len(drifts) != 1 || drifts[0].Drift != -30 || drifts[0].Name != "萧炎" {
+		t.Fatalf("ReconcileLedger(true) = %+v, %v", drifts, err)
+	}
+	if drifts, _ := db.ReconcileLedger(false); len(drifts) != 0 {
+		t.Fatalf("expected drift to be fixed, got %+v", drifts)
+	}
+}
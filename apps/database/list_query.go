@@ -0,0 +1,236 @@
+// 列表查询的通用筛选、排序与分页，供各 GetAll*/List* 接口共用
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ListQuery 列表查询条件；零值表示不筛选、按默认字段排序并返回全部记录
+type ListQuery struct {
+	NamePrefix string `json:"name_prefix"` // 名称前缀
+	MinLevel   *int   `json:"min_level"`   // 等级下限（含）
+	MaxLevel   *int   `json:"max_level"`   // 等级上限（含）
+	Owner      string `json:"owner"`       // 持有者/主人名称，人物列表为所属势力名称
+	OwnerID    *int   `json:"owner_id"`    // 持有者/主人的人物ID，人物列表为所属势力ID
+	Type       string `json:"type"`        // 类型或品种
+	PoolID     *int   `json:"pool_id"`     // 所属奖池，仅奖品列表支持
+	SortBy     string `json:"sort_by"`     // 排序字段，为空时使用默认排序
+	SortDesc   bool   `json:"sort_desc"`   // 是否降序
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`  // 每页条数，0 表示不限
+	Cursor     string `json:"cursor"` // 上一页返回的 next_cursor，不能与 offset 同时使用
+}
+
+// ListResult 列表查询的分页信息
+type ListResult struct {
+	Total      int    `json:"total"`       // 符合筛选条件的记录总数（不受分页影响）
+	NextCursor string `json:"next_cursor"` // 下一页游标，已无更多记录时为空
+}
+
+// listSpec 某张表支持的筛选列与排序字段；列为空表示不支持该筛选
+type listSpec struct {
+	label         string // 用于错误信息的实体名称
+	table         string
+	levelColumn   string
+	ownerColumn   string
+	ownerIDColumn string
+	typeColumn    string
+	poolColumn    string
+	sortColumns   map[string]string // 排序字段 -> 列或表达式
+	defaultSort   string
+	defaultDesc   bool // 未指定排序字段时的默认方向
+}
+
+// listCursor 游标内容：上一页最后一条记录的排序值和ID
+type listCursor struct {
+	Value interface{} `json:"v"`
+	ID    int64       `json:"id"`
+}
+
+// queryList 按查询条件执行列表查询；scan 读取一行并返回该行ID
+func (d *Database) queryList(spec listSpec, q ListQuery, columns string, scan func(*sql.Rows) (int64, error)) (ListResult, error) {
+	var result ListResult
+
+	conditions, args, err := spec.conditions(q)
+	if err != nil {
+		return result, err
+	}
+	sortExpr, desc, err := spec.sort(q)
+	if err != nil {
+		return result, err
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return result, fmt.Errorf("分页参数不能为负数")
+	}
+	if q.Cursor != "" && q.Offset > 0 {
+		return result, fmt.Errorf("游标与偏移量不能同时使用")
+	}
+
+	where := joinListConditions(conditions)
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM `+spec.table+where, args...).Scan(&result.Total); err != nil {
+		return result, fmt.Errorf("统计%s数量失败: %v", spec.label, err)
+	}
+
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+	if q.Cursor != "" {
+		cursor, err := decodeListCursor(q.Cursor)
+		if err != nil {
+			return result, err
+		}
+		// 排序值为空的记录无论升降序都排在最后，与 ORDER BY 保持一致
+		if cursor.Value == nil {
+			conditions = append(conditions, `(`+sortExpr+` IS NULL AND id `+comparison+` ?)`)
+			args = append(args, cursor.ID)
+		} else {
+			conditions = append(conditions, `(`+sortExpr+` IS NULL OR `+sortExpr+` `+comparison+` ? OR (`+sortExpr+` = ? AND id `+comparison+` ?))`)
+			args = append(args, cursor.Value, cursor.Value, cursor.ID)
+		}
+	}
+
+	query := `SELECT ` + columns + ` FROM ` + spec.table + joinListConditions(conditions) +
+		` ORDER BY ` + sortExpr + ` IS NULL, ` + sortExpr + ` ` + direction + `, id ` + direction
+	if q.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, q.Limit, q.Offset)
+	} else if q.Offset > 0 {
+		query += ` LIMIT -1 OFFSET ?`
+		args = append(args, q.Offset)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return result, fmt.Errorf("查询%s列表失败: %v", spec.label, err)
+	}
+	defer rows.Close()
+
+	count := 0
+	var lastID int64
+	for rows.Next() {
+		id, err := scan(rows)
+		if err != nil {
+			return result, err
+		}
+		lastID = id
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("查询%s列表失败: %v", spec.label, err)
+	}
+	rows.Close()
+
+	// 取满一页时才给出下一页游标
+	if q.Limit > 0 && count == q.Limit {
+		var value interface{}
+		if err := d.db.QueryRow(`SELECT `+sortExpr+` FROM `+spec.table+` WHERE id = ?`, lastID).Scan(&value); err != nil {
+			return result, fmt.Errorf("生成分页游标失败: %v", err)
+		}
+		if raw, ok := value.([]byte); ok {
+			value = string(raw)
+		}
+		result.NextCursor, err = encodeListCursor(listCursor{Value: value, ID: lastID})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// conditions 根据查询条件生成 WHERE 子句中的各项条件
+func (spec listSpec) conditions(q ListQuery) ([]string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	if q.NamePrefix != "" {
+		conditions = append(conditions, `name LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLikePattern(q.NamePrefix)+"%")
+	}
+	if q.MinLevel != nil || q.MaxLevel != nil {
+		if spec.levelColumn == "" {
+			return nil, nil, fmt.Errorf("%s列表不支持按等级筛选", spec.label)
+		}
+		if q.MinLevel != nil {
+			conditions = append(conditions, spec.levelColumn+` >= ?`)
+			args = append(args, *q.MinLevel)
+		}
+		if q.MaxLevel != nil {
+			conditions = append(conditions, spec.levelColumn+` <= ?`)
+			args = append(args, *q.MaxLevel)
+		}
+	}
+	if q.Owner != "" {
+		if spec.ownerColumn == "" {
+			return nil, nil, fmt.Errorf("%s列表不支持按持有者筛选", spec.label)
+		}
+		conditions = append(conditions, spec.ownerColumn+` = ?`)
+		args = append(args, q.Owner)
+	}
+	if q.OwnerID != nil {
+		if spec.ownerIDColumn == "" {
+			return nil, nil, fmt.Errorf("%s列表不支持按持有者筛选", spec.label)
+		}
+		conditions = append(conditions, spec.ownerIDColumn+` = ?`)
+		args = append(args, *q.OwnerID)
+	}
+	if q.Type != "" {
+		if spec.typeColumn == "" {
+			return nil, nil, fmt.Errorf("%s列表不支持按类型筛选", spec.label)
+		}
+		conditions = append(conditions, spec.typeColumn+` = ?`)
+		args = append(args, q.Type)
+	}
+	if q.PoolID != nil {
+		if spec.poolColumn == "" {
+			return nil, nil, fmt.Errorf("%s列表不支持按奖池筛选", spec.label)
+		}
+		conditions = append(conditions, spec.poolColumn+` = ?`)
+		args = append(args, *q.PoolID)
+	}
+	return conditions, args, nil
+}
+
+// sort 返回排序表达式和方向
+func (spec listSpec) sort(q ListQuery) (string, bool, error) {
+	if q.SortBy == "" {
+		return spec.sortColumns[spec.defaultSort], spec.defaultDesc, nil
+	}
+	expr, ok := spec.sortColumns[q.SortBy]
+	if !ok {
+		return "", false, fmt.Errorf("%s列表不支持按 %s 排序", spec.label, q.SortBy)
+	}
+	return expr, q.SortDesc, nil
+}
+
+func joinListConditions(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(conditions, ` AND `)
+}
+
+func encodeListCursor(cursor listCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("生成分页游标失败: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeListCursor(value string) (listCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, errors.New("无效的分页游标")
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return cursor, errors.New("无效的分页游标")
+	}
+	return cursor, nil
+}
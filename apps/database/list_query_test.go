@@ -0,0 +1,173 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestListQuery_FiltersSortsAndPages(t *testing.T) {
+	db := openTestDatabase(t)
+
+	linDong, _ := db.CreateCharacter("林动", "", 100, 1)
+	names := []string{"青锋剑", "青龙刀", "青玉杖", "赤炎枪", "青_铜"}
+	for i, name := range names {
+		holder := ""
+		if i%2 == 0 {
+			holder = "林动"
+		}
+		if _, err := db.CreateWeapon(name, holder, i+1); err != nil {
+			t.Fatalf("CreateWeapon() failed: %v", err)
+		}
+	}
+
+	minLevel := 2
+	weapons, result, err := db.ListWeapons(ListQuery{NamePrefix: "青", MinLevel: &minLevel, SortBy: "level", SortDesc: true})
+	if err != nil {
+		t.Fatalf("ListWeapons() failed: %v", err)
+	}
+	if result.Total != 3 || len(weapons) != 3 || weapons[0]["name"] != "青_铜" || weapons[2]["name"] != "青龙刀" {
+		t.Fatalf("unexpected filtered list: total=%d weapons=%v", result.Total, weapons)
+	}
+
+	// 前缀中的通配符按字面匹配
+	weapons, result, _ = db.ListWeapons(ListQuery{NamePrefix: "青_"})
+	if result.Total != 1 || weapons[0]["name"] != "青_铜" {
+		t.Fatalf("expected literal prefix match, got %v", weapons)
+	}
+
+	weapons, result, _ = db.ListWeapons(ListQuery{OwnerID: &linDong})
+	if result.Total != 3 || len(weapons) != 3 {
+		t.Fatalf("expected holder filter to match 3 weapons, got %d", result.Total)
+	}
+
+	// 游标分页与偏移分页得到相同的结果
+	var paged []string
+	cursor := ""
+	for {
+		page, result, err := db.ListWeapons(ListQuery{SortBy: "name", Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("ListWeapons() failed: %v", err)
+		}
+		if result.Total != 5 {
+			t.Fatalf("total should ignore paging, got %d", result.Total)
+		}
+		for _, weapon := range page {
+			paged = append(paged, weapon["name"].(string))
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	all, _, _ := db.ListWeapons(ListQuery{SortBy: "name"})
+	if len(paged) != len(all) {
+		t.Fatalf("cursor paging returned %v, want %d items", paged, len(all))
+	}
+	for i, weapon := range all {
+		if paged[i] != weapon["name"] {
+			t.Fatalf("cursor paging order %v differs from full list %v", paged, all)
+		}
+	}
+	offsetPage, _, _ := db.ListWeapons(ListQuery{SortBy: "name", Offset: 2, Limit: 2})
+	if len(offsetPage) != 2 || offsetPage[0]["name"] != paged[2] {
+		t.Fatalf("unexpected offset page: %v", offsetPage)
+	}
+
+	if _, _, err := db.ListWeapons(ListQuery{SortBy: "created_at"}); err == nil {
+		t.Fatalf("expected error for unsupported sort field")
+	}
+	if _, _, err := db.ListBeibao(ListQuery{MinLevel: &minLevel}); err == nil {
+		t.Fatalf("expected error for unsupported level filter")
+	}
+	if _, _, err := db.ListWeapons(ListQuery{Cursor: "not-a-cursor"}); err == nil {
+		t.Fatalf("expected error for invalid cursor")
+	}
+}
+
+func TestListQuery_CursorKeepsNullSortValues(t *testing.T) {
+	db := openTestDatabase(t)
+	for i, name := range []string{"甲剑", "乙剑", "丙剑", "丁剑", "戊剑"} {
+		id, _ := db.CreateWeapon(name, name+"主", i+1)
+		if i%2 == 0 {
+			db.db.Exec(`UPDATE wuqi SET holder = NULL WHERE id = ?`, id)
+		}
+	}
+	listIDs := func(q ListQuery) ([]int64, ListResult) {
+		var ids []int64
+		result, err := db.queryList(weaponListSpec, q, `id`, func(rows *sql.Rows) (int64, error) {
+			var id int64
+			err := rows.Scan(&id)
+			ids = append(ids, id)
+			return id, err
+		})
+		if err != nil {
+			t.Fatalf("queryList() failed: %v", err)
+		}
+		return ids, result
+	}
+
+	// 持有者为空的记录在游标分页中不能被跳过，且与不分页时顺序一致
+	for _, desc := range []bool{false, true} {
+		all, _ := listIDs(ListQuery{SortBy: "holder", SortDesc: desc})
+		var paged []int64
+		cursor := ""
+		for i := 0; i < len(all); i++ {
+			page, result := listIDs(ListQuery{SortBy: "holder", SortDesc: desc, Limit: 2, Cursor: cursor})
+			paged = append(paged, page...)
+			if cursor = result.NextCursor; cursor == "" {
+				break
+			}
+		}
+		if len(paged) != len(all) {
+			t.Fatalf("cursor paging (desc=%v) returned %v, want %v", desc, paged, all)
+		}
+		for i := range all {
+			if paged[i] != all[i] {
+				t.Fatalf("cursor paging (desc=%v) returned %v, want %v", desc, paged, all)
+			}
+		}
+	}
+}
+
+func TestListQuery_DerivedSortAndDefaultOrder(t *testing.T) {
+	db := openTestDatabase(t)
+
+	small, _ := db.CreateShili("小门派", "", 1, 0, 10)
+	big, _ := db.CreateShili("大宗门", "", 3, 0, 10)
+	for _, name := range []string{"甲", "乙"} {
+		id, _ := db.CreateCharacter(name, "", 100, 1)
+		if _, err := db.AddShiliMember(int(big), id, ""); err != nil {
+			t.Fatalf("AddShiliMember() failed: %v", err)
+		}
+	}
+
+	query := ListQuery{SortBy: "member_count", SortDesc: true, Limit: 1}
+	shiliList, result, err := db.ListShili(query)
+	if err != nil {
+		t.Fatalf("ListShili() failed: %v", err)
+	}
+	if len(shiliList) != 1 || int64(shiliList[0].ID) != big || shiliList[0].MemberCount != 2 {
+		t.Fatalf("unexpected sort by member count: %+v", shiliList)
+	}
+	query.Cursor = result.NextCursor
+	shiliList, _, err = db.ListShili(query)
+	if err != nil || len(shiliList) != 1 || int64(shiliList[0].ID) != small {
+		t.Fatalf("unexpected second page: %+v, %v", shiliList, err)
+	}
+
+	for _, prize := range []struct {
+		name string
+		rate float64
+	}{{"铜币", 50}, {"神兵", 0.5}, {"灵石", 20}} {
+		if _, err := db.CreatePrize(0, prize.name, prize.rate, "", "普通"); err != nil {
+			t.Fatalf("CreatePrize() failed: %v", err)
+		}
+	}
+	prizes, err := db.GetAllPrizes()
+	if err != nil {
+		t.Fatalf("GetAllPrizes() failed: %v", err)
+	}
+	if len(prizes) != 3 || prizes[0].Name != "铜币" || prizes[2].Name != "神兵" {
+		t.Fatalf("expected prizes ordered by rate descending, got %+v", prizes)
+	}
+}
@@ -0,0 +1,924 @@
+// 数据库结构版本化迁移
+// 迁移按编号顺序执行，每一步在独立事务中完成，并把已执行的版本记录到 schema_migrations 表
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ErrSchemaTooNew 数据库结构版本高于当前程序支持的版本
+var ErrSchemaTooNew = errors.New("数据库版本高于当前程序支持的版本，请升级程序后再打开")
+
+// schemaVersionQuery 查询已应用的最高迁移版本
+const schemaVersionQuery = `SELECT MAX(version) FROM schema_migrations`
+
+// migration 单个数据库迁移步骤
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// migrations 所有迁移步骤，版本号必须严格递增；已发布的迁移不可修改，只能追加新版本
+var migrations = []migration{
+	{version: 1, name: "创建基础表结构", up: migrateCreateBaseTables},
+	{version: 2, name: "重建人物表以修正列默认值", up: migrateRebuildRenwuDefaults},
+	{version: 3, name: "检查孤立数据", up: migrateReportOrphans},
+	{version: 4, name: "关联持有者、主人与所属势力", up: migrateLinkEntityReferences},
+	{version: 5, name: "建立势力成员关系", up: migrateCreateShiliMembers},
+	{version: 6, name: "添加全文搜索索引状态", up: migrateCreateSearchState},
+	{version: 7, name: "添加抽奖保底规则与计数", up: migrateCreatePityTables},
+	{version: 8, name: "建立多奖池", up: migrateCreatePrizePools},
+	{version: 9, name: "记录抽奖随机种子", up: migrateAddDrawSeeds},
+	{version: 10, name: "奖池概率模式与安慰奖", up: migrateAddPrizeRateModes},
+	{version: 11, name: "抽奖结果放入背包", up: migrateAddDrawClaims},
+	{version: 12, name: "商城购买与背包余额", up: migrateCreateShoppingPurchases},
+	{version: 13, name: "货币账本", up: migrateCreateLedger},
+	{version: 14, name: "背包容量与物品堆叠", up: migrateAddBeibaoCapacity},
+	{version: 15, name: "背包物品流水", up: migrateCreateBeibaoMovements},
+	{version: 16, name: "背包主人", up: migrateAddBeibaoOwner},
+	{version: 17, name: "任务流程", up: migrateShiqingWorkflow},
+	{version: 18, name: "世界历法", up: migrateCreateWorldCalendar},
+	{version: 19, name: "关联势力创立者", up: migrateLinkShiliFounder},
+}
+
+// LatestSchemaVersion 返回当前程序支持的最高数据库结构版本
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion 获取数据库当前的结构版本
+func (d *Database) SchemaVersion() (int, error) {
+	return currentSchemaVersion(d.db.QueryRow(schemaVersionQuery))
+}
+
+// migrate 执行所有尚未应用的迁移
+func (d *Database) migrate() error {
+	ctx := context.Background()
+
+	// 迁移期间固定使用同一个连接，保证连接级别的 PRAGMA 设置对所有步骤生效
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	if err := createSchemaMigrationsTable(ctx, conn); err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %v", err)
+	}
+
+	current, err := currentSchemaVersion(conn.QueryRowContext(ctx, schemaVersionQuery))
+	if err != nil {
+		return fmt.Errorf("读取数据库版本失败: %v", err)
+	}
+
+	latest := LatestSchemaVersion()
+	if current > latest {
+		return fmt.Errorf("%w（数据库版本 %d，程序支持的最高版本 %d）", ErrSchemaTooNew, current, latest)
+	}
+	if current == latest {
+		return nil
+	}
+
+	// 重建表时必须关闭外键约束，否则 DROP TABLE 会触发级联删除；
+	// PRAGMA foreign_keys 在事务内不生效，因此在连接级别切换并在结束后恢复
+	var foreignKeys int
+	if err := conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil {
+		return fmt.Errorf("读取外键约束状态失败: %v", err)
+	}
+	if foreignKeys == 1 {
+		if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+			return fmt.Errorf("关闭外键约束失败: %v", err)
+		}
+		defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, conn, m); err != nil {
+			return fmt.Errorf("执行数据库迁移 %d（%s）失败: %v", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyMigration 在单个事务中执行迁移并记录版本
+func applyMigration(ctx context.Context, conn *sql.Conn, m migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+
+	return runInTx(tx, func(tx *sql.Tx) error {
+		if err := m.up(tx); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+			return fmt.Errorf("记录迁移版本失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// createSchemaMigrationsTable 创建迁移记录表
+func createSchemaMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := conn.ExecContext(ctx, query)
+	return err
+}
+
+// currentSchemaVersion 读取已应用的最高迁移版本，未执行过任何迁移时返回 0
+func currentSchemaVersion(row *sql.Row) (int, error) {
+	var version sql.NullInt64
+	if err := row.Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// tableColumns 获取表的列名列表
+func tableColumns(tx dbExecutor, tableName string) ([]string, error) {
+	rows, err := tx.Query(`PRAGMA table_info(` + tableName + `)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var cid int
+		var name string
+		var dataType string
+		var notNull int
+		var dfltValue interface{}
+		var pk int
+
+		if err := rows.Scan(&cid, &name, &dataType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+
+	return columns, rows.Err()
+}
+
+// rebuildTable 按 SQLite 推荐流程重建表：新建临时表、复制数据、删除旧表、重命名。
+// 用于修改列默认值、约束或删除列等 ALTER TABLE 无法完成的变更。
+// columnDefs 为新表括号内的列与约束定义；新旧表同名的列会被复制，旧表独有的列会被丢弃。
+// 调用方需确保外键约束已关闭，并在之后重建该表上的索引和触发器。
+func rebuildTable(tx *sql.Tx, tableName, columnDefs string) error {
+	tempName := tableName + "_rebuild"
+
+	if _, err := tx.Exec(`DROP TABLE IF EXISTS ` + tempName); err != nil {
+		return fmt.Errorf("清理临时表 %s 失败: %v", tempName, err)
+	}
+	if _, err := tx.Exec(`CREATE TABLE ` + tempName + ` (` + columnDefs + `)`); err != nil {
+		return fmt.Errorf("创建临时表 %s 失败: %v", tempName, err)
+	}
+
+	oldColumns, err := tableColumns(tx, tableName)
+	if err != nil {
+		return fmt.Errorf("读取表 %s 结构失败: %v", tableName, err)
+	}
+	newColumns, err := tableColumns(tx, tempName)
+	if err != nil {
+		return fmt.Errorf("读取表 %s 结构失败: %v", tempName, err)
+	}
+
+	existing := make(map[string]bool, len(oldColumns))
+	for _, column := range oldColumns {
+		existing[column] = true
+	}
+	var shared []string
+	for _, column := range newColumns {
+		if existing[column] {
+			shared = append(shared, column)
+		}
+	}
+
+	// 保留自增序列，避免重建后复用已删除记录的ID
+	var seq sql.NullInt64
+	err = tx.QueryRow(`SELECT seq FROM sqlite_sequence WHERE name = ?`, tableName).Scan(&seq)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("读取表 %s 自增序列失败: %v", tableName, err)
+	}
+
+	if len(shared) > 0 {
+		columnList := strings.Join(shared, ", ")
+		copyQuery := `INSERT INTO ` + tempName + ` (` + columnList + `) SELECT ` + columnList + ` FROM ` + tableName
+		if _, err := tx.Exec(copyQuery); err != nil {
+			return fmt.Errorf("复制表 %s 数据失败: %v", tableName, err)
+		}
+	}
+
+	if _, err := tx.Exec(`DROP TABLE ` + tableName); err != nil {
+		return fmt.Errorf("删除旧表 %s 失败: %v", tableName, err)
+	}
+	if _, err := tx.Exec(`ALTER TABLE ` + tempName + ` RENAME TO ` + tableName); err != nil {
+		return fmt.Errorf("重命名表 %s 失败: %v", tempName, err)
+	}
+
+	if seq.Valid {
+		result, err := tx.Exec(`UPDATE sqlite_sequence SET seq = MAX(seq, ?) WHERE name = ?`, seq.Int64, tableName)
+		if err != nil {
+			return fmt.Errorf("恢复表 %s 自增序列失败: %v", tableName, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			if _, err := tx.Exec(`INSERT INTO sqlite_sequence (name, seq) VALUES (?, ?)`, tableName, seq.Int64); err != nil {
+				return fmt.Errorf("恢复表 %s 自增序列失败: %v", tableName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateCreateBaseTables 迁移 1：创建基础表结构，并为旧版本数据库补齐缺失的列
+func migrateCreateBaseTables(tx *sql.Tx) error {
+	return createBaseTables(tx)
+}
+
+// migrateRebuildRenwuDefaults 迁移 2：重建人物表，使旧版本数据库的
+// level、attack、defense 等列与当前默认值保持一致
+func migrateRebuildRenwuDefaults(tx *sql.Tx) error {
+	return rebuildTable(tx, "renwu", `
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT 'xx',
+		shili TEXT DEFAULT 'xxx',
+		level INTEGER DEFAULT 0,
+		property INTEGER DEFAULT 100,
+		health_current INTEGER DEFAULT 100,
+		mana_current INTEGER DEFAULT 100,
+		attack INTEGER DEFAULT 100,
+		defense INTEGER DEFAULT 100,
+		skills TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP`)
+}
+
+// migrateReportOrphans 迁移 3：外键约束启用后，报告旧版本遗留的孤立数据；
+// 迁移不删除任何记录，由用户在数据库检查中确认后再清理
+func migrateReportOrphans(tx *sql.Tx) error {
+	orphans, err := findOrphans(tx)
+	if err != nil {
+		return err
+	}
+	for table, count := range countOrphans(orphans) {
+		log.Printf("发现表 %s 中的孤立数据 %d 条，可在数据库检查中清理", table, count)
+	}
+	return nil
+}
+
+// migrateLinkEntityReferences 迁移 4：为武器/道具持有者、宠物主人和人物所属势力添加可为空的关联ID列，
+// 并把已有的名称文本解析为ID；只有名称唯一匹配时才建立关联，其余保持为空
+func migrateLinkEntityReferences(tx *sql.Tx) error {
+	references := []entityReference{
+		{table: "wuqi", column: "holder_renwu_id", textColumn: "holder", parent: "renwu"},
+		{table: "daoju", column: "holder_renwu_id", textColumn: "holder", parent: "renwu"},
+		{table: "chongwu", column: "owner_renwu_id", textColumn: "owner", parent: "renwu"},
+		{table: "renwu", column: "shili_id", textColumn: "shili", parent: "shili"},
+	}
+
+	for _, ref := range references {
+		if err := linkEntityReferenceColumn(tx, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkEntityReferenceColumn 添加可为空的关联ID列及索引，并按名称文本唯一匹配建立已有记录的关联
+func linkEntityReferenceColumn(tx *sql.Tx, ref entityReference) error {
+	columnDef := `INTEGER REFERENCES ` + ref.parent + `(id) ON DELETE SET NULL`
+	if err := addColumnIfNotExists(tx, ref.table, ref.column, columnDef); err != nil {
+		return fmt.Errorf("添加列 %s.%s 失败: %v", ref.table, ref.column, err)
+	}
+
+	index := `CREATE INDEX IF NOT EXISTS idx_` + ref.table + `_` + ref.column + ` ON ` + ref.table + `(` + ref.column + `)`
+	if _, err := tx.Exec(index); err != nil {
+		return fmt.Errorf("创建索引失败: %v", err)
+	}
+
+	resolve := `
+	UPDATE ` + ref.table + `
+	SET ` + ref.column + ` = (SELECT p.id FROM ` + ref.parent + ` p WHERE p.name = ` + ref.table + `.` + ref.textColumn + `)
+	WHERE ` + ref.column + ` IS NULL
+	AND (SELECT COUNT(*) FROM ` + ref.parent + ` p WHERE p.name = ` + ref.table + `.` + ref.textColumn + `) = 1`
+	result, err := tx.Exec(resolve)
+	if err != nil {
+		return fmt.Errorf("解析表 %s 的 %s 列失败: %v", ref.table, ref.textColumn, err)
+	}
+	if linked, _ := result.RowsAffected(); linked > 0 {
+		log.Printf("表 %s 已按 %s 列关联 %d 条记录", ref.table, ref.textColumn, linked)
+	}
+	return nil
+}
+
+// migrateCreateShiliMembers 迁移 5：创建势力成员表，成员数改为按成员表统计。
+// 已关联势力的人物直接成为成员；职务中的人名能唯一匹配人物时，把职务名记为该成员的身份
+func migrateCreateShiliMembers(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS shili_members (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		shili_id INTEGER NOT NULL,
+		renwu_id INTEGER NOT NULL UNIQUE,
+		role TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (shili_id) REFERENCES shili(id) ON DELETE CASCADE,
+		FOREIGN KEY (renwu_id) REFERENCES renwu(id) ON DELETE CASCADE
+	)`); err != nil {
+		return fmt.Errorf("创建势力成员表失败: %v", err)
+	}
+	if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_shili_members_shili_id ON shili_members(shili_id)`); err != nil {
+		return fmt.Errorf("创建索引失败: %v", err)
+	}
+
+	if err := backfillShiliMembers(tx); err != nil {
+		return err
+	}
+
+	positions, err := selectTableRows(tx, `
+	SELECT p.shili_id, p.position_name, s.name AS shili_name, r.id AS renwu_id
+	FROM shili_positions p
+	JOIN shili s ON s.id = p.shili_id
+	JOIN renwu r ON r.name = p.person_name
+	WHERE p.person_name <> ''
+	AND (SELECT COUNT(*) FROM renwu r2 WHERE r2.name = p.person_name) = 1
+	ORDER BY p.id ASC`)
+	if err != nil {
+		return fmt.Errorf("读取势力职务失败: %v", err)
+	}
+	for _, position := range positions {
+		// 人物尚未加入任何势力时随职务加入；已是其他势力成员时不做变更
+		if _, err := tx.Exec(`
+		INSERT OR IGNORE INTO shili_members (shili_id, renwu_id, role) VALUES (?, ?, '')`,
+			position["shili_id"], position["renwu_id"]); err != nil {
+			return fmt.Errorf("添加势力成员失败: %v", err)
+		}
+		if _, err := tx.Exec(`
+		UPDATE renwu SET shili_id = ?, shili = ? WHERE id = ? AND shili_id IS NULL`,
+			position["shili_id"], position["shili_name"], position["renwu_id"]); err != nil {
+			return fmt.Errorf("更新人物所属势力失败: %v", err)
+		}
+		if _, err := tx.Exec(`
+		UPDATE shili_members SET role = ? WHERE shili_id = ? AND renwu_id = ? AND role = ''`,
+			position["position_name"], position["shili_id"], position["renwu_id"]); err != nil {
+			return fmt.Errorf("更新成员身份失败: %v", err)
+		}
+	}
+
+	// 成员数由成员表统计，移除手工维护的 member_count 列
+	return rebuildTable(tx, "shili", `
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		level INTEGER DEFAULT 1,
+		founder TEXT NOT NULL DEFAULT '',
+		wealth INTEGER DEFAULT 0,
+		max_members INTEGER DEFAULT 10,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP`)
+}
+
+// migrateCreateSearchState 迁移 6：创建搜索索引状态表，并在各实体表上创建使索引失效的触发器。
+// 索引表本身依赖 FTS5 模块，属于可重建的派生数据，在首次搜索时创建
+func migrateCreateSearchState(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS search_index_state (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		dirty INTEGER NOT NULL DEFAULT 1,
+		markdown_signature TEXT NOT NULL DEFAULT ''
+	)`); err != nil {
+		return fmt.Errorf("创建搜索索引状态表失败: %v", err)
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO search_index_state (id, dirty) VALUES (1, 1)`); err != nil {
+		return fmt.Errorf("初始化搜索索引状态失败: %v", err)
+	}
+
+	for _, table := range []string{
+		"renwu", "renwu_attributes", "renwu_skills",
+		"wuqi", "wuqi_attributes", "wuqi_skills",
+		"chongwu", "chongwu_attributes", "chongwu_skills",
+		"daoju", "daoju_functions",
+		"shiqing", "shiqing_details",
+		"shili", "shili_positions", "shili_attributes",
+		"guaiwu", "guaiwu_attributes", "guaiwu_skills",
+		"beibao", "beibao_items",
+		"shopping", "prizes",
+	} {
+		if err := createSearchTriggers(tx, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateCreatePityTables 创建按奖品品种配置的保底规则表与保底计数表，并为奖品添加 UP（featured）标记
+func migrateCreatePityTables(tx *sql.Tx) error {
+	if err := addColumnIfNotExists(tx, "prizes", "featured", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return fmt.Errorf("添加列 prizes.featured 失败: %v", err)
+	}
+
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS pity_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			variety TEXT NOT NULL UNIQUE,
+			hard_pity INTEGER NOT NULL DEFAULT 0,
+			soft_pity_start INTEGER NOT NULL DEFAULT 0,
+			soft_pity_step REAL NOT NULL DEFAULT 0,
+			featured_guarantee INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS pity_counters (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			variety TEXT NOT NULL UNIQUE,
+			draws_since_hit INTEGER NOT NULL DEFAULT 0,
+			guaranteed_featured INTEGER NOT NULL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("创建保底表失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrateCreatePrizePools 迁移 8：创建奖池表，把已有奖品、抽奖历史和保底计数归入默认奖池，
+// 并重建 prizes、draw_history、pity_counters 使 pool_id 成为必填的外键；保底计数改为按奖池和品种分别记录
+func migrateCreatePrizePools(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS prize_pools (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		start_at TEXT,
+		end_at TEXT,
+		draw_cost INTEGER NOT NULL DEFAULT 0,
+		is_default INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("创建奖池表失败: %v", err)
+	}
+
+	result, err := tx.Exec(`INSERT INTO prize_pools (name, is_default) VALUES ('默认奖池', 1)`)
+	if err != nil {
+		return fmt.Errorf("创建默认奖池失败: %v", err)
+	}
+	defaultID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("创建默认奖池失败: %v", err)
+	}
+
+	for _, table := range []string{"prizes", "draw_history", "pity_counters"} {
+		if err := addColumnIfNotExists(tx, table, "pool_id", "INTEGER"); err != nil {
+			return fmt.Errorf("添加列 %s.pool_id 失败: %v", table, err)
+		}
+		if _, err := tx.Exec(`UPDATE `+table+` SET pool_id = ? WHERE pool_id IS NULL`, defaultID); err != nil {
+			return fmt.Errorf("归入默认奖池失败: %v", err)
+		}
+	}
+
+	if err := rebuildTable(tx, "prizes", `
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pool_id INTEGER NOT NULL REFERENCES prize_pools(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		rate REAL NOT NULL,
+		description TEXT DEFAULT '',
+		variety TEXT DEFAULT '',
+		featured INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP`); err != nil {
+		return err
+	}
+	// 重建会删除表上的触发器
+	if err := createSearchTriggers(tx, "prizes"); err != nil {
+		return err
+	}
+
+	if err := rebuildTable(tx, "draw_history", `
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pool_id INTEGER NOT NULL REFERENCES prize_pools(id) ON DELETE CASCADE,
+		prize_id INTEGER NOT NULL REFERENCES prizes(id) ON DELETE CASCADE,
+		prize_name TEXT NOT NULL,
+		cost INTEGER NOT NULL DEFAULT 0,
+		drawn_at DATETIME DEFAULT CURRENT_TIMESTAMP`); err != nil {
+		return err
+	}
+
+	if err := rebuildTable(tx, "pity_counters", `
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pool_id INTEGER NOT NULL REFERENCES prize_pools(id) ON DELETE CASCADE,
+		variety TEXT NOT NULL,
+		draws_since_hit INTEGER NOT NULL DEFAULT 0,
+		guaranteed_featured INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (pool_id, variety)`); err != nil {
+		return err
+	}
+
+	for _, index := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_prizes_pool_id ON prizes(pool_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_draw_history_pool_id ON draw_history(pool_id)`,
+	} {
+		if _, err := tx.Exec(index); err != nil {
+			return fmt.Errorf("创建索引失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrateAddDrawSeeds 迁移 9：为奖池增加随机种子和序号，为抽奖历史增加种子、序号、随机值和保底计数快照；
+// 迁移前的历史记录这些列为空，无法重放
+func migrateAddDrawSeeds(tx *sql.Tx) error {
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"prize_pools", "rng_seed", "INTEGER"},
+		{"prize_pools", "rng_sequence", "INTEGER NOT NULL DEFAULT 0"},
+		{"draw_history", "seed", "INTEGER"},
+		{"draw_history", "sequence", "INTEGER"},
+		{"draw_history", "roll", "REAL"},
+		{"draw_history", "pity_state", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfNotExists(tx, c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("添加列 %s.%s 失败: %v", c.table, c.column, err)
+		}
+	}
+
+	rows, err := tx.Query(`SELECT id FROM prize_pools WHERE rng_seed IS NULL`)
+	if err != nil {
+		return fmt.Errorf("查询奖池失败: %v", err)
+	}
+	var poolIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("查询奖池失败: %v", err)
+		}
+		poolIDs = append(poolIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("查询奖池失败: %v", err)
+	}
+	rows.Close()
+	for _, id := range poolIDs {
+		if _, err := tx.Exec(`UPDATE prize_pools SET rng_seed = ? WHERE id = ?`, newDrawSeed(), id); err != nil {
+			return fmt.Errorf("生成随机种子失败: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_draw_history_seed ON draw_history(pool_id, seed, sequence)`); err != nil {
+		return fmt.Errorf("创建索引失败: %v", err)
+	}
+	return nil
+}
+
+// migrateAddPrizeRateModes 迁移 10：为奖池增加概率模式、为奖品增加安慰奖标记，
+// 并重建 draw_history 使奖品ID可为空以记录“未中奖”
+func migrateAddPrizeRateModes(tx *sql.Tx) error {
+	if err := addColumnIfNotExists(tx, "prize_pools", "rate_mode", "TEXT NOT NULL DEFAULT 'normalized'"); err != nil {
+		return fmt.Errorf("添加列 prize_pools.rate_mode 失败: %v", err)
+	}
+	if err := addColumnIfNotExists(tx, "prizes", "consolation", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return fmt.Errorf("添加列 prizes.consolation 失败: %v", err)
+	}
+
+	if err := rebuildTable(tx, "draw_history", `
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pool_id INTEGER NOT NULL REFERENCES prize_pools(id) ON DELETE CASCADE,
+		prize_id INTEGER REFERENCES prizes(id) ON DELETE CASCADE,
+		prize_name TEXT NOT NULL,
+		cost INTEGER NOT NULL DEFAULT 0,
+		seed INTEGER,
+		sequence INTEGER,
+		roll REAL,
+		pity_state TEXT,
+		drawn_at DATETIME DEFAULT CURRENT_TIMESTAMP`); err != nil {
+		return err
+	}
+
+	// 重建会删除表上的索引
+	for _, index := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_draw_history_pool_id ON draw_history(pool_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_draw_history_seed ON draw_history(pool_id, seed, sequence)`,
+	} {
+		if _, err := tx.Exec(index); err != nil {
+			return fmt.Errorf("创建索引失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrateAddDrawClaims 迁移 11：为奖品增加放入背包的数量，为抽奖历史增加领取到的背包和领取时间
+func migrateAddDrawClaims(tx *sql.Tx) error {
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"prizes", "quantity", "INTEGER NOT NULL DEFAULT 1"},
+		{"draw_history", "claimed_beibao_id", "INTEGER REFERENCES beibao(id) ON DELETE SET NULL"},
+		{"draw_history", "claimed_at", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfNotExists(tx, c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("添加列 %s.%s 失败: %v", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+// migrateCreateShoppingPurchases 迁移 12：为商品增加库存（为空表示不限）、为背包增加货币余额，并创建购买记录表
+func migrateCreateShoppingPurchases(tx *sql.Tx) error {
+	if err := addColumnIfNotExists(tx, "shopping", "stock", "INTEGER"); err != nil {
+		return fmt.Errorf("添加列 shopping.stock 失败: %v", err)
+	}
+	if err := addColumnIfNotExists(tx, "beibao", "balance", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return fmt.Errorf("添加列 beibao.balance 失败: %v", err)
+	}
+
+	if _, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS shopping_purchases (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		shopping_id INTEGER REFERENCES shopping(id) ON DELETE SET NULL,
+		item_name TEXT NOT NULL,
+		beibao_id INTEGER REFERENCES beibao(id) ON DELETE SET NULL,
+		quantity INTEGER NOT NULL,
+		unit_price INTEGER NOT NULL,
+		total_price INTEGER NOT NULL,
+		balance_after INTEGER NOT NULL,
+		purchased_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("创建购买记录表失败: %v", err)
+	}
+	for _, index := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_shopping_purchases_shopping_id ON shopping_purchases(shopping_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_shopping_purchases_beibao_id ON shopping_purchases(beibao_id)`,
+	} {
+		if _, err := tx.Exec(index); err != nil {
+			return fmt.Errorf("创建索引失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrateCreateLedger 迁移 13：创建货币账本，并为已有的人物财产、势力财富和背包余额补记与世界之间的期初余额，
+// 使余额列与账本一致
+func migrateCreateLedger(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS ledger_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		from_type TEXT NOT NULL,
+		from_id INTEGER NOT NULL DEFAULT 0,
+		to_type TEXT NOT NULL,
+		to_id INTEGER NOT NULL DEFAULT 0,
+		amount INTEGER NOT NULL CHECK (amount > 0),
+		memo TEXT DEFAULT '',
+		reversal_of INTEGER UNIQUE REFERENCES ledger_entries(id),
+		created_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("创建账本表失败: %v", err)
+	}
+	for _, index := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_ledger_entries_from ON ledger_entries(from_type, from_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_ledger_entries_to ON ledger_entries(to_type, to_id)`,
+	} {
+		if _, err := tx.Exec(index); err != nil {
+			return fmt.Errorf("创建索引失败: %v", err)
+		}
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	for _, opening := range []struct{ accountType, table, column string }{
+		{"character", "renwu", "property"},
+		{"faction", "shili", "wealth"},
+		{"beibao", "beibao", "balance"},
+	} {
+		if _, err := tx.Exec(`
+		INSERT INTO ledger_entries (from_type, from_id, to_type, to_id, amount, memo, created_at)
+		SELECT 'world', 0, ?1, id, `+opening.column+`, '期初余额', ?2 FROM `+opening.table+` WHERE `+opening.column+` > 0
+		UNION ALL
+		SELECT ?1, id, 'world', 0, -`+opening.column+`, '期初余额', ?2 FROM `+opening.table+` WHERE `+opening.column+` < 0`,
+			opening.accountType, now); err != nil {
+			return fmt.Errorf("补记期初余额失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrateAddBeibaoCapacity 迁移 14：为背包增加格子容量和重量上限（为空表示不限），为道具增加堆叠上限和重量，
+// 背包物品可关联道具；已有物品按名称关联唯一的同名道具，有多个同名道具时不关联
+func migrateAddBeibaoCapacity(tx *sql.Tx) error {
+	columns := []struct{ table, column, definition string }{
+		{"beibao", "capacity", "INTEGER"},
+		{"beibao", "max_weight", "INTEGER"},
+		{"daoju", "max_stack", "INTEGER NOT NULL DEFAULT 0"},
+		{"daoju", "weight", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfNotExists(tx, c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("添加列 %s.%s 失败: %v", c.table, c.column, err)
+		}
+	}
+	return linkEntityReferenceColumn(tx, entityReference{table: "beibao_items", column: "daoju_id", textColumn: "name", parent: "daoju"})
+}
+
+// migrateCreateBeibaoMovements 迁移 15：创建背包物品流水表，记录背包之间的物品转移与交易
+func migrateCreateBeibaoMovements(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS beibao_movements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		batch INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		beibao_id INTEGER NOT NULL REFERENCES beibao(id) ON DELETE CASCADE,
+		counterpart_beibao_id INTEGER REFERENCES beibao(id) ON DELETE SET NULL,
+		item_name TEXT NOT NULL,
+		quantity INTEGER NOT NULL,
+		created_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("创建物品流水表失败: %v", err)
+	}
+	for _, index := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_beibao_movements_beibao_id ON beibao_movements(beibao_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_beibao_movements_counterpart ON beibao_movements(counterpart_beibao_id)`,
+	} {
+		if _, err := tx.Exec(index); err != nil {
+			return fmt.Errorf("创建索引失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrateAddBeibaoOwner 迁移 16：背包可以属于人物、宠物或势力，主人被删除时背包变为无主
+func migrateAddBeibaoOwner(tx *sql.Tx) error {
+	for _, owner := range []struct{ column, table string }{
+		{"owner_renwu_id", "renwu"},
+		{"owner_chongwu_id", "chongwu"},
+		{"owner_shili_id", "shili"},
+	} {
+		if err := addColumnIfNotExists(tx, "beibao", owner.column, "INTEGER REFERENCES "+owner.table+"(id) ON DELETE SET NULL"); err != nil {
+			return fmt.Errorf("添加列 beibao.%s 失败: %v", owner.column, err)
+		}
+		if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_beibao_` + owner.column + ` ON beibao(` + owner.column + `)`); err != nil {
+			return fmt.Errorf("创建索引失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrateShiqingWorkflow 迁移 17：任务状态流程与时间、有序可勾选的目标、关联人物的参与者和结构化奖励。
+// 旧的中文或空状态归一为状态常量，旧的参与者文本按名称唯一匹配关联到人物
+func migrateShiqingWorkflow(tx *sql.Tx) error {
+	columns := []struct{ table, column, definition string }{
+		{"shiqing", "started_at", "TEXT"},
+		{"shiqing", "ended_at", "TEXT"},
+		{"shiqing_details", "position", "INTEGER NOT NULL DEFAULT 0"},
+		{"shiqing_details", "done", "INTEGER NOT NULL DEFAULT 0"},
+		{"shiqing_details", "done_at", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfNotExists(tx, c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("添加列 %s.%s 失败: %v", c.table, c.column, err)
+		}
+	}
+
+	for _, statement := range []string{
+		`CREATE TABLE IF NOT EXISTS shiqing_participants (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			shiqing_id INTEGER NOT NULL REFERENCES shiqing(id) ON DELETE CASCADE,
+			renwu_id INTEGER NOT NULL REFERENCES renwu(id) ON DELETE CASCADE,
+			role TEXT NOT NULL DEFAULT '',
+			UNIQUE (shiqing_id, renwu_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS shiqing_rewards (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			shiqing_id INTEGER NOT NULL REFERENCES shiqing(id) ON DELETE CASCADE,
+			kind TEXT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			quantity INTEGER NOT NULL CHECK (quantity > 0)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_shiqing_participants_renwu_id ON shiqing_participants(renwu_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_shiqing_rewards_shiqing_id ON shiqing_rewards(shiqing_id)`,
+		`UPDATE shiqing_details SET position = (
+			SELECT COUNT(*) FROM shiqing_details d WHERE d.shiqing_id = shiqing_details.shiqing_id AND d.id <= shiqing_details.id)`,
+	} {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("创建任务流程表失败: %v", err)
+		}
+	}
+
+	for status, legacy := range map[string][]string{
+		"active":    {"进行中"},
+		"completed": {"已完成", "完成"},
+		"failed":    {"失败", "已失败"},
+		"abandoned": {"放弃", "已放弃"},
+	} {
+		for _, value := range legacy {
+			if _, err := tx.Exec(`UPDATE shiqing SET status = ? WHERE status = ?`, status, value); err != nil {
+				return fmt.Errorf("归一任务状态失败: %v", err)
+			}
+		}
+	}
+	if _, err := tx.Exec(`
+	UPDATE shiqing SET status = 'pending'
+	WHERE status IS NULL OR status NOT IN ('pending', 'active', 'completed', 'failed', 'abandoned')`); err != nil {
+		return fmt.Errorf("归一任务状态失败: %v", err)
+	}
+
+	rows, err := tx.Query(`SELECT id, participants FROM shiqing WHERE COALESCE(participants, '') <> ''`)
+	if err != nil {
+		return fmt.Errorf("查询任务参与者失败: %v", err)
+	}
+	legacyParticipants := map[int]string{}
+	for rows.Next() {
+		var id int
+		var participants string
+		if err := rows.Scan(&id, &participants); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描任务参与者失败: %v", err)
+		}
+		legacyParticipants[id] = participants
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("查询任务参与者失败: %v", err)
+	}
+	for shiqingID, participants := range legacyParticipants {
+		names := strings.FieldsFunc(participants, func(r rune) bool {
+			return strings.ContainsRune("，,、；; \t\n", r)
+		})
+		for _, name := range names {
+			// 只关联能唯一匹配的人物，重名的参与者保留在文本列中
+			renwuID, err := resolveReferenceID(tx, "renwu", name)
+			if err != nil {
+				return fmt.Errorf("查询任务参与者失败: %v", err)
+			}
+			if renwuID == nil {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO shiqing_participants (shiqing_id, renwu_id) VALUES (?, ?)`, shiqingID, renwuID); err != nil {
+				return fmt.Errorf("关联任务参与者失败: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// migrateCreateWorldCalendar 迁移 18：世界历法的纪元与月份，以及人物出生、势力创立和任务发生的世界日期（日序，可为空）
+func migrateCreateWorldCalendar(tx *sql.Tx) error {
+	for _, statement := range []string{
+		`CREATE TABLE IF NOT EXISTS calendar_eras (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			position INTEGER NOT NULL,
+			name TEXT NOT NULL UNIQUE,
+			years INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS calendar_months (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			position INTEGER NOT NULL,
+			name TEXT NOT NULL UNIQUE,
+			days INTEGER NOT NULL CHECK (days > 0)
+		)`,
+	} {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("创建历法表失败: %v", err)
+		}
+	}
+	for _, c := range worldDateColumns {
+		if err := addColumnIfNotExists(tx, c.table, c.column, "INTEGER"); err != nil {
+			return fmt.Errorf("添加列 %s.%s 失败: %v", c.table, c.column, err)
+		}
+		if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_` + c.table + `_` + c.column + ` ON ` + c.table + `(` + c.column + `)`); err != nil {
+			return fmt.Errorf("创建索引失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrateLinkShiliFounder 迁移 19：为势力创立者添加可为空的人物ID列，已有的创立者名称唯一匹配时建立关联
+func migrateLinkShiliFounder(tx *sql.Tx) error {
+	return linkEntityReferenceColumn(tx, entityReference{table: "shili", column: "founder_renwu_id", textColumn: "founder", parent: "renwu"})
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"nooltools/apps/storage"
+	"path/filepath"
+	"testing"
+)
+
+func openTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	db, err := NewDatabaseAtDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestMigrationsAreStrictlyOrdered(t *testing.T) {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version <= migrations[i-1].version {
+			t.Fatalf("migration %d (%s) must have a higher version than %d", migrations[i].version, migrations[i].name, migrations[i-1].version)
+		}
+	}
+}
+
+func TestMigrate_FreshDatabaseReachesLatestVersion(t *testing.T) {
+	db := openTestDatabase(t)
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion() failed: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Fatalf("expected schema version %d, got %d", LatestSchemaVersion(), version)
+	}
+
+	result, err := db.CheckDatabase()
+	if err != nil || !result.OK {
+		t.Fatalf("CheckDatabase() = %+v, %v", result, err)
+	}
+}
+
+func TestMigrate_UpgradesLegacyDatabase(t *testing.T) {
+	dataDir := t.TempDir()
+	raw, err := sql.Open("sqlite3", filepath.Join(dataDir, storage.DatabaseFileName))
+	if err != nil {
+		t.Fatalf("failed to open raw database: %v", err)
+	}
+	// 模拟早期版本：人物表缺少 shili、property 列，且 level 默认值不同
+	if _, err := raw.Exec(`CREATE TABLE renwu (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT 'xx',
+		level INTEGER DEFAULT 1
+	)`); err != nil {
+		t.Fatalf("failed to create legacy table: %v", err)
+	}
+	if _, err := raw.Exec(`INSERT INTO renwu (id, name, level) VALUES (7, '旧人物', 3)`); err != nil {
+		t.Fatalf("failed to insert legacy row: %v", err)
+	}
+	raw.Close()
+
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() on legacy database failed: %v", err)
+	}
+	defer db.Close()
+
+	info, err := db.GetCharacterInfo(7)
+	if err != nil {
+		t.Fatalf("legacy character lost after migration: %v", err)
+	}
+	if info.Name != "旧人物" || info.Level != 3 || info.Shili != "xxx" || info.Property != 100 {
+		t.Fatalf("unexpected migrated character: %+v", info)
+	}
+
+	if _, err := db.GetDB().Exec(`INSERT INTO renwu (name) VALUES ('新人物')`); err != nil {
+		t.Fatalf("insert after migration failed: %v", err)
+	}
+	var level int
+	if err := db.GetDB().QueryRow(`SELECT level FROM renwu WHERE name = '新人物'`).Scan(&level); err != nil {
+		t.Fatalf("failed to read new character: %v", err)
+	}
+	if level != 0 {
+		t.Fatalf("expected rebuilt default level 0, got %d", level)
+	}
+}
+
+func TestMigrate_RefusesNewerDatabase(t *testing.T) {
+	dataDir := t.TempDir()
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() failed: %v", err)
+	}
+	if _, err := db.GetDB().Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, 'future')`, LatestSchemaVersion()+1); err != nil {
+		t.Fatalf("failed to record future version: %v", err)
+	}
+	db.Close()
+
+	_, err = NewDatabaseAtDir(dataDir)
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+}
@@ -31,27 +31,33 @@ type PetInfo struct {
 	Skills     []PetSkill     `json:"skills"`
 }
 
+// petListSpec 宠物列表的筛选与排序
+var petListSpec = listSpec{
+	label:         "宠物",
+	table:         "chongwu",
+	levelColumn:   "level",
+	ownerColumn:   "owner",
+	ownerIDColumn: "owner_renwu_id",
+	sortColumns:   map[string]string{"id": "id", "name": "name", "level": "level", "owner": "owner"},
+	defaultSort:   "id",
+}
+
 // GetAllPets 获取所有宠物列表
 func (d *Database) GetAllPets() ([]map[string]interface{}, error) {
-	query := `
-	SELECT id, name, level, owner
-	FROM chongwu
-	ORDER BY id ASC`
-
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("查询宠物列表失败: %v", err)
-	}
-	defer rows.Close()
+	pets, _, err := d.ListPets(ListQuery{})
+	return pets, err
+}
 
+// ListPets 按筛选、排序和分页条件获取宠物列表
+func (d *Database) ListPets(q ListQuery) ([]map[string]interface{}, ListResult, error) {
 	var pets []map[string]interface{}
-	for rows.Next() {
+	result, err := d.queryList(petListSpec, q, `id, name, level, owner`, func(rows *sql.Rows) (int64, error) {
 		var id int
 		var name, owner string
 		var level int
 
 		if err := rows.Scan(&id, &name, &level, &owner); err != nil {
-			return nil, fmt.Errorf("扫描宠物数据失败: %v", err)
+			return 0, fmt.Errorf("扫描宠物数据失败: %v", err)
 		}
 
 		pet := map[string]interface{}{
@@ -61,9 +67,13 @@ func (d *Database) GetAllPets() ([]map[string]interface{}, error) {
 			"owner": owner,
 		}
 		pets = append(pets, pet)
+		return int64(id), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return pets, nil
+	return pets, result, nil
 }
 
 // GetPetInfo 获取宠物详细信息（包含属性和技能）
@@ -154,11 +164,16 @@ func (d *Database) GetPetSkills(petID int) ([]PetSkill, error) {
 
 // AddPetAttribute 添加宠物属性
 func (d *Database) AddPetAttribute(petID int, name, description string, value int) error {
+	return addPetAttribute(d.db, petID, name, description, value)
+}
+
+// addPetAttribute 使用指定的执行器添加宠物属性，供事务内复用
+func addPetAttribute(tx dbExecutor, petID int, name, description string, value int) error {
 	query := `
 	INSERT INTO chongwu_attributes (chongwu_id, name, description, value)
 	VALUES (?, ?, ?, ?)`
 
-	_, err := d.db.Exec(query, petID, name, description, value)
+	_, err := tx.Exec(query, petID, name, description, value)
 	if err != nil {
 		return fmt.Errorf("添加属性失败: %v", err)
 	}
@@ -254,51 +269,69 @@ func (d *Database) UpdatePetSkill(skillID int, name, description string) error {
 	return nil
 }
 
-// CreatePet 创建新宠物
+// CreatePet 创建新宠物（宠物与默认属性在同一事务中写入）
 func (d *Database) CreatePet(name, owner string, level int) (int, error) {
-	query := `
-	INSERT INTO chongwu (name, owner, level)
-	VALUES (?, ?, ?)`
-
-	result, err := d.db.Exec(query, name, owner, level)
-	if err != nil {
-		return 0, fmt.Errorf("创建宠物失败: %v", err)
-	}
+	var id int64
+	err := d.WithTx(func(tx *sql.Tx) error {
+		ownerID, err := resolveReferenceID(tx, "renwu", owner)
+		if err != nil {
+			return fmt.Errorf("查询主人失败: %v", err)
+		}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("获取宠物ID失败: %v", err)
-	}
+		query := `
+		INSERT INTO chongwu (name, owner, owner_renwu_id, level)
+		VALUES (?, ?, ?, ?)`
 
-	// 为新宠物添加默认属性
-	defaultAttributes := []struct {
-		name        string
-		description string
-		value       int
-	}{
-		{"攻击", "攻击力", 100},
-		{"防御", "防御力", 100},
-		{"忠诚", "忠诚度", 100},
-	}
+		result, err := tx.Exec(query, name, owner, ownerID, level)
+		if err != nil {
+			return fmt.Errorf("创建宠物失败: %v", err)
+		}
 
-	for _, attr := range defaultAttributes {
-		err = d.AddPetAttribute(int(id), attr.name, attr.description, attr.value)
+		id, err = result.LastInsertId()
 		if err != nil {
-			return 0, fmt.Errorf("添加默认属性失败: %v", err)
+			return fmt.Errorf("获取宠物ID失败: %v", err)
 		}
+
+		// 为新宠物添加默认属性
+		defaultAttributes := []struct {
+			name        string
+			description string
+			value       int
+		}{
+			{"攻击", "攻击力", 100},
+			{"防御", "防御力", 100},
+			{"忠诚", "忠诚度", 100},
+		}
+
+		for _, attr := range defaultAttributes {
+			if err := addPetAttribute(tx, int(id), attr.name, attr.description, attr.value); err != nil {
+				return fmt.Errorf("添加默认属性失败: %v", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return int(id), nil
 }
 
-// UpdatePetBasicInfo 更新宠物基本信息（名称不可变更）
+// UpdatePetBasicInfo 更新宠物基本信息（名称不可变更）；主人名称变化时按新名称重新关联人物
 func (d *Database) UpdatePetBasicInfo(petID int, owner string, level int) error {
+	ownerID, err := resolveReferenceID(d.db, "renwu", owner)
+	if err != nil {
+		return fmt.Errorf("查询主人失败: %v", err)
+	}
+
 	query := `
 	UPDATE chongwu
-	SET owner = ?, level = ?, updated_at = CURRENT_TIMESTAMP
+	SET owner_renwu_id = CASE WHEN owner = ? AND owner_renwu_id IS NOT NULL THEN owner_renwu_id ELSE ? END,
+		owner = ?, level = ?, updated_at = CURRENT_TIMESTAMP
 	WHERE id = ?`
 
-	result, err := d.db.Exec(query, owner, level, petID)
+	result, err := d.db.Exec(query, owner, ownerID, owner, level, petID)
 	if err != nil {
 		return fmt.Errorf("更新宠物信息失败: %v", err)
 	}
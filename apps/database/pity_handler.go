@@ -0,0 +1,365 @@
+// 抽奖保底：按奖品品种配置硬保底、软保底概率递增以及“歪了之后下次必出 UP”
+// 保底计数按奖池保存在 pity_counters 表中，各奖池互不影响，每次抽奖后在同一事务内更新
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// PityRule 某个奖品品种的保底规则
+type PityRule struct {
+	ID                int     `json:"id"`
+	Variety           string  `json:"variety"`            // 奖品品种
+	HardPity          int     `json:"hard_pity"`          // 连续 N 抽未出该品种时第 N 抽必出，0 表示不启用
+	SoftPityStart     int     `json:"soft_pity_start"`    // 从第几抽之后开始提高概率，0 表示不启用
+	SoftPityStep      float64 `json:"soft_pity_step"`     // 超过软保底起始次数后每抽增加的爆率（百分点）
+	FeaturedGuarantee bool    `json:"featured_guarantee"` // 抽中该品种的非 UP 奖品后，下次抽中该品种时必为 UP 奖品
+}
+
+// PityState 某个奖品品种在奖池中当前的保底状态
+type PityState struct {
+	Variety            string  `json:"variety"`
+	DrawsSinceHit      int     `json:"draws_since_hit"`       // 距上次抽中该品种已经过的抽数
+	HardPity           int     `json:"hard_pity"`             // 硬保底次数，0 表示未启用
+	DrawsUntilHardPity int     `json:"draws_until_hard_pity"` // 再抽多少次必出，未启用硬保底时为 0
+	NextRate           float64 `json:"next_rate"`             // 下一抽抽中该品种的概率（百分比，含软保底加成）
+	GuaranteedFeatured bool    `json:"guaranteed_featured"`   // 下次抽中该品种时是否必为 UP 奖品
+}
+
+// pityCounter 保底计数
+type pityCounter struct {
+	drawsSinceHit      int
+	guaranteedFeatured bool
+}
+
+// GetPityRules 获取所有保底规则
+func (db *Database) GetPityRules() ([]PityRule, error) {
+	rules, err := getPityRules(db.db)
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// SetPityRule 新增或更新某个奖品品种的保底规则
+func (db *Database) SetPityRule(rule PityRule) error {
+	rule.Variety = strings.TrimSpace(rule.Variety)
+	if rule.Variety == "" {
+		return fmt.Errorf("奖品品种不能为空")
+	}
+	if rule.HardPity < 0 || rule.SoftPityStart < 0 || rule.SoftPityStep < 0 {
+		return fmt.Errorf("保底次数和概率增量不能为负数")
+	}
+	if rule.HardPity > 0 && rule.SoftPityStart >= rule.HardPity {
+		return fmt.Errorf("软保底起始次数必须小于硬保底次数")
+	}
+
+	query := `
+	INSERT INTO pity_rules (variety, hard_pity, soft_pity_start, soft_pity_step, featured_guarantee)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(variety) DO UPDATE SET
+		hard_pity = excluded.hard_pity,
+		soft_pity_start = excluded.soft_pity_start,
+		soft_pity_step = excluded.soft_pity_step,
+		featured_guarantee = excluded.featured_guarantee,
+		updated_at = CURRENT_TIMESTAMP`
+	if _, err := db.db.Exec(query, rule.Variety, rule.HardPity, rule.SoftPityStart, rule.SoftPityStep, rule.FeaturedGuarantee); err != nil {
+		return fmt.Errorf("保存保底规则失败: %v", err)
+	}
+	return nil
+}
+
+// DeletePityRule 删除某个奖品品种的保底规则及其计数
+func (db *Database) DeletePityRule(variety string) error {
+	return db.WithTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM pity_rules WHERE variety = ?`, variety)
+		if err != nil {
+			return fmt.Errorf("删除保底规则失败: %v", err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return fmt.Errorf("保底规则不存在")
+		}
+		if _, err := tx.Exec(`DELETE FROM pity_counters WHERE variety = ?`, variety); err != nil {
+			return fmt.Errorf("删除保底计数失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// SetPrizeFeatured 设置奖品是否为 UP 奖品
+func (db *Database) SetPrizeFeatured(prizeID int, featured bool) error {
+	result, err := db.db.Exec(`UPDATE prizes SET featured = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, featured, prizeID)
+	if err != nil {
+		return fmt.Errorf("更新奖品失败: %v", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("奖品不存在")
+	}
+	return nil
+}
+
+// GetPityState 获取每条保底规则对应品种在奖池中的当前保底状态，poolID 为 0 时使用默认奖池
+func (db *Database) GetPityState(poolID int) ([]PityState, error) {
+	pool, err := getPrizePool(db.db, poolID)
+	if err != nil {
+		return nil, err
+	}
+	prizes, err := getPoolPrizes(db.db, pool.ID)
+	if err != nil {
+		return nil, err
+	}
+	prizes = drawCandidates(pool, prizes)
+	rules, err := getPityRules(db.db)
+	if err != nil {
+		return nil, err
+	}
+	counters, err := getPityCounters(db.db, pool.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := applyPity(prizes, rules, counters)
+	total := 0.0
+	for _, prize := range candidates {
+		total += prize.Rate
+	}
+
+	states := make([]PityState, 0, len(rules))
+	for _, rule := range rules {
+		counter := counters[rule.Variety]
+		state := PityState{
+			Variety:            rule.Variety,
+			DrawsSinceHit:      counter.drawsSinceHit,
+			HardPity:           rule.HardPity,
+			GuaranteedFeatured: counter.guaranteedFeatured,
+		}
+		if rule.HardPity > 0 {
+			state.DrawsUntilHardPity = rule.HardPity - counter.drawsSinceHit
+			if state.DrawsUntilHardPity < 1 {
+				state.DrawsUntilHardPity = 1
+			}
+		}
+		if total > 0 {
+			varietyRate := 0.0
+			for _, prize := range candidates {
+				if prize.Variety == rule.Variety {
+					varietyRate += prize.Rate
+				}
+			}
+			state.NextRate = varietyRate / total * 100
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// ResetPityState 清空奖池的保底计数，poolID 为 0 时使用默认奖池
+func (db *Database) ResetPityState(poolID int) error {
+	pool, err := getPrizePool(db.db, poolID)
+	if err != nil {
+		return err
+	}
+	if _, err := db.db.Exec(`DELETE FROM pity_counters WHERE pool_id = ?`, pool.ID); err != nil {
+		return fmt.Errorf("重置保底计数失败: %v", err)
+	}
+	return nil
+}
+
+// getPityRules 读取所有保底规则
+func getPityRules(tx dbExecutor) ([]PityRule, error) {
+	rows, err := tx.Query(`
+	SELECT id, variety, hard_pity, soft_pity_start, soft_pity_step, featured_guarantee
+	FROM pity_rules
+	ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询保底规则失败: %v", err)
+	}
+	defer rows.Close()
+
+	rules := []PityRule{}
+	for rows.Next() {
+		var rule PityRule
+		if err := rows.Scan(&rule.ID, &rule.Variety, &rule.HardPity, &rule.SoftPityStart, &rule.SoftPityStep, &rule.FeaturedGuarantee); err != nil {
+			return nil, fmt.Errorf("扫描保底规则失败: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// getPityCounters 读取奖池的保底计数
+func getPityCounters(tx dbExecutor, poolID int) (map[string]pityCounter, error) {
+	rows, err := tx.Query(`SELECT variety, draws_since_hit, guaranteed_featured FROM pity_counters WHERE pool_id = ?`, poolID)
+	if err != nil {
+		return nil, fmt.Errorf("查询保底计数失败: %v", err)
+	}
+	defer rows.Close()
+
+	counters := make(map[string]pityCounter)
+	for rows.Next() {
+		var variety string
+		var counter pityCounter
+		if err := rows.Scan(&variety, &counter.drawsSinceHit, &counter.guaranteedFeatured); err != nil {
+			return nil, fmt.Errorf("扫描保底计数失败: %v", err)
+		}
+		counters[variety] = counter
+	}
+	return counters, rows.Err()
+}
+
+// savePityCounters 保存奖池中有保底规则的品种的计数
+func savePityCounters(tx dbExecutor, poolID int, rules []PityRule, counters map[string]pityCounter) error {
+	query := `
+	INSERT INTO pity_counters (pool_id, variety, draws_since_hit, guaranteed_featured)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT(pool_id, variety) DO UPDATE SET
+		draws_since_hit = excluded.draws_since_hit,
+		guaranteed_featured = excluded.guaranteed_featured,
+		updated_at = CURRENT_TIMESTAMP`
+	for _, rule := range rules {
+		counter := counters[rule.Variety]
+		if _, err := tx.Exec(query, poolID, rule.Variety, counter.drawsSinceHit, counter.guaranteedFeatured); err != nil {
+			return fmt.Errorf("保存保底计数失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// pityCounterSnapshot 保底计数的 JSON 形式，随抽奖历史保存以便重放
+type pityCounterSnapshot struct {
+	DrawsSinceHit      int  `json:"draws_since_hit"`
+	GuaranteedFeatured bool `json:"guaranteed_featured"`
+}
+
+// encodePityCounters 将保底计数序列化为 JSON
+func encodePityCounters(counters map[string]pityCounter) (string, error) {
+	snapshot := make(map[string]pityCounterSnapshot, len(counters))
+	for variety, counter := range counters {
+		snapshot[variety] = pityCounterSnapshot{DrawsSinceHit: counter.drawsSinceHit, GuaranteedFeatured: counter.guaranteedFeatured}
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", fmt.Errorf("序列化保底计数失败: %v", err)
+	}
+	return string(data), nil
+}
+
+// decodePityCounters 解析 encodePityCounters 生成的 JSON，空字符串表示没有计数
+func decodePityCounters(value string) (map[string]pityCounter, error) {
+	counters := make(map[string]pityCounter)
+	if value == "" {
+		return counters, nil
+	}
+	var snapshot map[string]pityCounterSnapshot
+	if err := json.Unmarshal([]byte(value), &snapshot); err != nil {
+		return nil, fmt.Errorf("解析保底计数失败: %v", err)
+	}
+	for variety, counter := range snapshot {
+		counters[variety] = pityCounter{drawsSinceHit: counter.DrawsSinceHit, guaranteedFeatured: counter.GuaranteedFeatured}
+	}
+	return counters, nil
+}
+
+// drawWithPity 按保底规则抽取一次，并就地更新 counters；返回抽中的奖品和本次使用的随机值
+func drawWithPity(prizes []PrizeInfo, rules []PityRule, counters map[string]pityCounter, rng *rand.Rand) (PrizeInfo, float64, error) {
+	roll := rng.Float64()
+	prize, err := drawByRate(applyPity(prizes, rules, counters), roll)
+	if err != nil {
+		return PrizeInfo{}, 0, err
+	}
+
+	for _, rule := range rules {
+		counter := counters[rule.Variety]
+		if rule.Variety != prize.Variety {
+			counter.drawsSinceHit++
+			counters[rule.Variety] = counter
+			continue
+		}
+
+		counter.drawsSinceHit = 0
+		if rule.FeaturedGuarantee {
+			var featured []PrizeInfo
+			for _, candidate := range prizes {
+				if candidate.Variety == rule.Variety && candidate.Featured {
+					featured = append(featured, candidate)
+				}
+			}
+			switch {
+			case len(featured) == 0 || prize.Featured:
+				counter.guaranteedFeatured = false
+			case counter.guaranteedFeatured:
+				// 上次歪了，本次替换为 UP 奖品
+				if prize, err = drawByRate(featured, rng.Float64()); err != nil {
+					return PrizeInfo{}, 0, err
+				}
+				counter.guaranteedFeatured = false
+			default:
+				counter.guaranteedFeatured = true
+			}
+		}
+		counters[rule.Variety] = counter
+	}
+	return prize, roll, nil
+}
+
+// applyPity 根据保底计数调整本次抽奖的候选奖品和爆率：
+// 达到硬保底时只从该品种中抽取（多个品种同时达到时取基础爆率最低的品种），
+// 否则对超过软保底起始次数的品种按比例提高爆率
+func applyPity(prizes []PrizeInfo, rules []PityRule, counters map[string]pityCounter) []PrizeInfo {
+	varietyRates := make(map[string]float64)
+	varietyCounts := make(map[string]int)
+	for _, prize := range prizes {
+		varietyRates[prize.Variety] += prize.Rate
+		varietyCounts[prize.Variety]++
+	}
+
+	var forced *PityRule
+	for i, rule := range rules {
+		if rule.HardPity <= 0 || varietyCounts[rule.Variety] == 0 {
+			continue
+		}
+		if counters[rule.Variety].drawsSinceHit+1 < rule.HardPity {
+			continue
+		}
+		if forced == nil || varietyRates[rule.Variety] < varietyRates[forced.Variety] {
+			forced = &rules[i]
+		}
+	}
+	if forced != nil {
+		var candidates []PrizeInfo
+		for _, prize := range prizes {
+			if prize.Variety == forced.Variety {
+				candidates = append(candidates, prize)
+			}
+		}
+		return candidates
+	}
+
+	adjusted := make([]PrizeInfo, len(prizes))
+	copy(adjusted, prizes)
+	for _, rule := range rules {
+		n := counters[rule.Variety].drawsSinceHit + 1
+		if rule.SoftPityStart <= 0 || rule.SoftPityStep <= 0 || n <= rule.SoftPityStart {
+			continue
+		}
+		bonus := rule.SoftPityStep * float64(n-rule.SoftPityStart)
+		total := varietyRates[rule.Variety]
+		for i := range adjusted {
+			if adjusted[i].Variety != rule.Variety {
+				continue
+			}
+			if total > 0 {
+				adjusted[i].Rate += bonus * adjusted[i].Rate / total
+			} else {
+				adjusted[i].Rate += bonus / float64(varietyCounts[rule.Variety])
+			}
+		}
+	}
+	return adjusted
+}
@@ -0,0 +1,128 @@
+package database
+
+import "testing"
+
+func TestPity_HardPityAndFeaturedGuarantee(t *testing.T) {
+	db := openTestDatabase(t)
+
+	if _, err := db.CreatePrize(0, "铜币", 1e9, "", "普通"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if _, err := db.CreatePrize(0, "常驻神兵", 1, "", "金"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	featuredID, err := db.CreatePrize(0, "限定神兵", 0, "", "金")
+	if err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if err := db.SetPrizeFeatured(int(featuredID), true); err != nil {
+		t.Fatalf("SetPrizeFeatured() failed: %v", err)
+	}
+	if err := db.SetPityRule(PityRule{Variety: "金", HardPity: 100, SoftPityStart: 100}); err == nil {
+		t.Fatalf("expected error when soft pity starts at hard pity")
+	}
+	if err := db.SetPityRule(PityRule{Variety: "金", HardPity: 10, FeaturedGuarantee: true}); err != nil {
+		t.Fatalf("SetPityRule() failed: %v", err)
+	}
+
+	// 金色爆率几乎为零，只能靠硬保底抽出：第一次歪到常驻，第二次必出限定
+	var golds []string
+	for round := 0; round < 2; round++ {
+		results, err := db.DrawTenPrizes(0, 0)
+		if err != nil {
+			t.Fatalf("DrawTenPrizes() failed: %v", err)
+		}
+		if results[8].PrizeVariety != "普通" || results[9].PrizeVariety != "金" {
+			t.Fatalf("expected hard pity on 10th draw, got %+v", results)
+		}
+		golds = append(golds, results[9].PrizeName)
+	}
+	if len(golds) != 2 || golds[0] != "常驻神兵" || golds[1] != "限定神兵" {
+		t.Fatalf("unexpected gold results: %v", golds)
+	}
+
+	if _, err := db.DrawPrize(0, 0); err != nil {
+		t.Fatalf("DrawPrize() failed: %v", err)
+	}
+	states, err := db.GetPityState(0)
+	if err != nil {
+		t.Fatalf("GetPityState() failed: %v", err)
+	}
+	if len(states) != 1 || states[0].GuaranteedFeatured || states[0].DrawsUntilHardPity != 9 {
+		t.Fatalf("unexpected pity state: %+v", states)
+	}
+
+	if err := db.ResetPityState(0); err != nil {
+		t.Fatalf("ResetPityState() failed: %v", err)
+	}
+	states, _ = db.GetPityState(0)
+	if states[0].DrawsSinceHit != 0 || states[0].DrawsUntilHardPity != 10 {
+		t.Fatalf("expected counters to reset, got %+v", states)
+	}
+}
+
+func TestPity_CountersArePerPool(t *testing.T) {
+	db := openTestDatabase(t)
+	banner, _ := db.CreatePrizePool("限定卡池", "", "", "", 0)
+	for _, poolID := range []int{0, int(banner)} {
+		db.CreatePrize(poolID, "铜币", 1e9, "", "普通")
+		db.CreatePrize(poolID, "神兵", 1, "", "金")
+	}
+	db.SetPityRule(PityRule{Variety: "金", HardPity: 10})
+
+	for i := 0; i < 3; i++ {
+		if _, err := db.DrawPrize(int(banner), 0); err != nil {
+			t.Fatalf("DrawPrize() failed: %v", err)
+		}
+	}
+	if states, _ := db.GetPityState(int(banner)); states[0].DrawsSinceHit != 3 {
+		t.Fatalf("expected banner counter to advance, got %+v", states)
+	}
+	if states, _ := db.GetPityState(0); states[0].DrawsSinceHit != 0 {
+		t.Fatalf("drawing on a banner must not affect the default pool, got %+v", states)
+	}
+
+	if err := db.ResetPityState(0); err != nil {
+		t.Fatalf("ResetPityState() failed: %v", err)
+	}
+	if states, _ := db.GetPityState(int(banner)); states[0].DrawsSinceHit != 3 {
+		t.Fatalf("resetting the default pool must keep banner counters, got %+v", states)
+	}
+	if err := db.DeletePrizePool(int(banner)); err != nil {
+		t.Fatalf("DeletePrizePool() failed: %v", err)
+	}
+	var count int
+	if db.db.QueryRow(`SELECT COUNT(*) FROM pity_counters`).Scan(&count); count != 0 {
+		t.Fatalf("expected counters of a deleted pool to be removed, got %d", count)
+	}
+}
+
+func TestPity_SoftPityRaisesRate(t *testing.T) {
+	prizes := []PrizeInfo{
+		{ID: 1, Name: "铜币", Rate: 99, Variety: "普通"},
+		{ID: 2, Name: "神兵", Rate: 1, Variety: "金"},
+	}
+	rules := []PityRule{{Variety: "金", HardPity: 90, SoftPityStart: 70, SoftPityStep: 5}}
+
+	rate := func(counters map[string]pityCounter) float64 {
+		adjusted := applyPity(prizes, rules, counters)
+		total := 0.0
+		for _, prize := range adjusted {
+			total += prize.Rate
+		}
+		return adjusted[1].Rate / total * 100
+	}
+
+	base := rate(map[string]pityCounter{"金": {drawsSinceHit: 69}})
+	if base != 1 {
+		t.Fatalf("rate before soft pity = %v, want 1", base)
+	}
+	boosted := rate(map[string]pityCounter{"金": {drawsSinceHit: 72}})
+	if boosted <= base {
+		t.Fatalf("expected soft pity to raise rate, got %v", boosted)
+	}
+	forced := applyPity(prizes, rules, map[string]pityCounter{"金": {drawsSinceHit: 89}})
+	if len(forced) != 1 || forced[0].Variety != "金" {
+		t.Fatalf("expected hard pity to force variety, got %+v", forced)
+	}
+}
@@ -0,0 +1,274 @@
+// 奖池（卡池）：每个奖池拥有独立的奖品列表、开放时间、单抽消耗和抽奖历史
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultPrizePoolName 默认奖池名称；迁移前的奖品和抽奖历史归入该奖池
+const DefaultPrizePoolName = "默认奖池"
+
+// prizePoolTimeLayout 奖池开放时间的存储格式
+const prizePoolTimeLayout = "2006-01-02 15:04:05"
+
+// PrizePool 奖池信息
+type PrizePool struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	StartAt     string    `json:"start_at"`  // 开放时间，为空表示不限
+	EndAt       string    `json:"end_at"`    // 结束时间，为空表示不限
+	DrawCost    int       `json:"draw_cost"` // 单抽消耗，仅供展示并记入抽奖历史，抽奖时不从任何账户扣除
+	RateMode    string    `json:"rate_mode"` // 概率模式，见 PrizeRateModeNormalized/PrizeRateModeAbsolute
+	IsDefault   bool      `json:"is_default"`
+	Seed        int64     `json:"seed"`     // 抽奖随机种子
+	Sequence    int64     `json:"sequence"` // 当前种子下已进行的抽奖次数
+	PrizeCount  int       `json:"prize_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// prizePoolColumns 查询奖池时使用的列
+const prizePoolColumns = `id, name, description, COALESCE(start_at, ''), COALESCE(end_at, ''), draw_cost, rate_mode, is_default,
+	COALESCE(rng_seed, 0), rng_sequence, (SELECT COUNT(*) FROM prizes p WHERE p.pool_id = prize_pools.id), created_at, updated_at`
+
+// GetPrizePools 获取所有奖池，默认奖池在前
+func (db *Database) GetPrizePools() ([]PrizePool, error) {
+	if _, err := defaultPrizePoolID(db.db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.db.Query(`SELECT ` + prizePoolColumns + ` FROM prize_pools ORDER BY is_default DESC, id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询奖池列表失败: %v", err)
+	}
+	defer rows.Close()
+
+	pools := []PrizePool{}
+	for rows.Next() {
+		pool, err := scanPrizePool(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描奖池数据失败: %v", err)
+		}
+		pools = append(pools, pool)
+	}
+	return pools, rows.Err()
+}
+
+// GetPrizePool 获取奖池信息，poolID 为 0 时返回默认奖池
+func (db *Database) GetPrizePool(poolID int) (PrizePool, error) {
+	return getPrizePool(db.db, poolID)
+}
+
+// CreatePrizePool 创建奖池；startAt/endAt 为空表示不限
+func (db *Database) CreatePrizePool(name, description, startAt, endAt string, drawCost int) (int64, error) {
+	name, startAt, endAt, err := normalizePrizePool(name, startAt, endAt, drawCost)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.db.Exec(`
+	INSERT INTO prize_pools (name, description, start_at, end_at, draw_cost, rng_seed)
+	VALUES (?, ?, ?, ?, ?, ?)`, name, description, nullIfEmpty(startAt), nullIfEmpty(endAt), drawCost, newDrawSeed())
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return 0, fmt.Errorf("奖池「%s」已存在", name)
+		}
+		return 0, fmt.Errorf("创建奖池失败: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// UpdatePrizePool 更新奖池信息
+func (db *Database) UpdatePrizePool(poolID int, name, description, startAt, endAt string, drawCost int) error {
+	name, startAt, endAt, err := normalizePrizePool(name, startAt, endAt, drawCost)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.db.Exec(`
+	UPDATE prize_pools
+	SET name = ?, description = ?, start_at = ?, end_at = ?, draw_cost = ?, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?`, name, description, nullIfEmpty(startAt), nullIfEmpty(endAt), drawCost, poolID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return fmt.Errorf("奖池「%s」已存在", name)
+		}
+		return fmt.Errorf("更新奖池失败: %v", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("奖池不存在")
+	}
+	return nil
+}
+
+// DeletePrizePool 删除奖池及其奖品和抽奖历史；默认奖池不能删除
+func (db *Database) DeletePrizePool(poolID int) error {
+	pool, err := getPrizePool(db.db, poolID)
+	if err != nil {
+		return err
+	}
+	if pool.IsDefault {
+		return fmt.Errorf("默认奖池不能删除")
+	}
+
+	if _, err := db.db.Exec(`DELETE FROM prize_pools WHERE id = ?`, pool.ID); err != nil {
+		return fmt.Errorf("删除奖池失败: %v", err)
+	}
+	return nil
+}
+
+// defaultPrizePoolID 获取默认奖池ID，不存在时自动创建
+func defaultPrizePoolID(tx dbExecutor) (int64, error) {
+	var id int64
+	err := tx.QueryRow(`SELECT id FROM prize_pools WHERE is_default = 1 ORDER BY id ASC LIMIT 1`).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("查询默认奖池失败: %v", err)
+	}
+
+	// 同名奖池已存在（如导入时被取消了默认标记）则直接设为默认
+	result, err := tx.Exec(`UPDATE prize_pools SET is_default = 1 WHERE name = ?`, DefaultPrizePoolName)
+	if err != nil {
+		return 0, fmt.Errorf("设置默认奖池失败: %v", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		return defaultPrizePoolID(tx)
+	}
+
+	result, err = tx.Exec(`INSERT INTO prize_pools (name, is_default, rng_seed) VALUES (?, 1, ?)`, DefaultPrizePoolName, newDrawSeed())
+	if err != nil {
+		return 0, fmt.Errorf("创建默认奖池失败: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// getPrizePool 查询奖池，poolID 为 0 时返回默认奖池
+func getPrizePool(tx dbExecutor, poolID int) (PrizePool, error) {
+	id := int64(poolID)
+	if poolID == 0 {
+		defaultID, err := defaultPrizePoolID(tx)
+		if err != nil {
+			return PrizePool{}, err
+		}
+		id = defaultID
+	}
+
+	rows, err := tx.Query(`SELECT `+prizePoolColumns+` FROM prize_pools WHERE id = ?`, id)
+	if err != nil {
+		return PrizePool{}, fmt.Errorf("查询奖池信息失败: %v", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return PrizePool{}, fmt.Errorf("查询奖池信息失败: %v", err)
+		}
+		return PrizePool{}, fmt.Errorf("奖池不存在")
+	}
+	pool, err := scanPrizePool(rows)
+	if err != nil {
+		return PrizePool{}, fmt.Errorf("查询奖池信息失败: %v", err)
+	}
+	return pool, nil
+}
+
+// getPoolPrizes 查询奖池中的奖品，按爆率从高到低排列
+func getPoolPrizes(tx dbExecutor, poolID int) ([]PrizeInfo, error) {
+	rows, err := tx.Query(`
+	SELECT id, pool_id, name, rate, description, variety, featured, consolation, quantity
+	FROM prizes
+	WHERE pool_id = ?
+	ORDER BY rate DESC, id DESC`, poolID)
+	if err != nil {
+		return nil, fmt.Errorf("查询奖品列表失败: %v", err)
+	}
+	defer rows.Close()
+
+	var prizes []PrizeInfo
+	for rows.Next() {
+		var prize PrizeInfo
+		if err := rows.Scan(&prize.ID, &prize.PoolID, &prize.Name, &prize.Rate, &prize.Description, &prize.Variety, &prize.Featured, &prize.Consolation, &prize.Quantity); err != nil {
+			return nil, fmt.Errorf("扫描奖品数据失败: %v", err)
+		}
+		prizes = append(prizes, prize)
+	}
+	return prizes, rows.Err()
+}
+
+// checkPrizePoolOpen 检查奖池在指定时间是否开放
+func checkPrizePoolOpen(pool PrizePool, now time.Time) error {
+	current := now.Format(prizePoolTimeLayout)
+	if pool.StartAt != "" && current < pool.StartAt {
+		return fmt.Errorf("奖池「%s」尚未开放（%s 开放）", pool.Name, pool.StartAt)
+	}
+	if pool.EndAt != "" && current > pool.EndAt {
+		return fmt.Errorf("奖池「%s」已于 %s 结束", pool.Name, pool.EndAt)
+	}
+	return nil
+}
+
+// normalizePrizePool 校验奖池参数，并将开放时间统一为 prizePoolTimeLayout 格式
+func normalizePrizePool(name, startAt, endAt string, drawCost int) (string, string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", "", fmt.Errorf("奖池名称不能为空")
+	}
+	if drawCost < 0 {
+		return "", "", "", fmt.Errorf("单抽消耗不能为负数")
+	}
+
+	var err error
+	if startAt, err = normalizePrizePoolTime(startAt, false); err != nil {
+		return "", "", "", err
+	}
+	if endAt, err = normalizePrizePoolTime(endAt, true); err != nil {
+		return "", "", "", err
+	}
+	if startAt != "" && endAt != "" && startAt > endAt {
+		return "", "", "", fmt.Errorf("奖池结束时间不能早于开放时间")
+	}
+	return name, startAt, endAt, nil
+}
+
+// normalizePrizePoolTime 解析开放时间，只填写日期时开放时间取当天开始、结束时间取当天结束
+func normalizePrizePoolTime(value string, endOfDay bool) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.ParseInLocation(prizePoolTimeLayout, value, time.Local); err == nil {
+		return t.Format(prizePoolTimeLayout), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", value, time.Local); err == nil {
+		return t.Format(prizePoolTimeLayout), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("无效的时间: %s（格式应为 2006-01-02 15:04:05）", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t.Format(prizePoolTimeLayout), nil
+}
+
+func scanPrizePool(rows *sql.Rows) (PrizePool, error) {
+	var pool PrizePool
+	err := rows.Scan(&pool.ID, &pool.Name, &pool.Description, &pool.StartAt, &pool.EndAt, &pool.DrawCost, &pool.RateMode, &pool.IsDefault,
+		&pool.Seed, &pool.Sequence, &pool.PrizeCount, &pool.CreatedAt, &pool.UpdatedAt)
+	return pool, err
+}
+
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"database/sql"
+	"nooltools/apps/storage"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPrizePools_SeparatePrizesHistoryAndWindow(t *testing.T) {
+	db := openTestDatabase(t)
+
+	bannerID, err := db.CreatePrizePool("限定卡池", "", "", "", 160)
+	if err != nil {
+		t.Fatalf("CreatePrizePool() failed: %v", err)
+	}
+	if _, err := db.CreatePrizePool("限定卡池", "", "", "", 0); err == nil {
+		t.Fatalf("expected error for duplicate pool name")
+	}
+	if _, err := db.CreatePrize(0, "铜币", 100, "", "普通"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if _, err := db.CreatePrize(int(bannerID), "神兵", 100, "", "金"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+
+	results, err := db.DrawTenPrizes(int(bannerID), 0)
+	if err != nil {
+		t.Fatalf("DrawTenPrizes() failed: %v", err)
+	}
+	for _, result := range results {
+		if result.PrizeName != "神兵" || result.PoolID != int(bannerID) || result.Cost != 160 {
+			t.Fatalf("draw used the wrong pool: %+v", result)
+		}
+	}
+	if result, err := db.DrawPrize(0, 0); err != nil || result.PrizeName != "铜币" {
+		t.Fatalf("DrawPrize(default) = %+v, %v", result, err)
+	}
+
+	history, err := db.GetDrawHistory(int(bannerID), 100)
+	if err != nil || len(history) != 10 {
+		t.Fatalf("GetDrawHistory(banner) = %d records, %v", len(history), err)
+	}
+	if all, _ := db.GetDrawHistory(0, 100); len(all) != 11 {
+		t.Fatalf("expected 11 records across pools, got %d", len(all))
+	}
+	if err := db.ClearDrawHistory(int(bannerID)); err != nil {
+		t.Fatalf("ClearDrawHistory() failed: %v", err)
+	}
+	if all, _ := db.GetDrawHistory(0, 100); len(all) != 1 {
+		t.Fatalf("expected only the default pool history to remain, got %d", len(all))
+	}
+
+	// 奖池结束后不能再抽
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if err := db.UpdatePrizePool(int(bannerID), "限定卡池", "", "", yesterday, 160); err != nil {
+		t.Fatalf("UpdatePrizePool() failed: %v", err)
+	}
+	if _, err := db.DrawPrize(int(bannerID), 0); err == nil {
+		t.Fatalf("expected error when drawing from a closed pool")
+	}
+	if err := db.UpdatePrizePool(int(bannerID), "限定卡池", "", "2030-01-02", "2030-01-01", 0); err == nil {
+		t.Fatalf("expected error when end is before start")
+	}
+
+	pools, err := db.GetPrizePools()
+	if err != nil || len(pools) != 2 || !pools[0].IsDefault || pools[1].PrizeCount != 1 {
+		t.Fatalf("GetPrizePools() = %+v, %v", pools, err)
+	}
+	if err := db.DeletePrizePool(pools[0].ID); err == nil {
+		t.Fatalf("expected error when deleting the default pool")
+	}
+	if err := db.DeletePrizePool(int(bannerID)); err != nil {
+		t.Fatalf("DeletePrizePool() failed: %v", err)
+	}
+	if prizes, _ := db.GetAllPrizes(); len(prizes) != 1 {
+		t.Fatalf("expected banner prizes to be deleted with the pool, got %+v", prizes)
+	}
+}
+
+func TestMigrate_MovesLegacyPrizesIntoDefaultPool(t *testing.T) {
+	dataDir := t.TempDir()
+	raw, err := sql.Open("sqlite3", filepath.Join(dataDir, storage.DatabaseFileName))
+	if err != nil {
+		t.Fatalf("failed to open raw database: %v", err)
+	}
+	if err := createBaseTables(raw); err != nil {
+		t.Fatalf("createBaseTables() failed: %v", err)
+	}
+	statements := []string{
+		`INSERT INTO prizes (id, name, rate, variety) VALUES (1, '祖符', 1.5, '传说')`,
+		`INSERT INTO draw_history (prize_id, prize_name) VALUES (1, '祖符')`,
+		// 建立奖池之前的保底计数不区分奖池
+		`CREATE TABLE pity_counters (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			variety TEXT NOT NULL UNIQUE,
+			draws_since_hit INTEGER NOT NULL DEFAULT 0,
+			guaranteed_featured INTEGER NOT NULL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`INSERT INTO pity_counters (variety, draws_since_hit, guaranteed_featured) VALUES ('传说', 7, 1)`,
+	}
+	for _, statement := range statements {
+		if _, err := raw.Exec(statement); err != nil {
+			t.Fatalf("seed failed: %v", err)
+		}
+	}
+	raw.Close()
+
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() failed: %v", err)
+	}
+	defer db.Close()
+
+	pool, err := db.GetPrizePool(0)
+	if err != nil || pool.Name != DefaultPrizePoolName || pool.PrizeCount != 1 {
+		t.Fatalf("GetPrizePool(default) = %+v, %v", pool, err)
+	}
+	history, err := db.GetDrawHistory(pool.ID, 10)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("expected legacy history in default pool, got %+v, %v", history, err)
+	}
+	db.SetPityRule(PityRule{Variety: "传说", HardPity: 10})
+	states, err := db.GetPityState(0)
+	if err != nil || len(states) != 1 || states[0].DrawsSinceHit != 7 || !states[0].GuaranteedFeatured {
+		t.Fatalf("expected legacy pity counters in the default pool, got %+v, %v", states, err)
+	}
+	if _, err := db.DrawPrize(0, 0); err != nil {
+		t.Fatalf("DrawPrize() after migration failed: %v", err)
+	}
+}
+
+func TestWorldImport_RemapsPrizePools(t *testing.T) {
+	source := openTestDatabase(t)
+	bannerID, _ := source.CreatePrizePool("限定卡池", "", "", "", 0)
+	if _, err := source.CreatePrize(int(bannerID), "神兵", 1, "", "金"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if _, err := source.CreatePrize(0, "神兵", 1, "", "金"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	doc, err := source.ExportWorld()
+	if err != nil {
+		t.Fatalf("ExportWorld() failed: %v", err)
+	}
+
+	target := openTestDatabase(t)
+	if _, err := target.CreatePrizePool("占位", "", "", "", 0); err != nil {
+		t.Fatalf("CreatePrizePool() failed: %v", err)
+	}
+	if _, err := target.ImportWorld(doc, WorldImportMerge); err != nil {
+		t.Fatalf("ImportWorld() failed: %v", err)
+	}
+
+	pools, err := target.GetPrizePools()
+	if err != nil || len(pools) != 3 {
+		t.Fatalf("GetPrizePools() = %+v, %v", pools, err)
+	}
+	// 同名奖品分属不同奖池，不能合并成一条
+	for _, pool := range pools {
+		want := 1
+		if pool.Name == "占位" {
+			want = 0
+		}
+		if pool.PrizeCount != want {
+			t.Fatalf("pool %s has %d prizes, want %d", pool.Name, pool.PrizeCount, want)
+		}
+	}
+}
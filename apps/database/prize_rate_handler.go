@@ -0,0 +1,189 @@
+// 奖品爆率校验与奖池概率模式：
+// 按比例模式下爆率只表示相对权重，抽奖时按合计折算；绝对模式下爆率即为实际概率，合计不能超过 100%，
+// 剩余概率抽中奖池的安慰奖，没有安慰奖时记为“未中奖”
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// 奖池概率模式
+const (
+	PrizeRateModeNormalized = "normalized" // 按比例：爆率按合计折算
+	PrizeRateModeAbsolute   = "absolute"   // 绝对：爆率即为实际概率
+)
+
+// NothingPrizeName 绝对模式下没有安慰奖时，剩余概率对应的结果名称；抽奖历史中该结果的奖品ID为空
+const NothingPrizeName = "未中奖"
+
+// rateEpsilon 比较爆率合计时允许的浮点误差
+const rateEpsilon = 1e-9
+
+// PrizeRateCheck 奖池爆率检查结果
+type PrizeRateCheck struct {
+	PoolID             int      `json:"pool_id"`
+	RateMode           string   `json:"rate_mode"`
+	TotalRate          float64  `json:"total_rate"`           // 奖品爆率合计（百分比）
+	Leftover           float64  `json:"leftover"`             // 绝对模式下剩余的概率（百分比），按比例模式为 0
+	ConsolationPrizeID int      `json:"consolation_prize_id"` // 安慰奖，没有时为 0
+	Warnings           []string `json:"warnings"`
+}
+
+// SetPrizePoolRateMode 设置奖池的概率模式；切换为绝对模式时奖池爆率合计不能超过 100%
+func (db *Database) SetPrizePoolRateMode(poolID int, mode string) error {
+	if mode != PrizeRateModeNormalized && mode != PrizeRateModeAbsolute {
+		return fmt.Errorf("无效的概率模式: %s", mode)
+	}
+
+	return db.WithTx(func(tx *sql.Tx) error {
+		pool, err := getPrizePool(tx, poolID)
+		if err != nil {
+			return err
+		}
+		if mode == PrizeRateModeAbsolute {
+			_, total, err := poolRateTotal(tx, pool.ID, 0)
+			if err != nil {
+				return err
+			}
+			if err := checkRateTotal(total); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`UPDATE prize_pools SET rate_mode = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, mode, pool.ID); err != nil {
+			return fmt.Errorf("设置概率模式失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// SetPrizeConsolation 设置奖品是否为所在奖池的安慰奖；每个奖池最多一个安慰奖，设置时取消同奖池其他奖品的标记
+func (db *Database) SetPrizeConsolation(prizeID int, consolation bool) error {
+	return db.WithTx(func(tx *sql.Tx) error {
+		var poolID int
+		err := tx.QueryRow(`SELECT pool_id FROM prizes WHERE id = ?`, prizeID).Scan(&poolID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("奖品不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询奖品失败: %v", err)
+		}
+
+		if consolation {
+			if _, err := tx.Exec(`UPDATE prizes SET consolation = 0 WHERE pool_id = ? AND id != ?`, poolID, prizeID); err != nil {
+				return fmt.Errorf("更新奖品失败: %v", err)
+			}
+		}
+		if _, err := tx.Exec(`UPDATE prizes SET consolation = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, consolation, prizeID); err != nil {
+			return fmt.Errorf("更新奖品失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// CheckPrizePoolRates 检查奖池的爆率配置并给出提示，poolID 为 0 时检查默认奖池
+func (db *Database) CheckPrizePoolRates(poolID int) (PrizeRateCheck, error) {
+	pool, err := getPrizePool(db.db, poolID)
+	if err != nil {
+		return PrizeRateCheck{}, err
+	}
+	prizes, err := getPoolPrizes(db.db, pool.ID)
+	if err != nil {
+		return PrizeRateCheck{}, err
+	}
+
+	check := PrizeRateCheck{PoolID: pool.ID, RateMode: pool.RateMode, Warnings: []string{}}
+	for _, prize := range prizes {
+		check.TotalRate += prize.Rate
+		if prize.Consolation {
+			check.ConsolationPrizeID = prize.ID
+		}
+	}
+
+	switch {
+	case len(prizes) == 0:
+		check.Warnings = append(check.Warnings, "奖池中没有奖品")
+	case pool.RateMode == PrizeRateModeAbsolute:
+		check.Leftover = math.Max(0, 100-check.TotalRate)
+		if check.TotalRate > 100+rateEpsilon {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("爆率合计 %.4g%% 超过 100%%", check.TotalRate))
+		} else if check.Leftover > rateEpsilon && check.ConsolationPrizeID == 0 {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("剩余 %.4g%% 的概率为“%s”", check.Leftover, NothingPrizeName))
+		}
+	case math.Abs(check.TotalRate-100) > rateEpsilon:
+		check.Warnings = append(check.Warnings, fmt.Sprintf("爆率合计为 %.4g%%，抽奖时将按比例折算", check.TotalRate))
+	}
+	return check, nil
+}
+
+// validatePrizeRate 校验单个奖品的爆率
+func validatePrizeRate(rate float64) error {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return fmt.Errorf("爆率必须是有效数字")
+	}
+	if rate < 0 {
+		return fmt.Errorf("爆率不能为负数")
+	}
+	return nil
+}
+
+// checkAbsoluteRateTotal 检查绝对模式奖池在把 excludeID 奖品的爆率改为 rate 后（excludeID 为 0 表示新增奖品），
+// 爆率合计是否超过 100%；按比例模式的奖池不做限制
+func checkAbsoluteRateTotal(tx dbExecutor, poolID, excludeID int, rate float64) error {
+	mode, total, err := poolRateTotal(tx, poolID, excludeID)
+	if err != nil {
+		return err
+	}
+	if mode == PrizeRateModeAbsolute {
+		return checkRateTotal(total + rate)
+	}
+	return nil
+}
+
+// poolRateTotal 返回奖池的概率模式和除 excludeID 外所有奖品的爆率合计
+func poolRateTotal(tx dbExecutor, poolID, excludeID int) (string, float64, error) {
+	var mode string
+	var total float64
+	err := tx.QueryRow(`
+	SELECT rate_mode, COALESCE((SELECT SUM(rate) FROM prizes WHERE pool_id = prize_pools.id AND id != ?), 0)
+	FROM prize_pools WHERE id = ?`, excludeID, poolID).Scan(&mode, &total)
+	if err == sql.ErrNoRows {
+		return "", 0, fmt.Errorf("奖池不存在")
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("查询奖池爆率失败: %v", err)
+	}
+	return mode, total, nil
+}
+
+func checkRateTotal(total float64) error {
+	if total > 100+rateEpsilon {
+		return fmt.Errorf("绝对概率模式下奖池爆率合计不能超过 100%%（当前合计 %.4g%%）", total)
+	}
+	return nil
+}
+
+// drawCandidates 返回奖池实际参与抽奖的候选项：绝对模式下把剩余概率作为安慰奖或“未中奖”追加到末尾
+func drawCandidates(pool PrizePool, prizes []PrizeInfo) []PrizeInfo {
+	if pool.RateMode != PrizeRateModeAbsolute {
+		return prizes
+	}
+
+	total := 0.0
+	leftover := PrizeInfo{PoolID: pool.ID, Name: NothingPrizeName, Variety: NothingPrizeName}
+	for _, prize := range prizes {
+		total += prize.Rate
+		if prize.Consolation {
+			leftover = prize
+		}
+	}
+	if total >= 100-rateEpsilon {
+		return prizes
+	}
+
+	leftover.Rate = 100 - total
+	candidates := make([]PrizeInfo, 0, len(prizes)+1)
+	candidates = append(candidates, prizes...)
+	return append(candidates, leftover)
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPrizeRates_ValidationAndNormalizedWarning(t *testing.T) {
+	db := openTestDatabase(t)
+
+	if _, err := db.CreatePrize(0, "负数", -1, "", "普通"); err == nil {
+		t.Fatalf("expected error for negative rate")
+	}
+	if _, err := db.CreatePrize(0, "无效", math.NaN(), "", "普通"); err == nil {
+		t.Fatalf("expected error for NaN rate")
+	}
+	id, err := db.CreatePrize(0, "铜币", 200, "", "普通")
+	if err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if _, err := db.CreatePrize(0, "灵石", 50, "", "稀有"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if err := db.UpdatePrize(int(id), "铜币", -5, "", "普通"); err == nil {
+		t.Fatalf("expected error for negative rate on update")
+	}
+
+	check, err := db.CheckPrizePoolRates(0)
+	if err != nil {
+		t.Fatalf("CheckPrizePoolRates() failed: %v", err)
+	}
+	if check.RateMode != PrizeRateModeNormalized || check.TotalRate != 250 || len(check.Warnings) != 1 {
+		t.Fatalf("unexpected check: %+v", check)
+	}
+
+	// 合计超过 100% 时不能切换为绝对模式
+	if err := db.SetPrizePoolRateMode(check.PoolID, PrizeRateModeAbsolute); err == nil {
+		t.Fatalf("expected error when switching an over-100%% pool to absolute mode")
+	}
+	if err := db.SetPrizePoolRateMode(check.PoolID, "weird"); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
+
+func TestPrizeRates_AbsoluteModeLeftover(t *testing.T) {
+	db := openTestDatabase(t)
+	pool, _ := db.GetPrizePool(0)
+	if err := db.SetPrizePoolRateMode(pool.ID, PrizeRateModeAbsolute); err != nil {
+		t.Fatalf("SetPrizePoolRateMode() failed: %v", err)
+	}
+
+	goldID, err := db.CreatePrize(0, "神兵", 0, "", "金")
+	if err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if _, err := db.CreatePrize(0, "灵石", 101, "", "稀有"); err == nil {
+		t.Fatalf("expected error when absolute total exceeds 100%%")
+	}
+	if err := db.UpdatePrize(int(goldID), "神兵", 100.5, "", "金"); err == nil {
+		t.Fatalf("expected error when update pushes absolute total over 100%%")
+	}
+
+	// 剩余概率为 100%，没有安慰奖时全部未中奖
+	results, err := db.DrawTenPrizes(0, 0)
+	if err != nil {
+		t.Fatalf("DrawTenPrizes() failed: %v", err)
+	}
+	for _, result := range results {
+		if result.PrizeID != 0 || result.PrizeName != NothingPrizeName {
+			t.Fatalf("expected nothing outcome, got %+v", result)
+		}
+	}
+	history, err := db.GetDrawHistory(pool.ID, 20)
+	if err != nil || len(history) != 10 || history[0].PrizeName != NothingPrizeName {
+		t.Fatalf("GetDrawHistory() = %+v, %v", history, err)
+	}
+	check, _ := db.CheckPrizePoolRates(0)
+	if check.Leftover != 100 || len(check.Warnings) != 1 {
+		t.Fatalf("unexpected check: %+v", check)
+	}
+
+	// 设置安慰奖后剩余概率抽中安慰奖
+	consolationID, _ := db.CreatePrize(0, "铜币", 0, "", "普通")
+	if err := db.SetPrizeConsolation(int(consolationID), true); err != nil {
+		t.Fatalf("SetPrizeConsolation() failed: %v", err)
+	}
+	result, err := db.DrawPrize(0, 0)
+	if err != nil || result.PrizeID != int(consolationID) {
+		t.Fatalf("expected consolation prize, got %+v, %v", result, err)
+	}
+	check, _ = db.CheckPrizePoolRates(0)
+	if check.ConsolationPrizeID != int(consolationID) || len(check.Warnings) != 0 {
+		t.Fatalf("unexpected check: %+v", check)
+	}
+
+	stats, err := db.GetDrawStatistics(0)
+	if err != nil {
+		t.Fatalf("GetDrawStatistics() failed: %v", err)
+	}
+	for _, variety := range stats.Varieties {
+		if variety.Name == NothingPrizeName && variety.Hits != 10 {
+			t.Fatalf("expected 10 nothing outcomes in statistics, got %+v", variety)
+		}
+	}
+}
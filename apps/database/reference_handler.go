@@ -0,0 +1,289 @@
+// 武器/道具持有者、宠物主人、人物所属势力与实体记录之间的关联
+// 关联ID列可为空：引用的记录删除后自动置空；原有的文本列继续保存名称用于显示
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// entityReference 可选的实体引用列
+type entityReference struct {
+	table      string // 引用方表
+	column     string // 引用ID列
+	textColumn string // 同步保存被引用记录名称的文本列
+	parent     string // 被引用的表
+}
+
+// 各引用列的定义
+var (
+	weaponHolderReference   = entityReference{table: "wuqi", column: "holder_renwu_id", textColumn: "holder", parent: "renwu"}
+	daojuHolderReference    = entityReference{table: "daoju", column: "holder_renwu_id", textColumn: "holder", parent: "renwu"}
+	petOwnerReference       = entityReference{table: "chongwu", column: "owner_renwu_id", textColumn: "owner", parent: "renwu"}
+	characterShiliReference = entityReference{table: "renwu", column: "shili_id", textColumn: "shili", parent: "shili"}
+	shiliFounderReference   = entityReference{table: "shili", column: "founder_renwu_id", textColumn: "founder", parent: "renwu"}
+)
+
+// entityReferences 所有可选的实体引用列；世界数据导入时据此重新映射ID
+var entityReferences = []entityReference{
+	weaponHolderReference,
+	daojuHolderReference,
+	petOwnerReference,
+	characterShiliReference,
+	shiliFounderReference,
+}
+
+// characterReferences 引用人物的列，人物改名时需要同步文本列
+var characterReferences = []entityReference{
+	weaponHolderReference,
+	daojuHolderReference,
+	petOwnerReference,
+	shiliFounderReference,
+}
+
+// CharacterBelonging 人物拥有的武器、道具或宠物
+type CharacterBelonging struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+}
+
+// CharacterFaction 人物所属势力、成员身份及其在势力中担任的职务
+type CharacterFaction struct {
+	ShiliID   int             `json:"shili_id"`
+	Name      string          `json:"name"`
+	Role      string          `json:"role"`
+	Positions []ShiliPosition `json:"positions"`
+}
+
+// resolveReferenceID 按名称查找被引用的记录；只有唯一匹配时才返回ID，否则返回 nil
+func resolveReferenceID(tx dbExecutor, parent, name string) (interface{}, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	rows, err := tx.Query(`SELECT id FROM `+parent+` WHERE name = ? LIMIT 2`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) != 1 {
+		return nil, nil
+	}
+	return ids[0], nil
+}
+
+// setEntityReference 设置引用列并同步文本列；targetID 为 0 时清除关联
+func (d *Database) setEntityReference(ref entityReference, id, targetID int, notFound, targetNotFound string) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		var reference interface{}
+		name := ""
+		if targetID != 0 {
+			err := tx.QueryRow(`SELECT name FROM `+ref.parent+` WHERE id = ?`, targetID).Scan(&name)
+			if err == sql.ErrNoRows {
+				return errors.New(targetNotFound)
+			}
+			if err != nil {
+				return fmt.Errorf("查询关联记录失败: %v", err)
+			}
+			reference = targetID
+		}
+
+		query := `UPDATE ` + ref.table + ` SET ` + ref.column + ` = ?, ` + ref.textColumn + ` = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+		result, err := tx.Exec(query, reference, name, id)
+		if err != nil {
+			return fmt.Errorf("更新关联失败: %v", err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return errors.New(notFound)
+		}
+		return nil
+	})
+}
+
+// SetWeaponHolder 设置武器的持有人物，characterID 为 0 时清除持有者
+func (d *Database) SetWeaponHolder(weaponID, characterID int) error {
+	return d.setEntityReference(weaponHolderReference, weaponID, characterID, "武器不存在", "人物不存在")
+}
+
+// SetDaojuHolder 设置道具的持有人物，characterID 为 0 时清除持有者
+func (d *Database) SetDaojuHolder(daojuID, characterID int) error {
+	return d.setEntityReference(daojuHolderReference, daojuID, characterID, "道具不存在", "人物不存在")
+}
+
+// SetPetOwner 设置宠物的主人，characterID 为 0 时清除主人
+func (d *Database) SetPetOwner(petID, characterID int) error {
+	return d.setEntityReference(petOwnerReference, petID, characterID, "宠物不存在", "人物不存在")
+}
+
+// SetCharacterShili 设置人物所属势力并同步势力成员关系，shiliID 为 0 时退出所属势力
+func (d *Database) SetCharacterShili(characterID, shiliID int) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRow(`SELECT 1 FROM renwu WHERE id = ?`, characterID).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("人物不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询人物信息失败: %v", err)
+		}
+
+		if shiliID == 0 {
+			if err := setCharacterMembership(tx, characterID, nil); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`UPDATE renwu SET shili_id = NULL, shili = '', updated_at = CURRENT_TIMESTAMP WHERE id = ?`, characterID)
+			if err != nil {
+				return fmt.Errorf("更新人物所属势力失败: %v", err)
+			}
+			return nil
+		}
+		return setCharacterMembership(tx, characterID, int64(shiliID))
+	})
+}
+
+// relinkEntityReference 文本列被直接改写（如 CSV 导入）后，若已关联记录的名称与文本不一致，则按文本重新解析关联
+func relinkEntityReference(tx dbExecutor, ref entityReference, id int64) error {
+	var text string
+	var current sql.NullInt64
+	query := `SELECT COALESCE(` + ref.textColumn + `, ''), ` + ref.column + ` FROM ` + ref.table + ` WHERE id = ?`
+	if err := tx.QueryRow(query, id).Scan(&text, &current); err != nil {
+		return fmt.Errorf("查询关联失败: %v", err)
+	}
+
+	if current.Valid {
+		var name string
+		err := tx.QueryRow(`SELECT name FROM `+ref.parent+` WHERE id = ?`, current.Int64).Scan(&name)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("查询关联记录失败: %v", err)
+		}
+		if err == nil && name == text {
+			return nil
+		}
+	}
+
+	resolved, err := resolveReferenceID(tx, ref.parent, text)
+	if err != nil {
+		return fmt.Errorf("查询关联记录失败: %v", err)
+	}
+	if _, err := tx.Exec(`UPDATE `+ref.table+` SET `+ref.column+` = ? WHERE id = ?`, resolved, id); err != nil {
+		return fmt.Errorf("更新关联失败: %v", err)
+	}
+	return nil
+}
+
+// syncCharacterReferenceNames 人物改名后同步所有关联到该人物的文本列
+func syncCharacterReferenceNames(tx dbExecutor, characterID int, name string) error {
+	for _, ref := range characterReferences {
+		query := `UPDATE ` + ref.table + ` SET ` + ref.textColumn + ` = ? WHERE ` + ref.column + ` = ?`
+		if _, err := tx.Exec(query, name, characterID); err != nil {
+			return fmt.Errorf("同步表 %s 的人物名称失败: %v", ref.table, err)
+		}
+	}
+	return nil
+}
+
+// clearDanglingReferences 将指向已不存在记录的可空引用列置空，避免所在记录被当作孤立数据删除；
+// 处理范围为表结构中所有声明为 ON DELETE SET NULL 的外键列
+func clearDanglingReferences(tx dbExecutor) error {
+	refs, err := setNullReferences(tx)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		query := `UPDATE ` + ref.table + ` SET ` + ref.column + ` = NULL
+		WHERE ` + ref.column + ` IS NOT NULL AND ` + ref.column + ` NOT IN (SELECT id FROM ` + ref.parent + `)`
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("清理表 %s 的失效关联失败: %v", ref.table, err)
+		}
+	}
+	return nil
+}
+
+// setNullReferences 从表结构中读取所有声明为 ON DELETE SET NULL 的外键列
+func setNullReferences(tx dbExecutor) ([]entityReference, error) {
+	rows, err := tx.Query(`
+	SELECT m.name, f."from", f."table"
+	FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) f
+	WHERE m.type = 'table' AND f.on_delete = 'SET NULL'
+	ORDER BY m.name, f.id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询外键定义失败: %v", err)
+	}
+	defer rows.Close()
+
+	var refs []entityReference
+	for rows.Next() {
+		var ref entityReference
+		if err := rows.Scan(&ref.table, &ref.column, &ref.parent); err != nil {
+			return nil, fmt.Errorf("扫描外键定义失败: %v", err)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// getCharacterBelongings 查询关联到人物的武器、道具或宠物
+func (d *Database) getCharacterBelongings(ref entityReference, characterID int) ([]CharacterBelonging, error) {
+	query := `SELECT id, name, level FROM ` + ref.table + ` WHERE ` + ref.column + ` = ? ORDER BY id ASC`
+
+	rows, err := d.db.Query(query, characterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	belongings := []CharacterBelonging{}
+	for rows.Next() {
+		var belonging CharacterBelonging
+		if err := rows.Scan(&belonging.ID, &belonging.Name, &belonging.Level); err != nil {
+			return nil, err
+		}
+		belongings = append(belongings, belonging)
+	}
+	return belongings, rows.Err()
+}
+
+// getCharacterFaction 查询人物所属势力及其担任的职务，未关联势力时返回 nil
+func (d *Database) getCharacterFaction(characterID int) (*CharacterFaction, error) {
+	var faction CharacterFaction
+	var characterName string
+	err := d.db.QueryRow(`
+	SELECT s.id, s.name, COALESCE(m.role, ''), r.name
+	FROM renwu r
+	JOIN shili s ON s.id = r.shili_id
+	LEFT JOIN shili_members m ON m.renwu_id = r.id AND m.shili_id = s.id
+	WHERE r.id = ?`, characterID).Scan(&faction.ShiliID, &faction.Name, &faction.Role, &characterName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := d.GetShiliPositions(faction.ShiliID)
+	if err != nil {
+		return nil, err
+	}
+	faction.Positions = []ShiliPosition{}
+	for _, position := range positions {
+		if position.PersonName == characterName {
+			faction.Positions = append(faction.Positions, position)
+		}
+	}
+	return &faction, nil
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestMigrateLinkEntityReferences_ResolvesUniqueNames(t *testing.T) {
+	db := openTestDatabase(t)
+	raw := db.GetDB()
+
+	// 模拟迁移前的数据：只有文本列，关联ID为空；“阿三”重名，无法唯一解析
+	statements := []string{
+		`INSERT INTO shili (id, name) VALUES (1, '武府')`,
+		`INSERT INTO renwu (id, name, shili) VALUES (1, '林动', '武府'), (2, '阿三', ''), (3, '阿三', '')`,
+		`INSERT INTO wuqi (name, holder) VALUES ('青锋剑', '林动'), ('木棍', '阿三'), ('断刀', '路人')`,
+		`INSERT INTO daoju (name, holder) VALUES ('祖石', '林动')`,
+		`INSERT INTO chongwu (name, owner) VALUES ('小貂', '林动')`,
+	}
+	for _, statement := range statements {
+		if _, err := raw.Exec(statement); err != nil {
+			t.Fatalf("seed failed: %v", err)
+		}
+	}
+
+	err := db.WithTx(func(tx *sql.Tx) error {
+		return migrateLinkEntityReferences(tx)
+	})
+	if err != nil {
+		t.Fatalf("migrateLinkEntityReferences() failed: %v", err)
+	}
+
+	info, err := db.GetCharacterInfo(1)
+	if err != nil {
+		t.Fatalf("GetCharacterInfo() failed: %v", err)
+	}
+	if info.ShiliID == nil || *info.ShiliID != 1 {
+		t.Fatalf("expected shili to be linked, got %+v", info.ShiliID)
+	}
+	if len(info.Weapons) != 1 || info.Weapons[0].Name != "青锋剑" || len(info.Items) != 1 || len(info.Pets) != 1 {
+		t.Fatalf("unexpected belongings: weapons=%+v items=%+v pets=%+v", info.Weapons, info.Items, info.Pets)
+	}
+
+	var unlinked int
+	if err := raw.QueryRow(`SELECT COUNT(*) FROM wuqi WHERE holder_renwu_id IS NULL`).Scan(&unlinked); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if unlinked != 2 {
+		t.Fatalf("expected ambiguous and unknown holders to stay unlinked, got %d", unlinked)
+	}
+}
+
+func TestCharacterReferences_RenameDeleteAndFaction(t *testing.T) {
+	db := openTestDatabase(t)
+
+	shiliID, err := db.CreateShili("武府", "林震天", 1, 0, 10)
+	if err != nil {
+		t.Fatalf("CreateShili() failed: %v", err)
+	}
+	characterID, err := db.CreateCharacter("林动", "武府", 100, 1)
+	if err != nil {
+		t.Fatalf("CreateCharacter() failed: %v", err)
+	}
+	if err := db.AddShiliPosition(int(shiliID), "少主", "林动", ""); err != nil {
+		t.Fatalf("AddShiliPosition() failed: %v", err)
+	}
+	weaponID, err := db.CreateWeapon("青锋剑", "林动", 1)
+	if err != nil {
+		t.Fatalf("CreateWeapon() failed: %v", err)
+	}
+	petID, err := db.CreatePet("小貂", "", 1)
+	if err != nil {
+		t.Fatalf("CreatePet() failed: %v", err)
+	}
+	if err := db.SetPetOwner(petID, characterID); err != nil {
+		t.Fatalf("SetPetOwner() failed: %v", err)
+	}
+	if err := db.SetPetOwner(petID, 999); err == nil {
+		t.Fatalf("expected error for missing character")
+	}
+
+	if err := db.UpdateCharacterBasicInfo(characterID, "林动天", "武府", 100, 2); err != nil {
+		t.Fatalf("UpdateCharacterBasicInfo() failed: %v", err)
+	}
+	weapon, err := db.GetWeaponInfo(weaponID)
+	if err != nil || weapon.Holder != "林动天" {
+		t.Fatalf("holder name not synced after rename: %+v, %v", weapon, err)
+	}
+	pet, err := db.GetPetInfo(petID)
+	if err != nil || pet.Owner != "林动天" {
+		t.Fatalf("owner name not synced after rename: %+v, %v", pet, err)
+	}
+
+	// 只改等级、持有者文本不变时保留关联
+	if err := db.UpdateWeaponBasicInfo(weaponID, "青锋剑", "林动天", 3); err != nil {
+		t.Fatalf("UpdateWeaponBasicInfo() failed: %v", err)
+	}
+
+	info, err := db.GetCharacterInfo(characterID)
+	if err != nil {
+		t.Fatalf("GetCharacterInfo() failed: %v", err)
+	}
+	if len(info.Weapons) != 1 || info.Weapons[0].Level != 3 || len(info.Pets) != 1 {
+		t.Fatalf("unexpected belongings: weapons=%+v pets=%+v", info.Weapons, info.Pets)
+	}
+	if info.Faction == nil || info.Faction.Name != "武府" || len(info.Faction.Positions) != 0 {
+		// 人物已改名，职务表中的旧名称不再匹配
+		t.Fatalf("unexpected faction: %+v", info.Faction)
+	}
+	if err := db.UpdateCharacterBasicInfo(characterID, "林动", "武府", 100, 2); err != nil {
+		t.Fatalf("UpdateCharacterBasicInfo() failed: %v", err)
+	}
+	info, _ = db.GetCharacterInfo(characterID)
+	if info.Faction == nil || len(info.Faction.Positions) != 1 || info.Faction.Positions[0].PositionName != "少主" {
+		t.Fatalf("expected position in faction, got %+v", info.Faction)
+	}
+
+	if err := db.DeleteCharacter(characterID, CharacterBeibaoDisposal{}); err != nil {
+		t.Fatalf("DeleteCharacter() failed: %v", err)
+	}
+	if _, err := db.GetWeaponInfo(weaponID); err != nil {
+		t.Fatalf("weapon should survive holder deletion: %v", err)
+	}
+	var holderID sql.NullInt64
+	if err := db.GetDB().QueryRow(`SELECT holder_renwu_id FROM wuqi WHERE id = ?`, weaponID).Scan(&holderID); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if holderID.Valid {
+		t.Fatalf("expected holder link to be cleared, got %d", holderID.Int64)
+	}
+}
+
+func TestWorldImport_RemapsEntityReferences(t *testing.T) {
+	source := openTestDatabase(t)
+	if _, err := source.CreateShili("武府", "", 1, 0, 10); err != nil {
+		t.Fatalf("CreateShili() failed: %v", err)
+	}
+	if _, err := source.CreateCharacter("林动", "武府", 100, 1); err != nil {
+		t.Fatalf("CreateCharacter() failed: %v", err)
+	}
+	if _, err := source.CreateWeapon("青锋剑", "林动", 1); err != nil {
+		t.Fatalf("CreateWeapon() failed: %v", err)
+	}
+
+	doc, err := source.ExportWorld()
+	if err != nil {
+		t.Fatalf("ExportWorld() failed: %v", err)
+	}
+
+	target := openTestDatabase(t)
+	// 占用ID，确保关联列必须重新映射
+	if _, err := target.CreateCharacter("占位", "", 100, 1); err != nil {
+		t.Fatalf("CreateCharacter() failed: %v", err)
+	}
+	if _, err := target.CreateShili("占位势力", "", 1, 0, 10); err != nil {
+		t.Fatalf("CreateShili() failed: %v", err)
+	}
+	if _, err := target.ImportWorld(doc, WorldImportMerge); err != nil {
+		t.Fatalf("ImportWorld() failed: %v", err)
+	}
+
+	characters, err := target.GetAllCharacters()
+	if err != nil || len(characters) != 2 {
+		t.Fatalf("GetAllCharacters() = %v, %v", characters, err)
+	}
+	info, err := target.GetCharacterInfo(characters[1]["id"].(int))
+	if err != nil {
+		t.Fatalf("GetCharacterInfo() failed: %v", err)
+	}
+	if len(info.Weapons) != 1 || info.Faction == nil || info.Faction.Name != "武府" {
+		t.Fatalf("references not remapped: weapons=%+v faction=%+v", info.Weapons, info.Faction)
+	}
+}
@@ -0,0 +1,505 @@
+// 全局全文搜索：把各类实体与 markdown 笔记汇总到 FTS5 索引中，按相关度返回带类型的结果。
+// 索引是可随时重建的派生数据：实体表上的触发器在数据变化时标记索引失效，
+// markdown 目录按文件签名判断是否变化，搜索前按需整体重建。
+// FTS5 需要以 sqlite_fts5 构建标签编译驱动；不可用时退化为普通表加 LIKE 匹配。
+package database
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 搜索结果的实体类型，与表名一致；markdown 笔记单独一类
+const SearchKindMarkdown = "markdown"
+
+// 搜索结果数量限制
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// 索引表：FTS5 可用时使用虚拟表，否则使用普通表
+const (
+	searchFTSTable   = "search_fts"
+	searchPlainTable = "search_docs"
+)
+
+// trigramLength trigram 分词器能够 MATCH 的最短词长（按字符计）
+const trigramLength = 3
+
+// snippetRadius 摘要中命中词前后保留的字符数
+const snippetRadius = 24
+
+// searchSource 一类实体的索引来源，查询返回 id、标题、正文
+type searchSource struct {
+	kind  string
+	query string
+}
+
+// searchSources 参与全局搜索的实体及其索引内容
+var searchSources = []searchSource{
+	{"renwu", `
+	SELECT r.id, r.name, COALESCE(r.shili, '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(a.name || ' ' || COALESCE(a.description, ''), ' ') FROM renwu_attributes a WHERE a.renwu_id = r.id), '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(s.name || ' ' || COALESCE(s.description, ''), ' ') FROM renwu_skills s WHERE s.renwu_id = r.id), '')
+	FROM renwu r`},
+	{"wuqi", `
+	SELECT w.id, w.name, COALESCE(w.holder, '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(a.name || ' ' || COALESCE(a.description, ''), ' ') FROM wuqi_attributes a WHERE a.wuqi_id = w.id), '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(s.name || ' ' || COALESCE(s.description, ''), ' ') FROM wuqi_skills s WHERE s.wuqi_id = w.id), '')
+	FROM wuqi w`},
+	{"chongwu", `
+	SELECT c.id, c.name, COALESCE(c.owner, '') || ' ' || COALESCE(c.attributes, '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(a.name || ' ' || COALESCE(a.description, ''), ' ') FROM chongwu_attributes a WHERE a.chongwu_id = c.id), '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(s.name || ' ' || COALESCE(s.description, ''), ' ') FROM chongwu_skills s WHERE s.chongwu_id = c.id), '')
+	FROM chongwu c`},
+	{"daoju", `
+	SELECT d.id, d.name, COALESCE(d.function, '') || ' ' || COALESCE(d.holder, '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(f.name || ' ' || COALESCE(f.description, ''), ' ') FROM daoju_functions f WHERE f.daoju_id = d.id), '')
+	FROM daoju d`},
+	{"shiqing", `
+	SELECT q.id, q.name, COALESCE(q.details, '') || ' ' || COALESCE(q.time, '') || ' ' || COALESCE(q.rewards, '') || ' ' ||
+		COALESCE(q.location, '') || ' ' || COALESCE(q.participants, '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(dt.description, ' ') FROM shiqing_details dt WHERE dt.shiqing_id = q.id), '')
+	FROM shiqing q`},
+	{"shili", `
+	SELECT s.id, s.name, COALESCE(s.founder, '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(p.position_name || ' ' || p.person_name || ' ' || COALESCE(p.description, ''), ' ') FROM shili_positions p WHERE p.shili_id = s.id), '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(a.name || ' ' || COALESCE(a.description, ''), ' ') FROM shili_attributes a WHERE a.shili_id = s.id), '')
+	FROM shili s`},
+	{"guaiwu", `
+	SELECT g.id, g.name, COALESCE(g.type, '') || ' ' || COALESCE(g.rewards, '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(a.name || ' ' || COALESCE(a.description, ''), ' ') FROM guaiwu_attributes a WHERE a.guaiwu_id = g.id), '') || ' ' ||
+		COALESCE((SELECT GROUP_CONCAT(s.name || ' ' || COALESCE(s.description, ''), ' ') FROM guaiwu_skills s WHERE s.guaiwu_id = g.id), '')
+	FROM guaiwu g`},
+	{"beibao", `
+	SELECT b.id, b.name,
+		COALESCE((SELECT GROUP_CONCAT(i.name || ' ' || COALESCE(i.description, ''), ' ') FROM beibao_items i WHERE i.beibao_id = b.id), '')
+	FROM beibao b`},
+	{"shopping", `
+	SELECT s.id, s.name, COALESCE(s.description, '') || ' ' || COALESCE(s.condition, '')
+	FROM shopping s`},
+	{"prizes", `
+	SELECT p.id, p.name, COALESCE(p.description, '') || ' ' || COALESCE(p.variety, '')
+	FROM prizes p`},
+}
+
+// SearchFilters 搜索过滤条件
+type SearchFilters struct {
+	Kinds []string `json:"kinds"` // 限定实体类型，为空表示全部
+	Limit int      `json:"limit"` // 最多返回的条数，默认 50，最大 200
+}
+
+// SearchResult 单条搜索结果
+type SearchResult struct {
+	Kind    string  `json:"kind"`
+	ID      int64   `json:"id"`   // 实体ID，markdown 笔记为 0
+	Path    string  `json:"path"` // markdown 笔记的文件名
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"` // 命中内容摘要，已转义 HTML，命中词用 <mark> 标出
+	Score   float64 `json:"score"`   // 相关度，越大越相关
+}
+
+// GetSearchKinds 获取可用于过滤的实体类型
+func GetSearchKinds() []string {
+	kinds := make([]string, 0, len(searchSources)+1)
+	for _, source := range searchSources {
+		kinds = append(kinds, source.kind)
+	}
+	return append(kinds, SearchKindMarkdown)
+}
+
+// createSearchTriggers 为表创建使搜索索引失效的触发器；重建表后需要重新调用
+func createSearchTriggers(tx dbExecutor, tableName string) error {
+	for _, event := range []string{"INSERT", "UPDATE", "DELETE"} {
+		name := `search_dirty_` + tableName + `_` + strings.ToLower(event)
+		query := `CREATE TRIGGER IF NOT EXISTS ` + name + ` AFTER ` + event + ` ON ` + tableName + `
+		BEGIN
+			UPDATE search_index_state SET dirty = 1 WHERE dirty = 0;
+		END`
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("创建表 %s 的搜索触发器失败: %v", tableName, err)
+		}
+	}
+	return nil
+}
+
+// GlobalSearch 在所有实体和 markdown 笔记中搜索，结果按相关度排序
+func (d *Database) GlobalSearch(query string, filters SearchFilters) ([]SearchResult, error) {
+	terms := splitSearchTerms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	kinds := make(map[string]bool)
+	for _, kind := range filters.Kinds {
+		if !containsString(GetSearchKinds(), kind) {
+			return nil, fmt.Errorf("未知的搜索类型: %s", kind)
+		}
+		kinds[kind] = true
+	}
+
+	limit := filters.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	d.searchMu.Lock()
+	defer d.searchMu.Unlock()
+
+	table, err := d.refreshSearchIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions []string
+	var args []interface{}
+	useMatch := table == searchFTSTable
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < trigramLength {
+			useMatch = false
+		}
+	}
+	if useMatch {
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		}
+		conditions = append(conditions, searchFTSTable+` MATCH ?`)
+		args = append(args, strings.Join(quoted, " "))
+	} else {
+		for _, term := range terms {
+			conditions = append(conditions, `(title LIKE ? ESCAPE '\' OR body LIKE ? ESCAPE '\')`)
+			pattern := "%" + escapeLikePattern(term) + "%"
+			args = append(args, pattern, pattern)
+		}
+	}
+	if len(kinds) > 0 {
+		placeholders := make([]string, 0, len(kinds))
+		for kind := range kinds {
+			placeholders = append(placeholders, "?")
+			args = append(args, kind)
+		}
+		conditions = append(conditions, `kind IN (`+strings.Join(placeholders, ", ")+`)`)
+	}
+
+	// MATCH 查询直接按 bm25 排序并在数据库中截断；LIKE 查询取出全部命中后在内存中打分
+	selectQuery := `SELECT kind, entity_id, path, title, body, 0 FROM ` + table + ` WHERE ` + strings.Join(conditions, " AND ")
+	if useMatch {
+		selectQuery = `SELECT kind, entity_id, path, title, body, -bm25(` + searchFTSTable + `, 0, 0, 0, 10.0, 1.0)
+		FROM ` + searchFTSTable + ` WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY bm25(` + searchFTSTable + `, 0, 0, 0, 10.0, 1.0) LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := d.db.Query(selectQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("搜索失败: %v", err)
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		var body string
+		if err := rows.Scan(&result.Kind, &result.ID, &result.Path, &result.Title, &body, &result.Score); err != nil {
+			return nil, fmt.Errorf("扫描搜索结果失败: %v", err)
+		}
+		if !useMatch {
+			result.Score = scoreSearchText(result.Title, body, terms)
+		}
+		result.Snippet = makeSearchSnippet(body, terms)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取搜索结果失败: %v", err)
+	}
+
+	if !useMatch {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Score > results[j].Score
+		})
+		if len(results) > limit {
+			results = results[:limit]
+		}
+	}
+	return results, nil
+}
+
+// RebuildSearchIndex 立即重建搜索索引
+func (d *Database) RebuildSearchIndex() error {
+	d.searchMu.Lock()
+	defer d.searchMu.Unlock()
+
+	table, err := d.ensureSearchTable()
+	if err != nil {
+		return err
+	}
+	signature, err := d.markdownSignature()
+	if err != nil {
+		return err
+	}
+	return d.rebuildSearchIndex(table, signature)
+}
+
+// refreshSearchIndex 索引失效时重建，返回使用的索引表
+func (d *Database) refreshSearchIndex() (string, error) {
+	table, err := d.ensureSearchTable()
+	if err != nil {
+		return "", err
+	}
+
+	var dirty int
+	var storedSignature string
+	err = d.db.QueryRow(`SELECT dirty, markdown_signature FROM search_index_state WHERE id = 1`).Scan(&dirty, &storedSignature)
+	if err != nil {
+		return "", fmt.Errorf("读取搜索索引状态失败: %v", err)
+	}
+	signature, err := d.markdownSignature()
+	if err != nil {
+		return "", err
+	}
+	if dirty == 0 && signature == storedSignature {
+		return table, nil
+	}
+	return table, d.rebuildSearchIndex(table, signature)
+}
+
+// ensureSearchTable 创建索引表：优先使用 trigram 分词的 FTS5 虚拟表，使中文无需分词即可检索
+func (d *Database) ensureSearchTable() (string, error) {
+	_, err := d.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS ` + searchFTSTable + ` USING fts5(
+		kind UNINDEXED, entity_id UNINDEXED, path UNINDEXED, title, body,
+		tokenize = 'trigram'
+	)`)
+	if err == nil {
+		return searchFTSTable, nil
+	}
+	if !strings.Contains(err.Error(), "no such module") {
+		return "", fmt.Errorf("创建搜索索引失败: %v", err)
+	}
+
+	_, err = d.db.Exec(`CREATE TABLE IF NOT EXISTS ` + searchPlainTable + ` (
+		kind TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		path TEXT NOT NULL DEFAULT '',
+		title TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL DEFAULT ''
+	)`)
+	if err != nil {
+		return "", fmt.Errorf("创建搜索索引失败: %v", err)
+	}
+	return searchPlainTable, nil
+}
+
+// rebuildSearchIndex 清空并重新写入全部索引内容
+func (d *Database) rebuildSearchIndex(table, signature string) error {
+	notes, err := d.readMarkdownNotes()
+	if err != nil {
+		return err
+	}
+
+	return d.WithTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+			return fmt.Errorf("清空搜索索引失败: %v", err)
+		}
+
+		insert := `INSERT INTO ` + table + ` (kind, entity_id, path, title, body) VALUES (?, ?, ?, ?, ?)`
+		for _, source := range searchSources {
+			documents, err := selectSearchDocuments(tx, source.query)
+			if err != nil {
+				return fmt.Errorf("读取表 %s 的搜索内容失败: %v", source.kind, err)
+			}
+			for _, document := range documents {
+				if _, err := tx.Exec(insert, source.kind, document.id, "", document.title, document.body); err != nil {
+					return fmt.Errorf("写入搜索索引失败: %v", err)
+				}
+			}
+		}
+
+		for _, note := range notes {
+			if _, err := tx.Exec(insert, SearchKindMarkdown, 0, note.Name, note.Title, note.Content); err != nil {
+				return fmt.Errorf("写入搜索索引失败: %v", err)
+			}
+		}
+
+		_, err := tx.Exec(`UPDATE search_index_state SET dirty = 0, markdown_signature = ? WHERE id = 1`, signature)
+		if err != nil {
+			return fmt.Errorf("更新搜索索引状态失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// searchDocument 索引来源查询得到的一条记录
+type searchDocument struct {
+	id    int64
+	title string
+	body  string
+}
+
+// selectSearchDocuments 执行索引来源查询
+func selectSearchDocuments(tx dbExecutor, query string) ([]searchDocument, error) {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []searchDocument
+	for rows.Next() {
+		var document searchDocument
+		var title, body sql.NullString
+		if err := rows.Scan(&document.id, &title, &body); err != nil {
+			return nil, err
+		}
+		document.title = title.String
+		document.body = body.String
+		documents = append(documents, document)
+	}
+	return documents, rows.Err()
+}
+
+// readMarkdownNotes 读取 markdown 目录中的全部笔记内容
+func (d *Database) readMarkdownNotes() ([]MarkdownFile, error) {
+	files, err := d.GetMarkdownFiles()
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		content, err := d.ReadMarkdownFile(files[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		files[i].Content = content
+	}
+	return files, nil
+}
+
+// markdownSignature 根据 markdown 文件的名称、大小和修改时间生成签名，用于判断笔记是否变化
+func (d *Database) markdownSignature() (string, error) {
+	markdownDir, err := d.GetMarkdownDir()
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(markdownDir)
+	if err != nil {
+		return "", fmt.Errorf("读取markdown目录失败: %v", err)
+	}
+
+	hash := sha1.New()
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", fmt.Errorf("读取markdown文件信息失败: %v", err)
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// splitSearchTerms 按空白拆分搜索词，多个词之间为“且”的关系
+func splitSearchTerms(query string) []string {
+	return strings.Fields(query)
+}
+
+// escapeLikePattern 转义 LIKE 中的通配符
+func escapeLikePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// scoreSearchText 未使用 FTS5 排序时的相关度：标题命中权重更高
+func scoreSearchText(title, body string, terms []string) float64 {
+	lowerTitle := strings.ToLower(title)
+	lowerBody := strings.ToLower(body)
+	score := 0.0
+	for _, term := range terms {
+		lowerTerm := strings.ToLower(term)
+		score += 10 * float64(strings.Count(lowerTitle, lowerTerm))
+		score += float64(strings.Count(lowerBody, lowerTerm))
+	}
+	return score
+}
+
+// makeSearchSnippet 截取正文中第一个命中词附近的内容，并用 <mark> 标出命中词
+func makeSearchSnippet(body string, terms []string) string {
+	text := []rune(strings.Join(strings.Fields(body), " "))
+	lowerText := []rune(strings.ToLower(string(text)))
+	if len(lowerText) != len(text) {
+		// 大小写转换改变了长度时退化为区分大小写匹配
+		lowerText = text
+	}
+
+	first := -1
+	for _, term := range terms {
+		if idx := runeIndex(lowerText, []rune(strings.ToLower(term))); idx >= 0 && (first < 0 || idx < first) {
+			first = idx
+		}
+	}
+
+	start, end := 0, len(text)
+	if first >= 0 {
+		start = first - snippetRadius
+		end = first + snippetRadius*2
+	} else {
+		end = snippetRadius * 2
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString("…")
+	}
+	for pos := start; pos < end; {
+		matched := 0
+		for _, term := range terms {
+			termRunes := []rune(strings.ToLower(term))
+			if len(termRunes) > matched && pos+len(termRunes) <= len(lowerText) && string(lowerText[pos:pos+len(termRunes)]) == string(termRunes) {
+				matched = len(termRunes)
+			}
+		}
+		if matched > 0 {
+			builder.WriteString("<mark>" + html.EscapeString(string(text[pos:pos+matched])) + "</mark>")
+			pos += matched
+			continue
+		}
+		builder.WriteString(html.EscapeString(string(text[pos])))
+		pos++
+	}
+	if end < len(text) {
+		builder.WriteString("…")
+	}
+	return builder.String()
+}
+
+// runeIndex 在字符切片中查找子串位置
+func runeIndex(text, sub []rune) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	for i := 0; i+len(sub) <= len(text); i++ {
+		if string(text[i:i+len(sub)]) == string(sub) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGlobalSearch_FindsEntitiesAndMarkdown(t *testing.T) {
+	db := openTestDatabase(t)
+
+	characterID, err := db.CreateCharacter("林动", "武府", 100, 1)
+	if err != nil {
+		t.Fatalf("CreateCharacter() failed: %v", err)
+	}
+	if err := db.AddCharacterSkill(characterID, "吞噬祖符", "可吞噬血玉精华"); err != nil {
+		t.Fatalf("AddCharacterSkill() failed: %v", err)
+	}
+	if _, err := db.CreateShopping("血玉", 100, "罕见的玉石", "等级大于10"); err != nil {
+		t.Fatalf("CreateShopping() failed: %v", err)
+	}
+	if err := db.SaveMarkdownFile("第三章", "林动在山洞中发现了一块血玉。"); err != nil {
+		t.Fatalf("SaveMarkdownFile() failed: %v", err)
+	}
+
+	results, err := db.GlobalSearch("血玉", SearchFilters{})
+	if err != nil {
+		t.Fatalf("GlobalSearch() failed: %v", err)
+	}
+	kinds := map[string]SearchResult{}
+	for _, result := range results {
+		kinds[result.Kind] = result
+	}
+	if len(results) != 3 || kinds["renwu"].ID != int64(characterID) || kinds[SearchKindMarkdown].Path != "第三章.md" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	// 标题命中的商品排在最前
+	if results[0].Kind != "shopping" {
+		t.Fatalf("expected title match first, got %+v", results)
+	}
+	if !strings.Contains(kinds["renwu"].Snippet, "<mark>血玉</mark>") {
+		t.Fatalf("unexpected snippet: %q", kinds["renwu"].Snippet)
+	}
+
+	filtered, err := db.GlobalSearch("血玉 林动", SearchFilters{Kinds: []string{SearchKindMarkdown}})
+	if err != nil {
+		t.Fatalf("GlobalSearch() failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Title != "第三章" {
+		t.Fatalf("unexpected filtered results: %+v", filtered)
+	}
+	if _, err := db.GlobalSearch("血玉", SearchFilters{Kinds: []string{"unknown"}}); err == nil {
+		t.Fatalf("expected error for unknown kind")
+	}
+}
+
+func TestGlobalSearch_RefreshesAfterChanges(t *testing.T) {
+	db := openTestDatabase(t)
+
+	weaponID, err := db.CreateWeapon("青锋剑", "", 1)
+	if err != nil {
+		t.Fatalf("CreateWeapon() failed: %v", err)
+	}
+	if results, _ := db.GlobalSearch("青锋剑", SearchFilters{}); len(results) != 1 {
+		t.Fatalf("expected weapon to be found, got %+v", results)
+	}
+
+	if err := db.UpdateWeaponBasicInfo(weaponID, "紫电剑", "", 1); err != nil {
+		t.Fatalf("UpdateWeaponBasicInfo() failed: %v", err)
+	}
+	if results, _ := db.GlobalSearch("青锋剑", SearchFilters{}); len(results) != 0 {
+		t.Fatalf("expected stale entry to be gone, got %+v", results)
+	}
+	if results, _ := db.GlobalSearch("紫电剑", SearchFilters{}); len(results) != 1 || results[0].ID != int64(weaponID) {
+		t.Fatalf("expected renamed weapon to be found, got %+v", results)
+	}
+
+	markdownDir, err := db.GetMarkdownDir()
+	if err != nil {
+		t.Fatalf("GetMarkdownDir() failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(markdownDir, "设定.md"), []byte("紫电剑由雷池淬炼而成"), 0644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+	results, err := db.GlobalSearch("雷池", SearchFilters{})
+	if err != nil || len(results) != 1 || results[0].Kind != SearchKindMarkdown {
+		t.Fatalf("expected note written outside the app to be indexed, got %+v, %v", results, err)
+	}
+}
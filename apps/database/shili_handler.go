@@ -8,15 +8,27 @@ import (
 
 // ShiliInfo 势力基本信息
 type ShiliInfo struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Level       int       `json:"level"`
-	Founder     string    `json:"founder"`
-	Wealth      int       `json:"wealth"`
-	MemberCount int       `json:"member_count"`
-	MaxMembers  int       `json:"max_members"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int           `json:"id"`
+	Name        string        `json:"name"`
+	Level       int           `json:"level"`
+	Founder     string        `json:"founder"`
+	Wealth      int           `json:"wealth"`
+	MemberCount int           `json:"member_count"` // 由成员表统计
+	MaxMembers  int           `json:"max_members"`
+	FoundedDate string        `json:"founded_date"` // 世界历法中的创立日期，未设置时为空
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Members     []ShiliMember `json:"members,omitempty"` // 成员名单，仅在获取单个势力时返回
+}
+
+// ShiliMember 势力成员
+type ShiliMember struct {
+	ID        int       `json:"id"`
+	ShiliID   int       `json:"shili_id"`
+	RenwuID   int       `json:"renwu_id"`
+	Name      string    `json:"name"` // 人物名称
+	Role      string    `json:"role"` // 在势力中的身份或职务
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // ShiliPosition 势力职务
@@ -41,51 +53,250 @@ type ShiliAttribute struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// shiliMemberCountColumn 统计势力成员数的子查询
+const shiliMemberCountColumn = `(SELECT COUNT(*) FROM shili_members m WHERE m.shili_id = shili.id)`
+
+// shiliListSpec 势力列表的筛选与排序
+var shiliListSpec = listSpec{
+	label:       "势力",
+	table:       "shili",
+	levelColumn: "level",
+	sortColumns: map[string]string{
+		"id":           "id",
+		"name":         "name",
+		"level":        "level",
+		"wealth":       "wealth",
+		"member_count": shiliMemberCountColumn,
+	},
+	defaultSort: "id",
+}
+
 // GetAllShili 获取所有势力
 func (d *Database) GetAllShili() ([]ShiliInfo, error) {
-	query := `
-	SELECT id, name, level, founder, wealth, member_count, max_members, created_at, updated_at
-	FROM shili
-	ORDER BY id ASC`
-
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("查询势力列表失败: %v", err)
-	}
-	defer rows.Close()
+	shiliList, _, err := d.ListShili(ListQuery{})
+	return shiliList, err
+}
 
+// ListShili 按筛选、排序和分页条件获取势力
+func (d *Database) ListShili(q ListQuery) ([]ShiliInfo, ListResult, error) {
 	var shiliList []ShiliInfo
-	for rows.Next() {
+	columns := `id, name, level, founder, wealth, ` + shiliMemberCountColumn + `, max_members, created_at, updated_at`
+	result, err := d.queryList(shiliListSpec, q, columns, func(rows *sql.Rows) (int64, error) {
 		var s ShiliInfo
 		err := rows.Scan(&s.ID, &s.Name, &s.Level, &s.Founder, &s.Wealth, &s.MemberCount, &s.MaxMembers, &s.CreatedAt, &s.UpdatedAt)
 		if err != nil {
-			return nil, fmt.Errorf("扫描势力数据失败: %v", err)
+			return 0, fmt.Errorf("扫描势力数据失败: %v", err)
 		}
 		shiliList = append(shiliList, s)
+		return int64(s.ID), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return shiliList, nil
+	return shiliList, result, nil
 }
 
-// GetShiliInfo 获取势力基本信息
+// GetShiliInfo 获取势力基本信息及成员名单
 func (d *Database) GetShiliInfo(shiliID int) (*ShiliInfo, error) {
 	query := `
-	SELECT id, name, level, founder, wealth, member_count, max_members, created_at, updated_at
+	SELECT id, name, level, founder, wealth, ` + shiliMemberCountColumn + `, max_members, created_at, updated_at, founded_day
 	FROM shili
 	WHERE id = ?`
 
 	var s ShiliInfo
-	err := d.db.QueryRow(query, shiliID).Scan(&s.ID, &s.Name, &s.Level, &s.Founder, &s.Wealth, &s.MemberCount, &s.MaxMembers, &s.CreatedAt, &s.UpdatedAt)
+	var foundedDay sql.NullInt64
+	err := d.db.QueryRow(query, shiliID).Scan(&s.ID, &s.Name, &s.Level, &s.Founder, &s.Wealth, &s.MemberCount, &s.MaxMembers, &s.CreatedAt, &s.UpdatedAt, &foundedDay)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("势力不存在")
 		}
 		return nil, fmt.Errorf("查询势力信息失败: %v", err)
 	}
+	if s.FoundedDate, err = formatWorldDay(d.db, foundedDay); err != nil {
+		return nil, err
+	}
+
+	members, err := d.GetShiliMembers(shiliID)
+	if err != nil {
+		return nil, err
+	}
+	s.Members = members
 
 	return &s, nil
 }
 
+// GetShiliMembers 获取势力成员名单
+func (d *Database) GetShiliMembers(shiliID int) ([]ShiliMember, error) {
+	query := `
+	SELECT m.id, m.shili_id, m.renwu_id, r.name, m.role, m.created_at
+	FROM shili_members m
+	JOIN renwu r ON r.id = m.renwu_id
+	WHERE m.shili_id = ?
+	ORDER BY m.id ASC`
+
+	rows, err := d.db.Query(query, shiliID)
+	if err != nil {
+		return nil, fmt.Errorf("查询势力成员失败: %v", err)
+	}
+	defer rows.Close()
+
+	members := []ShiliMember{}
+	for rows.Next() {
+		var member ShiliMember
+		if err := rows.Scan(&member.ID, &member.ShiliID, &member.RenwuID, &member.Name, &member.Role, &member.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描势力成员数据失败: %v", err)
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// AddShiliMember 添加势力成员；人物已属于其他势力时需先退出，成员数不能超过容纳人数
+func (d *Database) AddShiliMember(shiliID, characterID int, role string) (int64, error) {
+	var memberID int64
+	err := d.WithTx(func(tx *sql.Tx) error {
+		var currentShili sql.NullString
+		err := tx.QueryRow(`
+		SELECT s.name FROM renwu r
+		LEFT JOIN shili_members m ON m.renwu_id = r.id
+		LEFT JOIN shili s ON s.id = m.shili_id
+		WHERE r.id = ?`, characterID).Scan(&currentShili)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("人物不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询人物所属势力失败: %v", err)
+		}
+		if currentShili.Valid {
+			return fmt.Errorf("人物已属于势力「%s」，请先退出", currentShili.String)
+		}
+
+		memberID, err = joinShili(tx, shiliID, characterID, role)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return memberID, nil
+}
+
+// UpdateShiliMemberRole 更新成员在势力中的身份
+func (d *Database) UpdateShiliMemberRole(memberID int, role string) error {
+	query := `
+	UPDATE shili_members
+	SET role = ?, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?`
+
+	result, err := d.db.Exec(query, role, memberID)
+	if err != nil {
+		return fmt.Errorf("更新成员身份失败: %v", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("势力成员不存在")
+	}
+
+	return nil
+}
+
+// RemoveShiliMember 将成员移出势力，同时清除人物的所属势力
+func (d *Database) RemoveShiliMember(memberID int) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		var characterID int
+		err := tx.QueryRow(`SELECT renwu_id FROM shili_members WHERE id = ?`, memberID).Scan(&characterID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("势力成员不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询势力成员失败: %v", err)
+		}
+		return setCharacterMembership(tx, characterID, nil)
+	})
+}
+
+// joinShili 在检查容纳人数后把人物加入势力，并同步人物的所属势力
+func joinShili(tx dbExecutor, shiliID, characterID int, role string) (int64, error) {
+	var name string
+	var memberCount, maxMembers int
+	err := tx.QueryRow(`
+	SELECT name, `+shiliMemberCountColumn+`, max_members FROM shili WHERE id = ?`, shiliID).Scan(&name, &memberCount, &maxMembers)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("势力不存在")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询势力信息失败: %v", err)
+	}
+	if memberCount >= maxMembers {
+		return 0, fmt.Errorf("势力「%s」成员已满（容纳 %d 人）", name, maxMembers)
+	}
+
+	result, err := tx.Exec(`INSERT INTO shili_members (shili_id, renwu_id, role) VALUES (?, ?, ?)`, shiliID, characterID, role)
+	if err != nil {
+		return 0, fmt.Errorf("添加势力成员失败: %v", err)
+	}
+	memberID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("获取成员ID失败: %v", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE renwu SET shili_id = ?, shili = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, shiliID, name, characterID); err != nil {
+		return 0, fmt.Errorf("更新人物所属势力失败: %v", err)
+	}
+	return memberID, nil
+}
+
+// setCharacterMembership 使人物的成员关系与所属势力一致：shiliID 为 nil 时退出势力，
+// 与当前势力不同时退出原势力并加入新势力；同一势力不做变更以保留身份
+func setCharacterMembership(tx dbExecutor, characterID int, shiliID interface{}) error {
+	var current sql.NullInt64
+	err := tx.QueryRow(`SELECT shili_id FROM shili_members WHERE renwu_id = ?`, characterID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("查询人物所属势力失败: %v", err)
+	}
+
+	if shiliID == nil {
+		if _, err := tx.Exec(`DELETE FROM shili_members WHERE renwu_id = ?`, characterID); err != nil {
+			return fmt.Errorf("退出势力失败: %v", err)
+		}
+		if current.Valid {
+			query := `UPDATE renwu SET shili_id = NULL, shili = '', updated_at = CURRENT_TIMESTAMP WHERE id = ? AND shili_id = ?`
+			if _, err := tx.Exec(query, characterID, current.Int64); err != nil {
+				return fmt.Errorf("更新人物所属势力失败: %v", err)
+			}
+		}
+		return nil
+	}
+
+	target, ok := toInt64(shiliID)
+	if !ok {
+		return fmt.Errorf("势力ID无效")
+	}
+	if current.Valid && current.Int64 == target {
+		return nil
+	}
+	if _, err := tx.Exec(`DELETE FROM shili_members WHERE renwu_id = ?`, characterID); err != nil {
+		return fmt.Errorf("退出原势力失败: %v", err)
+	}
+	_, err = joinShili(tx, int(target), characterID, "")
+	return err
+}
+
+// backfillShiliMembers 为已关联势力但还没有成员记录的人物补齐成员关系
+func backfillShiliMembers(tx dbExecutor) error {
+	_, err := tx.Exec(`
+	INSERT INTO shili_members (shili_id, renwu_id)
+	SELECT r.shili_id, r.id FROM renwu r
+	WHERE r.shili_id IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM shili_members m WHERE m.renwu_id = r.id)`)
+	if err != nil {
+		return fmt.Errorf("补齐势力成员失败: %v", err)
+	}
+	return nil
+}
+
 // GetShiliPositions 获取势力职务
 func (d *Database) GetShiliPositions(shiliID int) ([]ShiliPosition, error) {
 	query := `
@@ -140,48 +351,63 @@ func (d *Database) GetShiliAttributes(shiliID int) ([]ShiliAttribute, error) {
 	return attributes, nil
 }
 
-// CreateShili 创建势力
+// CreateShili 创建势力，初始财富记入账本；创立者名称唯一匹配人物时关联该人物
 func (d *Database) CreateShili(name string, founder string, level int, wealth int, maxMembers int) (int64, error) {
-	query := `
-	INSERT INTO shili (name, founder, level, wealth, member_count, max_members)
-	VALUES (?, ?, ?, ?, 0, ?)`
+	var shiliID int64
+	err := d.WithTx(func(tx *sql.Tx) error {
+		query := `
+		INSERT INTO shili (name, founder, level, wealth, max_members)
+		VALUES (?, ?, ?, ?, ?)`
 
-	result, err := d.db.Exec(query, name, founder, level, wealth, maxMembers)
-	if err != nil {
-		return 0, fmt.Errorf("创建势力失败: %v", err)
-	}
+		result, err := tx.Exec(query, name, founder, level, wealth, maxMembers)
+		if err != nil {
+			return fmt.Errorf("创建势力失败: %v", err)
+		}
 
-	shiliID, err := result.LastInsertId()
+		shiliID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("获取势力ID失败: %v", err)
+		}
+		if err := relinkEntityReference(tx, shiliFounderReference, shiliID); err != nil {
+			return err
+		}
+		return recordBalanceAdjustment(tx, LedgerAccount{Type: LedgerAccountFaction, ID: int(shiliID)}, 0, wealth, "期初余额")
+	})
 	if err != nil {
-		return 0, fmt.Errorf("获取势力ID失败: %v", err)
+		return 0, err
 	}
 
 	return shiliID, nil
 }
 
-// UpdateShiliBasicInfo 更新势力基本信息
-func (d *Database) UpdateShiliBasicInfo(shiliID int, level int, founder string, wealth int, memberCount int, maxMembers int) error {
-	// 验证现有人数不能超过容纳人数
-	if memberCount > maxMembers {
-		return fmt.Errorf("现有人数不能超过容纳人数")
-	}
-
-	query := `
-	UPDATE shili
-	SET level = ?, founder = ?, wealth = ?, member_count = ?, max_members = ?, updated_at = CURRENT_TIMESTAMP
-	WHERE id = ?`
-
-	result, err := d.db.Exec(query, level, founder, wealth, memberCount, maxMembers, shiliID)
-	if err != nil {
-		return fmt.Errorf("更新势力基本信息失败: %v", err)
-	}
+// UpdateShiliBasicInfo 更新势力基本信息；成员数由成员表统计，容纳人数不能少于现有成员数；财富变化记入账本
+func (d *Database) UpdateShiliBasicInfo(shiliID int, level int, founder string, wealth int, maxMembers int) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		var memberCount, oldWealth int
+		err := tx.QueryRow(`SELECT `+shiliMemberCountColumn+`, COALESCE(wealth, 0) FROM shili WHERE id = ?`, shiliID).Scan(&memberCount, &oldWealth)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("势力不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询势力信息失败: %v", err)
+		}
+		if memberCount > maxMembers {
+			return fmt.Errorf("容纳人数不能少于现有成员数（%d 人）", memberCount)
+		}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("势力不存在")
-	}
+		query := `
+		UPDATE shili
+		SET level = ?, founder = ?, wealth = ?, max_members = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`
 
-	return nil
+		if _, err := tx.Exec(query, level, founder, wealth, maxMembers, shiliID); err != nil {
+			return fmt.Errorf("更新势力基本信息失败: %v", err)
+		}
+		if err := relinkEntityReference(tx, shiliFounderReference, int64(shiliID)); err != nil {
+			return err
+		}
+		return recordBalanceAdjustment(tx, LedgerAccount{Type: LedgerAccountFaction, ID: shiliID}, oldWealth, wealth, "余额调整")
+	})
 }
 
 // DeleteShili 删除势力
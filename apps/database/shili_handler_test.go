@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"nooltools/apps/storage"
+	"path/filepath"
+	"testing"
+)
+
+func TestShiliMembers_CountCapacityAndRoster(t *testing.T) {
+	db := openTestDatabase(t)
+
+	shiliID, err := db.CreateShili("武府", "林震天", 1, 0, 2)
+	if err != nil {
+		t.Fatalf("CreateShili() failed: %v", err)
+	}
+	otherID, err := db.CreateShili("血狼帮", "", 1, 0, 10)
+	if err != nil {
+		t.Fatalf("CreateShili() failed: %v", err)
+	}
+	linDong, _ := db.CreateCharacter("林动", "", 100, 1)
+	linXiao, _ := db.CreateCharacter("林啸", "", 100, 1)
+	linLang, _ := db.CreateCharacter("林琅天", "", 100, 1)
+
+	memberID, err := db.AddShiliMember(int(shiliID), linDong, "少主")
+	if err != nil {
+		t.Fatalf("AddShiliMember() failed: %v", err)
+	}
+	if _, err := db.AddShiliMember(int(shiliID), linXiao, ""); err != nil {
+		t.Fatalf("AddShiliMember() failed: %v", err)
+	}
+	if _, err := db.AddShiliMember(int(shiliID), linLang, ""); err == nil {
+		t.Fatalf("expected error when faction is full")
+	}
+	if _, err := db.AddShiliMember(int(otherID), linDong, ""); err == nil {
+		t.Fatalf("expected error when character already belongs to a faction")
+	}
+
+	info, err := db.GetShiliInfo(int(shiliID))
+	if err != nil {
+		t.Fatalf("GetShiliInfo() failed: %v", err)
+	}
+	if info.MemberCount != 2 || len(info.Members) != 2 || info.Members[0].Name != "林动" || info.Members[0].Role != "少主" {
+		t.Fatalf("unexpected roster: count=%d members=%+v", info.MemberCount, info.Members)
+	}
+	if err := db.UpdateShiliBasicInfo(int(shiliID), 1, "林震天", 0, 1); err == nil {
+		t.Fatalf("expected error when max members is below member count")
+	}
+
+	character, err := db.GetCharacterInfo(linDong)
+	if err != nil {
+		t.Fatalf("GetCharacterInfo() failed: %v", err)
+	}
+	if character.Shili != "武府" || character.Faction == nil || character.Faction.Role != "少主" {
+		t.Fatalf("character faction not synced: shili=%q faction=%+v", character.Shili, character.Faction)
+	}
+
+	// 通过基本信息改填势力名称即转投其他势力，原势力的成员关系随之移除
+	if err := db.UpdateCharacterBasicInfo(linXiao, "林啸", "血狼帮", 100, 1); err != nil {
+		t.Fatalf("UpdateCharacterBasicInfo() failed: %v", err)
+	}
+	if err := db.RemoveShiliMember(int(memberID)); err != nil {
+		t.Fatalf("RemoveShiliMember() failed: %v", err)
+	}
+	info, _ = db.GetShiliInfo(int(shiliID))
+	if info.MemberCount != 0 {
+		t.Fatalf("expected empty faction, got %+v", info.Members)
+	}
+	other, _ := db.GetShiliInfo(int(otherID))
+	if other.MemberCount != 1 || other.Members[0].RenwuID != linXiao {
+		t.Fatalf("expected character to move to other faction, got %+v", other.Members)
+	}
+	character, _ = db.GetCharacterInfo(linDong)
+	if character.ShiliID != nil || character.Faction != nil {
+		t.Fatalf("expected faction to be cleared, got %+v", character.Faction)
+	}
+}
+
+func TestMigrate_MapsLegacyFactionMembership(t *testing.T) {
+	dataDir := t.TempDir()
+	raw, err := sql.Open("sqlite3", filepath.Join(dataDir, storage.DatabaseFileName))
+	if err != nil {
+		t.Fatalf("failed to open raw database: %v", err)
+	}
+	if err := createBaseTables(raw); err != nil {
+		t.Fatalf("createBaseTables() failed: %v", err)
+	}
+	statements := []string{
+		`INSERT INTO shili (id, name, member_count, max_members) VALUES (1, '武府', 99, 10)`,
+		`INSERT INTO renwu (id, name, shili) VALUES (1, '林动', '武府'), (2, '林啸', ''), (3, '路人', '')`,
+		`INSERT INTO shili_positions (shili_id, position_name, person_name) VALUES (1, '少主', '林动'), (1, '长老', '林啸'), (1, '供奉', '无名氏')`,
+	}
+	for _, statement := range statements {
+		if _, err := raw.Exec(statement); err != nil {
+			t.Fatalf("seed failed: %v", err)
+		}
+	}
+	raw.Close()
+
+	db, err := NewDatabaseAtDir(dataDir)
+	if err != nil {
+		t.Fatalf("NewDatabaseAtDir() failed: %v", err)
+	}
+	defer db.Close()
+
+	info, err := db.GetShiliInfo(1)
+	if err != nil {
+		t.Fatalf("GetShiliInfo() failed: %v", err)
+	}
+	if info.MemberCount != 2 || len(info.Members) != 2 {
+		t.Fatalf("unexpected migrated roster: count=%d members=%+v", info.MemberCount, info.Members)
+	}
+	roles := map[string]string{}
+	for _, member := range info.Members {
+		roles[member.Name] = member.Role
+	}
+	if roles["林动"] != "少主" || roles["林啸"] != "长老" {
+		t.Fatalf("unexpected roles: %v", roles)
+	}
+
+	character, err := db.GetCharacterInfo(2)
+	if err != nil {
+		t.Fatalf("GetCharacterInfo() failed: %v", err)
+	}
+	if character.Shili != "武府" || character.ShiliID == nil {
+		t.Fatalf("expected position holder to join faction, got %+v", character)
+	}
+}
@@ -6,41 +6,54 @@ import (
 	"fmt"
 )
 
-// ShiqingDetail 任务详情结构
+// ShiqingDetail 任务详情结构，作为任务目标按 position 排序并可勾选
 type ShiqingDetail struct {
-	ID          int    `json:"id"`
-	Description string `json:"description"`
+	ID          int     `json:"id"`
+	Description string  `json:"description"`
+	Position    int     `json:"position"`
+	Done        bool    `json:"done"`
+	DoneAt      *string `json:"done_at"`
 }
 
 // ShiqingInfo 任务信息结构
 type ShiqingInfo struct {
-	ID       int             `json:"id"`
-	Name     string          `json:"name"`
-	Location string          `json:"location"`
-	Time     string          `json:"time"`
-	Details  []ShiqingDetail `json:"details"`
+	ID           int                  `json:"id"`
+	Name         string               `json:"name"`
+	Location     string               `json:"location"`
+	Time         string               `json:"time"`
+	WorldDate    string               `json:"world_date"` // 世界历法中的日期，未设置时为空
+	Status       string               `json:"status"`
+	StartedAt    *string              `json:"started_at"`
+	EndedAt      *string              `json:"ended_at"`
+	Details      []ShiqingDetail      `json:"details"`
+	Participants []ShiqingParticipant `json:"participants"`
+	Rewards      []ShiqingReward      `json:"rewards"`
+}
+
+// shiqingListSpec 任务列表的筛选与排序；类型筛选对应任务状态
+var shiqingListSpec = listSpec{
+	label:       "任务",
+	table:       "shiqing",
+	typeColumn:  "status",
+	sortColumns: map[string]string{"id": "id", "name": "name", "location": "location", "time": "time", "status": "status"},
+	defaultSort: "id",
 }
 
 // GetAllShiqing 获取所有任务列表
 func (d *Database) GetAllShiqing() ([]map[string]interface{}, error) {
-	query := `
-	SELECT id, name, location, time
-	FROM shiqing
-	ORDER BY id ASC`
-
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("查询任务列表失败: %v", err)
-	}
-	defer rows.Close()
+	shiqingList, _, err := d.ListShiqing(ListQuery{})
+	return shiqingList, err
+}
 
+// ListShiqing 按筛选、排序和分页条件获取任务列表
+func (d *Database) ListShiqing(q ListQuery) ([]map[string]interface{}, ListResult, error) {
 	var shiqingList []map[string]interface{}
-	for rows.Next() {
+	result, err := d.queryList(shiqingListSpec, q, `id, name, location, time, COALESCE(status, 'pending')`, func(rows *sql.Rows) (int64, error) {
 		var id int
-		var name, location, time string
+		var name, location, time, status string
 
-		if err := rows.Scan(&id, &name, &location, &time); err != nil {
-			return nil, fmt.Errorf("扫描任务数据失败: %v", err)
+		if err := rows.Scan(&id, &name, &location, &time, &status); err != nil {
+			return 0, fmt.Errorf("扫描任务数据失败: %v", err)
 		}
 
 		shiqing := map[string]interface{}{
@@ -48,11 +61,16 @@ func (d *Database) GetAllShiqing() ([]map[string]interface{}, error) {
 			"name":     name,
 			"location": location,
 			"time":     time,
+			"status":   status,
 		}
 		shiqingList = append(shiqingList, shiqing)
+		return int64(id), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return shiqingList, nil
+	return shiqingList, result, nil
 }
 
 // GetShiqingInfo 获取任务详细信息（包含详情）
@@ -60,17 +78,22 @@ func (d *Database) GetShiqingInfo(shiqingID int) (*ShiqingInfo, error) {
 	// 查询任务基本信息
 	var info ShiqingInfo
 	query := `
-	SELECT id, name, location, time
+	SELECT id, name, location, time, COALESCE(status, 'pending'), started_at, ended_at, world_day
 	FROM shiqing
 	WHERE id = ?`
 
-	err := d.db.QueryRow(query, shiqingID).Scan(&info.ID, &info.Name, &info.Location, &info.Time)
+	var worldDay sql.NullInt64
+	err := d.db.QueryRow(query, shiqingID).Scan(&info.ID, &info.Name, &info.Location, &info.Time,
+		&info.Status, &info.StartedAt, &info.EndedAt, &worldDay)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("任务不存在")
 		}
 		return nil, fmt.Errorf("查询任务信息失败: %v", err)
 	}
+	if info.WorldDate, err = formatWorldDay(d.db, worldDay); err != nil {
+		return nil, err
+	}
 
 	// 查询任务详情
 	details, err := d.GetShiqingDetails(shiqingID)
@@ -79,16 +102,24 @@ func (d *Database) GetShiqingInfo(shiqingID int) (*ShiqingInfo, error) {
 	}
 	info.Details = details
 
+	// 查询参与者和奖励
+	if info.Participants, err = getShiqingParticipants(d.db, shiqingID); err != nil {
+		return nil, err
+	}
+	if info.Rewards, err = getShiqingRewards(d.db, shiqingID); err != nil {
+		return nil, err
+	}
+
 	return &info, nil
 }
 
 // CreateShiqing 创建任务
 func (d *Database) CreateShiqing(name, location, time string) (int64, error) {
 	query := `
-	INSERT INTO shiqing (name, location, time)
-	VALUES (?, ?, ?)`
+	INSERT INTO shiqing (name, location, time, status)
+	VALUES (?, ?, ?, ?)`
 
-	result, err := d.db.Exec(query, name, location, time)
+	result, err := d.db.Exec(query, name, location, time, ShiqingStatusPending)
 	if err != nil {
 		return 0, fmt.Errorf("创建任务失败: %v", err)
 	}
@@ -141,10 +172,10 @@ func (d *Database) UpdateShiqingBasicInfo(shiqingID int, location, time string)
 // GetShiqingDetails 获取任务详情列表
 func (d *Database) GetShiqingDetails(shiqingID int) ([]ShiqingDetail, error) {
 	query := `
-	SELECT id, description
+	SELECT id, description, position, done, done_at
 	FROM shiqing_details
 	WHERE shiqing_id = ?
-	ORDER BY id ASC`
+	ORDER BY position ASC, id ASC`
 
 	rows, err := d.db.Query(query, shiqingID)
 	if err != nil {
@@ -155,7 +186,7 @@ func (d *Database) GetShiqingDetails(shiqingID int) ([]ShiqingDetail, error) {
 	var details []ShiqingDetail
 	for rows.Next() {
 		var detail ShiqingDetail
-		if err := rows.Scan(&detail.ID, &detail.Description); err != nil {
+		if err := rows.Scan(&detail.ID, &detail.Description, &detail.Position, &detail.Done, &detail.DoneAt); err != nil {
 			return nil, fmt.Errorf("扫描详情数据失败: %v", err)
 		}
 		details = append(details, detail)
@@ -164,11 +195,11 @@ func (d *Database) GetShiqingDetails(shiqingID int) ([]ShiqingDetail, error) {
 	return details, nil
 }
 
-// AddShiqingDetail 添加任务详情
+// AddShiqingDetail 添加任务详情，排在已有目标之后
 func (d *Database) AddShiqingDetail(shiqingID int, description string) error {
 	query := `
-	INSERT INTO shiqing_details (shiqing_id, description)
-	VALUES (?, ?)`
+	INSERT INTO shiqing_details (shiqing_id, description, position)
+	VALUES (?1, ?2, (SELECT COALESCE(MAX(position), 0) + 1 FROM shiqing_details WHERE shiqing_id = ?1))`
 
 	_, err := d.db.Exec(query, shiqingID, description)
 	if err != nil {
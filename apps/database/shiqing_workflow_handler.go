@@ -0,0 +1,377 @@
+// 任务流程：状态按 pending → active → completed/failed/abandoned 流转并记录开始和结束时间；
+// 目标（shiqing_details）按顺序排列并可逐条勾选；参与者关联人物，任务完成时把奖励发给每位参与者：
+// 物品放入参与者名下的第一个背包，货币通过账本从世界转给人物
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// 任务状态
+const (
+	ShiqingStatusPending   = "pending"   // 未开始
+	ShiqingStatusActive    = "active"    // 进行中
+	ShiqingStatusCompleted = "completed" // 已完成
+	ShiqingStatusFailed    = "failed"    // 已失败
+	ShiqingStatusAbandoned = "abandoned" // 已放弃
+)
+
+// 任务奖励类型
+const (
+	ShiqingRewardItem     = "item"     // 物品，放入参与者的背包
+	ShiqingRewardCurrency = "currency" // 货币，计入参与者的财产
+)
+
+// shiqingTransitions 每个状态允许流转到的状态；已完成、已失败和已放弃为终态
+var shiqingTransitions = map[string][]string{
+	ShiqingStatusPending: {ShiqingStatusActive, ShiqingStatusAbandoned},
+	ShiqingStatusActive:  {ShiqingStatusCompleted, ShiqingStatusFailed, ShiqingStatusAbandoned},
+}
+
+// ShiqingParticipant 任务参与者
+type ShiqingParticipant struct {
+	ID          int    `json:"id"`
+	CharacterID int    `json:"character_id"`
+	Name        string `json:"name"`
+	Role        string `json:"role"`
+}
+
+// ShiqingReward 任务奖励，完成时每位参与者各得一份
+type ShiqingReward struct {
+	ID       int    `json:"id"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"` // 物品名称，货币奖励为空
+	Quantity int    `json:"quantity"`
+}
+
+// SetShiqingStatus 按流程修改任务状态；开始时记录开始时间，结束时记录结束时间，
+// 完成时要求全部目标已勾选，并在同一事务中发放奖励
+func (d *Database) SetShiqingStatus(shiqingID int, status string) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		current, err := getShiqingStatus(tx, shiqingID)
+		if err != nil {
+			return err
+		}
+		if !shiqingTransitionAllowed(current, status) {
+			return fmt.Errorf("任务状态不能从 %s 变为 %s", current, status)
+		}
+
+		if status == ShiqingStatusCompleted {
+			var pending int
+			if err := tx.QueryRow(`SELECT COUNT(*) FROM shiqing_details WHERE shiqing_id = ? AND done = 0`, shiqingID).Scan(&pending); err != nil {
+				return fmt.Errorf("查询任务目标失败: %v", err)
+			}
+			if pending > 0 {
+				return fmt.Errorf("任务还有 %d 个未完成的目标", pending)
+			}
+		}
+
+		now := time.Now().Format("2006-01-02 15:04:05")
+		switch status {
+		case ShiqingStatusActive:
+			_, err = tx.Exec(`UPDATE shiqing SET status = ?, started_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, status, now, shiqingID)
+		default:
+			_, err = tx.Exec(`UPDATE shiqing SET status = ?, ended_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, status, now, shiqingID)
+		}
+		if err != nil {
+			return fmt.Errorf("更新任务状态失败: %v", err)
+		}
+
+		if status != ShiqingStatusCompleted {
+			return nil
+		}
+		return grantShiqingRewards(tx, shiqingID)
+	})
+}
+
+// SetShiqingDetailDone 勾选或取消勾选任务目标，只有进行中的任务可以修改
+func (d *Database) SetShiqingDetailDone(detailID int, done bool) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		var shiqingID int
+		err := tx.QueryRow(`SELECT shiqing_id FROM shiqing_details WHERE id = ?`, detailID).Scan(&shiqingID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("详情不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询详情失败: %v", err)
+		}
+		status, err := getShiqingStatus(tx, shiqingID)
+		if err != nil {
+			return err
+		}
+		if status != ShiqingStatusActive {
+			return fmt.Errorf("只有进行中的任务可以勾选目标")
+		}
+
+		var doneAt interface{}
+		if done {
+			doneAt = time.Now().Format("2006-01-02 15:04:05")
+		}
+		if _, err := tx.Exec(`UPDATE shiqing_details SET done = ?, done_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			done, doneAt, detailID); err != nil {
+			return fmt.Errorf("更新任务目标失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// ReorderShiqingDetails 按 detailIDs 的顺序重新排列任务目标，必须列出该任务的全部目标
+func (d *Database) ReorderShiqingDetails(shiqingID int, detailIDs []int) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM shiqing_details WHERE shiqing_id = ?`, shiqingID).Scan(&count); err != nil {
+			return fmt.Errorf("查询任务目标失败: %v", err)
+		}
+		if count != len(detailIDs) {
+			return fmt.Errorf("目标数量不符：任务有 %d 个目标，提供了 %d 个", count, len(detailIDs))
+		}
+		for i, detailID := range detailIDs {
+			result, err := tx.Exec(`UPDATE shiqing_details SET position = ? WHERE id = ? AND shiqing_id = ?`, i+1, detailID, shiqingID)
+			if err != nil {
+				return fmt.Errorf("调整目标顺序失败: %v", err)
+			}
+			if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+				return fmt.Errorf("目标 %d 不属于该任务", detailID)
+			}
+		}
+		return nil
+	})
+}
+
+// AddShiqingParticipant 添加任务参与者，已结束的任务不能修改参与者
+func (d *Database) AddShiqingParticipant(shiqingID, characterID int, role string) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		if err := checkShiqingEditable(tx, shiqingID); err != nil {
+			return err
+		}
+		var name string
+		err := tx.QueryRow(`SELECT name FROM renwu WHERE id = ?`, characterID).Scan(&name)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("人物不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询人物失败: %v", err)
+		}
+		result, err := tx.Exec(`INSERT OR IGNORE INTO shiqing_participants (shiqing_id, renwu_id, role) VALUES (?, ?, ?)`,
+			shiqingID, characterID, role)
+		if err != nil {
+			return fmt.Errorf("添加参与者失败: %v", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return fmt.Errorf("人物「%s」已是该任务的参与者", name)
+		}
+		return nil
+	})
+}
+
+// RemoveShiqingParticipant 移除任务参与者
+func (d *Database) RemoveShiqingParticipant(shiqingID, characterID int) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		if err := checkShiqingEditable(tx, shiqingID); err != nil {
+			return err
+		}
+		result, err := tx.Exec(`DELETE FROM shiqing_participants WHERE shiqing_id = ? AND renwu_id = ?`, shiqingID, characterID)
+		if err != nil {
+			return fmt.Errorf("移除参与者失败: %v", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return fmt.Errorf("参与者不存在")
+		}
+		return nil
+	})
+}
+
+// AddShiqingReward 添加任务奖励；物品奖励需要名称，货币奖励忽略名称
+func (d *Database) AddShiqingReward(shiqingID int, kind, name string, quantity int) (int64, error) {
+	switch kind {
+	case ShiqingRewardItem:
+		if name == "" {
+			return 0, fmt.Errorf("物品奖励需要物品名称")
+		}
+	case ShiqingRewardCurrency:
+		name = ""
+	default:
+		return 0, fmt.Errorf("无效的奖励类型: %s", kind)
+	}
+	if quantity <= 0 {
+		return 0, fmt.Errorf("奖励数量必须大于 0")
+	}
+
+	var id int64
+	err := d.WithTx(func(tx *sql.Tx) error {
+		if err := checkShiqingEditable(tx, shiqingID); err != nil {
+			return err
+		}
+		result, err := tx.Exec(`INSERT INTO shiqing_rewards (shiqing_id, kind, name, quantity) VALUES (?, ?, ?, ?)`,
+			shiqingID, kind, name, quantity)
+		if err != nil {
+			return fmt.Errorf("添加奖励失败: %v", err)
+		}
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("获取奖励ID失败: %v", err)
+		}
+		return nil
+	})
+	return id, err
+}
+
+// DeleteShiqingReward 删除任务奖励
+func (d *Database) DeleteShiqingReward(rewardID int) error {
+	return d.WithTx(func(tx *sql.Tx) error {
+		var shiqingID int
+		err := tx.QueryRow(`SELECT shiqing_id FROM shiqing_rewards WHERE id = ?`, rewardID).Scan(&shiqingID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("奖励不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询奖励失败: %v", err)
+		}
+		if err := checkShiqingEditable(tx, shiqingID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM shiqing_rewards WHERE id = ?`, rewardID); err != nil {
+			return fmt.Errorf("删除奖励失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// getShiqingParticipants 获取任务参与者
+func getShiqingParticipants(q dbExecutor, shiqingID int) ([]ShiqingParticipant, error) {
+	rows, err := q.Query(`
+	SELECT p.id, p.renwu_id, r.name, p.role
+	FROM shiqing_participants p
+	JOIN renwu r ON r.id = p.renwu_id
+	WHERE p.shiqing_id = ?
+	ORDER BY p.id ASC`, shiqingID)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务参与者失败: %v", err)
+	}
+	defer rows.Close()
+
+	participants := []ShiqingParticipant{}
+	for rows.Next() {
+		var p ShiqingParticipant
+		if err := rows.Scan(&p.ID, &p.CharacterID, &p.Name, &p.Role); err != nil {
+			return nil, fmt.Errorf("扫描任务参与者失败: %v", err)
+		}
+		participants = append(participants, p)
+	}
+	return participants, rows.Err()
+}
+
+// getShiqingRewards 获取任务奖励
+func getShiqingRewards(q dbExecutor, shiqingID int) ([]ShiqingReward, error) {
+	rows, err := q.Query(`SELECT id, kind, name, quantity FROM shiqing_rewards WHERE shiqing_id = ? ORDER BY id ASC`, shiqingID)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务奖励失败: %v", err)
+	}
+	defer rows.Close()
+
+	rewards := []ShiqingReward{}
+	for rows.Next() {
+		var r ShiqingReward
+		if err := rows.Scan(&r.ID, &r.Kind, &r.Name, &r.Quantity); err != nil {
+			return nil, fmt.Errorf("扫描任务奖励失败: %v", err)
+		}
+		rewards = append(rewards, r)
+	}
+	return rewards, rows.Err()
+}
+
+// grantShiqingRewards 把任务奖励发给每位参与者：物品放入其名下的第一个背包并记入物品流水，货币从世界转给人物
+func grantShiqingRewards(tx dbExecutor, shiqingID int) error {
+	var name string
+	if err := tx.QueryRow(`SELECT name FROM shiqing WHERE id = ?`, shiqingID).Scan(&name); err != nil {
+		return fmt.Errorf("查询任务失败: %v", err)
+	}
+	participants, err := getShiqingParticipants(tx, shiqingID)
+	if err != nil {
+		return err
+	}
+	rewards, err := getShiqingRewards(tx, shiqingID)
+	if err != nil {
+		return err
+	}
+	if len(rewards) == 0 {
+		return nil
+	}
+
+	memo := fmt.Sprintf("任务奖励：%s", name)
+	now := time.Now().Format("2006-01-02 15:04:05")
+	var batch int
+	for _, p := range participants {
+		beibaoID := 0
+		for _, r := range rewards {
+			if r.Kind == ShiqingRewardCurrency {
+				if _, err := postLedgerEntry(tx, LedgerAccount{Type: LedgerAccountWorld},
+					LedgerAccount{Type: LedgerAccountCharacter, ID: p.CharacterID}, r.Quantity, memo, 0); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if beibaoID == 0 {
+				err := tx.QueryRow(`SELECT id FROM beibao WHERE owner_renwu_id = ? ORDER BY id ASC LIMIT 1`, p.CharacterID).Scan(&beibaoID)
+				if err == sql.ErrNoRows {
+					return fmt.Errorf("参与者「%s」没有背包，无法发放物品奖励", p.Name)
+				}
+				if err != nil {
+					return fmt.Errorf("查询参与者背包失败: %v", err)
+				}
+			}
+			if err := depositBeibaoItem(tx, beibaoID, r.Name, r.Quantity, "", 0); err != nil {
+				return fmt.Errorf("向「%s」发放奖励失败: %v", p.Name, err)
+			}
+			if batch == 0 {
+				if batch, err = nextBeibaoMovementBatch(tx); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.Exec(`
+			INSERT INTO beibao_movements (batch, kind, beibao_id, item_name, quantity, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)`, batch, BeibaoMovementReward, beibaoID, r.Name, r.Quantity, now); err != nil {
+				return fmt.Errorf("保存物品流水失败: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// getShiqingStatus 查询任务当前状态
+func getShiqingStatus(q dbExecutor, shiqingID int) (string, error) {
+	var status string
+	err := q.QueryRow(`SELECT COALESCE(status, ?) FROM shiqing WHERE id = ?`, ShiqingStatusPending, shiqingID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("任务不存在")
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询任务失败: %v", err)
+	}
+	return status, nil
+}
+
+// checkShiqingEditable 已结束的任务不能再修改参与者和奖励
+func checkShiqingEditable(q dbExecutor, shiqingID int) error {
+	status, err := getShiqingStatus(q, shiqingID)
+	if err != nil {
+		return err
+	}
+	if _, open := shiqingTransitions[status]; !open {
+		return fmt.Errorf("任务已结束，不能修改参与者和奖励")
+	}
+	return nil
+}
+
+// shiqingTransitionAllowed 判断状态流转是否允许
+func shiqingTransitionAllowed(from, to string) bool {
+	for _, next := range shiqingTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package database
+
+import "testing"
+
+func TestShiqingStatusTransitions(t *testing.T) {
+	db := openTestDatabase(t)
+	taskID, _ := db.CreateShiqing("除魔任务", "青阳镇", "第一日")
+	db.AddShiqingDetail(int(taskID), "找到妖兽巢穴")
+	db.AddShiqingDetail(int(taskID), "击败妖兽")
+
+	info, err := db.GetShiqingInfo(int(taskID))
+	if err != nil || info.Status != ShiqingStatusPending || info.StartedAt != nil || len(info.Details) != 2 {
+		t.Fatalf("GetShiqingInfo() = %+v, %v", info, err)
+	}
+	if err := db.SetShiqingStatus(int(taskID), ShiqingStatusCompleted); err == nil {
+		t.Fatalf("expected error when completing a pending quest")
+	}
+	if err := db.SetShiqingDetailDone(info.Details[0].ID, true); err == nil {
+		t.Fatalf("expected error when checking an objective of a pending quest")
+	}
+
+	if err := db.SetShiqingStatus(int(taskID), ShiqingStatusActive); err != nil {
+		t.Fatalf("SetShiqingStatus(active) failed: %v", err)
+	}
+	if err := db.SetShiqingDetailDone(info.Details[0].ID, true); err != nil {
+		t.Fatalf("SetShiqingDetailDone() failed: %v", err)
+	}
+	if err := db.SetShiqingStatus(int(taskID), ShiqingStatusCompleted); err == nil {
+		t.Fatalf("expected error when objectives are not done")
+	}
+
+	// 调整顺序后未完成的目标排在前面
+	if err := db.ReorderShiqingDetails(int(taskID), []int{info.Details[1].ID}); err == nil {
+		t.Fatalf("expected error when not listing every objective")
+	}
+	if err := db.ReorderShiqingDetails(int(taskID), []int{info.Details[1].ID, info.Details[0].ID}); err != nil {
+		t.Fatalf("ReorderShiqingDetails() failed: %v", err)
+	}
+	info, _ = db.GetShiqingInfo(int(taskID))
+	if info.Details[0].Description != "击败妖兽" || info.Details[0].Done || !info.Details[1].Done || info.Details[1].DoneAt == nil {
+		t.Fatalf("unexpected objectives: %+v", info.Details)
+	}
+
+	if err := db.SetShiqingStatus(int(taskID), ShiqingStatusFailed); err != nil {
+		t.Fatalf("SetShiqingStatus(failed) failed: %v", err)
+	}
+	info, _ = db.GetShiqingInfo(int(taskID))
+	if info.Status != ShiqingStatusFailed || info.StartedAt == nil || info.EndedAt == nil {
+		t.Fatalf("expected failed quest with timestamps, got %+v", info)
+	}
+	if err := db.SetShiqingStatus(int(taskID), ShiqingStatusActive); err == nil {
+		t.Fatalf("expected error when restarting a finished quest")
+	}
+
+	list, _, err := db.ListShiqing(ListQuery{Type: ShiqingStatusFailed})
+	if err != nil || len(list) != 1 || list[0]["status"] != ShiqingStatusFailed {
+		t.Fatalf("ListShiqing() by status = %+v, %v", list, err)
+	}
+}
+
+func TestShiqingCompletionGrantsRewards(t *testing.T) {
+	db := openTestDatabase(t)
+	heroID, _ := db.CreateCharacter("林动", "", 100, 10)
+	friendID, _ := db.CreateCharacter("林炎", "", 0, 5)
+	beibaoID, _ := db.CreateBeibao("林动的背包")
+	db.SetBeibaoOwner(int(beibaoID), BeibaoOwnerCharacter, heroID)
+	taskID, _ := db.CreateShiqing("护送商队", "", "")
+
+	if err := db.AddShiqingParticipant(int(taskID), heroID, "领队"); err != nil {
+		t.Fatalf("AddShiqingParticipant() failed: %v", err)
+	}
+	if err := db.AddShiqingParticipant(int(taskID), heroID, ""); err == nil {
+		t.Fatalf("expected error for duplicate participant")
+	}
+	db.AddShiqingParticipant(int(taskID), friendID, "")
+	if _, err := db.AddShiqingReward(int(taskID), ShiqingRewardItem, "", 1); err == nil {
+		t.Fatalf("expected error for item reward without a name")
+	}
+	db.AddShiqingReward(int(taskID), ShiqingRewardCurrency, "", 50)
+	db.AddShiqingReward(int(taskID), ShiqingRewardItem, "回血丹", 3)
+	db.SetShiqingStatus(int(taskID), ShiqingStatusActive)
+
+	// 林炎没有背包，整笔完成不生效
+	if err := db.SetShiqingStatus(int(taskID), ShiqingStatusCompleted); err == nil {
+		t.Fatalf("expected error when a participant has no backpack")
+	}
+	if hero, _ := db.GetCharacterInfo(heroID); hero.Property != 100 {
+		t.Fatalf("failed completion must not grant rewards, got property %d", hero.Property)
+	}
+	if err := db.RemoveShiqingParticipant(int(taskID), friendID); err != nil {
+		t.Fatalf("RemoveShiqingParticipant() failed: %v", err)
+	}
+
+	if err := db.SetShiqingStatus(int(taskID), ShiqingStatusCompleted); err != nil {
+		t.Fatalf("SetShiqingStatus(completed) failed: %v", err)
+	}
+	hero, _ := db.GetCharacterInfo(heroID)
+	if hero.Property != 150 || len(hero.Beibao) != 1 || len(hero.Beibao[0].Items) != 1 || hero.Beibao[0].Items[0].Quantity != 3 {
+		t.Fatalf("expected rewards to be granted, got %+v", hero)
+	}
+	movements, _ := db.GetBeibaoMovements(int(beibaoID), 10)
+	if len(movements) != 1 || movements[0].Kind != BeibaoMovementReward || movements[0].CounterpartBeibaoID != 0 {
+		t.Fatalf("expected a reward movement, got %+v", movements)
+	}
+	if balance, err := db.GetLedgerBalance(LedgerAccount{Type: LedgerAccountCharacter, ID: heroID}); err != nil || balance.Drift != 0 {
+		t.Fatalf("expected the ledger to match the property, got %+v, %v", balance, err)
+	}
+
+	if _, err := db.AddShiqingReward(int(taskID), ShiqingRewardCurrency, "", 10); err == nil {
+		t.Fatalf("expected error when editing rewards of a completed quest")
+	}
+	if owned, _ := characterOwnedCount(db.db, heroID, "回血丹"); owned != 3 {
+		t.Fatalf("expected rewarded items to count as owned, got %d", owned)
+	}
+}
+
+func TestMigrateShiqingWorkflowNormalizesLegacyData(t *testing.T) {
+	db := openTestDatabase(t)
+	heroID, _ := db.CreateCharacter("林动", "", 0, 1)
+	// 重名的人物无法确定是哪一个，不关联
+	db.CreateCharacter("萧炎", "", 0, 1)
+	db.CreateCharacter("萧炎", "", 0, 2)
+	if _, err := db.db.Exec(`INSERT INTO shiqing (id, name, location, time, status, participants)
+	VALUES (1, '旧任务', '', '', '已完成', '林动、路人甲、萧炎'), (2, '未知', '', '', '搁置', '')`); err != nil {
+		t.Fatalf("insert legacy quests failed: %v", err)
+	}
+	if err := db.WithTx(migrateShiqingWorkflow); err != nil {
+		t.Fatalf("migrateShiqingWorkflow() failed: %v", err)
+	}
+
+	info, err := db.GetShiqingInfo(1)
+	if err != nil || info.Status != ShiqingStatusCompleted || len(info.Participants) != 1 || info.Participants[0].CharacterID != heroID {
+		t.Fatalf("unexpected migrated quest: %+v, %v", info, err)
+	}
+	if info, _ = db.GetShiqingInfo(2); info.Status != ShiqingStatusPending {
+		t.Fatalf("expected unknown status to become pending, got %q", info.Status)
+	}
+}
@@ -0,0 +1,370 @@
+// 商品购买条件：把商品的条件文本解析为子句并针对指定人物求值。
+// 子句之间用“，”“；”“&&”或两侧带空白的“且”分隔，全部满足才算满足；子句内可用“||”或两侧带空白的“或”分隔多个备选，
+// 满足其一即可。不带空白的“且”“或”视为名称的一部分，如“持有:或天剑”。
+// 支持的子句（前缀“非”或“!”表示取反）：
+//
+//	等级≥30              人物等级，可用 level；比较符为 ≥ ≤ > < = ≠（或 >= <= == !=）
+//	势力=青云门          所属势力，可用 faction；只支持 = 和 ≠
+//	属性:力量≥50         人物属性（renwu_attributes）的数值
+//	持有:玄铁剑≥2        持有的同名武器、道具、宠物及名下背包中同名物品的数量，省略比较时为“至少 1 个”
+//	完成:除魔任务        人物参与的同名任务中有已完成的
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ShoppingConditionResult 商品购买条件的求值结果
+type ShoppingConditionResult struct {
+	ItemID      int                       `json:"item_id"`
+	CharacterID int                       `json:"character_id"`
+	Condition   string                    `json:"condition"`
+	Passed      bool                      `json:"passed"` // 全部子句满足；没有条件时为 true
+	Clauses     []ShoppingConditionClause `json:"clauses"`
+}
+
+// ShoppingConditionClause 单个子句的求值结果
+type ShoppingConditionClause struct {
+	Text   string `json:"text"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"` // 人物的实际情况与要求，无法解析时为错误说明
+}
+
+// 已完成任务的状态值
+var completedShiqingStatuses = []string{ShiqingStatusCompleted, "已完成"}
+
+var (
+	conditionClauseSeparators      = regexp.MustCompile(`[，,；;]|&&|[\s\p{Zs}]+且[\s\p{Zs}]+`)
+	conditionAlternativeSeparators = regexp.MustCompile(`\|\||[\s\p{Zs}]+或[\s\p{Zs}]+`)
+	// 按长度从长到短排列，避免 ">=" 被识别为 ">"
+	conditionOperators = []struct{ text, op string }{
+		{">=", ">="}, {"<=", "<="}, {"!=", "!="}, {"==", "="},
+		{"≥", ">="}, {"≤", "<="}, {"≠", "!="}, {"＞", ">"}, {"＜", "<"}, {"＝", "="},
+		{">", ">"}, {"<", "<"}, {"=", "="},
+	}
+)
+
+// 子句类型
+const (
+	conditionLevel     = "level"
+	conditionFaction   = "faction"
+	conditionAttribute = "attribute"
+	conditionOwns      = "owns"
+	conditionTask      = "task"
+)
+
+// conditionTerm 解析后的单个比较
+type conditionTerm struct {
+	text   string
+	negate bool
+	kind   string
+	key    string // 属性名、物品名或任务名
+	op     string
+	value  string
+	err    error
+}
+
+// EvaluateShoppingCondition 针对人物检查商品的购买条件，逐条说明每个子句是否满足
+func (db *Database) EvaluateShoppingCondition(itemID, characterID int) (ShoppingConditionResult, error) {
+	var condition string
+	err := db.db.QueryRow(`SELECT COALESCE(condition, '') FROM shopping WHERE id = ?`, itemID).Scan(&condition)
+	if err == sql.ErrNoRows {
+		return ShoppingConditionResult{}, fmt.Errorf("商品不存在")
+	}
+	if err != nil {
+		return ShoppingConditionResult{}, fmt.Errorf("查询商品失败: %v", err)
+	}
+
+	subject, err := loadConditionSubject(db.db, characterID)
+	if err != nil {
+		return ShoppingConditionResult{}, err
+	}
+
+	result := ShoppingConditionResult{
+		ItemID:      itemID,
+		CharacterID: characterID,
+		Condition:   condition,
+		Passed:      true,
+		Clauses:     []ShoppingConditionClause{},
+	}
+	for _, clause := range parseShoppingCondition(condition) {
+		evaluated, err := subject.evaluateClause(clause)
+		if err != nil {
+			return ShoppingConditionResult{}, err
+		}
+		result.Passed = result.Passed && evaluated.Passed
+		result.Clauses = append(result.Clauses, evaluated)
+	}
+	return result, nil
+}
+
+// parseShoppingCondition 把条件文本拆分为子句，每个子句由若干备选比较组成
+func parseShoppingCondition(condition string) [][]conditionTerm {
+	var clauses [][]conditionTerm
+	for _, clauseText := range splitCondition(condition, conditionClauseSeparators) {
+		var terms []conditionTerm
+		for _, termText := range splitCondition(clauseText, conditionAlternativeSeparators) {
+			terms = append(terms, parseConditionTerm(termText))
+		}
+		if len(terms) > 0 {
+			clauses = append(clauses, terms)
+		}
+	}
+	return clauses
+}
+
+// splitCondition 按分隔符拆分并去掉空白片段
+func splitCondition(text string, separators *regexp.Regexp) []string {
+	var parts []string
+	for _, part := range separators.Split(text, -1) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// parseConditionTerm 解析单个比较，无法识别时在 err 中说明
+func parseConditionTerm(text string) conditionTerm {
+	term := conditionTerm{text: text}
+	rest := text
+	for _, prefix := range []string{"非", "!", "！"} {
+		if strings.HasPrefix(rest, prefix) {
+			term.negate = true
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, prefix))
+			break
+		}
+	}
+
+	subject, op, value := splitConditionOperator(rest)
+	term.op, term.value = op, value
+	name, key, hasKey := strings.Cut(strings.ReplaceAll(subject, "：", ":"), ":")
+	name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+
+	switch {
+	case hasKey && name == "属性":
+		term.kind = conditionAttribute
+	case hasKey && name == "持有":
+		term.kind = conditionOwns
+		if term.op == "" {
+			term.op, term.value = ">=", "1"
+		}
+	case hasKey && name == "完成":
+		term.kind = conditionTask
+		if term.op != "" {
+			term.err = fmt.Errorf("任务条件不能带比较符")
+		}
+	case !hasKey && (name == "等级" || strings.EqualFold(name, "level")):
+		term.kind = conditionLevel
+	case !hasKey && (name == "势力" || strings.EqualFold(name, "faction")):
+		term.kind = conditionFaction
+		if term.op != "=" && term.op != "!=" {
+			term.err = fmt.Errorf("势力条件只支持 = 和 ≠")
+		}
+		return term
+	default:
+		term.err = fmt.Errorf("无法识别的条件")
+		return term
+	}
+	term.key = key
+	if hasKey && key == "" {
+		term.err = fmt.Errorf("缺少名称")
+		return term
+	}
+	if term.kind == conditionTask || term.err != nil {
+		return term
+	}
+	if term.op == "" {
+		term.err = fmt.Errorf("缺少比较符")
+	} else if _, err := strconv.Atoi(term.value); err != nil {
+		term.err = fmt.Errorf("比较值必须是整数")
+	}
+	return term
+}
+
+// splitConditionOperator 在第一个比较符处拆分，没有比较符时 op 为空
+func splitConditionOperator(text string) (subject, op, value string) {
+	index := -1
+	var match string
+	for _, candidate := range conditionOperators {
+		if i := strings.Index(text, candidate.text); i >= 0 && (index < 0 || i < index) {
+			index, match, op = i, candidate.text, candidate.op
+		}
+	}
+	if index < 0 {
+		return strings.TrimSpace(text), "", ""
+	}
+	return strings.TrimSpace(text[:index]), op, strings.TrimSpace(text[index+len(match):])
+}
+
+// conditionSubject 求值所需的人物信息
+type conditionSubject struct {
+	q          dbExecutor
+	id         int
+	level      int
+	faction    string
+	attributes map[string]int
+}
+
+// loadConditionSubject 读取人物等级、所属势力与属性
+func loadConditionSubject(q dbExecutor, characterID int) (*conditionSubject, error) {
+	subject := &conditionSubject{q: q, id: characterID, attributes: make(map[string]int)}
+	err := q.QueryRow(`
+	SELECT COALESCE(r.level, 0), COALESCE(s.name, r.shili, '')
+	FROM renwu r
+	LEFT JOIN shili s ON s.id = r.shili_id
+	WHERE r.id = ?`, characterID).Scan(&subject.level, &subject.faction)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("人物不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询人物失败: %v", err)
+	}
+
+	rows, err := q.Query(`SELECT name, COALESCE(value, 0) FROM renwu_attributes WHERE renwu_id = ?`, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("查询人物属性失败: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var value int
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("扫描人物属性失败: %v", err)
+		}
+		subject.attributes[name] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询人物属性失败: %v", err)
+	}
+	return subject, nil
+}
+
+// evaluateClause 求值一个子句：任一备选满足即满足
+func (s *conditionSubject) evaluateClause(terms []conditionTerm) (ShoppingConditionClause, error) {
+	texts := make([]string, len(terms))
+	details := make([]string, len(terms))
+	clause := ShoppingConditionClause{}
+	for i, term := range terms {
+		passed, detail, err := s.evaluateTerm(term)
+		if err != nil {
+			return ShoppingConditionClause{}, err
+		}
+		texts[i] = term.text
+		details[i] = detail
+		clause.Passed = clause.Passed || passed
+	}
+	clause.Text = strings.Join(texts, " 或 ")
+	clause.Detail = strings.Join(details, "；")
+	return clause, nil
+}
+
+// evaluateTerm 求值单个比较并给出说明
+func (s *conditionSubject) evaluateTerm(term conditionTerm) (bool, string, error) {
+	if term.err != nil {
+		return false, fmt.Sprintf("%s：%v", term.text, term.err), nil
+	}
+
+	var passed bool
+	var detail string
+	switch term.kind {
+	case conditionLevel:
+		passed = compareConditionInt(s.level, term.op, term.value)
+		detail = fmt.Sprintf("等级为 %d", s.level)
+	case conditionFaction:
+		passed = (s.faction == term.value) == (term.op == "=")
+		detail = fmt.Sprintf("所属势力为“%s”", s.faction)
+		if s.faction == "" {
+			detail = "未加入势力"
+		}
+	case conditionAttribute:
+		value, ok := s.attributes[term.key]
+		if !ok {
+			detail = fmt.Sprintf("没有属性“%s”", term.key)
+			break
+		}
+		passed = compareConditionInt(value, term.op, term.value)
+		detail = fmt.Sprintf("属性“%s”为 %d", term.key, value)
+	case conditionOwns:
+		count, err := characterOwnedCount(s.q, s.id, term.key)
+		if err != nil {
+			return false, "", err
+		}
+		passed = compareConditionInt(count, term.op, term.value)
+		detail = fmt.Sprintf("持有“%s” %d 个", term.key, count)
+	case conditionTask:
+		found, joined, completed, err := shiqingCompleted(s.q, s.id, term.key)
+		if err != nil {
+			return false, "", err
+		}
+		passed = completed
+		switch {
+		case !found:
+			detail = fmt.Sprintf("任务“%s”不存在", term.key)
+		case !joined:
+			detail = fmt.Sprintf("未参与任务“%s”", term.key)
+		case completed:
+			detail = fmt.Sprintf("任务“%s”已完成", term.key)
+		default:
+			detail = fmt.Sprintf("任务“%s”未完成", term.key)
+		}
+	}
+
+	if term.negate {
+		passed = !passed
+	}
+	return passed, detail, nil
+}
+
+// compareConditionInt 按比较符比较整数，value 已在解析时校验
+func compareConditionInt(actual int, op, value string) bool {
+	expected, _ := strconv.Atoi(value)
+	switch op {
+	case ">=":
+		return actual >= expected
+	case "<=":
+		return actual <= expected
+	case ">":
+		return actual > expected
+	case "<":
+		return actual < expected
+	case "!=":
+		return actual != expected
+	default:
+		return actual == expected
+	}
+}
+
+// characterOwnedCount 统计人物持有的同名武器、道具和宠物数量，以及人物名下背包中同名物品的件数
+func characterOwnedCount(q dbExecutor, characterID int, name string) (int, error) {
+	var count int
+	err := q.QueryRow(`
+	SELECT (SELECT COUNT(*) FROM wuqi WHERE holder_renwu_id = ?1 AND name = ?2)
+		+ (SELECT COUNT(*) FROM daoju WHERE holder_renwu_id = ?1 AND name = ?2)
+		+ (SELECT COUNT(*) FROM chongwu WHERE owner_renwu_id = ?1 AND name = ?2)
+		+ (SELECT COALESCE(SUM(i.quantity), 0) FROM beibao_items i JOIN beibao b ON b.id = i.beibao_id
+			WHERE b.owner_renwu_id = ?1 AND i.name = ?2)`, characterID, name).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("查询持有物品失败: %v", err)
+	}
+	return count, nil
+}
+
+// shiqingCompleted 返回是否存在同名任务、人物是否参与了其中的任务，以及参与的任务中是否有已完成的
+func shiqingCompleted(q dbExecutor, characterID int, name string) (bool, bool, bool, error) {
+	var total, joined, completed int
+	err := q.QueryRow(`
+	SELECT COUNT(*), COUNT(p.id), COALESCE(SUM(p.id IS NOT NULL AND s.status IN (?, ?)), 0)
+	FROM shiqing s
+	LEFT JOIN shiqing_participants p ON p.shiqing_id = s.id AND p.renwu_id = ?
+	WHERE s.name = ?`,
+		completedShiqingStatuses[0], completedShiqingStatuses[1], characterID, name).Scan(&total, &joined, &completed)
+	if err != nil {
+		return false, false, false, fmt.Errorf("查询任务失败: %v", err)
+	}
+	return total > 0, joined > 0, completed > 0, nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import "testing"
+
+func TestEvaluateShoppingCondition(t *testing.T) {
+	db := openTestDatabase(t)
+	db.CreateShili("青云门", "道玄", 5, 1000, 100)
+	characterID, err := db.CreateCharacter("张小凡", "青云门", 100, 35)
+	if err != nil {
+		t.Fatalf("CreateCharacter() failed: %v", err)
+	}
+	db.AddCharacterAttribute(characterID, "力量", "", 60)
+	db.CreateDaoju("噬魂棒", 3, "张小凡")
+	taskID, _ := db.CreateShiqing("七脉会武", "通天峰", "")
+	if err := db.AddShiqingParticipant(int(taskID), characterID, ""); err != nil {
+		t.Fatalf("AddShiqingParticipant() failed: %v", err)
+	}
+	if _, err := db.db.Exec(`UPDATE shiqing SET status = 'completed' WHERE id = ?`, taskID); err != nil {
+		t.Fatalf("update status failed: %v", err)
+	}
+
+	itemID, _ := db.CreateShopping("诛仙剑", 500, "", "等级≥30，势力=青云门；属性:力量>=50 且 持有:噬魂棒，完成:七脉会武，等级>40 或 属性：力量≥60，非持有:天书")
+	result, err := db.EvaluateShoppingCondition(int(itemID), characterID)
+	if err != nil {
+		t.Fatalf("EvaluateShoppingCondition() failed: %v", err)
+	}
+	if !result.Passed || len(result.Clauses) != 7 {
+		t.Fatalf("expected all 7 clauses to pass, got %+v", result)
+	}
+
+	db.UpdateShopping(int(itemID), "诛仙剑", 500, "", "等级≥50，势力≠青云门，属性:智力≥1，完成:不存在的任务，VIP专属")
+	result, err = db.EvaluateShoppingCondition(int(itemID), characterID)
+	if err != nil {
+		t.Fatalf("EvaluateShoppingCondition() failed: %v", err)
+	}
+	if result.Passed || len(result.Clauses) != 5 {
+		t.Fatalf("expected 5 failing clauses, got %+v", result)
+	}
+	for _, clause := range result.Clauses {
+		if clause.Passed || clause.Detail == "" {
+			t.Fatalf("expected failing clause with detail, got %+v", clause)
+		}
+	}
+	if result.Clauses[0].Detail != "等级为 35" {
+		t.Fatalf("unexpected level detail: %q", result.Clauses[0].Detail)
+	}
+
+	// 不带空白的“且”“或”属于名称；只有参与过的已完成任务才算完成
+	db.CreateDaoju("或天剑", 1, "张小凡")
+	otherID, _ := db.CreateCharacter("林惊羽", "青云门", 0, 35)
+	db.UpdateShopping(int(itemID), "诛仙剑", 500, "", "持有:或天剑 且 等级≥30，非持有:且战且退 或 等级>99")
+	result, _ = db.EvaluateShoppingCondition(int(itemID), characterID)
+	if !result.Passed || len(result.Clauses) != 3 || result.Clauses[0].Text != "持有:或天剑" || result.Clauses[2].Text != "非持有:且战且退 或 等级>99" {
+		t.Fatalf("unexpected split of names containing 且/或: %+v", result)
+	}
+	db.UpdateShopping(int(itemID), "诛仙剑", 500, "", "完成:七脉会武")
+	result, _ = db.EvaluateShoppingCondition(int(itemID), otherID)
+	if result.Passed || len(result.Clauses) != 1 || result.Clauses[0].Detail != "未参与任务“七脉会武”" {
+		t.Fatalf("expected quest condition to require participation, got %+v", result)
+	}
+
+	db.UpdateShopping(int(itemID), "诛仙剑", 500, "", "")
+	result, _ = db.EvaluateShoppingCondition(int(itemID), characterID)
+	if !result.Passed || len(result.Clauses) != 0 {
+		t.Fatalf("empty condition should pass, got %+v", result)
+	}
+	if _, err := db.EvaluateShoppingCondition(int(itemID), 9999); err == nil {
+		t.Fatalf("expected error for missing character")
+	}
+}
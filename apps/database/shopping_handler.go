@@ -1,5 +1,7 @@
 package database
 
+import "database/sql"
+
 // ShoppingInfo 商城商品信息结构体
 type ShoppingInfo struct {
 	ID          int    `json:"id"`
@@ -7,35 +9,47 @@ type ShoppingInfo struct {
 	Value       int    `json:"value"`
 	Description string `json:"description"`
 	Condition   string `json:"condition"`
+	Stock       *int   `json:"stock"` // 库存，为空表示不限
+}
+
+// shoppingListSpec 商城商品列表的筛选与排序
+var shoppingListSpec = listSpec{
+	label:       "商品",
+	table:       "shopping",
+	sortColumns: map[string]string{"id": "id", "name": "name", "value": "value"},
+	defaultSort: "id",
 }
 
 // GetAllShopping 获取所有商城商品
 func (db *Database) GetAllShopping() ([]ShoppingInfo, error) {
-	query := `SELECT id, name, value, description, condition FROM shopping ORDER BY id`
-	rows, err := db.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	items, _, err := db.ListShopping(ListQuery{})
+	return items, err
+}
 
+// ListShopping 按筛选、排序和分页条件获取商城商品
+func (db *Database) ListShopping(q ListQuery) ([]ShoppingInfo, ListResult, error) {
 	var items []ShoppingInfo
-	for rows.Next() {
+	result, err := db.queryList(shoppingListSpec, q, `id, name, value, description, condition, stock`, func(rows *sql.Rows) (int64, error) {
 		var item ShoppingInfo
-		err := rows.Scan(&item.ID, &item.Name, &item.Value, &item.Description, &item.Condition)
+		err := rows.Scan(&item.ID, &item.Name, &item.Value, &item.Description, &item.Condition, &item.Stock)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 		items = append(items, item)
+		return int64(item.ID), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return items, nil
+	return items, result, nil
 }
 
 // GetShoppingInfo 获取指定商城商品的详细信息
 func (db *Database) GetShoppingInfo(id int) (ShoppingInfo, error) {
-	query := `SELECT id, name, value, description, condition FROM shopping WHERE id = ?`
+	query := `SELECT id, name, value, description, condition, stock FROM shopping WHERE id = ?`
 	var item ShoppingInfo
-	err := db.db.QueryRow(query, id).Scan(&item.ID, &item.Name, &item.Value, &item.Description, &item.Condition)
+	err := db.db.QueryRow(query, id).Scan(&item.ID, &item.Name, &item.Value, &item.Description, &item.Condition, &item.Stock)
 	if err != nil {
 		return ShoppingInfo{}, err
 	}
@@ -0,0 +1,153 @@
+// 商城购买：背包持有货币余额，商品可设置库存；购买时在同一事务内检查余额与库存、扣款、
+// 扣减库存、把商品放入背包并写入购买记录
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ShoppingPurchase 购买记录
+type ShoppingPurchase struct {
+	ID           int    `json:"id"`
+	ShoppingID   int    `json:"shopping_id"` // 商品已删除时为 0
+	ItemName     string `json:"item_name"`
+	BeibaoID     int    `json:"beibao_id"` // 买方背包，背包已删除时为 0
+	Quantity     int    `json:"quantity"`
+	UnitPrice    int    `json:"unit_price"`
+	TotalPrice   int    `json:"total_price"`
+	BalanceAfter int    `json:"balance_after"` // 购买后买方背包的余额
+	PurchasedAt  string `json:"purchased_at"`
+}
+
+// SetShoppingStock 设置商品库存，stock 为 nil 表示不限库存
+func (db *Database) SetShoppingStock(itemID int, stock *int) error {
+	if stock != nil && *stock < 0 {
+		return fmt.Errorf("库存不能为负数")
+	}
+	result, err := db.db.Exec(`UPDATE shopping SET stock = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, stock, itemID)
+	if err != nil {
+		return fmt.Errorf("更新商品库存失败: %v", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("商品不存在")
+	}
+	return nil
+}
+
+// PurchaseShoppingItem 用买方背包（buyerID）的余额购买 qty 件商品，商品放入该背包；
+// 余额或库存不足时不做任何修改
+func (db *Database) PurchaseShoppingItem(itemID, buyerID, qty int) (ShoppingPurchase, error) {
+	if qty <= 0 {
+		return ShoppingPurchase{}, fmt.Errorf("购买数量必须大于 0")
+	}
+
+	var purchase ShoppingPurchase
+	err := db.WithTx(func(tx *sql.Tx) error {
+		var description string
+		var stock sql.NullInt64
+		err := tx.QueryRow(`SELECT id, name, value, COALESCE(description, ''), stock FROM shopping WHERE id = ?`, itemID).
+			Scan(&purchase.ShoppingID, &purchase.ItemName, &purchase.UnitPrice, &description, &stock)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("商品不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询商品失败: %v", err)
+		}
+		if purchase.UnitPrice < 0 {
+			return fmt.Errorf("商品「%s」的价格无效", purchase.ItemName)
+		}
+		if purchase.UnitPrice > 0 && qty > math.MaxInt32/purchase.UnitPrice {
+			return fmt.Errorf("购买总价过大")
+		}
+		if stock.Valid && stock.Int64 < int64(qty) {
+			return fmt.Errorf("商品「%s」库存不足（剩余 %d）", purchase.ItemName, stock.Int64)
+		}
+
+		var balance int
+		err = tx.QueryRow(`SELECT balance FROM beibao WHERE id = ?`, buyerID).Scan(&balance)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("背包不存在")
+		}
+		if err != nil {
+			return fmt.Errorf("查询背包余额失败: %v", err)
+		}
+
+		purchase.BeibaoID = buyerID
+		purchase.Quantity = qty
+		purchase.TotalPrice = purchase.UnitPrice * qty
+		if balance < purchase.TotalPrice {
+			return fmt.Errorf("余额不足：需要 %d，当前 %d", purchase.TotalPrice, balance)
+		}
+		purchase.BalanceAfter = balance - purchase.TotalPrice
+
+		if purchase.TotalPrice > 0 {
+			_, err := postLedgerEntry(tx, LedgerAccount{Type: LedgerAccountBeibao, ID: buyerID}, LedgerAccount{Type: LedgerAccountShop, ID: itemID},
+				purchase.TotalPrice, fmt.Sprintf("购买「%s」×%d", purchase.ItemName, qty), 0)
+			if err != nil {
+				return err
+			}
+		}
+		if stock.Valid {
+			if _, err := tx.Exec(`UPDATE shopping SET stock = stock - ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, qty, itemID); err != nil {
+				return fmt.Errorf("扣减库存失败: %v", err)
+			}
+		}
+		if err := depositBeibaoItem(tx, buyerID, purchase.ItemName, qty, description, 0); err != nil {
+			return err
+		}
+
+		purchase.PurchasedAt = time.Now().Format("2006-01-02 15:04:05")
+		result, err := tx.Exec(`
+		INSERT INTO shopping_purchases (shopping_id, item_name, beibao_id, quantity, unit_price, total_price, balance_after, purchased_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			purchase.ShoppingID, purchase.ItemName, purchase.BeibaoID, purchase.Quantity,
+			purchase.UnitPrice, purchase.TotalPrice, purchase.BalanceAfter, purchase.PurchasedAt)
+		if err != nil {
+			return fmt.Errorf("保存购买记录失败: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("保存购买记录失败: %v", err)
+		}
+		purchase.ID = int(id)
+		return nil
+	})
+	if err != nil {
+		return ShoppingPurchase{}, err
+	}
+	return purchase, nil
+}
+
+// GetShoppingPurchases 获取购买记录，按时间倒序；beibaoID 为 0 时返回所有背包的记录
+func (db *Database) GetShoppingPurchases(beibaoID, limit int) ([]ShoppingPurchase, error) {
+	query := `
+	SELECT id, COALESCE(shopping_id, 0), item_name, COALESCE(beibao_id, 0), quantity, unit_price, total_price, balance_after, purchased_at
+	FROM shopping_purchases`
+	var args []interface{}
+	if beibaoID != 0 {
+		query += ` WHERE beibao_id = ?`
+		args = append(args, beibaoID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询购买记录失败: %v", err)
+	}
+	defer rows.Close()
+
+	purchases := []ShoppingPurchase{}
+	for rows.Next() {
+		var p ShoppingPurchase
+		if err := rows.Scan(&p.ID, &p.ShoppingID, &p.ItemName, &p.BeibaoID, &p.Quantity, &p.UnitPrice, &p.TotalPrice, &p.BalanceAfter, &p.PurchasedAt); err != nil {
+			return nil, fmt.Errorf("扫描购买记录失败: %v", err)
+		}
+		purchases = append(purchases, p)
+	}
+	return purchases, rows.Err()
+}
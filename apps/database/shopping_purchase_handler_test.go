@@ -0,0 +1,62 @@
+package database
+
+import "testing"
+
+func TestPurchaseShoppingItem(t *testing.T) {
+	db := openTestDatabase(t)
+	itemID, _ := db.CreateShopping("回血丹", 30, "恢复气血", "")
+	stock := 5
+	if err := db.SetShoppingStock(int(itemID), &stock); err != nil {
+		t.Fatalf("SetShoppingStock() failed: %v", err)
+	}
+	beibaoID, _ := db.CreateBeibao("林动的背包")
+	if err := db.SetBeibaoBalance(int(beibaoID), 100); err != nil {
+		t.Fatalf("SetBeibaoBalance() failed: %v", err)
+	}
+
+	purchase, err := db.PurchaseShoppingItem(int(itemID), int(beibaoID), 2)
+	if err != nil {
+		t.Fatalf("PurchaseShoppingItem() failed: %v", err)
+	}
+	if purchase.TotalPrice != 60 || purchase.BalanceAfter != 40 {
+		t.Fatalf("unexpected purchase: %+v", purchase)
+	}
+	if _, err := db.PurchaseShoppingItem(int(itemID), int(beibaoID), 1); err != nil {
+		t.Fatalf("PurchaseShoppingItem() failed: %v", err)
+	}
+
+	// 余额不足与库存不足都不修改任何数据
+	if _, err := db.PurchaseShoppingItem(int(itemID), int(beibaoID), 1); err == nil {
+		t.Fatalf("expected error for insufficient balance")
+	}
+	if err := db.SetBeibaoBalance(int(beibaoID), 1000); err != nil {
+		t.Fatalf("SetBeibaoBalance() failed: %v", err)
+	}
+	if _, err := db.PurchaseShoppingItem(int(itemID), int(beibaoID), 3); err == nil {
+		t.Fatalf("expected error for insufficient stock")
+	}
+
+	info, _ := db.GetBeibaoInfo(int(beibaoID))
+	if info.Balance != 1000 || len(info.Items) != 1 || info.Items[0].Quantity != 3 || info.Items[0].Description != "恢复气血" {
+		t.Fatalf("unexpected backpack state: %+v", info)
+	}
+	item, _ := db.GetShoppingInfo(int(itemID))
+	if item.Stock == nil || *item.Stock != 2 {
+		t.Fatalf("expected 2 left in stock, got %v", item.Stock)
+	}
+	purchases, err := db.GetShoppingPurchases(int(beibaoID), 10)
+	if err != nil || len(purchases) != 2 || purchases[0].Quantity != 1 {
+		t.Fatalf("GetShoppingPurchases() = %+v, %v", purchases, err)
+	}
+
+	// 不限库存
+	if err := db.SetShoppingStock(int(itemID), nil); err != nil {
+		t.Fatalf("SetShoppingStock(nil) failed: %v", err)
+	}
+	if _, err := db.PurchaseShoppingItem(int(itemID), int(beibaoID), 10); err != nil {
+		t.Fatalf("PurchaseShoppingItem() with unlimited stock failed: %v", err)
+	}
+	if _, err := db.PurchaseShoppingItem(int(itemID), 9999, 1); err == nil {
+		t.Fatalf("expected error for missing backpack")
+	}
+}
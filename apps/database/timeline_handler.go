@@ -0,0 +1,325 @@
+// 世界时间线：把人物出生、势力创立和任务按世界日期合并为一条有序的事件流，
+// 并标出明显的前后矛盾（如参与者在任务发生时尚未出生），便于检查剧情的连贯性
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// 时间线事件类型
+const (
+	TimelineBirth    = "birth"    // 人物出生
+	TimelineFounding = "founding" // 势力创立
+	TimelineShiqing  = "shiqing"  // 任务
+)
+
+// timelineKindOrder 同一天内事件的排列顺序
+var timelineKindOrder = map[string]int{TimelineFounding: 0, TimelineBirth: 1, TimelineShiqing: 2}
+
+// TimelineEvent 时间线上的一个事件
+type TimelineEvent struct {
+	Day      int      `json:"day"`  // 日序
+	Date     string   `json:"date"` // 日期文本
+	Kind     string   `json:"kind"`
+	EntityID int      `json:"entity_id"` // 人物、势力或任务的ID
+	Title    string   `json:"title"`
+	Detail   string   `json:"detail"`
+	Warnings []string `json:"warnings"` // 与其他事件的前后矛盾
+}
+
+// TimelineFilter 时间线筛选条件
+type TimelineFilter struct {
+	Kinds       []string `json:"kinds"`        // 只包含这些类型的事件，为空表示全部
+	CharacterID int      `json:"character_id"` // 不为 0 时只包含该人物的出生、参与的任务和所属势力的创立
+	ShiliID     int      `json:"shili_id"`     // 不为 0 时只包含该势力的创立、成员的出生和成员参与的任务
+}
+
+// SetCharacterBirth 设置人物的出生日期，date 为空时清除
+func (d *Database) SetCharacterBirth(characterID int, date string) error {
+	return d.setWorldDate("renwu", "birth_day", "人物", characterID, date)
+}
+
+// SetShiliFounding 设置势力的创立日期，date 为空时清除
+func (d *Database) SetShiliFounding(shiliID int, date string) error {
+	return d.setWorldDate("shili", "founded_day", "势力", shiliID, date)
+}
+
+// SetShiqingWorldDate 设置任务发生的世界日期，date 为空时清除
+func (d *Database) SetShiqingWorldDate(shiqingID int, date string) error {
+	return d.setWorldDate("shiqing", "world_day", "任务", shiqingID, date)
+}
+
+// setWorldDate 按当前历法解析日期并保存日序
+func (d *Database) setWorldDate(table, column, label string, id int, date string) error {
+	var ordinal interface{}
+	if date != "" {
+		parsed, err := d.ParseWorldDate(date)
+		if err != nil {
+			return err
+		}
+		ordinal = parsed.Ordinal
+	}
+
+	result, err := d.db.Exec(`UPDATE `+table+` SET `+column+` = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, ordinal, id)
+	if err != nil {
+		return fmt.Errorf("设置%s日期失败: %v", label, err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("%s不存在", label)
+	}
+	return nil
+}
+
+// GetTimeline 获取 from 到 to（含）之间的事件，按世界日期排序；from、to 为日期文本，为空表示不限
+func (d *Database) GetTimeline(from, to string, filter TimelineFilter) ([]TimelineEvent, error) {
+	calendar, err := loadWorldCalendar(d.db)
+	if err != nil {
+		return nil, err
+	}
+	low, high := 0, -1
+	if from != "" {
+		date, err := calendar.Parse(from)
+		if err != nil {
+			return nil, err
+		}
+		low = date.Ordinal
+	}
+	if to != "" {
+		date, err := calendar.Parse(to)
+		if err != nil {
+			return nil, err
+		}
+		high = date.Ordinal
+		if high < low {
+			return nil, fmt.Errorf("结束日期不能早于开始日期")
+		}
+	}
+
+	kinds := map[string]bool{}
+	for _, kind := range filter.Kinds {
+		if _, ok := timelineKindOrder[kind]; !ok {
+			return nil, fmt.Errorf("无效的事件类型: %s", kind)
+		}
+		kinds[kind] = true
+	}
+
+	events := []TimelineEvent{}
+	for _, source := range timelineSources(filter) {
+		if len(kinds) > 0 && !kinds[source.kind] {
+			continue
+		}
+		source.where(source.dayColumn+` >= ?`, low)
+		if high >= 0 {
+			source.where(source.dayColumn+` <= ?`, high)
+		}
+		query := source.query
+		for _, condition := range source.conditions {
+			query += ` AND ` + condition
+		}
+		found, err := queryTimelineEvents(d.db, source.kind, query, source.args)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, found...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Day != events[j].Day {
+			return events[i].Day < events[j].Day
+		}
+		if events[i].Kind != events[j].Kind {
+			return timelineKindOrder[events[i].Kind] < timelineKindOrder[events[j].Kind]
+		}
+		return events[i].EntityID < events[j].EntityID
+	})
+
+	ctx, err := loadTimelineContext(d.db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		date, err := calendar.FromOrdinal(events[i].Day)
+		if err != nil {
+			return nil, err
+		}
+		events[i].Date = date.Text
+		if events[i].Warnings, err = ctx.warnings(calendar, events[i]); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// timelineSource 一类事件的查询，结果列为日序、ID、标题和说明
+type timelineSource struct {
+	kind       string
+	query      string // 不含筛选条件的查询，已包含 WHERE
+	dayColumn  string
+	conditions []string
+	args       []interface{}
+}
+
+// where 追加筛选条件
+func (s *timelineSource) where(condition string, arg interface{}) {
+	s.conditions = append(s.conditions, condition)
+	s.args = append(s.args, arg)
+}
+
+// timelineSources 按筛选条件生成各类事件的查询
+func timelineSources(filter TimelineFilter) []*timelineSource {
+	founding := &timelineSource{kind: TimelineFounding, dayColumn: "founded_day", query: `
+	SELECT founded_day, id, name || '创立', CASE WHEN COALESCE(founder, '') = '' THEN '' ELSE '创立者：' || founder END
+	FROM shili WHERE founded_day IS NOT NULL`}
+	birth := &timelineSource{kind: TimelineBirth, dayColumn: "birth_day", query: `
+	SELECT birth_day, id, name || '出生', COALESCE(shili, '')
+	FROM renwu WHERE birth_day IS NOT NULL`}
+	shiqing := &timelineSource{kind: TimelineShiqing, dayColumn: "world_day", query: `
+	SELECT world_day, id, name, COALESCE(location, '')
+	FROM shiqing WHERE world_day IS NOT NULL`}
+
+	if filter.CharacterID != 0 {
+		founding.where(`id = (SELECT shili_id FROM renwu WHERE id = ?)`, filter.CharacterID)
+		birth.where(`id = ?`, filter.CharacterID)
+		shiqing.where(`id IN (SELECT shiqing_id FROM shiqing_participants WHERE renwu_id = ?)`, filter.CharacterID)
+	}
+	if filter.ShiliID != 0 {
+		founding.where(`id = ?`, filter.ShiliID)
+		birth.where(`shili_id = ?`, filter.ShiliID)
+		shiqing.where(`id IN (SELECT p.shiqing_id FROM shiqing_participants p JOIN renwu r ON r.id = p.renwu_id WHERE r.shili_id = ?)`, filter.ShiliID)
+	}
+	return []*timelineSource{founding, birth, shiqing}
+}
+
+// queryTimelineEvents 执行一类事件的查询
+func queryTimelineEvents(q dbExecutor, kind, query string, args []interface{}) ([]TimelineEvent, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询时间线失败: %v", err)
+	}
+	defer rows.Close()
+
+	var events []TimelineEvent
+	for rows.Next() {
+		event := TimelineEvent{Kind: kind, Warnings: []string{}}
+		if err := rows.Scan(&event.Day, &event.EntityID, &event.Title, &event.Detail); err != nil {
+			return nil, fmt.Errorf("扫描时间线失败: %v", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// timelineContext 检查前后矛盾所需的人物出生日期、任务参与者和势力创立者，每次查询时间线只读取一次
+type timelineContext struct {
+	births       map[int]timelineBirth // 人物ID -> 名称与出生日序，只包含设置了出生日期的人物
+	participants map[int][]int         // 任务ID -> 参与者人物ID
+	founders     map[int]int           // 势力ID -> 创立者人物ID
+}
+
+// timelineBirth 人物的名称与出生日序
+type timelineBirth struct {
+	name string
+	day  int
+}
+
+// loadTimelineContext 读取所有人物的出生日期、任务参与者和势力创立者
+func loadTimelineContext(q dbExecutor) (*timelineContext, error) {
+	ctx := &timelineContext{births: map[int]timelineBirth{}, participants: map[int][]int{}, founders: map[int]int{}}
+
+	rows, err := q.Query(`SELECT id, name, birth_day FROM renwu WHERE birth_day IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("检查时间线失败: %v", err)
+	}
+	for rows.Next() {
+		var id int
+		var birth timelineBirth
+		if err := rows.Scan(&id, &birth.name, &birth.day); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("扫描时间线失败: %v", err)
+		}
+		ctx.births[id] = birth
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("检查时间线失败: %v", err)
+	}
+
+	rows, err = q.Query(`SELECT shiqing_id, renwu_id FROM shiqing_participants ORDER BY renwu_id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("检查时间线失败: %v", err)
+	}
+	for rows.Next() {
+		var shiqingID, renwuID int
+		if err := rows.Scan(&shiqingID, &renwuID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("扫描时间线失败: %v", err)
+		}
+		ctx.participants[shiqingID] = append(ctx.participants[shiqingID], renwuID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("检查时间线失败: %v", err)
+	}
+
+	rows, err = q.Query(`SELECT id, founder_renwu_id FROM shili WHERE founder_renwu_id IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("检查时间线失败: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var shiliID, renwuID int
+		if err := rows.Scan(&shiliID, &renwuID); err != nil {
+			return nil, fmt.Errorf("扫描时间线失败: %v", err)
+		}
+		ctx.founders[shiliID] = renwuID
+	}
+	return ctx, rows.Err()
+}
+
+// warnings 检查事件与相关人物出生日期的矛盾：任务的参与者、势力的创立者在事件发生时尚未出生
+func (ctx *timelineContext) warnings(calendar WorldCalendar, event TimelineEvent) ([]string, error) {
+	var people []int
+	var format string
+	switch event.Kind {
+	case TimelineShiqing:
+		people = ctx.participants[event.EntityID]
+		format = "参与者「%s」此时尚未出生（生于%s）"
+	case TimelineFounding:
+		if founderID, ok := ctx.founders[event.EntityID]; ok {
+			people = []int{founderID}
+		}
+		format = "创立者「%s」此时尚未出生（生于%s）"
+	}
+
+	warnings := []string{}
+	for _, id := range people {
+		birth, ok := ctx.births[id]
+		if !ok || birth.day <= event.Day {
+			continue
+		}
+		date, err := calendar.FromOrdinal(birth.day)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, fmt.Sprintf(format, birth.name, date.Text))
+	}
+	return warnings, nil
+}
+
+// formatWorldDay 把可为空的日序格式化为日期文本，为空时返回空字符串
+func formatWorldDay(q dbExecutor, day sql.NullInt64) (string, error) {
+	if !day.Valid {
+		return "", nil
+	}
+	calendar, err := loadWorldCalendar(q)
+	if err != nil {
+		return "", err
+	}
+	date, err := calendar.FromOrdinal(int(day.Int64))
+	if err != nil {
+		return "", err
+	}
+	return date.Text, nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import "testing"
+
+func TestGetTimeline(t *testing.T) {
+	db := openTestDatabase(t)
+	if err := db.SetWorldCalendar(testWorldCalendar()); err != nil {
+		t.Fatalf("SetWorldCalendar() failed: %v", err)
+	}
+	heroID, _ := db.CreateCharacter("林动", "", 0, 1)
+	shiliID, _ := db.CreateShili("道宗", "林动", 1, 0, 10)
+	db.SetCharacterShili(heroID, int(shiliID))
+	friendID, _ := db.CreateCharacter("林炎", "", 0, 1)
+	taskID, _ := db.CreateShiqing("除魔任务", "青阳镇", "")
+	otherTaskID, _ := db.CreateShiqing("护送商队", "", "")
+	db.CreateShiqing("未定日期的任务", "", "")
+	db.AddShiqingParticipant(int(taskID), heroID, "")
+	db.AddShiqingParticipant(int(otherTaskID), friendID, "")
+
+	for _, step := range []error{
+		db.SetShiliFounding(int(shiliID), "玄天10年春月1日"),
+		db.SetCharacterBirth(heroID, "玄天12年夏月3日"),
+		db.SetCharacterBirth(friendID, "玄天8年春月1日"),
+		db.SetShiqingWorldDate(int(taskID), "玄天30年秋月1日"),
+		db.SetShiqingWorldDate(int(otherTaskID), "玄天10年春月1日"),
+	} {
+		if step != nil {
+			t.Fatalf("setting world dates failed: %v", step)
+		}
+	}
+	if err := db.SetShiqingWorldDate(int(taskID), "玄天30年闰月1日"); err == nil {
+		t.Fatalf("expected error for an invalid date")
+	}
+
+	events, err := db.GetTimeline("", "", TimelineFilter{})
+	if err != nil {
+		t.Fatalf("GetTimeline() failed: %v", err)
+	}
+	var titles []string
+	for _, e := range events {
+		titles = append(titles, e.Title)
+	}
+	want := []string{"林炎出生", "道宗创立", "护送商队", "林动出生", "除魔任务"}
+	if len(titles) != len(want) {
+		t.Fatalf("GetTimeline() = %v, want %v", titles, want)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Fatalf("GetTimeline() = %v, want %v", titles, want)
+		}
+	}
+	// 道宗创立时创立者林动尚未出生
+	if founding := events[1]; founding.Date != "玄天10年春月1日" || len(founding.Warnings) != 1 {
+		t.Fatalf("expected a founder warning, got %+v", founding)
+	}
+
+	events, err = db.GetTimeline("玄天10年春月1日", "玄天20年冬月29日", TimelineFilter{Kinds: []string{TimelineBirth, TimelineShiqing}})
+	if err != nil || len(events) != 2 || events[0].Title != "护送商队" || events[1].Title != "林动出生" {
+		t.Fatalf("GetTimeline() with range and kinds = %+v, %v", events, err)
+	}
+
+	events, err = db.GetTimeline("", "", TimelineFilter{CharacterID: heroID})
+	if err != nil || len(events) != 3 || events[2].EntityID != int(taskID) {
+		t.Fatalf("GetTimeline() for a character = %+v, %v", events, err)
+	}
+
+	// 把任务挪到林动出生之前，参与者出现矛盾
+	db.SetShiqingWorldDate(int(taskID), "玄天11年春月1日")
+	events, _ = db.GetTimeline("", "", TimelineFilter{Kinds: []string{TimelineShiqing}, ShiliID: int(shiliID)})
+	if len(events) != 1 || len(events[0].Warnings) != 1 {
+		t.Fatalf("expected a participant warning, got %+v", events)
+	}
+
+	// 创立者按人物ID关联：改名后仍能发现矛盾，新增同名人物不会被误认为创立者
+	if err := db.UpdateCharacterBasicInfo(heroID, "林动天", "道宗", 0, 1); err != nil {
+		t.Fatalf("UpdateCharacterBasicInfo() failed: %v", err)
+	}
+	namesakeID, _ := db.CreateCharacter("林动", "", 0, 1)
+	db.SetCharacterBirth(namesakeID, "玄天50年春月1日")
+	events, _ = db.GetTimeline("", "", TimelineFilter{Kinds: []string{TimelineFounding}})
+	if len(events) != 1 || len(events[0].Warnings) != 1 || events[0].Warnings[0] != "创立者「林动天」此时尚未出生（生于玄天12年夏月3日）" {
+		t.Fatalf("expected the founder warning to follow the renamed character, got %+v", events)
+	}
+
+	if _, err := db.GetTimeline("玄天20年春月1日", "玄天10年春月1日", TimelineFilter{}); err == nil {
+		t.Fatalf("expected error when the range is reversed")
+	}
+}
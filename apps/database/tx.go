@@ -0,0 +1,38 @@
+// 事务辅助方法：保证多条语句组成的操作要么全部生效，要么全部回滚
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// WithTx 在单个事务中执行 fn；fn 返回错误或发生 panic 时回滚，否则提交
+func (d *Database) WithTx(fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	return runInTx(tx, fn)
+}
+
+// runInTx 在已开启的事务中执行 fn 并负责提交或回滚
+func runInTx(tx *sql.Tx, fn func(tx *sql.Tx) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w（回滚事务失败: %v）", err, rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	return nil
+}
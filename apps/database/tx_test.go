@@ -0,0 +1,108 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestWithTx_RollsBackOnError(t *testing.T) {
+	db := openTestDatabase(t)
+
+	errBoom := errors.New("boom")
+	err := db.WithTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`INSERT INTO beibao (name) VALUES ('临时背包')`); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected errBoom, got %v", err)
+	}
+
+	list, err := db.GetAllBeibao()
+	if err != nil {
+		t.Fatalf("GetAllBeibao() failed: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("expected rollback to discard insert, got %v", list)
+	}
+}
+
+func TestWithTx_RollsBackOnPanic(t *testing.T) {
+	db := openTestDatabase(t)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expected panic to propagate")
+			}
+		}()
+		_ = db.WithTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`INSERT INTO beibao (name) VALUES ('临时背包')`); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+
+	list, err := db.GetAllBeibao()
+	if err != nil {
+		t.Fatalf("GetAllBeibao() failed: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("expected rollback after panic, got %v", list)
+	}
+}
+
+func TestCreateCharacter_IsAtomic(t *testing.T) {
+	db := openTestDatabase(t)
+
+	// 让默认属性写入失败，人物本身也不应被保留
+	if _, err := db.GetDB().Exec(`
+	CREATE TRIGGER fail_attribute BEFORE INSERT ON renwu_attributes
+	BEGIN
+		SELECT RAISE(ABORT, 'attribute insert blocked');
+	END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+
+	if _, err := db.CreateCharacter("半成品", "无", 100, 1); err == nil {
+		t.Fatalf("expected CreateCharacter() to fail")
+	}
+
+	characters, err := db.GetAllCharacters()
+	if err != nil {
+		t.Fatalf("GetAllCharacters() failed: %v", err)
+	}
+	if len(characters) != 0 {
+		t.Fatalf("expected no half-built character, got %v", characters)
+	}
+}
+
+func TestDrawTenPrizes_RecordsAllResults(t *testing.T) {
+	db := openTestDatabase(t)
+
+	if _, err := db.CreatePrize(0, "金币", 90, "", "普通"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if _, err := db.CreatePrize(0, "神器", 10, "", "传说"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+
+	results, err := db.DrawTenPrizes(0, 0)
+	if err != nil {
+		t.Fatalf("DrawTenPrizes() failed: %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("expected 10 results, got %d", len(results))
+	}
+
+	history, err := db.GetDrawHistory(0, 100)
+	if err != nil {
+		t.Fatalf("GetDrawHistory() failed: %v", err)
+	}
+	if len(history) != 10 {
+		t.Fatalf("expected 10 history rows, got %d", len(history))
+	}
+}
@@ -31,27 +31,33 @@ type WeaponInfo struct {
 	Skills     []WeaponSkill     `json:"skills"`
 }
 
+// weaponListSpec 武器列表的筛选与排序
+var weaponListSpec = listSpec{
+	label:         "武器",
+	table:         "wuqi",
+	levelColumn:   "level",
+	ownerColumn:   "holder",
+	ownerIDColumn: "holder_renwu_id",
+	sortColumns:   map[string]string{"id": "id", "name": "name", "level": "level", "holder": "holder"},
+	defaultSort:   "id",
+}
+
 // GetAllWeapons 获取所有武器列表
 func (d *Database) GetAllWeapons() ([]map[string]interface{}, error) {
-	query := `
-	SELECT id, name, holder, level
-	FROM wuqi
-	ORDER BY id ASC`
-
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("查询武器列表失败: %v", err)
-	}
-	defer rows.Close()
+	weapons, _, err := d.ListWeapons(ListQuery{})
+	return weapons, err
+}
 
+// ListWeapons 按筛选、排序和分页条件获取武器列表
+func (d *Database) ListWeapons(q ListQuery) ([]map[string]interface{}, ListResult, error) {
 	var weapons []map[string]interface{}
-	for rows.Next() {
+	result, err := d.queryList(weaponListSpec, q, `id, name, holder, level`, func(rows *sql.Rows) (int64, error) {
 		var id int
 		var name, holder string
 		var level int
 
 		if err := rows.Scan(&id, &name, &holder, &level); err != nil {
-			return nil, fmt.Errorf("扫描武器数据失败: %v", err)
+			return 0, fmt.Errorf("扫描武器数据失败: %v", err)
 		}
 
 		weapon := map[string]interface{}{
@@ -61,9 +67,13 @@ func (d *Database) GetAllWeapons() ([]map[string]interface{}, error) {
 			"level":  level,
 		}
 		weapons = append(weapons, weapon)
+		return int64(id), nil
+	})
+	if err != nil {
+		return nil, result, err
 	}
 
-	return weapons, nil
+	return weapons, result, nil
 }
 
 // GetWeaponInfo 获取武器详细信息（包含属性和技能）
@@ -154,11 +164,16 @@ func (d *Database) GetWeaponSkills(weaponID int) ([]WeaponSkill, error) {
 
 // AddWeaponAttribute 添加武器属性
 func (d *Database) AddWeaponAttribute(weaponID int, name, description string, value int) error {
+	return addWeaponAttribute(d.db, weaponID, name, description, value)
+}
+
+// addWeaponAttribute 使用指定的执行器添加武器属性，供事务内复用
+func addWeaponAttribute(tx dbExecutor, weaponID int, name, description string, value int) error {
 	query := `
 	INSERT INTO wuqi_attributes (wuqi_id, name, description, value)
 	VALUES (?, ?, ?, ?)`
 
-	_, err := d.db.Exec(query, weaponID, name, description, value)
+	_, err := tx.Exec(query, weaponID, name, description, value)
 	if err != nil {
 		return fmt.Errorf("添加属性失败: %v", err)
 	}
@@ -254,39 +269,57 @@ func (d *Database) UpdateWeaponSkill(skillID int, name, description string) erro
 	return nil
 }
 
-// CreateWeapon 创建新武器
+// CreateWeapon 创建新武器（武器与默认属性在同一事务中写入）
 func (d *Database) CreateWeapon(name, holder string, level int) (int, error) {
-	query := `
-	INSERT INTO wuqi (name, holder, level)
-	VALUES (?, ?, ?)`
+	var id int64
+	err := d.WithTx(func(tx *sql.Tx) error {
+		holderID, err := resolveReferenceID(tx, "renwu", holder)
+		if err != nil {
+			return fmt.Errorf("查询持有人物失败: %v", err)
+		}
 
-	result, err := d.db.Exec(query, name, holder, level)
-	if err != nil {
-		return 0, fmt.Errorf("创建武器失败: %v", err)
-	}
+		query := `
+		INSERT INTO wuqi (name, holder, holder_renwu_id, level)
+		VALUES (?, ?, ?, ?)`
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("获取武器ID失败: %v", err)
-	}
+		result, err := tx.Exec(query, name, holder, holderID, level)
+		if err != nil {
+			return fmt.Errorf("创建武器失败: %v", err)
+		}
 
-	// 为新武器添加默认属性：耐久100
-	err = d.AddWeaponAttribute(int(id), "耐久", "武器耐久度", 100)
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("获取武器ID失败: %v", err)
+		}
+
+		// 为新武器添加默认属性：耐久100
+		if err := addWeaponAttribute(tx, int(id), "耐久", "武器耐久度", 100); err != nil {
+			return fmt.Errorf("添加默认属性失败: %v", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("添加默认属性失败: %v", err)
+		return 0, err
 	}
 
 	return int(id), nil
 }
 
-// UpdateWeaponBasicInfo 更新武器基本信息
+// UpdateWeaponBasicInfo 更新武器基本信息；持有者名称变化时按新名称重新关联人物
 func (d *Database) UpdateWeaponBasicInfo(weaponID int, name, holder string, level int) error {
+	holderID, err := resolveReferenceID(d.db, "renwu", holder)
+	if err != nil {
+		return fmt.Errorf("查询持有人物失败: %v", err)
+	}
+
 	query := `
 	UPDATE wuqi
-	SET name = ?, holder = ?, level = ?, updated_at = CURRENT_TIMESTAMP
+	SET name = ?, holder_renwu_id = CASE WHEN holder = ? AND holder_renwu_id IS NOT NULL THEN holder_renwu_id ELSE ? END,
+		holder = ?, level = ?, updated_at = CURRENT_TIMESTAMP
 	WHERE id = ?`
 
-	result, err := d.db.Exec(query, name, holder, level, weaponID)
+	result, err := d.db.Exec(query, name, holder, holderID, holder, level, weaponID)
 	if err != nil {
 		return fmt.Errorf("更新武器信息失败: %v", err)
 	}
@@ -0,0 +1,767 @@
+// 世界数据整体导出/导入（JSON），用于在不同机器和作者之间迁移设定数据
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// WorldFormatVersion 世界导出文件格式版本
+const WorldFormatVersion = 1
+
+// 世界数据导入模式
+const (
+	WorldImportMerge   = "merge"   // 合并：同名记录用导入数据覆盖，其余新增
+	WorldImportReplace = "replace" // 替换：清空现有数据后导入
+	WorldImportSkip    = "skip"    // 跳过冲突：同名记录保留现有数据，其余新增
+)
+
+// worldChildTable 随父记录一起导出的子表
+type worldChildTable struct {
+	name         string
+	parentColumn string
+}
+
+// worldIDReference 实体表或子表中指向其他实体表的ID列，导入时按该实体表的新ID重新映射
+type worldIDReference struct {
+	column   string
+	parent   string
+	unique   bool                               // 该列在子表中唯一，导入时先移除已有的同值记录
+	fallback func(tx dbExecutor) (int64, error) // 引用的记录不在导入数据中时改为引用的记录；为空时跳过该子记录
+	optional bool                               // 引用的记录不在导入数据中时将该列置空，不跳过子记录
+	deferred bool                               // 被引用的表在引用方之后导入：子记录先以空值写入，全部导入后再映射；只用于子表的 optional 引用
+}
+
+// worldIDReferences 实体表或子表中指向其他实体表的ID列；除 deferred 引用外，被引用的表必须先于引用方所属的实体表导入
+var worldIDReferences = map[string][]worldIDReference{
+	"shili_members":        {{column: "renwu_id", parent: "renwu", unique: true}},
+	"shiqing_participants": {{column: "renwu_id", parent: "renwu"}},
+	"prizes":               {{column: "pool_id", parent: "prize_pools", fallback: defaultPrizePoolID}},
+	"draw_history": {
+		{column: "prize_id", parent: "prizes", optional: true, deferred: true}, // “未中奖”记录没有奖品
+		{column: "claimed_beibao_id", parent: "beibao", optional: true},
+	},
+	"shopping_purchases": {{column: "beibao_id", parent: "beibao", optional: true}},
+	"beibao_items":       {{column: "daoju_id", parent: "daoju", optional: true}},
+	"beibao_movements":   {{column: "counterpart_beibao_id", parent: "beibao", optional: true, deferred: true}},
+	"beibao": {
+		{column: "owner_renwu_id", parent: "renwu", optional: true},
+		{column: "owner_chongwu_id", parent: "chongwu", optional: true},
+		{column: "owner_shili_id", parent: "shili", optional: true},
+	},
+}
+
+// worldSequenceColumns 子表中全表递增的编号列；导入时加上目标库中的当前最大值，避免与已有编号重复
+var worldSequenceColumns = map[string]string{
+	"beibao_movements": "batch",
+}
+
+// worldEntityTable 顶层实体表及其子表
+type worldEntityTable struct {
+	name     string
+	key      string // 判断是否为同一记录的列，为空时使用 name
+	scope    string // 同名记录只有该列取值也相同时才视为同一记录，为空表示按名称全表匹配
+	filter   string // 只导出满足该条件的记录，为空表示导出全部记录
+	children []worldChildTable
+}
+
+// worldTables 参与导出导入的实体表，按导入顺序排列
+var worldTables = []worldEntityTable{
+	{name: "renwu", children: []worldChildTable{{"renwu_attributes", "renwu_id"}, {"renwu_skills", "renwu_id"}}},
+	{name: "wuqi", children: []worldChildTable{{"wuqi_attributes", "wuqi_id"}, {"wuqi_skills", "wuqi_id"}}},
+	{name: "chongwu", children: []worldChildTable{{"chongwu_attributes", "chongwu_id"}, {"chongwu_skills", "chongwu_id"}}},
+	{name: "daoju", children: []worldChildTable{{"daoju_functions", "daoju_id"}}},
+	{name: "shiqing", children: []worldChildTable{{"shiqing_details", "shiqing_id"}, {"shiqing_participants", "shiqing_id"}, {"shiqing_rewards", "shiqing_id"}}},
+	{name: "shili", children: []worldChildTable{{"shili_positions", "shili_id"}, {"shili_attributes", "shili_id"}, {"shili_members", "shili_id"}}},
+	{name: "guaiwu", children: []worldChildTable{{"guaiwu_attributes", "guaiwu_id"}, {"guaiwu_skills", "guaiwu_id"}}},
+	{name: "beibao", children: []worldChildTable{{"beibao_items", "beibao_id"}, {"beibao_movements", "beibao_id"}}},
+	{name: "shopping", children: []worldChildTable{{"shopping_purchases", "shopping_id"}}},
+	{name: "shopping_purchases", filter: "shopping_id IS NULL"}, // 商品已删除的购买记录不随任何商品导出，单独作为顶层记录
+	{name: "pity_rules", key: "variety"},
+	{name: "prize_pools", children: []worldChildTable{{"pity_counters", "pool_id"}, {"draw_history", "pool_id"}}},
+	{name: "prizes", scope: "pool_id"},
+}
+
+// WorldRecord 单条实体记录及其子表数据
+type WorldRecord struct {
+	Fields   map[string]interface{}              `json:"fields"`
+	Children map[string][]map[string]interface{} `json:"children,omitempty"`
+}
+
+// WorldDocument 世界数据导出文件
+type WorldDocument struct {
+	FormatVersion int                      `json:"format_version"`
+	SchemaVersion int                      `json:"schema_version"`
+	ExportedAt    string                   `json:"exported_at"`
+	Entities      map[string][]WorldRecord `json:"entities"`
+	Ledger        []map[string]interface{} `json:"ledger,omitempty"`   // 账本记录，账户ID随对应的实体重新映射
+	Calendar      *WorldCalendar           `json:"calendar,omitempty"` // 世界历法，文件中的世界日期（日序）按它解释
+}
+
+// worldLedgerAccountTables 账本中需要随实体重新映射ID的账户类型及其实体表
+var worldLedgerAccountTables = map[string]string{
+	LedgerAccountCharacter: "renwu",
+	LedgerAccountFaction:   "shili",
+	LedgerAccountBeibao:    "beibao",
+	LedgerAccountShop:      "shopping",
+}
+
+// WorldTableStats 单张实体表的导入统计
+type WorldTableStats struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+}
+
+// WorldImportResult 世界数据导入结果
+type WorldImportResult struct {
+	Mode   string                     `json:"mode"`
+	Tables map[string]WorldTableStats `json:"tables"`
+}
+
+// ExportWorld 导出整个世界的数据
+func (d *Database) ExportWorld() (*WorldDocument, error) {
+	schemaVersion, err := d.SchemaVersion()
+	if err != nil {
+		return nil, fmt.Errorf("读取数据库版本失败: %v", err)
+	}
+
+	doc := &WorldDocument{
+		FormatVersion: WorldFormatVersion,
+		SchemaVersion: schemaVersion,
+		ExportedAt:    time.Now().Format("2006-01-02 15:04:05"),
+		Entities:      make(map[string][]WorldRecord),
+	}
+
+	for _, table := range worldTables {
+		query := `SELECT * FROM ` + table.name
+		if table.filter != "" {
+			query += ` WHERE ` + table.filter
+		}
+		rows, err := selectTableRows(d.db, query+` ORDER BY id ASC`)
+		if err != nil {
+			return nil, fmt.Errorf("导出表 %s 失败: %v", table.name, err)
+		}
+
+		// 先按父ID分组读取子表，避免逐条查询
+		childRows := make(map[string]map[int64][]map[string]interface{})
+		for _, child := range table.children {
+			grouped, err := selectChildRows(d.db, child)
+			if err != nil {
+				return nil, fmt.Errorf("导出表 %s 失败: %v", child.name, err)
+			}
+			childRows[child.name] = grouped
+		}
+
+		records := make([]WorldRecord, 0, len(rows))
+		for _, row := range rows {
+			record := WorldRecord{Fields: row}
+			id, _ := toInt64(row["id"])
+			for _, child := range table.children {
+				if children := childRows[child.name][id]; len(children) > 0 {
+					if record.Children == nil {
+						record.Children = make(map[string][]map[string]interface{})
+					}
+					record.Children[child.name] = children
+				}
+			}
+			records = append(records, record)
+		}
+		doc.Entities[table.name] = records
+	}
+
+	ledger, err := selectTableRows(d.db, `SELECT * FROM ledger_entries ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("导出账本失败: %v", err)
+	}
+	doc.Ledger = ledger
+
+	calendar, err := loadWorldCalendar(d.db)
+	if err != nil {
+		return nil, err
+	}
+	doc.Calendar = &calendar
+
+	return doc, nil
+}
+
+// ExportWorldToFile 导出整个世界的数据到 JSON 文件
+func (d *Database) ExportWorldToFile(path string) error {
+	doc, err := d.ExportWorld()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("编码导出数据失败: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入导出文件失败: %v", err)
+	}
+	return nil
+}
+
+// ImportWorldFromFile 从 JSON 文件导入世界数据
+func (d *Database) ImportWorldFromFile(path, mode string) (*WorldImportResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取导入文件失败: %v", err)
+	}
+
+	var doc WorldDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析导入文件失败: %v", err)
+	}
+
+	return d.ImportWorld(&doc, mode)
+}
+
+// ImportWorld 导入世界数据；所有记录都会分配新ID，子记录随父记录重新关联
+func (d *Database) ImportWorld(doc *WorldDocument, mode string) (*WorldImportResult, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("导入数据为空")
+	}
+	if doc.FormatVersion <= 0 || doc.FormatVersion > WorldFormatVersion {
+		return nil, fmt.Errorf("不支持的导出文件格式版本: %d", doc.FormatVersion)
+	}
+	switch mode {
+	case WorldImportMerge, WorldImportReplace, WorldImportSkip:
+	default:
+		return nil, fmt.Errorf("未知的导入模式: %s", mode)
+	}
+
+	result := &WorldImportResult{
+		Mode:   mode,
+		Tables: make(map[string]WorldTableStats),
+	}
+
+	err := d.WithTx(func(tx *sql.Tx) error {
+		if mode == WorldImportReplace {
+			// 倒序清空，子表数据由外键级联删除
+			for i := len(worldTables) - 1; i >= 0; i-- {
+				if _, err := tx.Exec(`DELETE FROM ` + worldTables[i].name); err != nil {
+					return fmt.Errorf("清空表 %s 失败: %v", worldTables[i].name, err)
+				}
+			}
+			if _, err := tx.Exec(`DELETE FROM ledger_entries`); err != nil {
+				return fmt.Errorf("清空账本失败: %v", err)
+			}
+		}
+
+		entities, err := importWorldCalendar(tx, doc, mode)
+		if err != nil {
+			return err
+		}
+
+		idMaps := make(map[string]map[int64]int64)
+		var pending []worldPendingReference
+		for _, table := range worldTables {
+			idMaps[table.name] = make(map[int64]int64)
+			stats, err := importWorldTable(tx, table, entities[table.name], mode, idMaps, &pending)
+			if err != nil {
+				return err
+			}
+			result.Tables[table.name] = stats
+		}
+		if err := resolveWorldReferences(tx, pending, idMaps); err != nil {
+			return err
+		}
+		if err := importWorldLedger(tx, doc.Ledger, idMaps); err != nil {
+			return err
+		}
+		// 导出文件早于势力成员表时，按人物的所属势力补齐成员关系
+		return backfillShiliMembers(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// worldPendingReference 等待所有表导入完成后再重新映射的关联列
+type worldPendingReference struct {
+	ref   entityReference
+	id    int64 // 导入后的记录ID
+	oldID int64 // 导出文件中被引用记录的ID，0 表示没有
+}
+
+// importWorldTable 导入单张实体表及其子表；idMaps 按表记录 导出文件中的ID -> 导入后的ID，
+// 关联其他实体的列先置空并加入 pending，待全部导入后统一映射
+func importWorldTable(tx *sql.Tx, table worldEntityTable, records []WorldRecord, mode string, idMaps map[string]map[int64]int64, pending *[]worldPendingReference) (WorldTableStats, error) {
+	var stats WorldTableStats
+	idMap := idMaps[table.name]
+
+	columns, err := tableColumns(tx, table.name)
+	if err != nil {
+		return stats, fmt.Errorf("读取表 %s 结构失败: %v", table.name, err)
+	}
+	key := table.key
+	if key == "" {
+		key = "name"
+	}
+	hasKey := containsString(columns, key)
+
+	sequenceOffsets := make(map[string]int64)
+	for _, child := range table.children {
+		if column, ok := worldSequenceColumns[child.name]; ok {
+			var offset int64
+			if err := tx.QueryRow(`SELECT COALESCE(MAX(` + column + `), 0) FROM ` + child.name).Scan(&offset); err != nil {
+				return stats, fmt.Errorf("查询表 %s 失败: %v", child.name, err)
+			}
+			sequenceOffsets[child.name] = offset
+		}
+	}
+
+	var references []entityReference
+	for _, ref := range entityReferences {
+		if ref.table == table.name && containsString(columns, ref.column) {
+			references = append(references, ref)
+		}
+	}
+
+	for _, record := range records {
+		oldID, hasOldID := toInt64(record.Fields["id"])
+		fields := record.Fields
+		referenceIDs := make(map[string]int64)
+		if len(references) > 0 || len(worldIDReferences[table.name]) > 0 {
+			fields = make(map[string]interface{}, len(record.Fields))
+			for column, value := range record.Fields {
+				fields[column] = value
+			}
+			for _, ref := range references {
+				if _, ok := fields[ref.column]; !ok {
+					continue
+				}
+				if refID, ok := toInt64(fields[ref.column]); ok {
+					referenceIDs[ref.column] = refID
+				}
+				fields[ref.column] = nil
+			}
+			for _, ref := range worldIDReferences[table.name] {
+				newRefID, err := mapWorldIDReference(tx, ref, fields[ref.column], idMaps)
+				if err != nil {
+					return stats, err
+				}
+				fields[ref.column] = newRefID
+			}
+		}
+
+		var existingID int64
+		if hasKey && mode != WorldImportReplace {
+			if value, ok := record.Fields[key]; ok {
+				query := `SELECT id FROM ` + table.name + ` WHERE ` + key + ` = ?`
+				args := []interface{}{value}
+				if table.scope != "" {
+					query += ` AND ` + table.scope + ` IS ?`
+					args = append(args, fields[table.scope])
+				}
+				err := tx.QueryRow(query+` ORDER BY id ASC LIMIT 1`, args...).Scan(&existingID)
+				if err != nil && err != sql.ErrNoRows {
+					return stats, fmt.Errorf("检查表 %s 的重名记录失败: %v", table.name, err)
+				}
+			}
+		}
+
+		if existingID != 0 && mode == WorldImportSkip {
+			if hasOldID {
+				idMap[oldID] = existingID
+			}
+			stats.Skipped++
+			continue
+		}
+
+		var parentID int64
+		if existingID != 0 {
+			if err := updateRow(tx, table.name, columns, existingID, fields); err != nil {
+				return stats, fmt.Errorf("更新表 %s 的记录失败: %v", table.name, err)
+			}
+			// 合并模式下子数据以导入文件为准
+			for _, child := range table.children {
+				if _, err := tx.Exec(`DELETE FROM `+child.name+` WHERE `+child.parentColumn+` = ?`, existingID); err != nil {
+					return stats, fmt.Errorf("清理表 %s 的旧数据失败: %v", child.name, err)
+				}
+			}
+			parentID = existingID
+			stats.Updated++
+		} else {
+			newID, err := insertRow(tx, table.name, columns, fields, nil)
+			if err != nil {
+				return stats, fmt.Errorf("写入表 %s 的记录失败: %v", table.name, err)
+			}
+			parentID = newID
+			stats.Inserted++
+		}
+
+		if hasOldID {
+			idMap[oldID] = parentID
+		}
+		for _, ref := range references {
+			*pending = append(*pending, worldPendingReference{ref: ref, id: parentID, oldID: referenceIDs[ref.column]})
+		}
+
+		for _, child := range table.children {
+			childColumns, err := tableColumns(tx, child.name)
+			if err != nil {
+				return stats, fmt.Errorf("读取表 %s 结构失败: %v", child.name, err)
+			}
+		childRecords:
+			for _, fields := range record.Children[child.name] {
+				overrides := map[string]interface{}{child.parentColumn: parentID}
+				if column, ok := worldSequenceColumns[child.name]; ok {
+					if value, ok := toInt64(fields[column]); ok {
+						overrides[column] = value + sequenceOffsets[child.name]
+					}
+				}
+				deferred := make(map[string]int64)
+				for _, ref := range worldIDReferences[child.name] {
+					if ref.deferred {
+						if oldRefID, ok := toInt64(fields[ref.column]); ok {
+							deferred[ref.column] = oldRefID
+						}
+						overrides[ref.column] = nil
+						continue
+					}
+					newRefID, err := mapWorldIDReference(tx, ref, fields[ref.column], idMaps)
+					if err != nil {
+						return stats, err
+					}
+					if newRefID == nil && !ref.optional {
+						// 引用的记录不在导入数据中，无法关联
+						continue childRecords
+					}
+					if ref.unique {
+						if _, err := tx.Exec(`DELETE FROM `+child.name+` WHERE `+ref.column+` = ?`, newRefID); err != nil {
+							return stats, fmt.Errorf("清理表 %s 的旧数据失败: %v", child.name, err)
+						}
+					}
+					overrides[ref.column] = newRefID
+				}
+				childID, err := insertRow(tx, child.name, childColumns, fields, overrides)
+				if err != nil {
+					return stats, fmt.Errorf("写入表 %s 的记录失败: %v", child.name, err)
+				}
+				for _, ref := range worldIDReferences[child.name] {
+					if oldRefID, ok := deferred[ref.column]; ok {
+						*pending = append(*pending, worldPendingReference{
+							ref: entityReference{table: child.name, column: ref.column, parent: ref.parent},
+							id:  childID, oldID: oldRefID,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// importWorldCalendar 在导入带日期的实体之前处理导出文件中的历法：替换模式或当前世界还没有保存任何世界日期时
+// 直接采用导入的历法；否则保留当前历法，把导入记录中的日期按日期文本换算到当前历法，无法换算时整体拒绝。
+// 返回日期已换算好的实体数据，doc 本身不做修改
+func importWorldCalendar(tx dbExecutor, doc *WorldDocument, mode string) (map[string][]WorldRecord, error) {
+	if doc.Calendar == nil {
+		return doc.Entities, nil
+	}
+	source := *doc.Calendar
+	if err := source.validate(); err != nil {
+		return nil, fmt.Errorf("导入文件中的历法无效: %v", err)
+	}
+
+	stored := 0
+	for _, c := range worldDateColumns {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM ` + c.table + ` WHERE ` + c.column + ` IS NOT NULL`).Scan(&count); err != nil {
+			return nil, fmt.Errorf("查询%s日期失败: %v", c.label, err)
+		}
+		stored += count
+	}
+	if mode == WorldImportReplace || stored == 0 {
+		return doc.Entities, saveWorldCalendar(tx, source)
+	}
+
+	current, err := loadWorldCalendar(tx)
+	if err != nil {
+		return nil, err
+	}
+	entities := make(map[string][]WorldRecord, len(doc.Entities))
+	for table, records := range doc.Entities {
+		entities[table] = records
+	}
+	for _, c := range worldDateColumns {
+		records := make([]WorldRecord, len(doc.Entities[c.table]))
+		for i, record := range doc.Entities[c.table] {
+			records[i] = record
+			ordinal, ok := toInt64(record.Fields[c.column])
+			if !ok {
+				continue
+			}
+			date, err := source.FromOrdinal(int(ordinal))
+			if err != nil {
+				return nil, fmt.Errorf("导入的%s「%v」日期无效: %v", c.label, record.Fields["name"], err)
+			}
+			converted, err := current.Parse(date.Text)
+			if err != nil {
+				return nil, fmt.Errorf("导入的%s「%v」的日期「%s」无法用当前历法表示: %v", c.label, record.Fields["name"], date.Text, err)
+			}
+			fields := make(map[string]interface{}, len(record.Fields))
+			for column, value := range record.Fields {
+				fields[column] = value
+			}
+			fields[c.column] = converted.Ordinal
+			records[i].Fields = fields
+		}
+		entities[c.table] = records
+	}
+	return entities, nil
+}
+
+// importWorldLedger 导入账本记录并按导入后的ID重新映射账户，涉及不在导入数据中的账户的记录不导入；
+// 合并或跳过的账户原有账本与导入的记录并存，最后为本次导入涉及的账户补记余额列与账本之间的差额，使对账结果保持一致
+func importWorldLedger(tx *sql.Tx, entries []map[string]interface{}, idMaps map[string]map[int64]int64) error {
+	mapAccount := func(accountType string, value interface{}) (int64, bool) {
+		oldID, _ := toInt64(value)
+		table, ok := worldLedgerAccountTables[accountType]
+		if !ok || (accountType == LedgerAccountShop && oldID == 0) {
+			return oldID, accountType == LedgerAccountWorld || accountType == LedgerAccountShop
+		}
+		newID, ok := idMaps[table][oldID]
+		return newID, ok
+	}
+
+	entryIDs := make(map[int64]int64)
+	for _, entry := range entries {
+		fromType, _ := entry["from_type"].(string)
+		toType, _ := entry["to_type"].(string)
+		fromID, fromOK := mapAccount(fromType, entry["from_id"])
+		toID, toOK := mapAccount(toType, entry["to_id"])
+		if !fromOK || !toOK {
+			continue
+		}
+
+		var reversalOf interface{}
+		if oldID, ok := toInt64(entry["reversal_of"]); ok {
+			if newID, ok := entryIDs[oldID]; ok {
+				reversalOf = newID
+			}
+		}
+		result, err := tx.Exec(`
+		INSERT INTO ledger_entries (from_type, from_id, to_type, to_id, amount, memo, reversal_of, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			fromType, fromID, toType, toID, entry["amount"], entry["memo"], reversalOf, entry["created_at"])
+		if err != nil {
+			return fmt.Errorf("写入账本记录失败: %v", err)
+		}
+		if oldID, ok := toInt64(entry["id"]); ok {
+			newID, _ := result.LastInsertId()
+			entryIDs[oldID] = newID
+		}
+	}
+
+	for _, accountType := range ledgerAccountTypes {
+		table := worldLedgerAccountTables[accountType]
+		stored := ledgerStoredBalances[accountType]
+		for _, id := range idMaps[table] {
+			account := LedgerAccount{Type: accountType, ID: int(id)}
+			balance, err := ledgerBalance(tx, account)
+			if err != nil {
+				return err
+			}
+			var value int
+			if err := tx.QueryRow(`SELECT COALESCE(`+stored.column+`, 0) FROM `+stored.table+` WHERE id = ?`, id).Scan(&value); err != nil {
+				return fmt.Errorf("查询余额失败: %v", err)
+			}
+			if err := recordBalanceAdjustment(tx, account, balance, value, "世界导入调整"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mapWorldIDReference 将导出文件中的ID映射为导入后的ID；无法映射且没有备用记录时返回 nil
+func mapWorldIDReference(tx dbExecutor, ref worldIDReference, value interface{}, idMaps map[string]map[int64]int64) (interface{}, error) {
+	oldID, _ := toInt64(value)
+	if newID, ok := idMaps[ref.parent][oldID]; ok {
+		return newID, nil
+	}
+	if ref.fallback == nil {
+		return nil, nil
+	}
+	return ref.fallback(tx)
+}
+
+// resolveWorldReferences 按导入后的ID重新设置关联列；
+// 被引用的记录不在导入数据中（或导出文件早于关联列）时，按文本列中的名称重新解析，没有文本列时保持为空
+func resolveWorldReferences(tx *sql.Tx, pending []worldPendingReference, idMaps map[string]map[int64]int64) error {
+	for _, p := range pending {
+		newID, ok := idMaps[p.ref.parent][p.oldID]
+		if !ok && p.ref.textColumn == "" {
+			continue
+		}
+		if !ok {
+			if err := relinkEntityReference(tx, p.ref, p.id); err != nil {
+				return fmt.Errorf("更新表 %s 的关联失败: %v", p.ref.table, err)
+			}
+			continue
+		}
+		query := `UPDATE ` + p.ref.table + ` SET ` + p.ref.column + ` = ? WHERE id = ?`
+		if _, err := tx.Exec(query, newID, p.id); err != nil {
+			return fmt.Errorf("更新表 %s 的关联失败: %v", p.ref.table, err)
+		}
+	}
+	return nil
+}
+
+// insertRow 按当前表结构插入一条记录，忽略 id 与表中不存在的列；overrides 中的值优先
+func insertRow(tx dbExecutor, tableName string, columns []string, fields, overrides map[string]interface{}) (int64, error) {
+	var names []string
+	var placeholders []string
+	var args []interface{}
+	for _, column := range columns {
+		if column == "id" {
+			continue
+		}
+		value, ok := overrides[column]
+		if !ok {
+			value, ok = fields[column]
+		}
+		if !ok {
+			continue
+		}
+		names = append(names, column)
+		placeholders = append(placeholders, "?")
+		args = append(args, value)
+	}
+
+	query := `INSERT INTO ` + tableName + ` DEFAULT VALUES`
+	if len(names) > 0 {
+		query = `INSERT INTO ` + tableName + ` (` + strings.Join(names, ", ") + `) VALUES (` + strings.Join(placeholders, ", ") + `)`
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// updateRow 按当前表结构更新一条记录，忽略 id 与表中不存在的列
+func updateRow(tx dbExecutor, tableName string, columns []string, id int64, fields map[string]interface{}) error {
+	var assignments []string
+	var args []interface{}
+	for _, column := range columns {
+		if column == "id" {
+			continue
+		}
+		value, ok := fields[column]
+		if !ok {
+			continue
+		}
+		assignments = append(assignments, column+" = ?")
+		args = append(args, value)
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	args = append(args, id)
+	_, err := tx.Exec(`UPDATE `+tableName+` SET `+strings.Join(assignments, ", ")+` WHERE id = ?`, args...)
+	return err
+}
+
+// selectChildRows 读取子表全部记录并按父ID分组，子记录中不保留 id 和父ID列；
+// 按 rowid 排序以兼容没有 id 列的子表。父ID为空的记录不在此返回，需要保留的由 worldTables 中带 filter 的顶层条目导出
+func selectChildRows(tx dbExecutor, child worldChildTable) (map[int64][]map[string]interface{}, error) {
+	rows, err := selectTableRows(tx, `SELECT * FROM `+child.name+` ORDER BY rowid ASC`)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[int64][]map[string]interface{})
+	for _, row := range rows {
+		parentID, ok := toInt64(row[child.parentColumn])
+		if !ok {
+			continue
+		}
+		delete(row, "id")
+		delete(row, child.parentColumn)
+		grouped[parentID] = append(grouped[parentID], row)
+	}
+	return grouped, nil
+}
+
+// selectTableRows 执行查询并把每行转换为 列名 -> 值 的映射
+func selectTableRows(tx dbExecutor, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			row[column] = normalizeExportValue(values[i])
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// normalizeExportValue 将驱动返回的值转换为便于 JSON 序列化且可原样写回的形式
+func normalizeExportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05")
+	default:
+		return v
+	}
+}
+
+// toInt64 将查询结果或 JSON 解码得到的数值转换为 int64
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
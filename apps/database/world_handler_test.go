@@ -0,0 +1,415 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func seedWorld(t *testing.T, db *Database) {
+	t.Helper()
+
+	characterID, err := db.CreateCharacter("林动", "武府", 500, 10)
+	if err != nil {
+		t.Fatalf("CreateCharacter() failed: %v", err)
+	}
+	if err := db.AddCharacterSkill(characterID, "通背拳", "基础拳法"); err != nil {
+		t.Fatalf("AddCharacterSkill() failed: %v", err)
+	}
+	beibaoID, err := db.CreateBeibao("随身背包")
+	if err != nil {
+		t.Fatalf("CreateBeibao() failed: %v", err)
+	}
+	if err := db.AddBeibaoItem(int(beibaoID), "回血丹", 3, "恢复气血", 0); err != nil {
+		t.Fatalf("AddBeibaoItem() failed: %v", err)
+	}
+	if _, err := db.CreatePrize(0, "祖符", 1.5, "", "传说"); err != nil {
+		t.Fatalf("CreatePrize() failed: %v", err)
+	}
+	if _, err := db.DrawPrize(0, 0); err != nil {
+		t.Fatalf("DrawPrize() failed: %v", err)
+	}
+}
+
+func TestWorldExportImport_RoundTripIntoEmptyDatabase(t *testing.T) {
+	source := openTestDatabase(t)
+	seedWorld(t, source)
+
+	path := filepath.Join(t.TempDir(), "world.json")
+	if err := source.ExportWorldToFile(path); err != nil {
+		t.Fatalf("ExportWorldToFile() failed: %v", err)
+	}
+
+	target := openTestDatabase(t)
+	// 占用ID 1，确保导入时必须重新映射子记录的父ID
+	if _, err := target.CreateBeibao("占位背包"); err != nil {
+		t.Fatalf("CreateBeibao() failed: %v", err)
+	}
+
+	result, err := target.ImportWorldFromFile(path, WorldImportMerge)
+	if err != nil {
+		t.Fatalf("ImportWorldFromFile() failed: %v", err)
+	}
+	if result.Tables["renwu"].Inserted != 1 || result.Tables["beibao"].Inserted != 1 {
+		t.Fatalf("unexpected import stats: %+v", result.Tables)
+	}
+
+	list, err := target.GetAllBeibao()
+	if err != nil {
+		t.Fatalf("GetAllBeibao() failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 backpacks, got %d", len(list))
+	}
+	imported, err := target.GetBeibaoInfo(list[1]["id"].(int))
+	if err != nil {
+		t.Fatalf("GetBeibaoInfo() failed: %v", err)
+	}
+	if imported.Name != "随身背包" || len(imported.Items) != 1 || imported.Items[0].Quantity != 3 {
+		t.Fatalf("items not attached to remapped backpack: %+v", imported)
+	}
+
+	characters, err := target.GetAllCharacters()
+	if err != nil || len(characters) != 1 {
+		t.Fatalf("GetAllCharacters() = %v, %v", characters, err)
+	}
+	info, err := target.GetCharacterInfo(characters[0]["id"].(int))
+	if err != nil {
+		t.Fatalf("GetCharacterInfo() failed: %v", err)
+	}
+	if len(info.Attributes) != 4 || len(info.Skills) != 1 {
+		t.Fatalf("character children not imported: %+v", info)
+	}
+
+	history, err := target.GetDrawHistory(0, 10)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("GetDrawHistory() = %v, %v", history, err)
+	}
+}
+
+func TestWorldImport_Modes(t *testing.T) {
+	source := openTestDatabase(t)
+	seedWorld(t, source)
+	doc, err := source.ExportWorld()
+	if err != nil {
+		t.Fatalf("ExportWorld() failed: %v", err)
+	}
+
+	target := openTestDatabase(t)
+	beibaoID, err := target.CreateBeibao("随身背包")
+	if err != nil {
+		t.Fatalf("CreateBeibao() failed: %v", err)
+	}
+	if err := target.AddBeibaoItem(int(beibaoID), "旧物品", 1, "", 0); err != nil {
+		t.Fatalf("AddBeibaoItem() failed: %v", err)
+	}
+	if _, err := target.CreateShopping("无关商品", 10, "", ""); err != nil {
+		t.Fatalf("CreateShopping() failed: %v", err)
+	}
+
+	result, err := target.ImportWorld(doc, WorldImportSkip)
+	if err != nil {
+		t.Fatalf("ImportWorld(skip) failed: %v", err)
+	}
+	if result.Tables["beibao"].Skipped != 1 {
+		t.Fatalf("expected conflicting backpack to be skipped: %+v", result.Tables["beibao"])
+	}
+	items, _ := target.GetBeibaoItems(int(beibaoID))
+	if len(items) != 1 || items[0].Name != "旧物品" {
+		t.Fatalf("skip mode must keep existing children, got %+v", items)
+	}
+
+	result, err = target.ImportWorld(doc, WorldImportMerge)
+	if err != nil {
+		t.Fatalf("ImportWorld(merge) failed: %v", err)
+	}
+	if result.Tables["beibao"].Updated != 1 {
+		t.Fatalf("expected conflicting backpack to be updated: %+v", result.Tables["beibao"])
+	}
+	items, _ = target.GetBeibaoItems(int(beibaoID))
+	if len(items) != 1 || items[0].Name != "回血丹" {
+		t.Fatalf("merge mode must replace children, got %+v", items)
+	}
+
+	if _, err := target.ImportWorld(doc, WorldImportReplace); err != nil {
+		t.Fatalf("ImportWorld(replace) failed: %v", err)
+	}
+	shopping, err := target.GetAllShopping()
+	if err != nil {
+		t.Fatalf("GetAllShopping() failed: %v", err)
+	}
+	if len(shopping) != 0 {
+		t.Fatalf("replace mode must clear existing data, got %+v", shopping)
+	}
+
+	if _, err := target.ImportWorld(doc, "unknown"); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
+
+func TestWorldExportImport_PityRulesAndCounters(t *testing.T) {
+	source := openTestDatabase(t)
+	banner, _ := source.CreatePrizePool("限定卡池", "", "", "", 0)
+	source.CreatePrize(int(banner), "铜币", 1e9, "", "普通")
+	source.CreatePrize(int(banner), "神兵", 1, "", "金")
+	source.SetPityRule(PityRule{Variety: "金", HardPity: 10, FeaturedGuarantee: true})
+	for i := 0; i < 4; i++ {
+		source.DrawPrize(int(banner), 0)
+	}
+	doc, err := source.ExportWorld()
+	if err != nil {
+		t.Fatalf("ExportWorld() failed: %v", err)
+	}
+
+	target := openTestDatabase(t)
+	// 占用奖池ID，确保计数随奖池重新映射
+	target.CreatePrizePool("占位奖池", "", "", "", 0)
+	target.SetPityRule(PityRule{Variety: "金", HardPity: 90})
+	for _, mode := range []string{WorldImportMerge, WorldImportMerge} {
+		if _, err := target.ImportWorld(doc, mode); err != nil {
+			t.Fatalf("ImportWorld(%s) failed: %v", mode, err)
+		}
+	}
+
+	rules, _ := target.GetPityRules()
+	if len(rules) != 1 || rules[0].HardPity != 10 || !rules[0].FeaturedGuarantee {
+		t.Fatalf("expected imported pity rule to replace the existing one, got %+v", rules)
+	}
+	pools, _ := target.GetPrizePools()
+	var poolID int
+	for _, pool := range pools {
+		if pool.Name == "限定卡池" {
+			poolID = pool.ID
+		}
+	}
+	states, err := target.GetPityState(poolID)
+	if err != nil || len(states) != 1 || states[0].DrawsSinceHit != 4 {
+		t.Fatalf("expected imported pity counters, got %+v, %v", states, err)
+	}
+}
+
+func TestWorldExportImport_KeepsDrawsWithoutPrize(t *testing.T) {
+	source := openTestDatabase(t)
+	pool, _ := source.GetPrizePool(0)
+	source.SetPrizePoolRateMode(pool.ID, PrizeRateModeAbsolute)
+	source.CreatePrize(0, "祖符", 0, "", "传说")
+	source.DrawPrize(0, 0)
+	source.UpdatePrize(mustPrizeID(t, source, "祖符"), "祖符", 100, "", "传说")
+	source.DrawPrize(0, 0)
+	doc, err := source.ExportWorld()
+	if err != nil {
+		t.Fatalf("ExportWorld() failed: %v", err)
+	}
+
+	target := openTestDatabase(t)
+	target.CreatePrize(0, "占位", 1, "", "普通")
+	if _, err := target.ImportWorld(doc, WorldImportReplace); err != nil {
+		t.Fatalf("ImportWorld() failed: %v", err)
+	}
+	history, err := target.GetDrawHistory(0, 10)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("expected both draws to be imported, got %+v, %v", history, err)
+	}
+	prizeID := mustPrizeID(t, target, "祖符")
+	if history[0].PrizeID != prizeID || history[1].PrizeID != 0 || history[1].PrizeName != NothingPrizeName {
+		t.Fatalf("unexpected imported history: %+v", history)
+	}
+}
+
+func mustPrizeID(t *testing.T, db *Database, name string) int {
+	t.Helper()
+	prizes, err := db.GetAllPrizes()
+	if err != nil {
+		t.Fatalf("GetAllPrizes() failed: %v", err)
+	}
+	for _, prize := range prizes {
+		if prize.Name == name {
+			return prize.ID
+		}
+	}
+	t.Fatalf("prize %s not found", name)
+	return 0
+}
+
+func TestWorldExportImport_RemapsLedger(t *testing.T) {
+	source := openTestDatabase(t)
+	heroID, _ := source.CreateCharacter("林动", "", 500, 10)
+	beibaoID, _ := source.CreateBeibao("随身背包")
+	hero := LedgerAccount{Type: LedgerAccountCharacter, ID: heroID}
+	bag := LedgerAccount{Type: LedgerAccountBeibao, ID: int(beibaoID)}
+	source.TransferCurrency(hero, bag, 200, "存钱")
+	entry, _ := source.TransferCurrency(bag, hero, 50, "取钱")
+	if _, err := source.ReverseLedgerEntry(entry.ID, ""); err != nil {
+		t.Fatalf("ReverseLedgerEntry() failed: %v", err)
+	}
+	doc, err := source.ExportWorld()
+	if err != nil {
+		t.Fatalf("ExportWorld() failed: %v", err)
+	}
+
+	for _, mode := range []string{WorldImportReplace, WorldImportMerge} {
+		target := openTestDatabase(t)
+		// 占用ID，使导入的人物和背包重新编号
+		otherID, _ := target.CreateCharacter("路人甲", "", 80, 1)
+		target.CreateBeibao("占位背包")
+		target.TransferCurrency(LedgerAccount{Type: LedgerAccountCharacter, ID: otherID}, LedgerAccount{Type: LedgerAccountWorld}, 30, "花费")
+		if mode == WorldImportMerge {
+			target.CreateCharacter("林动", "", 999, 1)
+		}
+		if _, err := target.ImportWorld(doc, mode); err != nil {
+			t.Fatalf("ImportWorld(%s) failed: %v", mode, err)
+		}
+
+		drifts, err := target.ReconcileLedger(false)
+		if err != nil || len(drifts) != 0 {
+			t.Fatalf("expected no drift after %s import, got %+v, %v", mode, drifts, err)
+		}
+		list, _ := target.GetAllBeibao()
+		imported := LedgerAccount{Type: LedgerAccountBeibao, ID: list[len(list)-1]["id"].(int)}
+		history, _ := target.GetLedgerHistory(imported, 10)
+		if len(history) != 3 || history[0].ReversalOf != history[1].ID {
+			t.Fatalf("expected remapped backpack history after %s import, got %+v", mode, history)
+		}
+	}
+}
+
+func TestWorldExportImport_KeepsPurchasesOfDeletedShopItems(t *testing.T) {
+	source := openTestDatabase(t)
+	beibaoID, _ := source.CreateBeibao("随身背包")
+	source.SetBeibaoBalance(int(beibaoID), 100)
+	itemID, _ := source.CreateShopping("回血丹", 30, "恢复气血", "")
+	if _, err := source.PurchaseShoppingItem(int(itemID), int(beibaoID), 2); err != nil {
+		t.Fatalf("PurchaseShoppingItem() failed: %v", err)
+	}
+	if err := source.DeleteShopping(int(itemID)); err != nil {
+		t.Fatalf("DeleteShopping() failed: %v", err)
+	}
+	doc, err := source.ExportWorld()
+	if err != nil {
+		t.Fatalf("ExportWorld() failed: %v", err)
+	}
+
+	target := openTestDatabase(t)
+	// 占用ID，使导入的背包重新编号；目标库原有的购买记录在替换模式下应被清空
+	otherID, _ := target.CreateBeibao("占位背包")
+	target.SetBeibaoBalance(int(otherID), 100)
+	otherItemID, _ := target.CreateShopping("聚气散", 10, "", "")
+	target.PurchaseShoppingItem(int(otherItemID), int(otherID), 1)
+	target.DeleteShopping(int(otherItemID))
+	if _, err := target.ImportWorld(doc, WorldImportReplace); err != nil {
+		t.Fatalf("ImportWorld() failed: %v", err)
+	}
+
+	purchases, err := target.GetShoppingPurchases(0, 10)
+	if err != nil || len(purchases) != 1 {
+		t.Fatalf("expected only the imported purchase, got %+v, %v", purchases, err)
+	}
+	list, _ := target.GetAllBeibao()
+	importedID := list[len(list)-1]["id"].(int)
+	p := purchases[0]
+	if p.ShoppingID != 0 || p.ItemName != "回血丹" || p.BeibaoID != importedID || p.TotalPrice != 60 {
+		t.Fatalf("unexpected imported purchase: %+v (backpack %d)", p, importedID)
+	}
+}
+
+func TestWorldExportImport_BeibaoMovements(t *testing.T) {
+	source := openTestDatabase(t)
+	// 先导入的背包引用后导入的背包，验证对方背包在全部导入后才映射
+	toID, _ := source.CreateBeibao("甲背包")
+	fromID, _ := source.CreateBeibao("乙背包")
+	source.AddBeibaoItem(int(fromID), "回血丹", 5, "", 0)
+	items, _ := source.GetBeibaoItems(int(fromID))
+	if err := source.TransferBeibaoItem(items[0].ID, int(toID), 2); err != nil {
+		t.Fatalf("TransferBeibaoItem() failed: %v", err)
+	}
+	doc, err := source.ExportWorld()
+	if err != nil {
+		t.Fatalf("ExportWorld() failed: %v", err)
+	}
+
+	target := openTestDatabase(t)
+	a, _ := target.CreateBeibao("占位甲")
+	b, _ := target.CreateBeibao("占位乙")
+	target.AddBeibaoItem(int(a), "铜币", 1, "", 0)
+	existing, _ := target.GetBeibaoItems(int(a))
+	target.TransferBeibaoItem(existing[0].ID, int(b), 1)
+	if _, err := target.ImportWorld(doc, WorldImportMerge); err != nil {
+		t.Fatalf("ImportWorld() failed: %v", err)
+	}
+
+	list, _ := target.GetAllBeibao()
+	ids := make(map[string]int)
+	for _, beibao := range list {
+		ids[beibao["name"].(string)] = beibao["id"].(int)
+	}
+	outgoing, _ := target.GetBeibaoMovements(ids["乙背包"], 10)
+	incoming, _ := target.GetBeibaoMovements(ids["甲背包"], 10)
+	if len(outgoing) != 1 || outgoing[0].CounterpartBeibaoID != ids["甲背包"] || outgoing[0].Quantity != -2 {
+		t.Fatalf("unexpected imported outgoing movements: %+v", outgoing)
+	}
+	if len(incoming) != 1 || incoming[0].CounterpartBeibaoID != ids["乙背包"] || incoming[0].Batch != outgoing[0].Batch {
+		t.Fatalf("unexpected imported incoming movements: %+v", incoming)
+	}
+	if old, _ := target.GetBeibaoMovements(int(a), 10); old[0].Batch == outgoing[0].Batch {
+		t.Fatalf("imported batch must not collide with existing batches")
+	}
+}
+
+func TestWorldExportImport_CalendarAndDates(t *testing.T) {
+	source := openTestDatabase(t)
+	source.SetWorldCalendar(testWorldCalendar())
+	heroID, _ := source.CreateCharacter("林动", "", 0, 1)
+	if err := source.SetCharacterBirth(heroID, "玄天12年夏月3日"); err != nil {
+		t.Fatalf("SetCharacterBirth() failed: %v", err)
+	}
+	doc, err := source.ExportWorld()
+	if err != nil {
+		t.Fatalf("ExportWorld() failed: %v", err)
+	}
+	birthDate := func(db *Database) string {
+		t.Helper()
+		characters, _ := db.GetAllCharacters()
+		for _, c := range characters {
+			if c["name"] == "林动" {
+				info, _ := db.GetCharacterInfo(c["id"].(int))
+				return info.BirthDate
+			}
+		}
+		t.Fatalf("imported character not found")
+		return ""
+	}
+
+	// 还没有世界日期的世界直接采用导入的历法
+	fresh := openTestDatabase(t)
+	if _, err := fresh.ImportWorld(doc, WorldImportMerge); err != nil {
+		t.Fatalf("ImportWorld() into a fresh world failed: %v", err)
+	}
+	if got := birthDate(fresh); got != "玄天12年夏月3日" {
+		t.Fatalf("expected the imported calendar to be adopted, got %q", got)
+	}
+
+	// 已有日期时保留当前历法，导入的日期按文本换算
+	dated := openTestDatabase(t)
+	dated.SetWorldCalendar(WorldCalendar{Eras: []CalendarEra{{Name: "玄天"}}, Months: testWorldCalendar().Months})
+	otherID, _ := dated.CreateCharacter("林炎", "", 0, 1)
+	dated.SetCharacterBirth(otherID, "玄天1年春月1日")
+	if _, err := dated.ImportWorld(doc, WorldImportMerge); err != nil {
+		t.Fatalf("ImportWorld() into a dated world failed: %v", err)
+	}
+	if got := birthDate(dated); got != "玄天12年夏月3日" {
+		t.Fatalf("expected the imported date to be converted, got %q", got)
+	}
+
+	// 当前历法无法表示导入的日期时整体拒绝，替换模式则改用导入的历法
+	other := openTestDatabase(t)
+	otherID, _ = other.CreateCharacter("林炎", "", 0, 1)
+	other.SetCharacterBirth(otherID, "1年一月1日")
+	if _, err := other.ImportWorld(doc, WorldImportMerge); err == nil {
+		t.Fatalf("expected error when a date cannot be converted")
+	}
+	if _, err := other.ImportWorld(doc, WorldImportReplace); err != nil {
+		t.Fatalf("ImportWorld(replace) failed: %v", err)
+	}
+	if got := birthDate(other); got != "玄天12年夏月3日" {
+		t.Fatalf("expected replace mode to adopt the imported calendar, got %q", got)
+	}
+}
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WorldsDirName is the directory under the default data dir where new
+// worlds are created when no explicit location is given.
+const WorldsDirName = "worlds"
+
+// Project is one independent world: a named data dir with its own
+// database and markdown files.
+type Project struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	DataDir      string    `json:"data_dir"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastOpenedAt time.Time `json:"last_opened_at"`
+}
+
+var ErrProjectNotFound = errors.New("project not found")
+
+// ListProjects returns all registered projects, most recently opened first.
+func ListProjects() ([]Project, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	projects := append([]Project{}, cfg.Projects...)
+	sort.SliceStable(projects, func(i, j int) bool {
+		return projects[i].LastOpenedAt.After(projects[j].LastOpenedAt)
+	})
+	return projects, nil
+}
+
+func FindProject(id string) (Project, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return Project{}, err
+	}
+	idx := findProjectIndex(cfg.Projects, id)
+	if idx < 0 {
+		return Project{}, ErrProjectNotFound
+	}
+	return cfg.Projects[idx], nil
+}
+
+// FindProjectByDataDir returns the project registered for dataDir, if any.
+func FindProjectByDataDir(dataDir string) (Project, bool, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return Project{}, false, err
+	}
+	for _, project := range cfg.Projects {
+		if PathsEqual(project.DataDir, dataDir) {
+			return project, true, nil
+		}
+	}
+	return Project{}, false, nil
+}
+
+// RegisterProject adds a new project for dataDir. The directory itself is
+// not created or inspected here.
+func RegisterProject(name, dataDir string) (Project, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Project{}, errors.New("project name is empty")
+	}
+	normalized, err := NormalizePath(dataDir)
+	if err != nil {
+		return Project{}, err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return Project{}, err
+	}
+	if err := checkProjectName(cfg.Projects, name, ""); err != nil {
+		return Project{}, err
+	}
+	for _, project := range cfg.Projects {
+		if PathsEqual(project.DataDir, normalized) {
+			return Project{}, fmt.Errorf("data directory is already registered as project %q", project.Name)
+		}
+	}
+
+	id, err := newProjectID()
+	if err != nil {
+		return Project{}, err
+	}
+	project := Project{
+		ID:        id,
+		Name:      name,
+		DataDir:   normalized,
+		CreatedAt: time.Now().UTC(),
+	}
+	cfg.Projects = append(cfg.Projects, project)
+	if err := SaveConfig(cfg); err != nil {
+		return Project{}, err
+	}
+	return project, nil
+}
+
+// EnsureProject returns the project registered for dataDir, registering it
+// under name when it is not known yet. Used to adopt data dirs created
+// before the project registry existed.
+func EnsureProject(name, dataDir string) (Project, error) {
+	project, found, err := FindProjectByDataDir(dataDir)
+	if err != nil {
+		return Project{}, err
+	}
+	if found {
+		return project, nil
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return Project{}, err
+	}
+	uniqueName := name
+	for idx := 2; checkProjectName(cfg.Projects, uniqueName, "") != nil; idx++ {
+		uniqueName = fmt.Sprintf("%s %d", name, idx)
+	}
+	return RegisterProject(uniqueName, dataDir)
+}
+
+func RenameProject(id, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("project name is empty")
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	idx := findProjectIndex(cfg.Projects, id)
+	if idx < 0 {
+		return ErrProjectNotFound
+	}
+	if err := checkProjectName(cfg.Projects, name, id); err != nil {
+		return err
+	}
+	cfg.Projects[idx].Name = name
+	return SaveConfig(cfg)
+}
+
+// RemoveProject unregisters a project. Its data dir is left untouched.
+func RemoveProject(id string) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	idx := findProjectIndex(cfg.Projects, id)
+	if idx < 0 {
+		return ErrProjectNotFound
+	}
+	cfg.Projects = append(cfg.Projects[:idx], cfg.Projects[idx+1:]...)
+	return SaveConfig(cfg)
+}
+
+// ActivateProject makes the project the current data dir and records the
+// time it was opened.
+func ActivateProject(id string) (Project, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return Project{}, err
+	}
+	idx := findProjectIndex(cfg.Projects, id)
+	if idx < 0 {
+		return Project{}, ErrProjectNotFound
+	}
+
+	defaultDir, err := DefaultDataDir()
+	if err != nil {
+		return Project{}, err
+	}
+	if PathsEqual(cfg.Projects[idx].DataDir, defaultDir) {
+		cfg.CustomDataDir = ""
+	} else {
+		cfg.CustomDataDir = cfg.Projects[idx].DataDir
+	}
+	cfg.Projects[idx].LastOpenedAt = time.Now().UTC()
+
+	if err := SaveConfig(cfg); err != nil {
+		return Project{}, err
+	}
+	return cfg.Projects[idx], nil
+}
+
+// MoveProjectDataDir points the project registered at fromDir to toDir,
+// e.g. after the storage directory has been migrated.
+func MoveProjectDataDir(fromDir, toDir string) error {
+	normalized, err := NormalizePath(toDir)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for idx := range cfg.Projects {
+		if PathsEqual(cfg.Projects[idx].DataDir, fromDir) {
+			cfg.Projects[idx].DataDir = normalized
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return SaveConfig(cfg)
+}
+
+// NewProjectDataDir builds a fresh location under the default data dir for
+// a project created without an explicit directory.
+func NewProjectDataDir() (string, error) {
+	defaultDir, err := DefaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	name, err := newProjectID()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(defaultDir, WorldsDirName, name), nil
+}
+
+func findProjectIndex(projects []Project, id string) int {
+	for idx, project := range projects {
+		if project.ID == id {
+			return idx
+		}
+	}
+	return -1
+}
+
+func checkProjectName(projects []Project, name, exceptID string) error {
+	for _, project := range projects {
+		if project.ID != exceptID && strings.EqualFold(project.Name, name) {
+			return fmt.Errorf("project name %q already exists", name)
+		}
+	}
+	return nil
+}
+
+func newProjectID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate project id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProjects_RegisterActivateRenameRemove(t *testing.T) {
+	tempHome := t.TempDir()
+	originalUserHomeDir := userHomeDir
+	userHomeDir = func() (string, error) {
+		return tempHome, nil
+	}
+	t.Cleanup(func() {
+		userHomeDir = originalUserHomeDir
+	})
+
+	defaultDir, err := DefaultDataDir()
+	if err != nil {
+		t.Fatalf("DefaultDataDir() failed: %v", err)
+	}
+	first, err := EnsureProject("默认世界", defaultDir)
+	if err != nil {
+		t.Fatalf("EnsureProject() failed: %v", err)
+	}
+	again, err := EnsureProject("默认世界", defaultDir)
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("EnsureProject() must return the existing project, got %+v, %v", again, err)
+	}
+
+	secondDir := filepath.Join(tempHome, "novel-2")
+	second, err := RegisterProject("第二部", secondDir)
+	if err != nil {
+		t.Fatalf("RegisterProject() failed: %v", err)
+	}
+	if _, err := RegisterProject("第二部", filepath.Join(tempHome, "other")); err == nil {
+		t.Fatalf("expected duplicate name to be rejected")
+	}
+	if _, err := RegisterProject("另一个", secondDir); err == nil {
+		t.Fatalf("expected duplicate data dir to be rejected")
+	}
+
+	if _, err := ActivateProject(second.ID); err != nil {
+		t.Fatalf("ActivateProject() failed: %v", err)
+	}
+	resolved, err := ResolveDataDir()
+	if err != nil || !PathsEqual(resolved, secondDir) {
+		t.Fatalf("expected current data dir %q, got %q, %v", secondDir, resolved, err)
+	}
+	projects, err := ListProjects()
+	if err != nil || len(projects) != 2 || projects[0].ID != second.ID {
+		t.Fatalf("expected most recently opened project first, got %+v, %v", projects, err)
+	}
+
+	if _, err := ActivateProject(first.ID); err != nil {
+		t.Fatalf("ActivateProject() failed: %v", err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil || cfg.CustomDataDir != "" {
+		t.Fatalf("activating the default dir must clear the custom dir, got %+v, %v", cfg, err)
+	}
+
+	if err := RenameProject(second.ID, "续集"); err != nil {
+		t.Fatalf("RenameProject() failed: %v", err)
+	}
+	if err := RemoveProject(second.ID); err != nil {
+		t.Fatalf("RemoveProject() failed: %v", err)
+	}
+	if _, err := FindProject(second.ID); err != ErrProjectNotFound {
+		t.Fatalf("expected ErrProjectNotFound, got %v", err)
+	}
+}
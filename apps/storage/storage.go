@@ -17,13 +17,34 @@ const (
 	DatabaseFileName    = "nooltools.db"
 	MarkdownDirName     = "markdown"
 	UpdatesDirName      = "updates"
+	BackupsDirName      = "backups"
 	GitHubTokenFileName = "github_token.json"
 )
 
 var userHomeDir = os.UserHomeDir
 
 type Config struct {
-	CustomDataDir string `json:"custom_data_dir"`
+	CustomDataDir string        `json:"custom_data_dir"`
+	Backup        *BackupConfig `json:"backup,omitempty"`
+	Projects      []Project     `json:"projects,omitempty"`
+}
+
+// BackupConfig controls automatic backups. IntervalMinutes <= 0 disables
+// periodic backups; startup and shutdown backups always run.
+type BackupConfig struct {
+	IntervalMinutes int `json:"interval_minutes"`
+	KeepLast        int `json:"keep_last"`
+	KeepDaily       int `json:"keep_daily"`
+	KeepWeekly      int `json:"keep_weekly"`
+}
+
+func DefaultBackupConfig() BackupConfig {
+	return BackupConfig{
+		IntervalMinutes: 30,
+		KeepLast:        10,
+		KeepDaily:       7,
+		KeepWeekly:      4,
+	}
 }
 
 func DefaultDataDir() (string, error) {
@@ -128,6 +149,33 @@ func ClearCustomDataDir() error {
 	return SaveConfig(cfg)
 }
 
+func LoadBackupConfig() (BackupConfig, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return BackupConfig{}, err
+	}
+	if cfg.Backup == nil {
+		return DefaultBackupConfig(), nil
+	}
+	return *cfg.Backup, nil
+}
+
+func SetBackupConfig(backup BackupConfig) error {
+	if backup.IntervalMinutes < 0 || backup.KeepLast < 0 || backup.KeepDaily < 0 || backup.KeepWeekly < 0 {
+		return errors.New("backup settings cannot be negative")
+	}
+	if backup.KeepLast == 0 && backup.KeepDaily == 0 && backup.KeepWeekly == 0 {
+		return errors.New("backup retention must keep at least one backup")
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.Backup = &backup
+	return SaveConfig(cfg)
+}
+
 func ResolveDataDir() (string, error) {
 	defaultDir, err := DefaultDataDir()
 	if err != nil {
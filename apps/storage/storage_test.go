@@ -70,3 +70,49 @@ func TestBuildTargetDataDir(t *testing.T) {
 		t.Fatalf("expected target %q, got %q", expected, target)
 	}
 }
+
+func TestBackupConfig_DefaultAndPersisted(t *testing.T) {
+	tempHome := t.TempDir()
+	originalUserHomeDir := userHomeDir
+	userHomeDir = func() (string, error) {
+		return tempHome, nil
+	}
+	t.Cleanup(func() {
+		userHomeDir = originalUserHomeDir
+	})
+
+	backup, err := LoadBackupConfig()
+	if err != nil {
+		t.Fatalf("LoadBackupConfig() failed: %v", err)
+	}
+	if backup != DefaultBackupConfig() {
+		t.Fatalf("expected default backup config, got %+v", backup)
+	}
+
+	if err := SetCustomDataDir(filepath.Join(tempHome, "custom-data")); err != nil {
+		t.Fatalf("SetCustomDataDir() failed: %v", err)
+	}
+	want := BackupConfig{IntervalMinutes: 0, KeepLast: 3}
+	if err := SetBackupConfig(want); err != nil {
+		t.Fatalf("SetBackupConfig() failed: %v", err)
+	}
+
+	backup, err = LoadBackupConfig()
+	if err != nil {
+		t.Fatalf("LoadBackupConfig() after save failed: %v", err)
+	}
+	if backup != want {
+		t.Fatalf("expected %+v, got %+v", want, backup)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+	if cfg.CustomDataDir == "" {
+		t.Fatalf("saving backup config must keep the custom data dir")
+	}
+
+	if err := SetBackupConfig(BackupConfig{}); err == nil {
+		t.Fatalf("expected error when retention keeps nothing")
+	}
+}
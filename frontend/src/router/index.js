@@ -12,6 +12,7 @@ const Guaiwu = () => import('../views/Guaiwu.vue')
 const Beibao = () => import('../views/Beibao.vue')
 const Shopping = () => import('../views/Shopping.vue')
 const Getjiang = () => import('../views/Getjiang.vue')
+const Timeline = () => import('../views/Timeline.vue')
 
 // 路由配置
 const routes = [
@@ -85,6 +86,12 @@ const routes = [
     component: Getjiang,
     meta: { title: '抽奖', icon: '🎰' }
   },
+  {
+    path: '/timeline',
+    name: 'Timeline',
+    component: Timeline,
+    meta: { title: '时间线', icon: '📅' }
+  },
 ]
 
 // 创建路由实例
@@ -1,9 +1,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
-import {main} from '../models';
 import {database} from '../models';
+import {main} from '../models';
+import {storage} from '../models';
 
-export function AddBeibaoItem(arg1:number,arg2:string,arg3:number,arg4:string):Promise<void>;
+export function AddBeibaoItem(arg1:number,arg2:string,arg3:number,arg4:string,arg5:number):Promise<void>;
 
 export function AddCharacterAttribute(arg1:number,arg2:string,arg3:string,arg4:number):Promise<void>;
 
@@ -21,19 +22,31 @@ export function AddPetSkill(arg1:number,arg2:string,arg3:string):Promise<void>;
 
 export function AddShiliAttribute(arg1:number,arg2:string,arg3:string,arg4:number):Promise<void>;
 
+export function AddShiliMember(arg1:number,arg2:number,arg3:string):Promise<number>;
+
 export function AddShiliPosition(arg1:number,arg2:string,arg3:string,arg4:string):Promise<void>;
 
 export function AddShiqingDetail(arg1:number,arg2:string):Promise<void>;
 
+export function AddShiqingParticipant(arg1:number,arg2:number,arg3:string):Promise<void>;
+
+export function AddShiqingReward(arg1:number,arg2:string,arg3:string,arg4:number):Promise<number>;
+
 export function AddWeaponAttribute(arg1:number,arg2:string,arg3:string,arg4:number):Promise<void>;
 
 export function AddWeaponSkill(arg1:number,arg2:string,arg3:string):Promise<void>;
 
 export function CheckDatabaseStatus():Promise<boolean|string>;
 
+export function CheckPrizePoolRates(arg1:number):Promise<database.PrizeRateCheck>;
+
 export function CheckReleaseUpdate():Promise<main.UpdateCheckResult>;
 
-export function ClearDrawHistory():Promise<void>;
+export function ClaimDrawHistory(arg1:Array<number>,arg2:number):Promise<number>;
+
+export function ClearDrawHistory(arg1:number):Promise<void>;
+
+export function CreateBackup():Promise<database.BackupInfo>;
 
 export function CreateBeibao(arg1:string):Promise<number>;
 
@@ -45,7 +58,9 @@ export function CreateGuaiwu(arg1:string,arg2:string,arg3:number,arg4:number,arg
 
 export function CreatePet(arg1:string,arg2:string,arg3:number):Promise<number>;
 
-export function CreatePrize(arg1:string,arg2:number,arg3:string,arg4:string):Promise<number>;
+export function CreatePrize(arg1:number,arg2:string,arg3:number,arg4:string,arg5:string):Promise<number>;
+
+export function CreatePrizePool(arg1:string,arg2:string,arg3:string,arg4:string,arg5:number):Promise<number>;
 
 export function CreateShili(arg1:string,arg2:string,arg3:number,arg4:number,arg5:number):Promise<number>;
 
@@ -55,11 +70,13 @@ export function CreateShopping(arg1:string,arg2:number,arg3:string,arg4:string):
 
 export function CreateWeapon(arg1:string,arg2:string,arg3:number):Promise<number>;
 
+export function CreateWorld(arg1:string,arg2:string):Promise<main.WorldProject>;
+
 export function DeleteBeibao(arg1:number):Promise<void>;
 
 export function DeleteBeibaoItem(arg1:number):Promise<void>;
 
-export function DeleteCharacter(arg1:number):Promise<void>;
+export function DeleteCharacter(arg1:number,arg2:database.CharacterBeibaoDisposal):Promise<void>;
 
 export function DeleteCharacterAttribute(arg1:number):Promise<void>;
 
@@ -83,8 +100,12 @@ export function DeletePetAttribute(arg1:number):Promise<void>;
 
 export function DeletePetSkill(arg1:number):Promise<void>;
 
+export function DeletePityRule(arg1:string):Promise<void>;
+
 export function DeletePrize(arg1:number):Promise<void>;
 
+export function DeletePrizePool(arg1:number):Promise<void>;
+
 export function DeleteShili(arg1:number):Promise<void>;
 
 export function DeleteShiliAttribute(arg1:number):Promise<void>;
@@ -95,6 +116,8 @@ export function DeleteShiqing(arg1:number):Promise<void>;
 
 export function DeleteShiqingDetail(arg1:number):Promise<void>;
 
+export function DeleteShiqingReward(arg1:number):Promise<void>;
+
 export function DeleteShopping(arg1:number):Promise<void>;
 
 export function DeleteWeapon(arg1:number):Promise<void>;
@@ -103,11 +126,17 @@ export function DeleteWeaponAttribute(arg1:number):Promise<void>;
 
 export function DeleteWeaponSkill(arg1:number):Promise<void>;
 
-export function DrawOnce():Promise<Record<string, any>>;
+export function DrawOnce(arg1:number,arg2:number):Promise<Record<string, any>>;
+
+export function DrawTen(arg1:number,arg2:number):Promise<Array<Record<string, any>>>;
+
+export function EvaluateShoppingCondition(arg1:number,arg2:number):Promise<database.ShoppingConditionResult>;
 
-export function DrawTen():Promise<Array<Record<string, any>>>;
+export function ExportEntityCSV(arg1:string,arg2:string):Promise<void>;
 
-export function GetAllBeibao():Promise<Array<Record<string, any>>>;
+export function ExportWorld(arg1:string):Promise<void>;
+
+export function GetAllBeibao(arg1:string,arg2:number):Promise<Array<Record<string, any>>>;
 
 export function GetAllCharacters():Promise<Array<Record<string, any>>>;
 
@@ -117,7 +146,7 @@ export function GetAllGuaiwu():Promise<Array<Record<string, any>>>;
 
 export function GetAllPets():Promise<Array<Record<string, any>>>;
 
-export function GetAllPrizes():Promise<Array<Record<string, any>>>;
+export function GetAllPrizes(arg1:number):Promise<Array<Record<string, any>>>;
 
 export function GetAllShili():Promise<Array<Record<string, any>>>;
 
@@ -127,10 +156,18 @@ export function GetAllShopping():Promise<Array<Record<string, any>>>;
 
 export function GetAllWeapons():Promise<Array<Record<string, any>>>;
 
+export function GetBackupSettings():Promise<storage.BackupConfig>;
+
 export function GetBeibaoInfo(arg1:number):Promise<Record<string, any>>;
 
+export function GetBeibaoMovements(arg1:number):Promise<Array<database.BeibaoMovement>>;
+
+export function GetCSVEntityTypes():Promise<Array<string>>;
+
 export function GetCharacterInfo(arg1:number):Promise<Record<string, any>>;
 
+export function GetCurrentWorld():Promise<main.WorldProject>;
+
 export function GetDaojuFunctions(arg1:number):Promise<Array<Record<string, any>>>;
 
 export function GetDaojuInfo(arg1:number):Promise<Record<string, any>>;
@@ -139,7 +176,9 @@ export function GetDatabase():Promise<database.Database>;
 
 export function GetDatabaseInfo():Promise<Record<string, any>>;
 
-export function GetDrawHistory():Promise<Array<Record<string, any>>>;
+export function GetDrawHistory(arg1:number):Promise<Array<Record<string, any>>>;
+
+export function GetDrawStatistics(arg1:number):Promise<database.DrawStatistics>;
 
 export function GetGuaiwuAttributes(arg1:number):Promise<Array<Record<string, any>>>;
 
@@ -147,16 +186,30 @@ export function GetGuaiwuInfo(arg1:number):Promise<Record<string, any>>;
 
 export function GetGuaiwuSkills(arg1:number):Promise<Array<Record<string, any>>>;
 
+export function GetLedgerBalance(arg1:database.LedgerAccount):Promise<database.LedgerBalance>;
+
+export function GetLedgerHistory(arg1:database.LedgerAccount):Promise<Array<database.LedgerEntry>>;
+
 export function GetMarkdownFiles():Promise<Array<Record<string, any>>>;
 
 export function GetPetInfo(arg1:number):Promise<Record<string, any>>;
 
+export function GetPityRules():Promise<Array<database.PityRule>>;
+
+export function GetPityState(arg1:number):Promise<Array<database.PityState>>;
+
 export function GetPrizeInfo(arg1:number):Promise<Record<string, any>>;
 
+export function GetPrizePools():Promise<Array<database.PrizePool>>;
+
+export function GetSearchKinds():Promise<Array<string>>;
+
 export function GetShiliAttributes(arg1:number):Promise<Array<Record<string, any>>>;
 
 export function GetShiliInfo(arg1:number):Promise<Record<string, any>>;
 
+export function GetShiliMembers(arg1:number):Promise<Array<database.ShiliMember>>;
+
 export function GetShiliPositions(arg1:number):Promise<Array<Record<string, any>>>;
 
 export function GetShiqingDetails(arg1:number):Promise<Array<Record<string, any>>>;
@@ -165,27 +218,145 @@ export function GetShiqingInfo(arg1:number):Promise<Record<string, any>>;
 
 export function GetShoppingInfo(arg1:number):Promise<Record<string, any>>;
 
+export function GetShoppingPurchases(arg1:number):Promise<Array<database.ShoppingPurchase>>;
+
 export function GetStorageSettings():Promise<main.StorageSettings>;
 
+export function GetTimeline(arg1:string,arg2:string,arg3:database.TimelineFilter):Promise<Array<database.TimelineEvent>>;
+
 export function GetWeaponInfo(arg1:number):Promise<Record<string, any>>;
 
+export function GetWorldCalendar():Promise<database.WorldCalendar>;
+
+export function GlobalSearch(arg1:string,arg2:database.SearchFilters):Promise<Array<database.SearchResult>>;
+
+export function ImportEntityCSV(arg1:string,arg2:string):Promise<database.CSVImportResult>;
+
+export function ImportWorld(arg1:string,arg2:string):Promise<database.WorldImportResult>;
+
+export function ListBackups():Promise<Array<database.BackupInfo>>;
+
+export function ListBeibao(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListCharacters(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListDaoju(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListGuaiwu(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListPets(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListPrizes(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListShili(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListShiqing(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListShopping(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListWeapons(arg1:database.ListQuery):Promise<main.ListPage>;
+
+export function ListWorlds():Promise<Array<main.WorldProject>>;
+
 export function MigrateStorageDirectory(arg1:string):Promise<main.StorageMigrationResult>;
 
+export function OpenWorld(arg1:string,arg2:string):Promise<main.WorldProject>;
+
+export function ParseWorldDate(arg1:string):Promise<database.WorldDate>;
+
+export function PurchaseShoppingItem(arg1:number,arg2:number,arg3:number):Promise<database.ShoppingPurchase>;
+
+export function PurgeOrphanRecords():Promise<Record<string, number>>;
+
 export function ReadMarkdownFile(arg1:string):Promise<string>;
 
+export function ReconcileLedger(arg1:boolean):Promise<Array<database.LedgerBalance>>;
+
+export function RemoveShiliMember(arg1:number):Promise<void>;
+
+export function RemoveShiqingParticipant(arg1:number,arg2:number):Promise<void>;
+
+export function RemoveWorld(arg1:string):Promise<void>;
+
 export function RenameMarkdownFile(arg1:string,arg2:string):Promise<void>;
 
+export function RenameWorld(arg1:string,arg2:string):Promise<void>;
+
+export function ReorderShiqingDetails(arg1:number,arg2:Array<number>):Promise<void>;
+
+export function ReplayDraws(arg1:number,arg2:number):Promise<Array<database.DrawReplay>>;
+
+export function ResetPityState(arg1:number):Promise<void>;
+
 export function RestartApplication():Promise<void>;
 
+export function RestoreBackup(arg1:string):Promise<void>;
+
+export function ReverseLedgerEntry(arg1:number,arg2:string):Promise<database.LedgerEntry>;
+
 export function SaveMarkdownFile(arg1:string,arg2:string):Promise<void>;
 
 export function SelectStorageParentDirectory():Promise<string>;
 
+export function SetBeibaoBalance(arg1:number,arg2:number):Promise<void>;
+
+export function SetBeibaoCapacity(arg1:number,arg2:any,arg3:any):Promise<void>;
+
+export function SetBeibaoOwner(arg1:number,arg2:string,arg3:number):Promise<void>;
+
+export function SetCharacterBirth(arg1:number,arg2:string):Promise<void>;
+
+export function SetCharacterShili(arg1:number,arg2:number):Promise<void>;
+
+export function SetDaojuHolder(arg1:number,arg2:number):Promise<void>;
+
+export function SetDaojuStacking(arg1:number,arg2:number,arg3:number):Promise<void>;
+
+export function SetPetOwner(arg1:number,arg2:number):Promise<void>;
+
+export function SetPityRule(arg1:database.PityRule):Promise<void>;
+
+export function SetPrizeConsolation(arg1:number,arg2:boolean):Promise<void>;
+
+export function SetPrizeFeatured(arg1:number,arg2:boolean):Promise<void>;
+
+export function SetPrizePoolRateMode(arg1:number,arg2:string):Promise<void>;
+
+export function SetPrizePoolSeed(arg1:number,arg2:number):Promise<void>;
+
+export function SetPrizeQuantity(arg1:number,arg2:number):Promise<void>;
+
+export function SetShiliFounding(arg1:number,arg2:string):Promise<void>;
+
+export function SetShiqingDetailDone(arg1:number,arg2:boolean):Promise<void>;
+
+export function SetShiqingStatus(arg1:number,arg2:string):Promise<void>;
+
+export function SetShiqingWorldDate(arg1:number,arg2:string):Promise<void>;
+
+export function SetShoppingStock(arg1:number,arg2:any):Promise<void>;
+
+export function SetWeaponHolder(arg1:number,arg2:number):Promise<void>;
+
+export function SetWorldCalendar(arg1:database.WorldCalendar):Promise<void>;
+
+export function SimulateDraws(arg1:number,arg2:number,arg3:number):Promise<database.DrawStatistics>;
+
 export function StartAutoUpdate():Promise<Record<string, any>>;
 
+export function SwitchWorld(arg1:string):Promise<main.WorldProject>;
+
+export function TradeBetweenBeibao(arg1:database.BeibaoTradeOffer,arg2:database.BeibaoTradeOffer):Promise<void>;
+
+export function TransferBeibaoItem(arg1:number,arg2:number,arg3:number):Promise<void>;
+
+export function TransferCurrency(arg1:database.LedgerAccount,arg2:database.LedgerAccount,arg3:number,arg4:string):Promise<database.LedgerEntry>;
+
+export function UpdateBackupSettings(arg1:storage.BackupConfig):Promise<void>;
+
 export function UpdateBeibao(arg1:number,arg2:string):Promise<void>;
 
-export function UpdateBeibaoItem(arg1:number,arg2:string,arg3:number,arg4:string):Promise<void>;
+export function UpdateBeibaoItem(arg1:number,arg2:string,arg3:number,arg4:string,arg5:number):Promise<void>;
 
 export function UpdateCharacterAttribute(arg1:number,arg2:string,arg3:string,arg4:number):Promise<void>;
 
@@ -211,9 +382,13 @@ export function UpdatePetSkill(arg1:number,arg2:string,arg3:string):Promise<void
 
 export function UpdatePrize(arg1:number,arg2:string,arg3:number,arg4:string,arg5:string):Promise<void>;
 
+export function UpdatePrizePool(arg1:number,arg2:string,arg3:string,arg4:string,arg5:string,arg6:number):Promise<void>;
+
 export function UpdateShiliAttribute(arg1:number,arg2:string,arg3:string,arg4:number):Promise<void>;
 
-export function UpdateShiliBasicInfo(arg1:number,arg2:number,arg3:string,arg4:number,arg5:number,arg6:number):Promise<void>;
+export function UpdateShiliBasicInfo(arg1:number,arg2:number,arg3:string,arg4:number,arg5:number):Promise<void>;
+
+export function UpdateShiliMemberRole(arg1:number,arg2:string):Promise<void>;
 
 export function UpdateShiliPosition(arg1:number,arg2:string,arg3:string,arg4:string):Promise<void>;
 
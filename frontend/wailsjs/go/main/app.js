@@ -2,8 +2,8 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function AddBeibaoItem(arg1, arg2, arg3, arg4) {
-  return window['go']['main']['app']['AddBeibaoItem'](arg1, arg2, arg3, arg4);
+export function AddBeibaoItem(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['app']['AddBeibaoItem'](arg1, arg2, arg3, arg4, arg5);
 }
 
 export function AddCharacterAttribute(arg1, arg2, arg3, arg4) {
@@ -38,6 +38,10 @@ export function AddShiliAttribute(arg1, arg2, arg3, arg4) {
   return window['go']['main']['app']['AddShiliAttribute'](arg1, arg2, arg3, arg4);
 }
 
+export function AddShiliMember(arg1, arg2, arg3) {
+  return window['go']['main']['app']['AddShiliMember'](arg1, arg2, arg3);
+}
+
 export function AddShiliPosition(arg1, arg2, arg3, arg4) {
   return window['go']['main']['app']['AddShiliPosition'](arg1, arg2, arg3, arg4);
 }
@@ -46,6 +50,14 @@ export function AddShiqingDetail(arg1, arg2) {
   return window['go']['main']['app']['AddShiqingDetail'](arg1, arg2);
 }
 
+export function AddShiqingParticipant(arg1, arg2, arg3) {
+  return window['go']['main']['app']['AddShiqingParticipant'](arg1, arg2, arg3);
+}
+
+export function AddShiqingReward(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['app']['AddShiqingReward'](arg1, arg2, arg3, arg4);
+}
+
 export function AddWeaponAttribute(arg1, arg2, arg3, arg4) {
   return window['go']['main']['app']['AddWeaponAttribute'](arg1, arg2, arg3, arg4);
 }
@@ -58,12 +70,24 @@ export function CheckDatabaseStatus() {
   return window['go']['main']['app']['CheckDatabaseStatus']();
 }
 
+export function CheckPrizePoolRates(arg1) {
+  return window['go']['main']['app']['CheckPrizePoolRates'](arg1);
+}
+
 export function CheckReleaseUpdate() {
   return window['go']['main']['app']['CheckReleaseUpdate']();
 }
 
-export function ClearDrawHistory() {
-  return window['go']['main']['app']['ClearDrawHistory']();
+export function ClaimDrawHistory(arg1, arg2) {
+  return window['go']['main']['app']['ClaimDrawHistory'](arg1, arg2);
+}
+
+export function ClearDrawHistory(arg1) {
+  return window['go']['main']['app']['ClearDrawHistory'](arg1);
+}
+
+export function CreateBackup() {
+  return window['go']['main']['app']['CreateBackup']();
 }
 
 export function CreateBeibao(arg1) {
@@ -86,8 +110,12 @@ export function CreatePet(arg1, arg2, arg3) {
   return window['go']['main']['app']['CreatePet'](arg1, arg2, arg3);
 }
 
-export function CreatePrize(arg1, arg2, arg3, arg4) {
-  return window['go']['main']['app']['CreatePrize'](arg1, arg2, arg3, arg4);
+export function CreatePrize(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['app']['CreatePrize'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function CreatePrizePool(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['app']['CreatePrizePool'](arg1, arg2, arg3, arg4, arg5);
 }
 
 export function CreateShili(arg1, arg2, arg3, arg4, arg5) {
@@ -106,6 +134,10 @@ export function CreateWeapon(arg1, arg2, arg3) {
   return window['go']['main']['app']['CreateWeapon'](arg1, arg2, arg3);
 }
 
+export function CreateWorld(arg1, arg2) {
+  return window['go']['main']['app']['CreateWorld'](arg1, arg2);
+}
+
 export function DeleteBeibao(arg1) {
   return window['go']['main']['app']['DeleteBeibao'](arg1);
 }
@@ -114,8 +146,8 @@ export function DeleteBeibaoItem(arg1) {
   return window['go']['main']['app']['DeleteBeibaoItem'](arg1);
 }
 
-export function DeleteCharacter(arg1) {
-  return window['go']['main']['app']['DeleteCharacter'](arg1);
+export function DeleteCharacter(arg1, arg2) {
+  return window['go']['main']['app']['DeleteCharacter'](arg1, arg2);
 }
 
 export function DeleteCharacterAttribute(arg1) {
@@ -162,10 +194,18 @@ export function DeletePetSkill(arg1) {
   return window['go']['main']['app']['DeletePetSkill'](arg1);
 }
 
+export function DeletePityRule(arg1) {
+  return window['go']['main']['app']['DeletePityRule'](arg1);
+}
+
 export function DeletePrize(arg1) {
   return window['go']['main']['app']['DeletePrize'](arg1);
 }
 
+export function DeletePrizePool(arg1) {
+  return window['go']['main']['app']['DeletePrizePool'](arg1);
+}
+
 export function DeleteShili(arg1) {
   return window['go']['main']['app']['DeleteShili'](arg1);
 }
@@ -186,6 +226,10 @@ export function DeleteShiqingDetail(arg1) {
   return window['go']['main']['app']['DeleteShiqingDetail'](arg1);
 }
 
+export function DeleteShiqingReward(arg1) {
+  return window['go']['main']['app']['DeleteShiqingReward'](arg1);
+}
+
 export function DeleteShopping(arg1) {
   return window['go']['main']['app']['DeleteShopping'](arg1);
 }
@@ -202,16 +246,28 @@ export function DeleteWeaponSkill(arg1) {
   return window['go']['main']['app']['DeleteWeaponSkill'](arg1);
 }
 
-export function DrawOnce() {
-  return window['go']['main']['app']['DrawOnce']();
+export function DrawOnce(arg1, arg2) {
+  return window['go']['main']['app']['DrawOnce'](arg1, arg2);
 }
 
-export function DrawTen() {
-  return window['go']['main']['app']['DrawTen']();
+export function DrawTen(arg1, arg2) {
+  return window['go']['main']['app']['DrawTen'](arg1, arg2);
 }
 
-export function GetAllBeibao() {
-  return window['go']['main']['app']['GetAllBeibao']();
+export function EvaluateShoppingCondition(arg1, arg2) {
+  return window['go']['main']['app']['EvaluateShoppingCondition'](arg1, arg2);
+}
+
+export function ExportEntityCSV(arg1, arg2) {
+  return window['go']['main']['app']['ExportEntityCSV'](arg1, arg2);
+}
+
+export function ExportWorld(arg1) {
+  return window['go']['main']['app']['ExportWorld'](arg1);
+}
+
+export function GetAllBeibao(arg1, arg2) {
+  return window['go']['main']['app']['GetAllBeibao'](arg1, arg2);
 }
 
 export function GetAllCharacters() {
@@ -230,8 +286,8 @@ export function GetAllPets() {
   return window['go']['main']['app']['GetAllPets']();
 }
 
-export function GetAllPrizes() {
-  return window['go']['main']['app']['GetAllPrizes']();
+export function GetAllPrizes(arg1) {
+  return window['go']['main']['app']['GetAllPrizes'](arg1);
 }
 
 export function GetAllShili() {
@@ -250,14 +306,30 @@ export function GetAllWeapons() {
   return window['go']['main']['app']['GetAllWeapons']();
 }
 
+export function GetBackupSettings() {
+  return window['go']['main']['app']['GetBackupSettings']();
+}
+
 export function GetBeibaoInfo(arg1) {
   return window['go']['main']['app']['GetBeibaoInfo'](arg1);
 }
 
+export function GetBeibaoMovements(arg1) {
+  return window['go']['main']['app']['GetBeibaoMovements'](arg1);
+}
+
+export function GetCSVEntityTypes() {
+  return window['go']['main']['app']['GetCSVEntityTypes']();
+}
+
 export function GetCharacterInfo(arg1) {
   return window['go']['main']['app']['GetCharacterInfo'](arg1);
 }
 
+export function GetCurrentWorld() {
+  return window['go']['main']['app']['GetCurrentWorld']();
+}
+
 export function GetDaojuFunctions(arg1) {
   return window['go']['main']['app']['GetDaojuFunctions'](arg1);
 }
@@ -274,8 +346,12 @@ export function GetDatabaseInfo() {
   return window['go']['main']['app']['GetDatabaseInfo']();
 }
 
-export function GetDrawHistory() {
-  return window['go']['main']['app']['GetDrawHistory']();
+export function GetDrawHistory(arg1) {
+  return window['go']['main']['app']['GetDrawHistory'](arg1);
+}
+
+export function GetDrawStatistics(arg1) {
+  return window['go']['main']['app']['GetDrawStatistics'](arg1);
 }
 
 export function GetGuaiwuAttributes(arg1) {
@@ -290,6 +366,14 @@ export function GetGuaiwuSkills(arg1) {
   return window['go']['main']['app']['GetGuaiwuSkills'](arg1);
 }
 
+export function GetLedgerBalance(arg1) {
+  return window['go']['main']['app']['GetLedgerBalance'](arg1);
+}
+
+export function GetLedgerHistory(arg1) {
+  return window['go']['main']['app']['GetLedgerHistory'](arg1);
+}
+
 export function GetMarkdownFiles() {
   return window['go']['main']['app']['GetMarkdownFiles']();
 }
@@ -298,10 +382,26 @@ export function GetPetInfo(arg1) {
   return window['go']['main']['app']['GetPetInfo'](arg1);
 }
 
+export function GetPityRules() {
+  return window['go']['main']['app']['GetPityRules']();
+}
+
+export function GetPityState(arg1) {
+  return window['go']['main']['app']['GetPityState'](arg1);
+}
+
 export function GetPrizeInfo(arg1) {
   return window['go']['main']['app']['GetPrizeInfo'](arg1);
 }
 
+export function GetPrizePools() {
+  return window['go']['main']['app']['GetPrizePools']();
+}
+
+export function GetSearchKinds() {
+  return window['go']['main']['app']['GetSearchKinds']();
+}
+
 export function GetShiliAttributes(arg1) {
   return window['go']['main']['app']['GetShiliAttributes'](arg1);
 }
@@ -310,6 +410,10 @@ export function GetShiliInfo(arg1) {
   return window['go']['main']['app']['GetShiliInfo'](arg1);
 }
 
+export function GetShiliMembers(arg1) {
+  return window['go']['main']['app']['GetShiliMembers'](arg1);
+}
+
 export function GetShiliPositions(arg1) {
   return window['go']['main']['app']['GetShiliPositions'](arg1);
 }
@@ -326,30 +430,158 @@ export function GetShoppingInfo(arg1) {
   return window['go']['main']['app']['GetShoppingInfo'](arg1);
 }
 
+export function GetShoppingPurchases(arg1) {
+  return window['go']['main']['app']['GetShoppingPurchases'](arg1);
+}
+
 export function GetStorageSettings() {
   return window['go']['main']['app']['GetStorageSettings']();
 }
 
+export function GetTimeline(arg1, arg2, arg3) {
+  return window['go']['main']['app']['GetTimeline'](arg1, arg2, arg3);
+}
+
 export function GetWeaponInfo(arg1) {
   return window['go']['main']['app']['GetWeaponInfo'](arg1);
 }
 
+export function GetWorldCalendar() {
+  return window['go']['main']['app']['GetWorldCalendar']();
+}
+
+export function GlobalSearch(arg1, arg2) {
+  return window['go']['main']['app']['GlobalSearch'](arg1, arg2);
+}
+
+export function ImportEntityCSV(arg1, arg2) {
+  return window['go']['main']['app']['ImportEntityCSV'](arg1, arg2);
+}
+
+export function ImportWorld(arg1, arg2) {
+  return window['go']['main']['app']['ImportWorld'](arg1, arg2);
+}
+
+export function ListBackups() {
+  return window['go']['main']['app']['ListBackups']();
+}
+
+export function ListBeibao(arg1) {
+  return window['go']['main']['app']['ListBeibao'](arg1);
+}
+
+export function ListCharacters(arg1) {
+  return window['go']['main']['app']['ListCharacters'](arg1);
+}
+
+export function ListDaoju(arg1) {
+  return window['go']['main']['app']['ListDaoju'](arg1);
+}
+
+export function ListGuaiwu(arg1) {
+  return window['go']['main']['app']['ListGuaiwu'](arg1);
+}
+
+export function ListPets(arg1) {
+  return window['go']['main']['app']['ListPets'](arg1);
+}
+
+export function ListPrizes(arg1) {
+  return window['go']['main']['app']['ListPrizes'](arg1);
+}
+
+export function ListShili(arg1) {
+  return window['go']['main']['app']['ListShili'](arg1);
+}
+
+export function ListShiqing(arg1) {
+  return window['go']['main']['app']['ListShiqing'](arg1);
+}
+
+export function ListShopping(arg1) {
+  return window['go']['main']['app']['ListShopping'](arg1);
+}
+
+export function ListWeapons(arg1) {
+  return window['go']['main']['app']['ListWeapons'](arg1);
+}
+
+export function ListWorlds() {
+  return window['go']['main']['app']['ListWorlds']();
+}
+
 export function MigrateStorageDirectory(arg1) {
   return window['go']['main']['app']['MigrateStorageDirectory'](arg1);
 }
 
+export function OpenWorld(arg1, arg2) {
+  return window['go']['main']['app']['OpenWorld'](arg1, arg2);
+}
+
+export function ParseWorldDate(arg1) {
+  return window['go']['main']['app']['ParseWorldDate'](arg1);
+}
+
+export function PurchaseShoppingItem(arg1, arg2, arg3) {
+  return window['go']['main']['app']['PurchaseShoppingItem'](arg1, arg2, arg3);
+}
+
+export function PurgeOrphanRecords() {
+  return window['go']['main']['app']['PurgeOrphanRecords']();
+}
+
 export function ReadMarkdownFile(arg1) {
   return window['go']['main']['app']['ReadMarkdownFile'](arg1);
 }
 
+export function ReconcileLedger(arg1) {
+  return window['go']['main']['app']['ReconcileLedger'](arg1);
+}
+
+export function RemoveShiliMember(arg1) {
+  return window['go']['main']['app']['RemoveShiliMember'](arg1);
+}
+
+export function RemoveShiqingParticipant(arg1, arg2) {
+  return window['go']['main']['app']['RemoveShiqingParticipant'](arg1, arg2);
+}
+
+export function RemoveWorld(arg1) {
+  return window['go']['main']['app']['RemoveWorld'](arg1);
+}
+
 export function RenameMarkdownFile(arg1, arg2) {
   return window['go']['main']['app']['RenameMarkdownFile'](arg1, arg2);
 }
 
+export function RenameWorld(arg1, arg2) {
+  return window['go']['main']['app']['RenameWorld'](arg1, arg2);
+}
+
+export function ReorderShiqingDetails(arg1, arg2) {
+  return window['go']['main']['app']['ReorderShiqingDetails'](arg1, arg2);
+}
+
+export function ReplayDraws(arg1, arg2) {
+  return window['go']['main']['app']['ReplayDraws'](arg1, arg2);
+}
+
+export function ResetPityState(arg1) {
+  return window['go']['main']['app']['ResetPityState'](arg1);
+}
+
 export function RestartApplication() {
   return window['go']['main']['app']['RestartApplication']();
 }
 
+export function RestoreBackup(arg1) {
+  return window['go']['main']['app']['RestoreBackup'](arg1);
+}
+
+export function ReverseLedgerEntry(arg1, arg2) {
+  return window['go']['main']['app']['ReverseLedgerEntry'](arg1, arg2);
+}
+
 export function SaveMarkdownFile(arg1, arg2) {
   return window['go']['main']['app']['SaveMarkdownFile'](arg1, arg2);
 }
@@ -358,16 +590,124 @@ export function SelectStorageParentDirectory() {
   return window['go']['main']['app']['SelectStorageParentDirectory']();
 }
 
+export function SetBeibaoBalance(arg1, arg2) {
+  return window['go']['main']['app']['SetBeibaoBalance'](arg1, arg2);
+}
+
+export function SetBeibaoCapacity(arg1, arg2, arg3) {
+  return window['go']['main']['app']['SetBeibaoCapacity'](arg1, arg2, arg3);
+}
+
+export function SetBeibaoOwner(arg1, arg2, arg3) {
+  return window['go']['main']['app']['SetBeibaoOwner'](arg1, arg2, arg3);
+}
+
+export function SetCharacterBirth(arg1, arg2) {
+  return window['go']['main']['app']['SetCharacterBirth'](arg1, arg2);
+}
+
+export function SetCharacterShili(arg1, arg2) {
+  return window['go']['main']['app']['SetCharacterShili'](arg1, arg2);
+}
+
+export function SetDaojuHolder(arg1, arg2) {
+  return window['go']['main']['app']['SetDaojuHolder'](arg1, arg2);
+}
+
+export function SetDaojuStacking(arg1, arg2, arg3) {
+  return window['go']['main']['app']['SetDaojuStacking'](arg1, arg2, arg3);
+}
+
+export function SetPetOwner(arg1, arg2) {
+  return window['go']['main']['app']['SetPetOwner'](arg1, arg2);
+}
+
+export function SetPityRule(arg1) {
+  return window['go']['main']['app']['SetPityRule'](arg1);
+}
+
+export function SetPrizeConsolation(arg1, arg2) {
+  return window['go']['main']['app']['SetPrizeConsolation'](arg1, arg2);
+}
+
+export function SetPrizeFeatured(arg1, arg2) {
+  return window['go']['main']['app']['SetPrizeFeatured'](arg1, arg2);
+}
+
+export function SetPrizePoolRateMode(arg1, arg2) {
+  return window['go']['main']['app']['SetPrizePoolRateMode'](arg1, arg2);
+}
+
+export function SetPrizePoolSeed(arg1, arg2) {
+  return window['go']['main']['app']['SetPrizePoolSeed'](arg1, arg2);
+}
+
+export function SetPrizeQuantity(arg1, arg2) {
+  return window['go']['main']['app']['SetPrizeQuantity'](arg1, arg2);
+}
+
+export function SetShiliFounding(arg1, arg2) {
+  return window['go']['main']['app']['SetShiliFounding'](arg1, arg2);
+}
+
+export function SetShiqingDetailDone(arg1, arg2) {
+  return window['go']['main']['app']['SetShiqingDetailDone'](arg1, arg2);
+}
+
+export function SetShiqingStatus(arg1, arg2) {
+  return window['go']['main']['app']['SetShiqingStatus'](arg1, arg2);
+}
+
+export function SetShiqingWorldDate(arg1, arg2) {
+  return window['go']['main']['app']['SetShiqingWorldDate'](arg1, arg2);
+}
+
+export function SetShoppingStock(arg1, arg2) {
+  return window['go']['main']['app']['SetShoppingStock'](arg1, arg2);
+}
+
+export function SetWeaponHolder(arg1, arg2) {
+  return window['go']['main']['app']['SetWeaponHolder'](arg1, arg2);
+}
+
+export function SetWorldCalendar(arg1) {
+  return window['go']['main']['app']['SetWorldCalendar'](arg1);
+}
+
+export function SimulateDraws(arg1, arg2, arg3) {
+  return window['go']['main']['app']['SimulateDraws'](arg1, arg2, arg3);
+}
+
 export function StartAutoUpdate() {
   return window['go']['main']['app']['StartAutoUpdate']();
 }
 
+export function SwitchWorld(arg1) {
+  return window['go']['main']['app']['SwitchWorld'](arg1);
+}
+
+export function TradeBetweenBeibao(arg1, arg2) {
+  return window['go']['main']['app']['TradeBetweenBeibao'](arg1, arg2);
+}
+
+export function TransferBeibaoItem(arg1, arg2, arg3) {
+  return window['go']['main']['app']['TransferBeibaoItem'](arg1, arg2, arg3);
+}
+
+export function TransferCurrency(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['app']['TransferCurrency'](arg1, arg2, arg3, arg4);
+}
+
+export function UpdateBackupSettings(arg1) {
+  return window['go']['main']['app']['UpdateBackupSettings'](arg1);
+}
+
 export function UpdateBeibao(arg1, arg2) {
   return window['go']['main']['app']['UpdateBeibao'](arg1, arg2);
 }
 
-export function UpdateBeibaoItem(arg1, arg2, arg3, arg4) {
-  return window['go']['main']['app']['UpdateBeibaoItem'](arg1, arg2, arg3, arg4);
+export function UpdateBeibaoItem(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['app']['UpdateBeibaoItem'](arg1, arg2, arg3, arg4, arg5);
 }
 
 export function UpdateCharacterAttribute(arg1, arg2, arg3, arg4) {
@@ -418,12 +758,20 @@ export function UpdatePrize(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['app']['UpdatePrize'](arg1, arg2, arg3, arg4, arg5);
 }
 
+export function UpdatePrizePool(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['app']['UpdatePrizePool'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
 export function UpdateShiliAttribute(arg1, arg2, arg3, arg4) {
   return window['go']['main']['app']['UpdateShiliAttribute'](arg1, arg2, arg3, arg4);
 }
 
-export function UpdateShiliBasicInfo(arg1, arg2, arg3, arg4, arg5, arg6) {
-  return window['go']['main']['app']['UpdateShiliBasicInfo'](arg1, arg2, arg3, arg4, arg5, arg6);
+export function UpdateShiliBasicInfo(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['app']['UpdateShiliBasicInfo'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function UpdateShiliMemberRole(arg1, arg2) {
+  return window['go']['main']['app']['UpdateShiliMemberRole'](arg1, arg2);
 }
 
 export function UpdateShiliPosition(arg1, arg2, arg3, arg4) {
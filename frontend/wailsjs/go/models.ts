@@ -1,5 +1,213 @@
 export namespace database {
 	
+	export class BackupInfo {
+	    id: string;
+	    reason: string;
+	    schema_version: number;
+	    // Go type: time
+	    created_at: any;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.reason = source["reason"];
+	        this.schema_version = source["schema_version"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.size = source["size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BeibaoMovement {
+	    id: number;
+	    batch: number;
+	    kind: string;
+	    beibao_id: number;
+	    counterpart_beibao_id: number;
+	    counterpart_name: string;
+	    item_name: string;
+	    quantity: number;
+	    created_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BeibaoMovement(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.batch = source["batch"];
+	        this.kind = source["kind"];
+	        this.beibao_id = source["beibao_id"];
+	        this.counterpart_beibao_id = source["counterpart_beibao_id"];
+	        this.counterpart_name = source["counterpart_name"];
+	        this.item_name = source["item_name"];
+	        this.quantity = source["quantity"];
+	        this.created_at = source["created_at"];
+	    }
+	}
+	export class BeibaoTradeItem {
+	    item_id: number;
+	    quantity: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BeibaoTradeItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item_id = source["item_id"];
+	        this.quantity = source["quantity"];
+	    }
+	}
+	export class BeibaoTradeOffer {
+	    beibao_id: number;
+	    items: BeibaoTradeItem[];
+	    currency: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BeibaoTradeOffer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.beibao_id = source["beibao_id"];
+	        this.items = this.convertValues(source["items"], BeibaoTradeItem);
+	        this.currency = source["currency"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CSVRowError {
+	    row: number;
+	    column: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CSVRowError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.row = source["row"];
+	        this.column = source["column"];
+	        this.message = source["message"];
+	    }
+	}
+	export class CSVImportResult {
+	    inserted: number;
+	    updated: number;
+	    errors: CSVRowError[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CSVImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.inserted = source["inserted"];
+	        this.updated = source["updated"];
+	        this.errors = this.convertValues(source["errors"], CSVRowError);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class CalendarEra {
+	    name: string;
+	    years: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalendarEra(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.years = source["years"];
+	    }
+	}
+	export class CalendarMonth {
+	    name: string;
+	    days: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalendarMonth(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.days = source["days"];
+	    }
+	}
+	export class CharacterBeibaoDisposal {
+	    action: string;
+	    transfer_to: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CharacterBeibaoDisposal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.action = source["action"];
+	        this.transfer_to = source["transfer_to"];
+	    }
+	}
 	export class Database {
 	
 	
@@ -12,11 +220,687 @@ export namespace database {
 	
 	    }
 	}
+	export class DrawPullCount {
+	    pulls: number;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DrawPullCount(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pulls = source["pulls"];
+	        this.count = source["count"];
+	    }
+	}
+	export class DrawItemStatistics {
+	    prize_id: number;
+	    name: string;
+	    variety: string;
+	    configured_rate: number;
+	    observed_rate: number;
+	    hits: number;
+	    trial_hit_rate: number;
+	    expected_pulls: number;
+	    p50: number;
+	    p90: number;
+	    p99: number;
+	    distribution: DrawPullCount[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DrawItemStatistics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.prize_id = source["prize_id"];
+	        this.name = source["name"];
+	        this.variety = source["variety"];
+	        this.configured_rate = source["configured_rate"];
+	        this.observed_rate = source["observed_rate"];
+	        this.hits = source["hits"];
+	        this.trial_hit_rate = source["trial_hit_rate"];
+	        this.expected_pulls = source["expected_pulls"];
+	        this.p50 = source["p50"];
+	        this.p90 = source["p90"];
+	        this.p99 = source["p99"];
+	        this.distribution = this.convertValues(source["distribution"], DrawPullCount);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class DrawReplay {
+	    sequence: number;
+	    roll: number;
+	    prize_id: number;
+	    prize_name: string;
+	    history_id: number;
+	    recorded_prize_id: number;
+	    recorded_prize_name: string;
+	    recorded_roll: number;
+	    matches: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DrawReplay(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sequence = source["sequence"];
+	        this.roll = source["roll"];
+	        this.prize_id = source["prize_id"];
+	        this.prize_name = source["prize_name"];
+	        this.history_id = source["history_id"];
+	        this.recorded_prize_id = source["recorded_prize_id"];
+	        this.recorded_prize_name = source["recorded_prize_name"];
+	        this.recorded_roll = source["recorded_roll"];
+	        this.matches = source["matches"];
+	    }
+	}
+	export class DrawStatistics {
+	    pool_id: number;
+	    trials: number;
+	    draws_per_trial: number;
+	    total_draws: number;
+	    prizes: DrawItemStatistics[];
+	    varieties: DrawItemStatistics[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DrawStatistics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pool_id = source["pool_id"];
+	        this.trials = source["trials"];
+	        this.draws_per_trial = source["draws_per_trial"];
+	        this.total_draws = source["total_draws"];
+	        this.prizes = this.convertValues(source["prizes"], DrawItemStatistics);
+	        this.varieties = this.convertValues(source["varieties"], DrawItemStatistics);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LedgerAccount {
+	    type: string;
+	    id: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LedgerAccount(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.id = source["id"];
+	    }
+	}
+	export class LedgerBalance {
+	    type: string;
+	    id: number;
+	    name: string;
+	    balance: number;
+	    stored?: number;
+	    drift: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LedgerBalance(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.balance = source["balance"];
+	        this.stored = source["stored"];
+	        this.drift = source["drift"];
+	    }
+	}
+	export class LedgerEntry {
+	    id: number;
+	    from_type: string;
+	    from_id: number;
+	    to_type: string;
+	    to_id: number;
+	    amount: number;
+	    memo: string;
+	    reversal_of: number;
+	    reversed_by: number;
+	    created_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LedgerEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.from_type = source["from_type"];
+	        this.from_id = source["from_id"];
+	        this.to_type = source["to_type"];
+	        this.to_id = source["to_id"];
+	        this.amount = source["amount"];
+	        this.memo = source["memo"];
+	        this.reversal_of = source["reversal_of"];
+	        this.reversed_by = source["reversed_by"];
+	        this.created_at = source["created_at"];
+	    }
+	}
+	export class ListQuery {
+	    name_prefix: string;
+	    min_level?: number;
+	    max_level?: number;
+	    owner: string;
+	    owner_id?: number;
+	    type: string;
+	    pool_id?: number;
+	    sort_by: string;
+	    sort_desc: boolean;
+	    offset: number;
+	    limit: number;
+	    cursor: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ListQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name_prefix = source["name_prefix"];
+	        this.min_level = source["min_level"];
+	        this.max_level = source["max_level"];
+	        this.owner = source["owner"];
+	        this.owner_id = source["owner_id"];
+	        this.type = source["type"];
+	        this.pool_id = source["pool_id"];
+	        this.sort_by = source["sort_by"];
+	        this.sort_desc = source["sort_desc"];
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	        this.cursor = source["cursor"];
+	    }
+	}
+	export class PityRule {
+	    id: number;
+	    variety: string;
+	    hard_pity: number;
+	    soft_pity_start: number;
+	    soft_pity_step: number;
+	    featured_guarantee: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PityRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.variety = source["variety"];
+	        this.hard_pity = source["hard_pity"];
+	        this.soft_pity_start = source["soft_pity_start"];
+	        this.soft_pity_step = source["soft_pity_step"];
+	        this.featured_guarantee = source["featured_guarantee"];
+	    }
+	}
+	export class PityState {
+	    variety: string;
+	    draws_since_hit: number;
+	    hard_pity: number;
+	    draws_until_hard_pity: number;
+	    next_rate: number;
+	    guaranteed_featured: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PityState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.variety = source["variety"];
+	        this.draws_since_hit = source["draws_since_hit"];
+	        this.hard_pity = source["hard_pity"];
+	        this.draws_until_hard_pity = source["draws_until_hard_pity"];
+	        this.next_rate = source["next_rate"];
+	        this.guaranteed_featured = source["guaranteed_featured"];
+	    }
+	}
+	export class PrizePool {
+	    id: number;
+	    name: string;
+	    description: string;
+	    start_at: string;
+	    end_at: string;
+	    draw_cost: number;
+	    rate_mode: string;
+	    is_default: boolean;
+	    seed: number;
+	    sequence: number;
+	    prize_count: number;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PrizePool(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.start_at = source["start_at"];
+	        this.end_at = source["end_at"];
+	        this.draw_cost = source["draw_cost"];
+	        this.rate_mode = source["rate_mode"];
+	        this.is_default = source["is_default"];
+	        this.seed = source["seed"];
+	        this.sequence = source["sequence"];
+	        this.prize_count = source["prize_count"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PrizeRateCheck {
+	    pool_id: number;
+	    rate_mode: string;
+	    total_rate: number;
+	    leftover: number;
+	    consolation_prize_id: number;
+	    warnings: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PrizeRateCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pool_id = source["pool_id"];
+	        this.rate_mode = source["rate_mode"];
+	        this.total_rate = source["total_rate"];
+	        this.leftover = source["leftover"];
+	        this.consolation_prize_id = source["consolation_prize_id"];
+	        this.warnings = source["warnings"];
+	    }
+	}
+	export class SearchFilters {
+	    kinds: string[];
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchFilters(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kinds = source["kinds"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class SearchResult {
+	    kind: string;
+	    id: number;
+	    path: string;
+	    title: string;
+	    snippet: string;
+	    score: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.id = source["id"];
+	        this.path = source["path"];
+	        this.title = source["title"];
+	        this.snippet = source["snippet"];
+	        this.score = source["score"];
+	    }
+	}
+	export class ShiliMember {
+	    id: number;
+	    shili_id: number;
+	    renwu_id: number;
+	    name: string;
+	    role: string;
+	    // Go type: time
+	    created_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShiliMember(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.shili_id = source["shili_id"];
+	        this.renwu_id = source["renwu_id"];
+	        this.name = source["name"];
+	        this.role = source["role"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ShoppingConditionClause {
+	    text: string;
+	    passed: boolean;
+	    detail: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShoppingConditionClause(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.passed = source["passed"];
+	        this.detail = source["detail"];
+	    }
+	}
+	export class ShoppingConditionResult {
+	    item_id: number;
+	    character_id: number;
+	    condition: string;
+	    passed: boolean;
+	    clauses: ShoppingConditionClause[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ShoppingConditionResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item_id = source["item_id"];
+	        this.character_id = source["character_id"];
+	        this.condition = source["condition"];
+	        this.passed = source["passed"];
+	        this.clauses = this.convertValues(source["clauses"], ShoppingConditionClause);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ShoppingPurchase {
+	    id: number;
+	    shopping_id: number;
+	    item_name: string;
+	    beibao_id: number;
+	    quantity: number;
+	    unit_price: number;
+	    total_price: number;
+	    balance_after: number;
+	    purchased_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShoppingPurchase(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.shopping_id = source["shopping_id"];
+	        this.item_name = source["item_name"];
+	        this.beibao_id = source["beibao_id"];
+	        this.quantity = source["quantity"];
+	        this.unit_price = source["unit_price"];
+	        this.total_price = source["total_price"];
+	        this.balance_after = source["balance_after"];
+	        this.purchased_at = source["purchased_at"];
+	    }
+	}
+	export class TimelineEvent {
+	    day: number;
+	    date: string;
+	    kind: string;
+	    entity_id: number;
+	    title: string;
+	    detail: string;
+	    warnings: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.day = source["day"];
+	        this.date = source["date"];
+	        this.kind = source["kind"];
+	        this.entity_id = source["entity_id"];
+	        this.title = source["title"];
+	        this.detail = source["detail"];
+	        this.warnings = source["warnings"];
+	    }
+	}
+	export class TimelineFilter {
+	    kinds: string[];
+	    character_id: number;
+	    shili_id: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kinds = source["kinds"];
+	        this.character_id = source["character_id"];
+	        this.shili_id = source["shili_id"];
+	    }
+	}
+	export class WorldCalendar {
+	    eras: CalendarEra[];
+	    months: CalendarMonth[];
+	
+	    static createFrom(source: any = {}) {
+	        return new WorldCalendar(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.eras = this.convertValues(source["eras"], CalendarEra);
+	        this.months = this.convertValues(source["months"], CalendarMonth);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class WorldDate {
+	    era: string;
+	    year: number;
+	    month: number;
+	    month_name: string;
+	    day: number;
+	    ordinal: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WorldDate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.era = source["era"];
+	        this.year = source["year"];
+	        this.month = source["month"];
+	        this.month_name = source["month_name"];
+	        this.day = source["day"];
+	        this.ordinal = source["ordinal"];
+	        this.text = source["text"];
+	    }
+	}
+	export class WorldTableStats {
+	    inserted: number;
+	    updated: number;
+	    skipped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WorldTableStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.inserted = source["inserted"];
+	        this.updated = source["updated"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+	export class WorldImportResult {
+	    mode: string;
+	    tables: Record<string, WorldTableStats>;
+	
+	    static createFrom(source: any = {}) {
+	        return new WorldImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.tables = this.convertValues(source["tables"], WorldTableStats, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
 export namespace main {
 	
+	export class ListPage {
+	    items: any[];
+	    total: number;
+	    next_cursor: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ListPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.items = source["items"];
+	        this.total = source["total"];
+	        this.next_cursor = source["next_cursor"];
+	    }
+	}
 	export class StorageMigrationResult {
 	    from_dir: string;
 	    to_dir: string;
@@ -89,6 +973,73 @@ export namespace main {
 	        this.auto_update_reason = source["auto_update_reason"];
 	    }
 	}
+	export class WorldProject {
+	    id: string;
+	    name: string;
+	    data_dir: string;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    last_opened_at: any;
+	    is_current: boolean;
+	    is_missing: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new WorldProject(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.data_dir = source["data_dir"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.last_opened_at = this.convertValues(source["last_opened_at"], null);
+	        this.is_current = source["is_current"];
+	        this.is_missing = source["is_missing"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace storage {
+	
+	export class BackupConfig {
+	    interval_minutes: number;
+	    keep_last: number;
+	    keep_daily: number;
+	    keep_weekly: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.interval_minutes = source["interval_minutes"];
+	        this.keep_last = source["keep_last"];
+	        this.keep_daily = source["keep_daily"];
+	        this.keep_weekly = source["keep_weekly"];
+	    }
+	}
 
 }
 